@@ -37,6 +37,10 @@ SOFTWARE`
 	GroupPrefix     = "symphony"
 	ManagerMetaKey  = GroupPrefix + "/managed-by"
 	InstanceMetaKey = GroupPrefix + "/instance"
+	// CatalogRedeployMetaKey is the label that opts an instance out of redeploys on catalog changes when it's "false"
+	CatalogRedeployMetaKey = GroupPrefix + "/catalog-redeploy"
+	// TargetRuntimePrefix prefixes the name of the instance a target is deployed as
+	TargetRuntimePrefix = "target-runtime-"
 )
 
 // Environment variables keys
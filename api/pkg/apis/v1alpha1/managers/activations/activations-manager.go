@@ -9,12 +9,11 @@ package activations
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 	"sync"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
-	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	observability "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -94,6 +93,11 @@ func getActivationState(body interface{}, etag string) (model.ActivationState, e
 	return activationState, nil
 }
 
+// ValidateUpsert checks the name of an activation before it's stored
+func (m *ActivationsManager) ValidateUpsert(ctx context.Context, name string, state model.ActivationState) error {
+	return api_utils.ValidateObjectName(name, state.ObjectMeta)
+}
+
 func (m *ActivationsManager) UpsertState(ctx context.Context, name string, state model.ActivationState) error {
 	ctx, span := observability.StartSpan("Activations Manager", ctx, &map[string]string{
 		"method": "UpsertState",
@@ -101,8 +105,8 @@ func (m *ActivationsManager) UpsertState(ctx context.Context, name string, state
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	if state.ObjectMeta.Name != "" && state.ObjectMeta.Name != name {
-		return v1alpha2.NewCOAError(nil, fmt.Sprintf("Name in metadata (%s) does not match name in request (%s)", state.ObjectMeta.Name, name), v1alpha2.BadRequest)
+	if err = m.ValidateUpsert(ctx, name, state); err != nil {
+		return err
 	}
 	state.ObjectMeta.FixNames(name)
 
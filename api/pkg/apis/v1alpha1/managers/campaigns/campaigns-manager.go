@@ -12,6 +12,7 @@ import (
 	"fmt"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
@@ -84,6 +85,19 @@ func getCampaignState(body interface{}) (model.CampaignState, error) {
 	return campaignState, nil
 }
 
+// ValidateUpsert checks the name of a campaign and that its first stage is defined
+func (m *CampaignsManager) ValidateUpsert(ctx context.Context, name string, state model.CampaignState) error {
+	if err := api_utils.ValidateObjectName(name, state.ObjectMeta); err != nil {
+		return err
+	}
+	if state.Spec != nil && state.Spec.FirstStage != "" {
+		if _, ok := state.Spec.Stages[state.Spec.FirstStage]; !ok {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("first stage %s is not defined", state.Spec.FirstStage), v1alpha2.BadRequest)
+		}
+	}
+	return nil
+}
+
 func (m *CampaignsManager) UpsertState(ctx context.Context, name string, state model.CampaignState) error {
 	ctx, span := observability.StartSpan("Campaigns Manager", ctx, &map[string]string{
 		"method": "UpsertState",
@@ -91,8 +105,8 @@ func (m *CampaignsManager) UpsertState(ctx context.Context, name string, state m
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	if state.ObjectMeta.Name != "" && state.ObjectMeta.Name != name {
-		return v1alpha2.NewCOAError(nil, fmt.Sprintf("Name in metadata (%s) does not match name in request (%s)", state.ObjectMeta.Name, name), v1alpha2.BadRequest)
+	if err = m.ValidateUpsert(ctx, name, state); err != nil {
+		return err
 	}
 	state.ObjectMeta.FixNames(name)
 
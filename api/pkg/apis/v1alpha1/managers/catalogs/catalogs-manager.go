@@ -10,6 +10,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/graph"
@@ -19,6 +21,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 
 	observability "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -31,6 +34,23 @@ type CatalogsManager struct {
 	managers.Manager
 	StateProvider states.IStateProvider
 	GraphProvider graph.IGraphProvider
+	// RevisionProvider keeps the revisions of catalogs, revisions aren't kept when it's not configured
+	RevisionProvider states.IStateProvider
+	RevisionLimit    int
+	// RevisionMaxSize is the largest size in bytes of the stored history of a catalog, the oldest revisions are
+	// dropped beyond it
+	RevisionMaxSize int
+	// RedeployDebounce is how long the manager waits after the last change of a catalog before it redeploys the
+	// instances that depend on it
+	RedeployDebounce time.Duration
+	// RedeployMaxDelay is the longest a queued redeploy waits for the changes of a catalog to stop
+	RedeployMaxDelay time.Duration
+	// DependencyProvider keeps the catalog properties instances depend on, they're kept in memory when it's not
+	// configured
+	DependencyProvider states.IStateProvider
+	pendingRedeploys   map[string]model.CatalogDependencies
+	redeployTimer      *time.Timer
+	redeployDeadline   time.Time
 }
 
 func (s *CatalogsManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -49,6 +69,72 @@ func (s *CatalogsManager) Init(context *contexts.VendorContext, config managers.
 			s.GraphProvider = cProvider
 		}
 	}
+	s.RevisionProvider = nil
+	if name, ok := config.Properties["providers.revisions"]; ok {
+		provider, ok := providers[name]
+		if !ok {
+			return v1alpha2.NewCOAError(nil, "revision provider is not supplied", v1alpha2.MissingConfig)
+		}
+		revisionProvider, ok := provider.(states.IStateProvider)
+		if !ok {
+			return v1alpha2.NewCOAError(nil, "supplied revision provider is not a state provider", v1alpha2.BadConfig)
+		}
+		s.RevisionProvider = revisionProvider
+	}
+	s.RevisionLimit = defaultRevisionLimit
+	if v, ok := config.Properties["revisions.limit"]; ok {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return v1alpha2.NewCOAError(err, "'revisions.limit' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.RevisionLimit = limit
+	}
+	s.RevisionMaxSize = defaultRevisionMaxSize
+	if v, ok := config.Properties["revisions.maxSizeInBytes"]; ok {
+		size, err := strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return v1alpha2.NewCOAError(err, "'revisions.maxSizeInBytes' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.RevisionMaxSize = size
+	}
+	s.RedeployDebounce = defaultRedeployDebounce
+	if v, ok := config.Properties["redeploy.debounceInSec"]; ok {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			return v1alpha2.NewCOAError(err, "'redeploy.debounceInSec' must be a non-negative integer", v1alpha2.BadConfig)
+		}
+		s.RedeployDebounce = time.Duration(seconds) * time.Second
+	}
+	s.RedeployMaxDelay = defaultRedeployMaxDelay
+	if v, ok := config.Properties["redeploy.maxDelayInSec"]; ok {
+		seconds, err := strconv.Atoi(v)
+		if err != nil || seconds < 0 {
+			return v1alpha2.NewCOAError(err, "'redeploy.maxDelayInSec' must be a non-negative integer", v1alpha2.BadConfig)
+		}
+		s.RedeployMaxDelay = time.Duration(seconds) * time.Second
+	}
+	if s.RedeployMaxDelay < s.RedeployDebounce {
+		return v1alpha2.NewCOAError(nil, "'redeploy.maxDelayInSec' must not be less than 'redeploy.debounceInSec'", v1alpha2.BadConfig)
+	}
+	if name, ok := config.Properties["providers.dependencies"]; ok {
+		provider, ok := providers[name]
+		if !ok {
+			return v1alpha2.NewCOAError(nil, "dependency provider is not supplied", v1alpha2.MissingConfig)
+		}
+		dependencyProvider, ok := provider.(states.IStateProvider)
+		if !ok {
+			return v1alpha2.NewCOAError(nil, "supplied dependency provider is not a state provider", v1alpha2.BadConfig)
+		}
+		s.DependencyProvider = dependencyProvider
+	} else {
+		dependencyProvider := &memorystate.MemoryStateProvider{}
+		err = dependencyProvider.Init(memorystate.MemoryStateProviderConfig{})
+		if err != nil {
+			return err
+		}
+		s.DependencyProvider = dependencyProvider
+	}
+	s.pendingRedeploys = make(map[string]model.CatalogDependencies)
 	return nil
 }
 
@@ -107,29 +193,34 @@ func (m *CatalogsManager) ValidateState(ctx context.Context, state model.Catalog
 
 	if state.Spec != nil && state.Spec.Metadata != nil {
 		if schemaName, ok := state.Spec.Metadata["schema"]; ok {
-			var schema model.CatalogState
-			schema, err = m.GetState(ctx, schemaName, state.ObjectMeta.Namespace)
-			if err != nil {
-				err = v1alpha2.NewCOAError(err, "schema not found", v1alpha2.ValidateFailed)
-				return utils.SchemaResult{Valid: false}, err
-			}
-			if s, ok := schema.Spec.Properties["spec"]; ok {
-				var schemaObj utils.Schema
-				jData, _ := json.Marshal(s)
-				err = json.Unmarshal(jData, &schemaObj)
-				if err != nil {
-					err = v1alpha2.NewCOAError(err, "invalid schema", v1alpha2.ValidateFailed)
-					return utils.SchemaResult{Valid: false}, err
-				}
-				return schemaObj.CheckProperties(state.Spec.Properties, nil)
-			} else {
-				err = v1alpha2.NewCOAError(fmt.Errorf("schema not found"), "schema validation error", v1alpha2.ValidateFailed)
-				return utils.SchemaResult{Valid: false}, err
-			}
+			var result utils.SchemaResult
+			result, err = m.ValidateProperties(ctx, schemaName, state.ObjectMeta.Namespace, state.Spec.Properties, "/spec/properties")
+			return result, err
 		}
 	}
 	return utils.SchemaResult{Valid: true}, nil
 }
+
+// ValidateUpsert checks the name and spec of a catalog, and its properties against its schema
+func (m *CatalogsManager) ValidateUpsert(ctx context.Context, name string, state model.CatalogState) error {
+	if err := utils.ValidateObjectName(name, state.ObjectMeta); err != nil {
+		return err
+	}
+	if state.Spec == nil {
+		return v1alpha2.NewCOAError(nil, "catalog spec is missing", v1alpha2.BadRequest)
+	}
+	state.ObjectMeta.FixNames(name)
+	result, err := m.ValidateState(ctx, state)
+	if err != nil {
+		return err
+	}
+	if !result.Valid {
+		jData, _ := json.Marshal(result.Errors)
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("schema validation error: %s", string(jData)), v1alpha2.ValidateFailed)
+	}
+	return nil
+}
+
 func (m *CatalogsManager) UpsertState(ctx context.Context, name string, state model.CatalogState) error {
 	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
 		"method": "UpsertState",
@@ -137,20 +228,18 @@ func (m *CatalogsManager) UpsertState(ctx context.Context, name string, state mo
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	if state.ObjectMeta.Name != "" && state.ObjectMeta.Name != name {
-		return v1alpha2.NewCOAError(nil, fmt.Sprintf("Name in metadata (%s) does not match name in request (%s)", state.ObjectMeta.Name, name), v1alpha2.BadRequest)
-	}
-	state.ObjectMeta.FixNames(name)
+	err = m.upsertState(ctx, name, state, model.CatalogRevisionUpdate, 0)
+	return err
+}
 
-	var result utils.SchemaResult
-	result, err = m.ValidateState(ctx, state)
-	if err != nil {
-		return err
-	}
-	if !result.Valid {
-		err = v1alpha2.NewCOAError(nil, "schema validation error", v1alpha2.ValidateFailed)
+// upsertState stores a catalog and records a revision with the given action
+func (m *CatalogsManager) upsertState(ctx context.Context, name string, state model.CatalogState, action string, sourceRevision int) error {
+	var err error
+
+	if err = m.ValidateUpsert(ctx, name, state); err != nil {
 		return err
 	}
+	state.ObjectMeta.FixNames(name)
 
 	upsertRequest := states.UpsertRequest{
 		Value: states.StateEntry{
@@ -170,10 +259,35 @@ func (m *CatalogsManager) UpsertState(ctx context.Context, name string, state mo
 			"kind":      "Catalog",
 		},
 	}
+	var previous *model.CatalogSpec
+	if existing, getErr := m.GetState(ctx, name, state.ObjectMeta.Namespace); getErr == nil {
+		previous = existing.Spec
+	}
+	diff := model.DiffCatalogSpecs(previous, state.Spec)
+	// the revision is recorded first, and dropped if the catalog can't be stored
+	revision, err := m.recordRevision(ctx, name, state.ObjectMeta.Namespace, model.CatalogRevision{
+		Action:         action,
+		SourceRevision: sourceRevision,
+		Diff:           diff,
+		Spec:           state.Spec,
+	})
+	if err != nil {
+		return err
+	}
 	_, err = m.StateProvider.Upsert(ctx, upsertRequest)
 	if err != nil {
+		m.dropRevision(ctx, name, state.ObjectMeta.Namespace, revision)
 		return err
 	}
+	if revision > 0 {
+		// state providers don't all return the etag from Upsert, so the generation is read back
+		if stored, getErr := m.GetState(ctx, name, state.ObjectMeta.Namespace); getErr == nil {
+			m.setRevisionGeneration(ctx, name, state.ObjectMeta.Namespace, revision, stored.Spec.Generation)
+		}
+	}
+	if len(diff) > 0 {
+		m.publishChange(model.NewCatalogChangedEvent(name, state.ObjectMeta.Namespace, state.Spec.Type, action, diff))
+	}
 	m.Context.Publish("catalog", v1alpha2.Event{
 		Metadata: map[string]string{
 			"objectType": state.Spec.Type,
@@ -194,7 +308,20 @@ func (m *CatalogsManager) DeleteState(ctx context.Context, name string, namespac
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	//TODO: publish DELETE event
+	objectType := ""
+	var previous *model.CatalogSpec
+	if catalog, getErr := m.GetState(ctx, name, namespace); getErr == nil && catalog.Spec != nil {
+		objectType = catalog.Spec.Type
+		previous = catalog.Spec
+	}
+	var revision int
+	revision, err = m.recordRevision(ctx, name, namespace, model.CatalogRevision{
+		Action: model.CatalogRevisionDelete,
+		Diff:   model.DiffCatalogSpecs(previous, nil),
+	})
+	if err != nil {
+		return err
+	}
 	err = m.StateProvider.Delete(ctx, states.DeleteRequest{
 		ID: name,
 		Metadata: map[string]interface{}{
@@ -205,7 +332,27 @@ func (m *CatalogsManager) DeleteState(ctx context.Context, name string, namespac
 			"kind":      "Catalog",
 		},
 	})
-	return err
+	if err != nil {
+		m.dropRevision(ctx, name, namespace, revision)
+		return err
+	}
+	m.publishChange(model.NewCatalogChangedEvent(name, namespace, objectType, model.CatalogRevisionDelete, nil))
+	m.Context.Publish("catalog", v1alpha2.Event{
+		Metadata: map[string]string{
+			"objectType": objectType,
+		},
+		Body: v1alpha2.JobData{
+			Id:     name,
+			Action: v1alpha2.JobDelete,
+			Body: model.CatalogState{
+				ObjectMeta: model.ObjectMeta{
+					Name:      name,
+					Namespace: namespace,
+				},
+			},
+		},
+	})
+	return nil
 }
 
 func (t *CatalogsManager) ListState(ctx context.Context, namespace string, filterType string, filterValue string) ([]model.CatalogState, error) {
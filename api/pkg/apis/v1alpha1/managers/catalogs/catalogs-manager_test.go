@@ -288,3 +288,138 @@ func TestSchemaCheck(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.True(t, strings.Contains(err.Error(), "schema validation error"))
 }
+
+func upsertSchemaCatalog(name string, schema string) error {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(schema), &doc); err != nil {
+		return err
+	}
+	return manager.UpsertState(context.Background(), name, model.CatalogState{
+		ObjectMeta: model.ObjectMeta{
+			Name: name,
+		},
+		Spec: &model.CatalogSpec{
+			Type: "schema",
+			Properties: map[string]interface{}{
+				"spec": doc,
+			},
+		},
+	})
+}
+
+func schemaCheckedCatalog(schemaName string, properties map[string]interface{}) model.CatalogState {
+	return model.CatalogState{
+		ObjectMeta: model.ObjectMeta{
+			Name:      "config",
+			Namespace: "default",
+		},
+		Spec: &model.CatalogSpec{
+			Type:       "config",
+			Properties: properties,
+			Metadata: map[string]string{
+				"schema": schemaName,
+			},
+		},
+	}
+}
+
+func TestJsonSchemaCheck(t *testing.T) {
+	err := initalizeManager()
+	assert.Nil(t, err)
+
+	err = upsertSchemaCatalog("address", `{
+		"type": "object",
+		"properties": {
+			"city": {"type": "string"},
+			"zip": {"type": "string", "pattern": "^[0-9]{5}$"}
+		},
+		"required": ["city"]
+	}`)
+	assert.Nil(t, err)
+	err = upsertSchemaCatalog("device-config", `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"mode": {"enum": ["auto", "manual"]},
+			"owner": {"type": "string", "format": "email"},
+			"address": {"$ref": "address"},
+			"ports": {"type": "array", "items": {"type": "integer", "maximum": 65535}}
+		},
+		"required": ["mode"]
+	}`)
+	assert.Nil(t, err)
+
+	result, err := manager.ValidateState(context.Background(), schemaCheckedCatalog("device-config", map[string]interface{}{
+		"mode":    "auto",
+		"owner":   "admin@contoso.com",
+		"address": map[string]interface{}{"city": "Redmond", "zip": "98052"},
+		"ports":   []interface{}{80, 443},
+	}))
+	assert.Nil(t, err)
+	assert.True(t, result.Valid)
+
+	result, err = manager.ValidateState(context.Background(), schemaCheckedCatalog("device-config", map[string]interface{}{
+		"mode":    "off",
+		"owner":   "admin",
+		"address": map[string]interface{}{"zip": "980"},
+		"ports":   []interface{}{80, 70000},
+	}))
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, 5, len(result.Errors))
+	assert.Contains(t, result.Errors, "/spec/properties/mode")
+	assert.Contains(t, result.Errors, "/spec/properties/owner")
+	assert.Contains(t, result.Errors, "/spec/properties/address")
+	assert.Contains(t, result.Errors["/spec/properties/address"].Error, "city")
+	assert.Contains(t, result.Errors, "/spec/properties/address/zip")
+	assert.Contains(t, result.Errors, "/spec/properties/ports/1")
+
+	result, err = manager.ValidateState(context.Background(), schemaCheckedCatalog("device-config", map[string]interface{}{}))
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+	assert.Contains(t, result.Errors["/spec/properties"].Error, "mode")
+
+	err = manager.UpsertState(context.Background(), "config", schemaCheckedCatalog("device-config", map[string]interface{}{
+		"mode": "off",
+	}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "schema validation error")
+	assert.Contains(t, err.Error(), "/spec/properties/mode")
+}
+
+func TestJsonSchemaCheckReferences(t *testing.T) {
+	err := initalizeManager()
+	assert.Nil(t, err)
+
+	err = upsertSchemaCatalog("remote", `{"$ref": "https://example.com/schema.json"}`)
+	assert.Nil(t, err)
+	_, err = manager.ValidateState(context.Background(), schemaCheckedCatalog("remote", map[string]interface{}{}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "only catalog references are supported")
+
+	err = upsertSchemaCatalog("dangling", `{"$ref": "catalog://default/missing"}`)
+	assert.Nil(t, err)
+	_, err = manager.ValidateState(context.Background(), schemaCheckedCatalog("dangling", map[string]interface{}{}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid schema")
+
+	err = upsertSchemaCatalog("invalid", `{"type": 12}`)
+	assert.Nil(t, err)
+	_, err = manager.ValidateState(context.Background(), schemaCheckedCatalog("invalid", map[string]interface{}{}))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "invalid schema")
+}
+
+func TestValidatePropertiesWithRules(t *testing.T) {
+	err := initalizeManager()
+	assert.Nil(t, err)
+
+	err = upsertSchemaCatalog("rules", `{"rules": {"email": {"pattern": "<email>"}}}`)
+	assert.Nil(t, err)
+	result, err := manager.ValidateProperties(context.Background(), "rules", "default", map[string]interface{}{
+		"email": "not an email",
+	}, "/properties")
+	assert.Nil(t, err)
+	assert.False(t, result.Valid)
+	assert.Contains(t, result.Errors, "email")
+}
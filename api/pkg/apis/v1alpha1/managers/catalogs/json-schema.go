@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package catalogs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// catalogScheme is the URL scheme of schema catalogs, catalog://<namespace>/<name>, used by $ref
+const catalogScheme = "catalog"
+
+func catalogSchemaURL(name string, namespace string) string {
+	return fmt.Sprintf("%s://%s/%s", catalogScheme, namespace, name)
+}
+
+// isJsonSchema tells a JSON Schema document from a rule set of utils.Schema. A document is a JSON Schema when it
+// declares $schema, or when it has no rules.
+func isJsonSchema(schema interface{}) bool {
+	doc, ok := schema.(map[string]interface{})
+	if !ok {
+		// boolean schemas
+		_, ok = schema.(bool)
+		return ok
+	}
+	if _, ok := doc["$schema"]; ok {
+		return true
+	}
+	_, ok = doc["rules"]
+	return !ok
+}
+
+// getSchema returns the schema document in the spec property of a schema catalog
+func (m *CatalogsManager) getSchema(ctx context.Context, name string, namespace string) (interface{}, error) {
+	catalog, err := m.GetState(ctx, name, namespace)
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("schema %s not found", name), v1alpha2.ValidateFailed)
+	}
+	schema, ok := catalog.Spec.Properties["spec"]
+	if !ok {
+		return nil, v1alpha2.NewCOAError(fmt.Errorf("catalog %s has no spec property", name), "schema validation error", v1alpha2.ValidateFailed)
+	}
+	return schema, nil
+}
+
+// compileJsonSchema compiles a draft 2020-12 JSON Schema, unless it declares another draft with $schema. Format
+// validators are asserted. References to other catalogs, catalog://<namespace>/<name> or a name relative to the
+// schema catalog, are loaded from the state provider. Other URLs aren't loaded.
+func (m *CatalogsManager) compileJsonSchema(ctx context.Context, name string, namespace string, schema interface{}) (*jsonschema.Schema, error) {
+	compiler := jsonschema.NewCompiler()
+	compiler.Draft = jsonschema.Draft2020
+	compiler.AssertFormat = true
+	compiler.LoadURL = func(s string) (io.ReadCloser, error) {
+		u, err := url.Parse(s)
+		if err != nil || u.Scheme != catalogScheme {
+			return nil, fmt.Errorf("schema %s can't be loaded, only catalog references are supported", s)
+		}
+		ref, err := m.getSchema(ctx, strings.TrimPrefix(u.Path, "/"), u.Host)
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(ref)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	schemaURL := catalogSchemaURL(name, namespace)
+	if err = compiler.AddResource(schemaURL, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return compiler.Compile(schemaURL)
+}
+
+// validateJsonSchema validates a value against a compiled schema. Errors are keyed by the JSON Pointer of the
+// invalid value, prefixed with location.
+func validateJsonSchema(schema *jsonschema.Schema, value interface{}, location string) (utils.SchemaResult, error) {
+	// the value is converted to JSON types first, as the validator doesn't handle structs and typed maps
+	var doc interface{}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return utils.SchemaResult{Valid: false}, err
+	}
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return utils.SchemaResult{Valid: false}, err
+	}
+	err = schema.Validate(doc)
+	if err == nil {
+		return utils.SchemaResult{Valid: true}, nil
+	}
+	var validationErr *jsonschema.ValidationError
+	if !errors.As(err, &validationErr) {
+		return utils.SchemaResult{Valid: false}, err
+	}
+	messages := make(map[string][]string)
+	collectValidationErrors(validationErr, location, messages)
+	ret := utils.SchemaResult{Valid: false, Errors: make(map[string]utils.RuleResult)}
+	for pointer, m := range messages {
+		sort.Strings(m)
+		ret.Errors[pointer] = utils.RuleResult{Valid: false, Error: strings.Join(m, "; ")}
+	}
+	return ret, nil
+}
+
+// collectValidationErrors keeps the innermost errors, which describe the failed keywords
+func collectValidationErrors(err *jsonschema.ValidationError, location string, messages map[string][]string) {
+	if len(err.Causes) == 0 {
+		pointer := location + err.InstanceLocation
+		if pointer == "" {
+			pointer = "/"
+		}
+		messages[pointer] = append(messages[pointer], err.Message)
+		return
+	}
+	for _, cause := range err.Causes {
+		collectValidationErrors(cause, location, messages)
+	}
+}
+
+// ValidateProperties checks properties against the schema catalog schemaName, which holds either a JSON Schema or
+// a rule set in its spec property. JSON Schema errors are keyed by the JSON Pointer of the invalid value, prefixed
+// with location, and rule set errors by property name.
+func (m *CatalogsManager) ValidateProperties(ctx context.Context, schemaName string, namespace string, properties map[string]interface{}, location string) (utils.SchemaResult, error) {
+	schema, err := m.getSchema(ctx, schemaName, namespace)
+	if err != nil {
+		return utils.SchemaResult{Valid: false}, err
+	}
+	if !isJsonSchema(schema) {
+		var schemaObj utils.Schema
+		jData, _ := json.Marshal(schema)
+		if err = json.Unmarshal(jData, &schemaObj); err != nil {
+			return utils.SchemaResult{Valid: false}, v1alpha2.NewCOAError(err, "invalid schema", v1alpha2.ValidateFailed)
+		}
+		return schemaObj.CheckProperties(properties, nil)
+	}
+	compiled, err := m.compileJsonSchema(ctx, schemaName, namespace, schema)
+	if err != nil {
+		return utils.SchemaResult{Valid: false}, v1alpha2.NewCOAError(err, "invalid schema", v1alpha2.ValidateFailed)
+	}
+	if properties == nil {
+		properties = map[string]interface{}{}
+	}
+	return validateJsonSchema(compiled, properties, location)
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package catalogs
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+
+	observability "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+)
+
+// defaultRedeployDebounce is how long changes are collected before dependent instances are redeployed when
+// redeploy.debounceInSec isn't set
+const defaultRedeployDebounce = 5 * time.Second
+
+// defaultRedeployMaxDelay is the longest a queued redeploy waits for changes to stop when redeploy.maxDelayInSec
+// isn't set
+const defaultRedeployMaxDelay = 60 * time.Second
+
+var redeployLock sync.Mutex
+
+func dependencyMetadata(namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":   "v1",
+		"group":     model.FederationGroup,
+		"resource":  "catalogdependencies",
+		"namespace": namespace,
+		"kind":      "CatalogDependency",
+	}
+}
+
+// dependencyRecord is the stored dependencies of an instance. It's shaped like a CatalogDependency custom resource
+// named after the instance, so that the Kubernetes state provider can keep it.
+type dependencyRecord struct {
+	Metadata model.ObjectMeta          `json:"metadata"`
+	Spec     model.CatalogDependencies `json:"spec"`
+}
+
+// publishChange publishes a catalog-changed event
+func (m *CatalogsManager) publishChange(event model.CatalogChangedEvent) {
+	m.Context.Publish("catalog-changed", v1alpha2.Event{
+		Metadata: map[string]string{
+			"objectType": event.Type,
+			"namespace":  event.Namespace,
+		},
+		Body: event,
+	})
+}
+
+// SetDependencies replaces the catalog properties an instance depends on. Instances without dependencies are
+// forgotten. Dependencies are stored with the dependency provider, so they're shared by all API replicas and kept
+// across restarts when the provider is persistent.
+func (m *CatalogsManager) SetDependencies(ctx context.Context, dependencies model.CatalogDependencies) error {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "SetDependencies",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	if dependencies.Namespace == "" {
+		dependencies.Namespace = "default"
+	}
+	if len(dependencies.Catalogs) == 0 {
+		err = m.DependencyProvider.Delete(ctx, states.DeleteRequest{
+			ID:       dependencies.Instance,
+			Metadata: dependencyMetadata(dependencies.Namespace),
+		})
+		if err != nil && v1alpha2.IsNotFound(err) {
+			err = nil
+		}
+		return err
+	}
+	_, err = m.DependencyProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID: dependencies.Instance,
+			Body: dependencyRecord{
+				Metadata: model.ObjectMeta{Name: dependencies.Instance, Namespace: dependencies.Namespace},
+				Spec:     dependencies,
+			},
+		},
+		Metadata: dependencyMetadata(dependencies.Namespace),
+	})
+	return err
+}
+
+// listDependencies returns the stored dependencies of the instances in a namespace
+func (m *CatalogsManager) listDependencies(ctx context.Context, namespace string) ([]model.CatalogDependencies, error) {
+	entries, _, err := m.DependencyProvider.List(ctx, states.ListRequest{
+		Metadata: dependencyMetadata(namespace),
+	})
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	ret := make([]model.CatalogDependencies, 0, len(entries))
+	for _, entry := range entries {
+		var record dependencyRecord
+		jData, _ := json.Marshal(entry.Body)
+		err = json.Unmarshal(jData, &record)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to parse catalog dependencies", v1alpha2.InternalError)
+		}
+		ret = append(ret, record.Spec)
+	}
+	return ret, nil
+}
+
+// QueueRedeploys queues a deployment job for each instance that depends on the changed catalog properties and
+// returns the queued instances. Jobs are sent once no other change has been queued for the debounce window, so
+// a burst of changes redeploys an instance once, but no later than the maximum delay after the first queued change.
+func (m *CatalogsManager) QueueRedeploys(ctx context.Context, event model.CatalogChangedEvent) ([]string, error) {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "QueueRedeploys",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	if event.Namespace == "" {
+		event.Namespace = "default"
+	}
+	var instances []model.CatalogDependencies
+	instances, err = m.listDependencies(ctx, event.Namespace)
+	if err != nil {
+		log.Errorf(" M (Catalogs): failed to list the dependencies of catalog %s: %+v", event.Name, err)
+		return nil, err
+	}
+
+	redeployLock.Lock()
+	defer redeployLock.Unlock()
+
+	queued := make([]string, 0)
+	for _, dependencies := range instances {
+		if dependencies.Namespace != event.Namespace {
+			continue
+		}
+		if keys, ok := dependencies.Catalogs[event.Name]; ok && event.Affects(keys) {
+			m.pendingRedeploys[dependencyKey(dependencies.Instance, dependencies.Namespace)] = dependencies
+			queued = append(queued, dependencies.Instance)
+		}
+	}
+	sort.Strings(queued)
+	if len(queued) == 0 {
+		return queued, nil
+	}
+	log.Debugf(" M (Catalogs): catalog %s changed, queuing redeploys of %v", event.Name, queued)
+	now := time.Now()
+	if m.redeployTimer != nil {
+		m.redeployTimer.Stop()
+	} else {
+		m.redeployDeadline = now.Add(m.RedeployMaxDelay)
+	}
+	delay := m.RedeployDebounce
+	if remaining := m.redeployDeadline.Sub(now); remaining < delay {
+		delay = remaining
+	}
+	m.redeployTimer = time.AfterFunc(delay, m.flushRedeploys)
+	return queued, nil
+}
+
+func dependencyKey(instance string, namespace string) string {
+	return namespace + "/" + instance
+}
+
+// flushRedeploys publishes the deployment jobs of the queued instances
+func (m *CatalogsManager) flushRedeploys() {
+	redeployLock.Lock()
+	pending := m.pendingRedeploys
+	m.pendingRedeploys = make(map[string]model.CatalogDependencies)
+	m.redeployTimer = nil
+	redeployLock.Unlock()
+
+	for _, dependencies := range pending {
+		log.Infof(" M (Catalogs): redeploying instance %s after catalog changes", dependencies.Instance)
+		m.Context.Publish("job", v1alpha2.Event{
+			Metadata: map[string]string{
+				"objectType": "instance",
+				"namespace":  dependencies.Namespace,
+			},
+			Body: v1alpha2.JobData{
+				Id:     dependencies.Instance,
+				Action: v1alpha2.JobUpdate,
+				Scope:  dependencies.Namespace,
+			},
+		})
+	}
+}
@@ -0,0 +1,306 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package catalogs
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	memorygraph "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/graph/memory"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	contexts "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRedeployManager(t *testing.T, debounce string) (*CatalogsManager, *contexts.VendorContext) {
+	dependencyProvider := &memorystate.MemoryStateProvider{}
+	dependencyProvider.Init(memorystate.MemoryStateProviderConfig{})
+	return createRedeployManagerWithDependencies(t, debounce, dependencyProvider)
+}
+
+func createRedeployManagerWithDependencies(t *testing.T, debounce string, dependencyProvider states.IStateProvider) (*CatalogsManager, *contexts.VendorContext) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	vendorContext := &contexts.VendorContext{}
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendorContext.Init(&pubSubProvider)
+	m := &CatalogsManager{}
+	err := m.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state":        "state",
+			"providers.dependencies": "dependencies",
+			"redeploy.debounceInSec": debounce,
+		},
+	}, map[string]providers.IProvider{
+		"state":        stateProvider,
+		"dependencies": dependencyProvider,
+	})
+	assert.Nil(t, err)
+	return m, vendorContext
+}
+
+func TestRedeployConfig(t *testing.T) {
+	testCases := []struct {
+		name       string
+		properties map[string]string
+		debounce   time.Duration
+		maxDelay   time.Duration
+		err        string
+	}{
+		{name: "defaults", debounce: defaultRedeployDebounce, maxDelay: defaultRedeployMaxDelay},
+		{name: "delays", properties: map[string]string{"redeploy.debounceInSec": "2", "redeploy.maxDelayInSec": "10"}, debounce: 2 * time.Second, maxDelay: 10 * time.Second},
+		{name: "no delay", properties: map[string]string{"redeploy.debounceInSec": "0", "redeploy.maxDelayInSec": "0"}},
+		{name: "invalid debounce", properties: map[string]string{"redeploy.debounceInSec": "-1"}, err: "redeploy.debounceInSec"},
+		{name: "invalid maximum delay", properties: map[string]string{"redeploy.maxDelayInSec": "soon"}, err: "redeploy.maxDelayInSec"},
+		{name: "maximum delay shorter than debounce", properties: map[string]string{"redeploy.debounceInSec": "10", "redeploy.maxDelayInSec": "5"}, err: "must not be less than"},
+		{name: "missing dependency provider", properties: map[string]string{"providers.dependencies": "missing"}, err: "dependency provider is not supplied"},
+		{name: "dependency provider isn't a state provider", properties: map[string]string{"providers.dependencies": "graph"}, err: "not a state provider"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vendorContext := &contexts.VendorContext{}
+			pubSubProvider := memory.InMemoryPubSubProvider{}
+			pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+			vendorContext.Init(&pubSubProvider)
+			properties := map[string]string{"providers.state": "state"}
+			for k, v := range tc.properties {
+				properties[k] = v
+			}
+			stateProvider := &memorystate.MemoryStateProvider{}
+			stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+			m := &CatalogsManager{}
+			err := m.Init(vendorContext, managers.ManagerConfig{Properties: properties}, map[string]providers.IProvider{
+				"state": stateProvider,
+				"graph": &memorygraph.MemoryGraphProvider{},
+			})
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.debounce, m.RedeployDebounce)
+			assert.Equal(t, tc.maxDelay, m.RedeployMaxDelay)
+			// dependencies are kept in memory without a dependency provider
+			assert.NotNil(t, m.DependencyProvider)
+		})
+	}
+}
+
+func TestPublishCatalogChanged(t *testing.T) {
+	m, vendorContext := createRedeployManager(t, "0")
+	events := make(chan model.CatalogChangedEvent, 10)
+	vendorContext.Subscribe("catalog-changed", func(topic string, event v1alpha2.Event) error {
+		var change model.CatalogChangedEvent
+		jData, _ := json.Marshal(event.Body)
+		json.Unmarshal(jData, &change)
+		events <- change
+		return nil
+	})
+
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 1, "image": "nginx"})
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 2, "image": "nginx"})
+	// an unchanged catalog isn't reported
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 2, "image": "nginx"})
+	err := m.DeleteState(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+
+	received := make([]model.CatalogChangedEvent, 0)
+	for len(received) < 3 {
+		select {
+		case change := <-events:
+			received = append(received, change)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "catalog-changed events weren't published")
+			return
+		}
+	}
+	// events are delivered asynchronously, they're matched by content
+	assert.Contains(t, received, model.CatalogChangedEvent{Name: "config1", Namespace: "default", Type: "config", Action: model.CatalogRevisionUpdate, AllKeys: true})
+	assert.Contains(t, received, model.CatalogChangedEvent{Name: "config1", Namespace: "default", Type: "config", Action: model.CatalogRevisionUpdate, Keys: []string{"replicas"}})
+	assert.Contains(t, received, model.CatalogChangedEvent{Name: "config1", Namespace: "default", Type: "config", Action: model.CatalogRevisionDelete, AllKeys: true})
+	select {
+	case change := <-events:
+		assert.Fail(t, "unexpected catalog-changed event", "%+v", change)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestQueueRedeploys(t *testing.T) {
+	m, _ := createRedeployManager(t, "60")
+	for _, dependencies := range []model.CatalogDependencies{
+		{Instance: "instance1", Namespace: "default", Catalogs: map[string][]string{"config1": {"image"}}},
+		{Instance: "instance2", Namespace: "default", Catalogs: map[string][]string{"config1": {""}, "config2": {"port"}}},
+		{Instance: "instance3", Namespace: "other", Catalogs: map[string][]string{"config1": {"image"}}},
+		{Instance: "instance4", Namespace: "default", Catalogs: map[string][]string{"config1": {"image"}}},
+	} {
+		assert.Nil(t, m.SetDependencies(context.Background(), dependencies))
+	}
+	// instances without dependencies are forgotten
+	assert.Nil(t, m.SetDependencies(context.Background(), model.CatalogDependencies{Instance: "instance4", Namespace: "default"}))
+	assert.Nil(t, m.SetDependencies(context.Background(), model.CatalogDependencies{Instance: "instance5", Namespace: "default"}))
+
+	testCases := []struct {
+		name   string
+		event  model.CatalogChangedEvent
+		queued []string
+	}{
+		{name: "read property", event: model.CatalogChangedEvent{Name: "config1", Namespace: "default", Keys: []string{"image"}}, queued: []string{"instance1", "instance2"}},
+		{name: "whole catalog", event: model.CatalogChangedEvent{Name: "config1", Namespace: "default", Keys: []string{"replicas"}}, queued: []string{"instance2"}},
+		{name: "unread property", event: model.CatalogChangedEvent{Name: "config2", Namespace: "default", Keys: []string{"image"}}, queued: []string{}},
+		{name: "all properties", event: model.CatalogChangedEvent{Name: "config2", Namespace: "default", AllKeys: true}, queued: []string{"instance2"}},
+		{name: "other namespace", event: model.CatalogChangedEvent{Name: "config1", Namespace: "other", AllKeys: true}, queued: []string{"instance3"}},
+		{name: "unknown namespace", event: model.CatalogChangedEvent{Name: "config1", Namespace: "unknown", AllKeys: true}, queued: []string{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			queued, err := m.QueueRedeploys(context.Background(), tc.event)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.queued, queued)
+		})
+	}
+	m.redeployTimer.Stop()
+}
+
+func TestDependenciesSharedByManagers(t *testing.T) {
+	// managers sharing a dependency provider stand for API replicas, or an API before and after a restart
+	dependencyProvider := &memorystate.MemoryStateProvider{}
+	dependencyProvider.Init(memorystate.MemoryStateProviderConfig{})
+	m1, _ := createRedeployManagerWithDependencies(t, "60", dependencyProvider)
+	m2, _ := createRedeployManagerWithDependencies(t, "60", dependencyProvider)
+
+	err := m1.SetDependencies(context.Background(), model.CatalogDependencies{
+		Instance:  "instance1",
+		Namespace: "default",
+		Catalogs:  map[string][]string{"config1": {"image"}},
+	})
+	assert.Nil(t, err)
+	entry, err := dependencyProvider.Get(context.Background(), states.GetRequest{ID: "instance1", Metadata: dependencyMetadata("default")})
+	assert.Nil(t, err)
+	var record dependencyRecord
+	jData, _ := json.Marshal(entry.Body)
+	assert.Nil(t, json.Unmarshal(jData, &record))
+	assert.Equal(t, model.ObjectMeta{Name: "instance1", Namespace: "default"}, record.Metadata)
+
+	event := model.CatalogChangedEvent{Name: "config1", Namespace: "default", Keys: []string{"image"}}
+	queued, err := m2.QueueRedeploys(context.Background(), event)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"instance1"}, queued)
+	m2.redeployTimer.Stop()
+
+	err = m1.SetDependencies(context.Background(), model.CatalogDependencies{Instance: "instance1", Namespace: "default"})
+	assert.Nil(t, err)
+	queued, err = m2.QueueRedeploys(context.Background(), event)
+	assert.Nil(t, err)
+	assert.Empty(t, queued)
+}
+
+func TestDependencyProviderFailures(t *testing.T) {
+	dependencyProvider := &memorystate.MemoryStateProvider{}
+	dependencyProvider.Init(memorystate.MemoryStateProviderConfig{})
+	m, _ := createRedeployManagerWithDependencies(t, "60", &failingStateProvider{IStateProvider: dependencyProvider, fail: true})
+
+	err := m.SetDependencies(context.Background(), model.CatalogDependencies{
+		Instance:  "instance1",
+		Namespace: "default",
+		Catalogs:  map[string][]string{"config1": {"image"}},
+	})
+	assert.NotNil(t, err)
+	err = m.SetDependencies(context.Background(), model.CatalogDependencies{Instance: "instance1", Namespace: "default"})
+	assert.NotNil(t, err)
+}
+
+func TestRedeployDebounce(t *testing.T) {
+	m, vendorContext := createRedeployManager(t, "1")
+	jobs := make(chan v1alpha2.JobData, 10)
+	vendorContext.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		assert.Equal(t, "instance", event.Metadata["objectType"])
+		assert.Equal(t, "default", event.Metadata["namespace"])
+		var job v1alpha2.JobData
+		jData, _ := json.Marshal(event.Body)
+		json.Unmarshal(jData, &job)
+		jobs <- job
+		return nil
+	})
+	err := m.SetDependencies(context.Background(), model.CatalogDependencies{
+		Instance:  "instance1",
+		Namespace: "default",
+		Catalogs:  map[string][]string{"config1": {"image"}},
+	})
+	assert.Nil(t, err)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		_, err = m.QueueRedeploys(context.Background(), model.CatalogChangedEvent{Name: "config1", Namespace: "default", Keys: []string{"image"}})
+		assert.Nil(t, err)
+	}
+	select {
+	case job := <-jobs:
+		assert.Equal(t, "instance1", job.Id)
+		assert.Equal(t, v1alpha2.JobUpdate, job.Action)
+		assert.Equal(t, "default", job.Scope)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "redeploy job wasn't published")
+	}
+	// a burst of changes redeploys an instance once
+	select {
+	case job := <-jobs:
+		assert.Fail(t, "unexpected redeploy job", "%+v", job)
+	case <-time.After(1500 * time.Millisecond):
+	}
+}
+
+func TestRedeployMaxDelay(t *testing.T) {
+	m, vendorContext := createRedeployManager(t, "0")
+	m.RedeployDebounce, m.RedeployMaxDelay = 300*time.Millisecond, 600*time.Millisecond
+	jobs := make(chan time.Time, 10)
+	vendorContext.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		jobs <- time.Now()
+		return nil
+	})
+	err := m.SetDependencies(context.Background(), model.CatalogDependencies{
+		Instance:  "instance1",
+		Namespace: "default",
+		Catalogs:  map[string][]string{"config1": {"image"}},
+	})
+	assert.Nil(t, err)
+
+	// changes keep coming faster than the debounce window
+	event := model.CatalogChangedEvent{Name: "config1", Namespace: "default", Keys: []string{"image"}}
+	start := time.Now()
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-time.After(100 * time.Millisecond):
+				m.QueueRedeploys(context.Background(), event)
+			}
+		}
+	}()
+	_, err = m.QueueRedeploys(context.Background(), event)
+	assert.Nil(t, err)
+	select {
+	case sent := <-jobs:
+		assert.GreaterOrEqual(t, sent.Sub(start), 600*time.Millisecond)
+		assert.Less(t, sent.Sub(start), 2*time.Second)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "redeploy job wasn't published before the maximum delay")
+	}
+}
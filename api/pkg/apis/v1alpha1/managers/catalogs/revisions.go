@@ -0,0 +1,327 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package catalogs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+
+	observability "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+)
+
+const (
+	// defaultRevisionLimit is the number of revisions kept for each catalog when revisions.limit isn't set
+	defaultRevisionLimit = 20
+	// defaultRevisionMaxSize is the largest size of the history of a catalog when revisions.maxSizeInBytes isn't
+	// set. The history is a single object, which stays below the 1.5 MiB etcd accepts.
+	defaultRevisionMaxSize = 1 << 20
+	// revisionRetries is how many times a history changed by another replica meanwhile is read and edited again
+	revisionRetries = 5
+)
+
+func revisionMetadata(namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":   "v1",
+		"group":     model.FederationGroup,
+		"resource":  "catalogrevisions",
+		"namespace": namespace,
+		"kind":      "CatalogRevision",
+	}
+}
+
+// revisionHistory is the stored history of a catalog. It's shaped like a CatalogRevision custom resource, so that
+// the Kubernetes state provider can keep it.
+type revisionHistory struct {
+	Metadata model.ObjectMeta `json:"metadata"`
+	Spec     struct {
+		Revisions []model.CatalogRevision `json:"revisions"`
+	} `json:"spec"`
+}
+
+func (m *CatalogsManager) getRevisions(ctx context.Context, name string, namespace string) ([]model.CatalogRevision, error) {
+	revisions, _, err := m.getHistory(ctx, name, namespace)
+	return revisions, err
+}
+
+// getHistory returns the revisions of a catalog and the ETag of its history
+func (m *CatalogsManager) getHistory(ctx context.Context, name string, namespace string) ([]model.CatalogRevision, string, error) {
+	if m.RevisionProvider == nil {
+		return nil, "", v1alpha2.NewCOAError(nil, "catalog revisions are not enabled", v1alpha2.NotImplemented)
+	}
+	entry, err := m.RevisionProvider.Get(ctx, states.GetRequest{
+		ID:       name,
+		Metadata: revisionMetadata(namespace),
+	})
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return nil, "", v1alpha2.NewCOAError(err, fmt.Sprintf("catalog %s has no revisions", name), v1alpha2.NotFound)
+		}
+		return nil, "", err
+	}
+	var history revisionHistory
+	jData, _ := json.Marshal(entry.Body)
+	if err = json.Unmarshal(jData, &history); err != nil {
+		return nil, "", err
+	}
+	return history.Spec.Revisions, entry.ETag, nil
+}
+
+// editRevisions replaces the history of a catalog with the revisions returned by edit. The history is only
+// written if it hasn't changed since it was read, as other replicas may edit it too, and it's read and edited
+// again otherwise.
+func (m *CatalogsManager) editRevisions(ctx context.Context, name string, namespace string, edit func(revisions []model.CatalogRevision) []model.CatalogRevision) error {
+	var err error
+	for i := 0; i < revisionRetries; i++ {
+		var revisions []model.CatalogRevision
+		var etag string
+		revisions, etag, err = m.getHistory(ctx, name, namespace)
+		if err != nil && !v1alpha2.IsNotFound(err) {
+			return err
+		}
+		history := revisionHistory{
+			Metadata: model.ObjectMeta{Name: name, Namespace: namespace},
+		}
+		history.Spec.Revisions, err = m.trimRevisions(name, edit(revisions))
+		if err != nil {
+			return err
+		}
+
+		// revisions are stored as plain JSON values, so later changes to the specs don't alter them
+		var body interface{}
+		jData, _ := json.Marshal(history)
+		json.Unmarshal(jData, &body)
+		_, err = m.RevisionProvider.Upsert(ctx, states.UpsertRequest{
+			Value: states.StateEntry{
+				ID:   name,
+				Body: body,
+				ETag: etag,
+			},
+			ETag:     &etag,
+			Metadata: revisionMetadata(namespace),
+		})
+		if !v1alpha2.IsConflict(err) {
+			return err
+		}
+		log.Debugf(" M (Catalogs): the revisions of catalog %s changed meanwhile, retrying", name)
+	}
+	return err
+}
+
+// trimRevisions drops the oldest revisions until the history of a catalog fits in RevisionMaxSize. It fails
+// when the latest revision alone doesn't fit.
+func (m *CatalogsManager) trimRevisions(name string, revisions []model.CatalogRevision) ([]model.CatalogRevision, error) {
+	if m.RevisionMaxSize <= 0 {
+		return revisions, nil
+	}
+	sizes := make([]int, len(revisions))
+	total := 0
+	for i, r := range revisions {
+		jData, _ := json.Marshal(r)
+		sizes[i] = len(jData)
+		total += sizes[i]
+	}
+	first := 0
+	for total > m.RevisionMaxSize && first < len(revisions) {
+		total -= sizes[first]
+		first++
+	}
+	if first == len(revisions) && len(revisions) > 0 {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("revision of catalog %s is %d bytes, more than the %d bytes revisions can take", name, sizes[len(sizes)-1], m.RevisionMaxSize), v1alpha2.BadRequest)
+	}
+	return revisions[first:], nil
+}
+
+// recordRevision appends a revision to the history of a catalog, dropping the oldest revisions beyond the limit,
+// and returns its number. The author is the user authenticated for the request. It's called before the catalog
+// is changed, so that no change goes unrecorded, and returns 0 when revisions aren't enabled.
+func (m *CatalogsManager) recordRevision(ctx context.Context, name string, namespace string, revision model.CatalogRevision) (int, error) {
+	if m.RevisionProvider == nil {
+		return 0, nil
+	}
+	err := m.editRevisions(ctx, name, namespace, func(revisions []model.CatalogRevision) []model.CatalogRevision {
+		revision.Revision = 1
+		if len(revisions) > 0 {
+			revision.Revision = revisions[len(revisions)-1].Revision + 1
+		}
+		if user, ok := ctx.Value(v1alpha2.AuthenticatedUser).(string); ok {
+			revision.Author = user
+		}
+		revision.Time = time.Now().UTC()
+		revisions = append(revisions, revision)
+		if len(revisions) > m.RevisionLimit {
+			revisions = revisions[len(revisions)-m.RevisionLimit:]
+		}
+		return revisions
+	})
+	if err != nil {
+		log.Errorf(" M (Catalogs): failed to record a revision of catalog %s: %v", name, err)
+		state := v1alpha2.InternalError
+		if coaE, ok := err.(v1alpha2.COAError); ok && coaE.State == v1alpha2.BadRequest {
+			state = v1alpha2.BadRequest
+		}
+		return 0, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to record a revision of catalog %s", name), state)
+	}
+	return revision.Revision, nil
+}
+
+// dropRevision removes a recorded revision when the change it records fails. Failures are logged, as the error
+// of the change is the one returned.
+func (m *CatalogsManager) dropRevision(ctx context.Context, name string, namespace string, revision int) {
+	if revision == 0 {
+		return
+	}
+	err := m.editRevisions(ctx, name, namespace, func(revisions []model.CatalogRevision) []model.CatalogRevision {
+		kept := make([]model.CatalogRevision, 0, len(revisions))
+		for _, r := range revisions {
+			if r.Revision != revision {
+				kept = append(kept, r)
+			}
+		}
+		return kept
+	})
+	if err != nil {
+		log.Errorf(" M (Catalogs): failed to drop revision %d of catalog %s: %v", revision, name, err)
+	}
+}
+
+// setRevisionGeneration sets the generation of the catalog a revision produced, which is only known once the
+// catalog is stored. Failures are logged, as the catalog is already changed.
+func (m *CatalogsManager) setRevisionGeneration(ctx context.Context, name string, namespace string, revision int, generation string) {
+	if revision == 0 || generation == "" {
+		return
+	}
+	err := m.editRevisions(ctx, name, namespace, func(revisions []model.CatalogRevision) []model.CatalogRevision {
+		for i := range revisions {
+			if revisions[i].Revision == revision {
+				revisions[i].Generation = generation
+			}
+		}
+		return revisions
+	})
+	if err != nil {
+		log.Errorf(" M (Catalogs): failed to set the generation of revision %d of catalog %s: %v", revision, name, err)
+	}
+}
+
+// RecordExternalChange records a revision for a catalog changed without the API, such as with kubectl. A nil
+// spec is a deletion. Changes made through the API are recorded already, so a revision is only recorded when the
+// spec differs from the one of the latest revision.
+func (m *CatalogsManager) RecordExternalChange(ctx context.Context, name string, namespace string, spec *model.CatalogSpec, generation string) error {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "RecordExternalChange",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	if m.RevisionProvider == nil {
+		return nil
+	}
+	var revisions []model.CatalogRevision
+	revisions, err = m.getRevisions(ctx, name, namespace)
+	if err != nil && !v1alpha2.IsNotFound(err) {
+		return err
+	}
+	err = nil
+	var latest *model.CatalogSpec
+	if len(revisions) > 0 {
+		latest = revisions[len(revisions)-1].Spec
+	}
+	diff := model.DiffCatalogSpecs(latest, spec)
+	if len(diff) == 0 {
+		return nil
+	}
+	action := model.CatalogRevisionUpdate
+	if spec == nil {
+		action = model.CatalogRevisionDelete
+	}
+	_, err = m.recordRevision(ctx, name, namespace, model.CatalogRevision{
+		Action:     action,
+		Generation: generation,
+		Diff:       diff,
+		Spec:       spec,
+	})
+	return err
+}
+
+// ListRevisions returns the kept revisions of a catalog, oldest first
+func (m *CatalogsManager) ListRevisions(ctx context.Context, name string, namespace string) ([]model.CatalogRevision, error) {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "ListRevisions",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var revisions []model.CatalogRevision
+	revisions, err = m.getRevisions(ctx, name, namespace)
+	return revisions, err
+}
+
+// GetRevision returns a revision of a catalog
+func (m *CatalogsManager) GetRevision(ctx context.Context, name string, namespace string, revision int) (model.CatalogRevision, error) {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "GetRevision",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var revisions []model.CatalogRevision
+	revisions, err = m.getRevisions(ctx, name, namespace)
+	if err != nil {
+		return model.CatalogRevision{}, err
+	}
+	for _, r := range revisions {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+	err = v1alpha2.NewCOAError(nil, fmt.Sprintf("revision %d of catalog %s not found", revision, name), v1alpha2.NotFound)
+	return model.CatalogRevision{}, err
+}
+
+// Rollback restores the spec of a catalog revision. The catalog keeps its current labels and annotations, and the
+// rollback is recorded as a new revision.
+func (m *CatalogsManager) Rollback(ctx context.Context, name string, namespace string, revision int) (model.CatalogState, error) {
+	ctx, span := observability.StartSpan("Catalogs Manager", ctx, &map[string]string{
+		"method": "Rollback",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var target model.CatalogRevision
+	target, err = m.GetRevision(ctx, name, namespace, revision)
+	if err != nil {
+		return model.CatalogState{}, err
+	}
+	if target.Spec == nil {
+		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("revision %d of catalog %s is a deletion and can't be restored", revision, name), v1alpha2.BadRequest)
+		return model.CatalogState{}, err
+	}
+	state := model.CatalogState{
+		ObjectMeta: model.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+	if existing, getErr := m.GetState(ctx, name, namespace); getErr == nil {
+		state.ObjectMeta = existing.ObjectMeta
+	}
+	state.Spec = target.Spec
+	state.Spec.Generation = ""
+	err = m.upsertState(ctx, name, state, model.CatalogRevisionRollback, revision)
+	if err != nil {
+		return model.CatalogState{}, err
+	}
+	state, err = m.GetState(ctx, name, namespace)
+	return state, err
+}
@@ -0,0 +1,374 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package catalogs
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	contexts "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/stretchr/testify/assert"
+)
+
+func createRevisionsManager(t *testing.T, limit string) *CatalogsManager {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	revisionProvider := &memorystate.MemoryStateProvider{}
+	revisionProvider.Init(memorystate.MemoryStateProviderConfig{})
+	vendorContext := &contexts.VendorContext{}
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendorContext.Init(&pubSubProvider)
+	config := managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state":     "state",
+			"providers.revisions": "revisions",
+		},
+	}
+	if limit != "" {
+		config.Properties["revisions.limit"] = limit
+	}
+	m := &CatalogsManager{}
+	err := m.Init(vendorContext, config, map[string]providers.IProvider{
+		"state":     stateProvider,
+		"revisions": revisionProvider,
+	})
+	assert.Nil(t, err)
+	return m
+}
+
+func upsertConfig(t *testing.T, ctx context.Context, m *CatalogsManager, properties map[string]interface{}) {
+	err := m.UpsertState(ctx, "config1", model.CatalogState{
+		ObjectMeta: model.ObjectMeta{Name: "config1"},
+		Spec: &model.CatalogSpec{
+			Type:       "config",
+			Properties: properties,
+		},
+	})
+	assert.Nil(t, err)
+}
+
+func TestRevisionsNotEnabled(t *testing.T) {
+	err := initalizeManager()
+	assert.Nil(t, err)
+	upsertConfig(t, context.Background(), &manager, map[string]interface{}{"replicas": 1})
+	_, err = manager.ListRevisions(context.Background(), "config1", "default")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "catalog revisions are not enabled")
+}
+
+func TestRevisionsInvalidLimit(t *testing.T) {
+	vendorContext := &contexts.VendorContext{}
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendorContext.Init(&pubSubProvider)
+	m := &CatalogsManager{}
+	err := m.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state": "state",
+			"revisions.limit": "0",
+		},
+	}, map[string]providers.IProvider{
+		"state": &memorystate.MemoryStateProvider{},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestRevisions(t *testing.T) {
+	m := createRevisionsManager(t, "")
+	ctx := context.WithValue(context.Background(), v1alpha2.AuthenticatedUser, "alice")
+	upsertConfig(t, ctx, m, map[string]interface{}{"replicas": 1, "image": "nginx"})
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 2, "image": "nginx", "port": 80})
+
+	revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, 1, revisions[0].Revision)
+	assert.Equal(t, model.CatalogRevisionUpdate, revisions[0].Action)
+	assert.Equal(t, "alice", revisions[0].Author)
+	assert.NotEmpty(t, revisions[0].Generation)
+	assert.False(t, revisions[0].Time.IsZero())
+	assert.Equal(t, 2, revisions[1].Revision)
+	assert.Equal(t, "", revisions[1].Author)
+	assert.Equal(t, []model.CatalogChange{
+		{Op: "add", Path: "/properties/port", Value: float64(80)},
+		{Op: "replace", Path: "/properties/replicas", Old: float64(1), Value: float64(2)},
+	}, revisions[1].Diff)
+
+	revision, err := m.GetRevision(context.Background(), "config1", "default", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), revision.Spec.Properties["replicas"])
+
+	_, err = m.GetRevision(context.Background(), "config1", "default", 3)
+	assert.True(t, v1alpha2.IsNotFound(err))
+	_, err = m.ListRevisions(context.Background(), "config2", "default")
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestRevisionsLimit(t *testing.T) {
+	m := createRevisionsManager(t, "2")
+	for i := 1; i <= 3; i++ {
+		upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": i})
+	}
+	revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, 2, revisions[0].Revision)
+	assert.Equal(t, 3, revisions[1].Revision)
+	_, err = m.GetRevision(context.Background(), "config1", "default", 1)
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestRevisionsMaxSize(t *testing.T) {
+	m := createRevisionsManager(t, "")
+	large := strings.Repeat("x", 400)
+	m.RevisionMaxSize = 2000
+	for i := 1; i <= 5; i++ {
+		upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": i, "large": large})
+	}
+	revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Less(t, len(revisions), 5)
+	assert.Equal(t, 5, revisions[len(revisions)-1].Revision)
+	jData, _ := json.Marshal(revisions)
+	assert.LessOrEqual(t, len(jData), 2000+len(revisions))
+
+	// a revision larger than the history can take isn't recorded, and the catalog isn't changed
+	err = m.UpsertState(context.Background(), "config1", model.CatalogState{
+		ObjectMeta: model.ObjectMeta{Name: "config1"},
+		Spec:       &model.CatalogSpec{Type: "config", Properties: map[string]interface{}{"large": strings.Repeat("x", 3000)}},
+	})
+	assert.NotNil(t, err)
+	coaE, ok := err.(v1alpha2.COAError)
+	assert.True(t, ok)
+	assert.Equal(t, v1alpha2.BadRequest, coaE.State)
+	state, err := m.GetState(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(5), state.Spec.Properties["replicas"])
+}
+
+// interleavingStateProvider lets another replica change the stored history right before the next upsert
+type interleavingStateProvider struct {
+	states.IStateProvider
+	interleave func()
+}
+
+func (p *interleavingStateProvider) Upsert(ctx context.Context, request states.UpsertRequest) (string, error) {
+	if interleave := p.interleave; interleave != nil {
+		p.interleave = nil
+		interleave()
+	}
+	return p.IStateProvider.Upsert(ctx, request)
+}
+
+func TestRevisionsEditedByAnotherReplica(t *testing.T) {
+	m := createRevisionsManager(t, "")
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 1})
+
+	// another replica records a revision between the read and the write of the history
+	other := &CatalogsManager{RevisionProvider: m.RevisionProvider, RevisionLimit: defaultRevisionLimit}
+	m.RevisionProvider = &interleavingStateProvider{
+		IStateProvider: m.RevisionProvider,
+		interleave: func() {
+			_, err := other.recordRevision(context.Background(), "config1", "default", model.CatalogRevision{Action: model.CatalogRevisionUpdate})
+			assert.Nil(t, err)
+		},
+	}
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 2})
+
+	revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	for i, r := range revisions {
+		assert.Equal(t, i+1, r.Revision)
+	}
+	assert.Nil(t, revisions[1].Spec)
+	assert.Equal(t, float64(2), revisions[2].Spec.Properties["replicas"])
+}
+
+func TestRollback(t *testing.T) {
+	m := createRevisionsManager(t, "")
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 1})
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 2})
+
+	ctx := context.WithValue(context.Background(), v1alpha2.AuthenticatedUser, "bob")
+	state, err := m.Rollback(ctx, "config1", "default", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), state.Spec.Properties["replicas"])
+
+	state, err = m.GetState(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), state.Spec.Properties["replicas"])
+
+	revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(revisions))
+	assert.Equal(t, model.CatalogRevisionRollback, revisions[2].Action)
+	assert.Equal(t, 1, revisions[2].SourceRevision)
+	assert.Equal(t, "bob", revisions[2].Author)
+	assert.Equal(t, []model.CatalogChange{
+		{Op: "replace", Path: "/properties/replicas", Old: float64(2), Value: float64(1)},
+	}, revisions[2].Diff)
+
+	// earlier revisions aren't changed by a rollback
+	revision, err := m.GetRevision(context.Background(), "config1", "default", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(2), revision.Spec.Properties["replicas"])
+
+	_, err = m.Rollback(context.Background(), "config1", "default", 5)
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestRollbackDeletedCatalog(t *testing.T) {
+	m := createRevisionsManager(t, "")
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 1})
+	err := m.DeleteState(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+
+	revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, model.CatalogRevisionDelete, revisions[1].Action)
+	assert.Nil(t, revisions[1].Spec)
+
+	_, err = m.Rollback(context.Background(), "config1", "default", 2)
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "is a deletion")
+
+	state, err := m.Rollback(context.Background(), "config1", "default", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "config", state.Spec.Type)
+	assert.Equal(t, float64(1), state.Spec.Properties["replicas"])
+}
+
+// failingStateProvider is a state provider whose changes fail while fail is set
+type failingStateProvider struct {
+	states.IStateProvider
+	fail bool
+}
+
+func (p *failingStateProvider) Upsert(ctx context.Context, request states.UpsertRequest) (string, error) {
+	if p.fail {
+		return "", v1alpha2.NewCOAError(nil, "provider is unavailable", v1alpha2.InternalError)
+	}
+	return p.IStateProvider.Upsert(ctx, request)
+}
+
+func (p *failingStateProvider) Delete(ctx context.Context, request states.DeleteRequest) error {
+	if p.fail {
+		return v1alpha2.NewCOAError(nil, "provider is unavailable", v1alpha2.InternalError)
+	}
+	return p.IStateProvider.Delete(ctx, request)
+}
+
+func TestRevisionsStoredAsResources(t *testing.T) {
+	m := createRevisionsManager(t, "")
+	upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 1})
+	entry, err := m.RevisionProvider.Get(context.Background(), states.GetRequest{
+		ID:       "config1",
+		Metadata: revisionMetadata("default"),
+	})
+	assert.Nil(t, err)
+	body := entry.Body.(map[string]interface{})
+	assert.Equal(t, "config1", body["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, 1, len(body["spec"].(map[string]interface{})["revisions"].([]interface{})))
+}
+
+func TestRevisionsWithFailures(t *testing.T) {
+	testCases := []struct {
+		name           string
+		failRevisions  bool
+		failState      bool
+		revisions      int
+		storedReplicas float64
+	}{
+		{name: "revision isn't recorded", failRevisions: true, revisions: 1, storedReplicas: 1},
+		{name: "catalog isn't stored", failState: true, revisions: 1, storedReplicas: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := createRevisionsManager(t, "")
+			stateProvider := &failingStateProvider{IStateProvider: m.StateProvider}
+			revisionProvider := &failingStateProvider{IStateProvider: m.RevisionProvider}
+			m.StateProvider, m.RevisionProvider = stateProvider, revisionProvider
+			upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 1})
+
+			stateProvider.fail, revisionProvider.fail = tc.failState, tc.failRevisions
+			err := m.UpsertState(context.Background(), "config1", model.CatalogState{
+				ObjectMeta: model.ObjectMeta{Name: "config1"},
+				Spec:       &model.CatalogSpec{Type: "config", Properties: map[string]interface{}{"replicas": 2}},
+			})
+			assert.NotNil(t, err)
+			err = m.DeleteState(context.Background(), "config1", "default")
+			assert.NotNil(t, err)
+			stateProvider.fail, revisionProvider.fail = false, false
+
+			// the catalog and its revisions are unchanged
+			state, err := m.GetState(context.Background(), "config1", "default")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.storedReplicas, state.Spec.Properties["replicas"])
+			revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.revisions, len(revisions))
+		})
+	}
+}
+
+func TestRecordExternalChange(t *testing.T) {
+	spec := func(replicas int) *model.CatalogSpec {
+		return &model.CatalogSpec{Type: "config", Properties: map[string]interface{}{"replicas": replicas}}
+	}
+	testCases := []struct {
+		name      string
+		spec      *model.CatalogSpec
+		revisions int
+		action    string
+	}{
+		{name: "change made through the API", spec: spec(1), revisions: 1, action: model.CatalogRevisionUpdate},
+		{name: "changed spec", spec: spec(2), revisions: 2, action: model.CatalogRevisionUpdate},
+		{name: "deleted catalog", revisions: 2, action: model.CatalogRevisionDelete},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := createRevisionsManager(t, "")
+			upsertConfig(t, context.Background(), m, map[string]interface{}{"replicas": 1})
+			err := m.RecordExternalChange(context.Background(), "config1", "default", tc.spec, "7")
+			assert.Nil(t, err)
+			revisions, err := m.ListRevisions(context.Background(), "config1", "default")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.revisions, len(revisions))
+			latest := revisions[len(revisions)-1]
+			assert.Equal(t, tc.action, latest.Action)
+			if tc.revisions == 2 {
+				assert.Equal(t, "7", latest.Generation)
+			}
+
+			// the same change isn't recorded twice
+			err = m.RecordExternalChange(context.Background(), "config1", "default", tc.spec, "7")
+			assert.Nil(t, err)
+			revisions, err = m.ListRevisions(context.Background(), "config1", "default")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.revisions, len(revisions))
+		})
+	}
+}
+
+func TestRecordExternalChangeNotEnabled(t *testing.T) {
+	err := initalizeManager()
+	assert.Nil(t, err)
+	err = manager.RecordExternalChange(context.Background(), "config1", "default", nil, "")
+	assert.Nil(t, err)
+}
@@ -9,10 +9,9 @@ package instances
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
-	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
@@ -61,6 +60,11 @@ func (t *InstancesManager) DeleteState(ctx context.Context, name string, namespa
 	return err
 }
 
+// ValidateUpsert checks the name of an instance before it's stored
+func (t *InstancesManager) ValidateUpsert(ctx context.Context, name string, state model.InstanceState) error {
+	return api_utils.ValidateObjectName(name, state.ObjectMeta)
+}
+
 func (t *InstancesManager) UpsertState(ctx context.Context, name string, state model.InstanceState) error {
 	ctx, span := observability.StartSpan("Instances Manager", ctx, &map[string]string{
 		"method": "UpsertSpec",
@@ -68,8 +72,8 @@ func (t *InstancesManager) UpsertState(ctx context.Context, name string, state m
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	if state.ObjectMeta.Name != "" && state.ObjectMeta.Name != name {
-		return v1alpha2.NewCOAError(nil, fmt.Sprintf("Name in metadata (%s) does not match name in request (%s)", state.ObjectMeta.Name, name), v1alpha2.BadRequest)
+	if err = t.ValidateUpsert(ctx, name, state); err != nil {
+		return err
 	}
 	state.ObjectMeta.FixNames(name)
 
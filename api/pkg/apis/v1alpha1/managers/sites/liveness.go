@@ -0,0 +1,205 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package sites
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+)
+
+const (
+	defaultOfflineThreshold = 180 * time.Second
+	defaultHistorySize      = 20
+)
+
+func (s *SitesManager) livenessEnabled() bool {
+	return s.Config.Properties["liveness.enabled"] == "true"
+}
+
+func (s *SitesManager) offlineThreshold() time.Duration {
+	if s.OfflineThreshold <= 0 {
+		return defaultOfflineThreshold
+	}
+	return s.OfflineThreshold
+}
+
+func (s *SitesManager) historySize() int {
+	if s.HistorySize <= 0 {
+		return defaultHistorySize
+	}
+	return s.HistorySize
+}
+
+// RecordHeartbeat records that a site has just contacted this site, for example to sync. Heartbeats are kept in
+// memory and complement the reports a site sends.
+func (s *SitesManager) RecordHeartbeat(site string) {
+	s.heartbeatLock.Lock()
+	defer s.heartbeatLock.Unlock()
+	if s.heartbeats == nil {
+		s.heartbeats = make(map[string]time.Time)
+	}
+	s.heartbeats[site] = time.Now().UTC()
+}
+
+// lastSeen returns when a site has last reported or sent a heartbeat
+func (s *SitesManager) lastSeen(site model.SiteState) time.Time {
+	var ret time.Time
+	if site.Status.LastReported != "" {
+		if t, err := time.Parse(time.RFC3339, site.Status.LastReported); err == nil {
+			ret = t
+		}
+	}
+	s.heartbeatLock.Lock()
+	defer s.heartbeatLock.Unlock()
+	if t, ok := s.heartbeats[site.Id]; ok && t.After(ret) {
+		ret = t
+	}
+	return ret
+}
+
+// trackLiveness marks the child sites that haven't been seen within the offline threshold as offline, and the
+// sites that have been seen again as online
+func (s *SitesManager) trackLiveness(ctx context.Context) []error {
+	ctx, span := observability.StartSpan("Sites Manager", ctx, &map[string]string{
+		"method": "trackLiveness",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var sites []model.SiteState
+	sites, err = s.ListState(ctx)
+	if err != nil {
+		return []error{err}
+	}
+	errs := make([]error, 0)
+	now := time.Now().UTC()
+	for _, site := range sites {
+		if site.Spec.IsSelf || site.Id == s.VendorContext.SiteInfo.SiteId {
+			continue
+		}
+		lastSeen := s.lastSeen(site)
+		online := !lastSeen.IsZero() && now.Sub(lastSeen) <= s.offlineThreshold()
+		if online == site.Status.IsOnline {
+			continue
+		}
+		reason := fmt.Sprintf("site was seen at %s", lastSeen.Format(time.RFC3339))
+		if !online {
+			reason = fmt.Sprintf("site hasn't been seen for more than %v", s.offlineThreshold())
+			if !lastSeen.IsZero() {
+				reason = fmt.Sprintf("site hasn't been seen since %s", lastSeen.Format(time.RFC3339))
+			}
+		}
+		if e := s.setOnline(ctx, site.Id, online, reason); e != nil {
+			log.Errorf(" M (Sites): failed to update the liveness of site %s: %s", site.Id, e.Error())
+			errs = append(errs, e)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// setOnline records a connectivity transition of a site and publishes it
+func (s *SitesManager) setOnline(ctx context.Context, name string, online bool, reason string) error {
+	getRequest := states.GetRequest{
+		ID: name,
+		Metadata: map[string]interface{}{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "sites",
+		},
+	}
+	entry, err := s.StateProvider.Get(ctx, getRequest)
+	if err != nil {
+		return err
+	}
+	siteState, err := getSiteState(entry.ID, entry.Body)
+	if err != nil {
+		return err
+	}
+	if siteState.Status.IsOnline == online {
+		return nil
+	}
+	transition := s.recordTransition(siteState.Status, online, reason)
+	_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{ID: name, Body: siteState, ETag: entry.ETag},
+		Metadata: map[string]interface{}{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "sites",
+		},
+	})
+	if err != nil {
+		return err
+	}
+	s.publishTransition(name, transition)
+	return nil
+}
+
+// recordTransition flips the online flag of a site status and appends the transition to its history
+func (s *SitesManager) recordTransition(status *model.SiteStatus, online bool, reason string) model.SiteConnectivity {
+	transition := model.SiteConnectivity{
+		IsOnline: online,
+		Time:     time.Now().UTC().Format(time.RFC3339),
+		Reason:   reason,
+	}
+	status.IsOnline = online
+	status.ConnectivityHistory = append(status.ConnectivityHistory, transition)
+	if len(status.ConnectivityHistory) > s.historySize() {
+		status.ConnectivityHistory = status.ConnectivityHistory[len(status.ConnectivityHistory)-s.historySize():]
+	}
+	return transition
+}
+
+func (s *SitesManager) publishTransition(name string, transition model.SiteConnectivity) {
+	topic := "site-offline"
+	if transition.IsOnline {
+		topic = "site-online"
+		log.Infof(" M (Sites): site %s is online: %s", name, transition.Reason)
+	} else {
+		log.Infof(" M (Sites): site %s is offline: %s", name, transition.Reason)
+	}
+	if s.VendorContext == nil {
+		return
+	}
+	err := s.VendorContext.Publish(topic, v1alpha2.Event{
+		Metadata: map[string]string{
+			"site": name,
+		},
+		Body: transition,
+	})
+	if err != nil {
+		log.Errorf(" M (Sites): failed to publish %s event of site %s: %s", topic, name, err.Error())
+	}
+}
+
+// GetConnectivityHistory returns the latest online and offline transitions of a site, oldest first
+func (s *SitesManager) GetConnectivityHistory(ctx context.Context, name string) ([]model.SiteConnectivity, error) {
+	ctx, span := observability.StartSpan("Sites Manager", ctx, &map[string]string{
+		"method": "GetConnectivityHistory",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var site model.SiteState
+	site, err = s.GetState(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if site.Status.ConnectivityHistory == nil {
+		return []model.SiteConnectivity{}, nil
+	}
+	return site.Status.ConnectivityHistory, nil
+}
@@ -0,0 +1,263 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package sites
+
+import (
+	"context"
+	"sort"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+)
+
+func statusKey(site string, namespace string, name string) string {
+	return site + "/" + namespace + "/" + name
+}
+
+// deployableState converts the provisioning status of an instance or a target to a site status
+func deployableState(status model.DeployableStatus) (v1alpha2.State, string) {
+	switch status.ProvisioningStatus.Status {
+	case "Succeeded":
+		return v1alpha2.OK, ""
+	case "Failed":
+		reason := status.ProvisioningStatus.FailureCause
+		if reason == "" {
+			reason = status.ProvisioningStatus.Error.Message
+		}
+		return v1alpha2.InternalError, reason
+	case "Cancelled":
+		return v1alpha2.InternalError, "Cancelled"
+	case "":
+		return v1alpha2.Untouched, "status isn't reported"
+	}
+	return v1alpha2.Running, status.ProvisioningStatus.Status
+}
+
+// rollupStatus records the statuses of this site's own targets and instances, together with the statuses its
+// child sites reported, in the status of this site. This site reports them to its parent, so that each site
+// knows about all the targets and instances of its descendants.
+func (s *SitesManager) rollupStatus(ctx context.Context) error {
+	ctx, span := observability.StartSpan("Sites Manager", ctx, &map[string]string{
+		"method": "rollupStatus",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	self := s.VendorContext.SiteInfo.SiteId
+	getRequest := states.GetRequest{
+		ID: self,
+		Metadata: map[string]interface{}{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "sites",
+		},
+	}
+	var entry states.StateEntry
+	entry, err = s.StateProvider.Get(ctx, getRequest)
+	if err != nil {
+		return err
+	}
+	var siteState model.SiteState
+	siteState, err = getSiteState(entry.ID, entry.Body)
+	if err != nil {
+		return err
+	}
+
+	targets, instances := s.ownStatuses(siteState.Status)
+	if currentSite := s.VendorContext.SiteInfo.CurrentSite; currentSite.BaseUrl != "" {
+		targets, instances, err = s.collectOwnStatuses(ctx, currentSite)
+		if err != nil {
+			return err
+		}
+	}
+	var childTargets map[string]model.SiteTargetStatus
+	var childInstances map[string]model.SiteInstanceStatus
+	childTargets, childInstances, err = s.collectChildStatuses(ctx)
+	if err != nil {
+		return err
+	}
+	for k, v := range childTargets {
+		targets[k] = v
+	}
+	for k, v := range childInstances {
+		instances[k] = v
+	}
+	siteState.Status.TargetStatuses = targets
+	siteState.Status.InstanceStatuses = instances
+
+	_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{ID: self, Body: siteState, ETag: entry.ETag},
+		Metadata: map[string]interface{}{
+			"version":  "v1",
+			"group":    model.FederationGroup,
+			"resource": "sites",
+		},
+	})
+	return err
+}
+
+// ownStatuses returns the statuses of this site's own targets and instances recorded in its status
+func (s *SitesManager) ownStatuses(status *model.SiteStatus) (map[string]model.SiteTargetStatus, map[string]model.SiteInstanceStatus) {
+	self := s.VendorContext.SiteInfo.SiteId
+	targets := make(map[string]model.SiteTargetStatus)
+	instances := make(map[string]model.SiteInstanceStatus)
+	for k, v := range status.TargetStatuses {
+		if v.Site == self {
+			targets[k] = v
+		}
+	}
+	for k, v := range status.InstanceStatuses {
+		if v.Site == self {
+			instances[k] = v
+		}
+	}
+	return targets, instances
+}
+
+// collectOwnStatuses reads the statuses of the targets and instances deployed on this site
+func (s *SitesManager) collectOwnStatuses(ctx context.Context, currentSite v1alpha2.SiteConnection) (map[string]model.SiteTargetStatus, map[string]model.SiteInstanceStatus, error) {
+	self := s.VendorContext.SiteInfo.SiteId
+	targetStates, err := utils.GetTargetsForAllNamespaces(ctx, currentSite.BaseUrl, currentSite.Username, currentSite.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+	instanceStates, err := utils.GetInstancesForAllNamespaces(ctx, currentSite.BaseUrl, currentSite.Username, currentSite.Password)
+	if err != nil {
+		return nil, nil, err
+	}
+	targets := make(map[string]model.SiteTargetStatus)
+	for _, target := range targetStates {
+		state, reason := deployableState(target.Status)
+		targets[statusKey(self, target.ObjectMeta.Namespace, target.ObjectMeta.Name)] = model.SiteTargetStatus{
+			State:     state,
+			Reason:    reason,
+			Name:      target.ObjectMeta.Name,
+			Namespace: target.ObjectMeta.Namespace,
+			Site:      self,
+			Path:      []string{self},
+		}
+	}
+	instances := make(map[string]model.SiteInstanceStatus)
+	for _, instance := range instanceStates {
+		state, reason := deployableState(instance.Status)
+		instances[statusKey(self, instance.ObjectMeta.Namespace, instance.ObjectMeta.Name)] = model.SiteInstanceStatus{
+			State:     state,
+			Reason:    reason,
+			Name:      instance.ObjectMeta.Name,
+			Namespace: instance.ObjectMeta.Namespace,
+			Site:      self,
+			Path:      []string{self},
+		}
+	}
+	return targets, instances, nil
+}
+
+// collectChildStatuses gathers the statuses the child sites reported, which include the statuses of their own
+// descendants. This site is prepended to their paths.
+func (s *SitesManager) collectChildStatuses(ctx context.Context) (map[string]model.SiteTargetStatus, map[string]model.SiteInstanceStatus, error) {
+	self := s.VendorContext.SiteInfo.SiteId
+	sites, err := s.ListState(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	targets := make(map[string]model.SiteTargetStatus)
+	instances := make(map[string]model.SiteInstanceStatus)
+	for _, site := range sites {
+		if site.Spec.IsSelf || site.Id == self {
+			continue
+		}
+		for k, v := range site.Status.TargetStatuses {
+			v.Path = append([]string{self}, v.Path...)
+			targets[k] = v
+		}
+		for k, v := range site.Status.InstanceStatuses {
+			v.Path = append([]string{self}, v.Path...)
+			instances[k] = v
+		}
+	}
+	return targets, instances, nil
+}
+
+// LocateInstance returns where the instances with the given name run across this site and its descendants,
+// with their statuses. An empty namespace matches all namespaces.
+func (s *SitesManager) LocateInstance(ctx context.Context, name string, namespace string) ([]model.SiteInstanceStatus, error) {
+	ctx, span := observability.StartSpan("Sites Manager", ctx, &map[string]string{
+		"method": "LocateInstance",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var instances map[string]model.SiteInstanceStatus
+	_, instances, err = s.treeStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]model.SiteInstanceStatus, 0)
+	for _, v := range instances {
+		if v.Name == name && (namespace == "" || v.Namespace == namespace) {
+			ret = append(ret, v)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return statusKey(ret[i].Site, ret[i].Namespace, ret[i].Name) < statusKey(ret[j].Site, ret[j].Namespace, ret[j].Name)
+	})
+	return ret, nil
+}
+
+// LocateTarget returns where the targets with the given name are across this site and its descendants, with
+// their statuses. An empty namespace matches all namespaces.
+func (s *SitesManager) LocateTarget(ctx context.Context, name string, namespace string) ([]model.SiteTargetStatus, error) {
+	ctx, span := observability.StartSpan("Sites Manager", ctx, &map[string]string{
+		"method": "LocateTarget",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var targets map[string]model.SiteTargetStatus
+	targets, _, err = s.treeStatuses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]model.SiteTargetStatus, 0)
+	for _, v := range targets {
+		if v.Name == name && (namespace == "" || v.Namespace == namespace) {
+			ret = append(ret, v)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return statusKey(ret[i].Site, ret[i].Namespace, ret[i].Name) < statusKey(ret[j].Site, ret[j].Namespace, ret[j].Name)
+	})
+	return ret, nil
+}
+
+// treeStatuses combines the last recorded statuses of this site's own objects with the latest statuses reported
+// by its child sites
+func (s *SitesManager) treeStatuses(ctx context.Context) (map[string]model.SiteTargetStatus, map[string]model.SiteInstanceStatus, error) {
+	targets := make(map[string]model.SiteTargetStatus)
+	instances := make(map[string]model.SiteInstanceStatus)
+	self, err := s.GetState(ctx, s.VendorContext.SiteInfo.SiteId)
+	if err == nil {
+		targets, instances = s.ownStatuses(self.Status)
+	} else if !v1alpha2.IsNotFound(err) {
+		return nil, nil, err
+	}
+	childTargets, childInstances, err := s.collectChildStatuses(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range childTargets {
+		targets[k] = v
+	}
+	for k, v := range childInstances {
+		instances[k] = v
+	}
+	return targets, instances, nil
+}
@@ -10,6 +10,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -21,11 +23,18 @@ import (
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 )
 
+var log = logger.NewLogger("coa.runtime")
+
 type SitesManager struct {
 	managers.Manager
-	StateProvider states.IStateProvider
+	StateProvider    states.IStateProvider
+	OfflineThreshold time.Duration
+	HistorySize      int
+	heartbeats       map[string]time.Time
+	heartbeatLock    sync.Mutex
 }
 
 func (s *SitesManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -39,6 +48,20 @@ func (s *SitesManager) Init(context *contexts.VendorContext, config managers.Man
 	} else {
 		return err
 	}
+	if v, ok := config.Properties["liveness.offlineThresholdInSec"]; ok {
+		threshold, err := strconv.Atoi(v)
+		if err != nil || threshold <= 0 {
+			return v1alpha2.NewCOAError(err, "'liveness.offlineThresholdInSec' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.OfflineThreshold = time.Duration(threshold) * time.Second
+	}
+	if v, ok := config.Properties["liveness.historySize"]; ok {
+		size, err := strconv.Atoi(v)
+		if err != nil || size <= 0 {
+			return v1alpha2.NewCOAError(err, "'liveness.historySize' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.HistorySize = size
+	}
 	return nil
 }
 
@@ -133,14 +156,19 @@ func (t *SitesManager) ReportState(ctx context.Context, current model.SiteState)
 		siteState.Status = &model.SiteStatus{}
 	}
 
-	// if current.Status is not nil, update the status using new IsOnline, InstanceStatuses and TargetStatuses
+	// if current.Status is not nil, update the status using new InstanceStatuses and TargetStatuses
 	// otherwise, only update LastReported as time.Now()
 	if current.Status != nil {
-		siteState.Status.IsOnline = current.Status.IsOnline
 		siteState.Status.InstanceStatuses = current.Status.InstanceStatuses
 		siteState.Status.TargetStatuses = current.Status.TargetStatuses
 	}
 	siteState.Status.LastReported = time.Now().UTC().Format(time.RFC3339)
+	// a report proves that the site is online, it's marked as offline by Poll once it stops reporting
+	var transition *model.SiteConnectivity
+	if !siteState.Status.IsOnline {
+		online := t.recordTransition(siteState.Status, true, "site reported its status")
+		transition = &online
+	}
 
 	updateRequest := states.UpsertRequest{
 		Value:    states.StateEntry{ID: current.Id, Body: siteState, ETag: entry.ETag},
@@ -151,6 +179,9 @@ func (t *SitesManager) ReportState(ctx context.Context, current model.SiteState)
 	if err != nil {
 		return err
 	}
+	if transition != nil {
+		t.publishTransition(current.Id, *transition)
+	}
 	return nil
 }
 
@@ -241,7 +272,7 @@ func (t *SitesManager) ListState(ctx context.Context) ([]model.SiteState, error)
 	return ret, nil
 }
 func (s *SitesManager) Enabled() bool {
-	return s.VendorContext.SiteInfo.ParentSite.BaseUrl != ""
+	return s.VendorContext.SiteInfo.ParentSite.BaseUrl != "" || s.livenessEnabled()
 }
 func (s *SitesManager) Poll() []error {
 	ctx, span := observability.StartSpan("Sites Manager", context.Background(), &map[string]string{
@@ -250,11 +281,23 @@ func (s *SitesManager) Poll() []error {
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	var thisSite model.SiteState
-	thisSite, err = s.GetState(ctx, s.VendorContext.SiteInfo.SiteId)
+	// the rollup runs before the report, so that the parent receives the statuses of the whole subtree
+	if rollupErr := s.rollupStatus(ctx); rollupErr != nil && !v1alpha2.IsNotFound(rollupErr) {
+		log.Errorf(" M (Sites): failed to roll up the statuses of site %s: %+v", s.VendorContext.SiteInfo.SiteId, rollupErr)
+	}
+	if s.VendorContext.SiteInfo.ParentSite.BaseUrl != "" {
+		s.reportToParent(ctx)
+	}
+	if s.livenessEnabled() {
+		return s.trackLiveness(ctx)
+	}
+	return nil
+}
+func (s *SitesManager) reportToParent(ctx context.Context) {
+	thisSite, err := s.GetState(ctx, s.VendorContext.SiteInfo.SiteId)
 	if err != nil {
 		//TOOD: only ignore not found, and log the error
-		return nil
+		return
 	}
 	thisSite.Spec.IsSelf = false
 	jData, _ := json.Marshal(thisSite)
@@ -266,7 +309,6 @@ func (s *SitesManager) Poll() []error {
 		s.VendorContext.SiteInfo.ParentSite.Password,
 		jData,
 	)
-	return nil
 }
 func (s *SitesManager) Reconcil() []error {
 	return nil
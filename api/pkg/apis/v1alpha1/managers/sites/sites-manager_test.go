@@ -8,9 +8,18 @@ package sites
 
 import (
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/stretchr/testify/assert"
 )
@@ -58,3 +67,211 @@ func TestUpdateTargetStatus(t *testing.T) {
 	assert.Equal(t, true, spec.Status.IsOnline)
 	assert.NotEqual(t, "", spec.Status.LastReported)
 }
+
+func newLivenessTestManager(t *testing.T) (*SitesManager, chan v1alpha2.Event) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	pubsubProvider := &memory.InMemoryPubSubProvider{}
+	pubsubProvider.Init(memory.InMemoryPubSubConfig{})
+	vendorContext := &contexts.VendorContext{}
+	vendorContext.Init(pubsubProvider)
+	vendorContext.SiteInfo = v1alpha2.SiteInfo{
+		SiteId: "hq",
+	}
+	manager := &SitesManager{}
+	err := manager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state":      "mem-state",
+			"liveness.enabled":     "true",
+			"liveness.historySize": "2",
+		},
+	}, map[string]providers.IProvider{
+		"mem-state": stateProvider,
+	})
+	assert.Nil(t, err)
+	manager.OfflineThreshold = 50 * time.Millisecond
+
+	events := make(chan v1alpha2.Event, 10)
+	handler := func(topic string, event v1alpha2.Event) error {
+		event.Metadata["topic"] = topic
+		events <- event
+		return nil
+	}
+	vendorContext.Subscribe("site-online", handler)
+	vendorContext.Subscribe("site-offline", handler)
+	return manager, events
+}
+
+func waitForSiteEvent(t *testing.T, events chan v1alpha2.Event) v1alpha2.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "no site event was published")
+		return v1alpha2.Event{}
+	}
+}
+
+func TestInitWithBadOfflineThreshold(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	vendorContext := &contexts.VendorContext{}
+	vendorContext.Init(nil)
+	manager := SitesManager{}
+	err := manager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state":                "mem-state",
+			"liveness.offlineThresholdInSec": "soon",
+		},
+	}, map[string]providers.IProvider{
+		"mem-state": stateProvider,
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestSiteLiveness(t *testing.T) {
+	manager, events := newLivenessTestManager(t)
+	assert.True(t, manager.Enabled())
+
+	err := manager.ReportState(context.Background(), model.SiteState{
+		Id:   "child",
+		Spec: &model.SiteSpec{Name: "child"},
+	})
+	assert.Nil(t, err)
+	event := waitForSiteEvent(t, events)
+	assert.Equal(t, "site-online", event.Metadata["topic"])
+	assert.Equal(t, "child", event.Metadata["site"])
+
+	// the site stops reporting
+	time.Sleep(100 * time.Millisecond)
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	event = waitForSiteEvent(t, events)
+	assert.Equal(t, "site-offline", event.Metadata["topic"])
+	site, err := manager.GetState(context.Background(), "child")
+	assert.Nil(t, err)
+	assert.False(t, site.Status.IsOnline)
+
+	// a heartbeat brings the site back online
+	manager.RecordHeartbeat("child")
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	event = waitForSiteEvent(t, events)
+	assert.Equal(t, "site-online", event.Metadata["topic"])
+
+	// the history is capped
+	history, err := manager.GetConnectivityHistory(context.Background(), "child")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(history))
+	assert.False(t, history[0].IsOnline)
+	assert.True(t, history[1].IsOnline)
+}
+
+func TestSiteLivenessDisabledByDefault(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	vendorContext := &contexts.VendorContext{}
+	vendorContext.Init(nil)
+	vendorContext.SiteInfo = v1alpha2.SiteInfo{
+		SiteId: "hq",
+	}
+	manager := SitesManager{}
+	err := manager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"providers.state": "mem-state",
+		},
+	}, map[string]providers.IProvider{
+		"mem-state": stateProvider,
+	})
+	assert.Nil(t, err)
+	assert.False(t, manager.Enabled())
+}
+
+func TestSiteLivenessIgnoresSelf(t *testing.T) {
+	manager, events := newLivenessTestManager(t)
+	err := manager.UpsertSpec(context.Background(), "hq", model.SiteSpec{Name: "hq", IsSelf: true})
+	assert.Nil(t, err)
+	err = manager.UpsertSpec(context.Background(), "quiet", model.SiteSpec{Name: "quiet"})
+	assert.Nil(t, err)
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	select {
+	case event := <-events:
+		assert.Fail(t, "unexpected site event", "%v", event)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestSiteStatusRollup(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/instances":
+			response = []model.InstanceState{{
+				ObjectMeta: model.ObjectMeta{Name: "instance1", Namespace: "default"},
+				Status: model.InstanceStatus{
+					ProvisioningStatus: model.ProvisioningStatus{Status: "Failed", FailureCause: "image not found"},
+				},
+			}}
+		case "/targets/registry":
+			response = []model.TargetState{{
+				ObjectMeta: model.ObjectMeta{Name: "target1", Namespace: "default"},
+				Status: model.TargetStatus{
+					ProvisioningStatus: model.ProvisioningStatus{Status: "Succeeded"},
+				},
+			}}
+		default:
+			response = map[string]interface{}{"accessToken": "test-token", "tokenType": "Bearer"}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer ts.Close()
+
+	manager, _ := newLivenessTestManager(t)
+	manager.VendorContext.SiteInfo.CurrentSite = v1alpha2.SiteConnection{BaseUrl: ts.URL + "/"}
+	err := manager.UpsertSpec(context.Background(), "hq", model.SiteSpec{Name: "hq", IsSelf: true})
+	assert.Nil(t, err)
+	// child reports the statuses of its own subtree
+	err = manager.ReportState(context.Background(), model.SiteState{
+		Id:   "child",
+		Spec: &model.SiteSpec{Name: "child"},
+		Status: &model.SiteStatus{
+			InstanceStatuses: map[string]model.SiteInstanceStatus{
+				"grandchild/default/instance1": {
+					State:     v1alpha2.OK,
+					Name:      "instance1",
+					Namespace: "default",
+					Site:      "grandchild",
+					Path:      []string{"child", "grandchild"},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+	site, err := manager.GetState(context.Background(), "hq")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(site.Status.InstanceStatuses))
+	assert.Equal(t, 1, len(site.Status.TargetStatuses))
+	assert.Equal(t, v1alpha2.OK, site.Status.TargetStatuses["hq/default/target1"].State)
+
+	instances, err := manager.LocateInstance(context.Background(), "instance1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(instances))
+	assert.Equal(t, "grandchild", instances[0].Site)
+	assert.Equal(t, []string{"hq", "child", "grandchild"}, instances[0].Path)
+	assert.Equal(t, v1alpha2.OK, instances[0].State)
+	assert.Equal(t, "hq", instances[1].Site)
+	assert.Equal(t, []string{"hq"}, instances[1].Path)
+	assert.Equal(t, v1alpha2.InternalError, instances[1].State)
+	assert.Equal(t, "image not found", instances[1].Reason)
+
+	targets, err := manager.LocateTarget(context.Background(), "target1", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(targets))
+	assert.Equal(t, "hq", targets[0].Site)
+}
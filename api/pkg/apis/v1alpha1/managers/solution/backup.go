@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	states "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+)
+
+// ExportDeploymentStates returns the deployment states and summaries kept for the instances and targets of a
+// namespace, so that they can be restored with ImportDeploymentState
+func (s *SolutionManager) ExportDeploymentStates(ctx context.Context, namespace string) ([]model.BackupDeploymentState, error) {
+	iCtx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
+		"method": "ExportDeploymentStates",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	log.Infof(" M (Solution): export deployment states, namespace: %s, traceId: %s", namespace, span.SpanContext().TraceID().String())
+
+	var entries []states.StateEntry
+	entries, _, err = s.StateProvider.List(iCtx, states.ListRequest{
+		Metadata: map[string]interface{}{
+			"namespace": namespace,
+		},
+	})
+	if err != nil {
+		log.Errorf(" M (Solution): failed to list deployment states: %+v", err)
+		return nil, err
+	}
+	ret := make([]model.BackupDeploymentState, 0, len(entries))
+	for _, entry := range entries {
+		ret = append(ret, model.BackupDeploymentState{
+			Namespace: namespace,
+			Id:        entry.ID,
+			Body:      entry.Body,
+		})
+	}
+	return ret, nil
+}
+
+// ImportDeploymentState restores a deployment state or summary exported by ExportDeploymentStates
+func (s *SolutionManager) ImportDeploymentState(ctx context.Context, state model.BackupDeploymentState) error {
+	iCtx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
+		"method": "ImportDeploymentState",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	log.Infof(" M (Solution): import deployment state %s, namespace: %s, traceId: %s", state.Id, state.Namespace, span.SpanContext().TraceID().String())
+
+	_, err = s.StateProvider.Upsert(iCtx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   state.Id,
+			Body: state.Body,
+		},
+		Metadata: map[string]interface{}{
+			"namespace": state.Namespace,
+		},
+	})
+	if err != nil {
+		log.Errorf(" M (Solution): failed to import deployment state %s: %+v", state.Id, err)
+	}
+	return err
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	tgt "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+const (
+	defaultHealthCheckTimeout  = 60 * time.Second
+	defaultHealthCheckInterval = 2 * time.Second
+)
+
+func (s *SolutionManager) healthCheckTimeout() time.Duration {
+	if s.HealthCheckTimeout <= 0 {
+		return defaultHealthCheckTimeout
+	}
+	return s.HealthCheckTimeout
+}
+
+func (s *SolutionManager) healthCheckInterval() time.Duration {
+	if s.HealthCheckInterval <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return s.HealthCheckInterval
+}
+
+// waitForHealthy waits until the components updated by a step pass the provider's health check (if the
+// provider implements IHealthCheckable) and their own probes. Components that are still unhealthy when the
+// health check timeout is reached are marked as HealthCheckFailed in the returned results. It does nothing
+// unless health checks are enabled.
+func (s *SolutionManager) waitForHealthy(ctx context.Context, provider tgt.ITargetProvider, deployment model.DeploymentSpec, step model.DeploymentStep, results map[string]model.ComponentResultSpec) (map[string]model.ComponentResultSpec, error) {
+	if !s.HealthCheckEnabled {
+		return results, nil
+	}
+	updated := step.GetUpdatedComponents()
+	checkable, isCheckable := provider.(tgt.IHealthCheckable)
+	probed := make([]model.ComponentSpec, 0)
+	for _, component := range updated {
+		if api_utils.HasProbe(component) {
+			probed = append(probed, component)
+		}
+	}
+	if len(updated) == 0 || (!isCheckable && len(probed) == 0) {
+		return results, nil
+	}
+	if !s.AllowCommandProbes {
+		// a command probe would fail on every attempt, so there's no point in waiting
+		for _, component := range probed {
+			if api_utils.HasCommandProbe(component) {
+				if results == nil {
+					results = step.PrepareResultMap()
+				}
+				message := fmt.Sprintf("command probe of component %s isn't allowed, set 'healthCheck.allowCommandProbes' to allow it", component.Name)
+				results[component.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.HealthCheckFailed,
+					Message: message,
+				}
+				log.Errorf(" M (Solution): %s", message)
+				return results, v1alpha2.NewCOAError(nil, message, v1alpha2.HealthCheckFailed)
+			}
+		}
+	}
+
+	log.Debugf(" M (Solution): waiting for %d component(s) on target %s to become healthy", len(updated), step.Target)
+	timeout := s.healthCheckTimeout()
+	deadline := time.Now().Add(timeout)
+	for {
+		failures := make(map[string]string)
+		if isCheckable {
+			health, err := checkable.CheckHealth(ctx, deployment, step)
+			if err != nil {
+				for _, component := range updated {
+					failures[component.Name] = err.Error()
+				}
+			}
+			for name, result := range health {
+				if result.Status == v1alpha2.HealthCheckFailed {
+					failures[name] = result.Message
+				}
+			}
+		}
+		for _, component := range probed {
+			if _, ok := failures[component.Name]; ok {
+				continue
+			}
+			if err := api_utils.ProbeComponent(ctx, component, s.AllowCommandProbes); err != nil {
+				failures[component.Name] = err.Error()
+			}
+		}
+		if len(failures) == 0 {
+			return results, nil
+		}
+
+		if time.Now().After(deadline) {
+			if results == nil {
+				results = step.PrepareResultMap()
+			}
+			names := make([]string, 0, len(failures))
+			for name, message := range failures {
+				results[name] = model.ComponentResultSpec{
+					Status:  v1alpha2.HealthCheckFailed,
+					Message: message,
+				}
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			log.Errorf(" M (Solution): components %s on target %s are not healthy after %s", strings.Join(names, ", "), step.Target, timeout)
+			return results, v1alpha2.NewCOAError(nil, fmt.Sprintf("components %s are not healthy after %s", strings.Join(names, ", "), timeout), v1alpha2.HealthCheckFailed)
+		}
+		select {
+		case <-ctx.Done():
+			return results, ctx.Err()
+		case <-time.After(s.healthCheckInterval()):
+		}
+	}
+}
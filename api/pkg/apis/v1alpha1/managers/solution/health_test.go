@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// healthCheckableProvider is a mock target provider whose components become healthy after a number of checks
+type healthCheckableProvider struct {
+	mock.MockTargetProvider
+	healthyAfter int
+	checks       int
+}
+
+func (p *healthCheckableProvider) CheckHealth(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep) (map[string]model.ComponentResultSpec, error) {
+	p.checks++
+	ret := make(map[string]model.ComponentResultSpec)
+	for _, component := range step.GetUpdatedComponents() {
+		if p.healthyAfter < 0 || p.checks < p.healthyAfter {
+			ret[component.Name] = model.ComponentResultSpec{Status: v1alpha2.HealthCheckFailed, Message: "0 of 1 replica(s) ready"}
+		} else {
+			ret[component.Name] = model.ComponentResultSpec{Status: v1alpha2.OK}
+		}
+	}
+	return ret, nil
+}
+
+func healthCheckDeployment(properties map[string]interface{}) model.DeploymentSpec {
+	return model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{},
+		},
+		Solution: model.SolutionState{
+			Spec: &model.SolutionSpec{
+				Components: []model.ComponentSpec{
+					{
+						Name:       "a",
+						Type:       "mock",
+						Properties: properties,
+					},
+				},
+			},
+		},
+		Assignments: map[string]string{
+			"T1": "{a}",
+		},
+		Targets: map[string]model.TargetState{
+			"T1": {
+				Spec: &model.TargetSpec{
+					Topologies: []model.TopologySpec{
+						{
+							Bindings: []model.BindingSpec{
+								{
+									Role:     "mock",
+									Provider: "providers.target.mock",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newHealthCheckManager(provider target.ITargetProvider, allowCommandProbes bool) SolutionManager {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	return SolutionManager{
+		TargetProviders: map[string]target.ITargetProvider{
+			"mock": provider,
+		},
+		StateProvider:       stateProvider,
+		HealthCheckEnabled:  true,
+		AllowCommandProbes:  allowCommandProbes,
+		HealthCheckTimeout:  200 * time.Millisecond,
+		HealthCheckInterval: 10 * time.Millisecond,
+	}
+}
+
+func TestMockApplyWaitsForHealthy(t *testing.T) {
+	provider := &healthCheckableProvider{healthyAfter: 3}
+	provider.Init(mock.MockTargetProviderConfig{ID: uuid.New().String()})
+	manager := newHealthCheckManager(provider, true)
+
+	summary, err := manager.Reconcile(context.Background(), healthCheckDeployment(nil), false, "default", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, summary.SuccessCount)
+	assert.True(t, summary.AllAssignedDeployed)
+	assert.Equal(t, 3, provider.checks)
+}
+
+func TestMockApplyHealthCheckFailed(t *testing.T) {
+	provider := &healthCheckableProvider{healthyAfter: -1}
+	provider.Init(mock.MockTargetProviderConfig{ID: uuid.New().String()})
+	manager := newHealthCheckManager(provider, true)
+
+	summary, err := manager.Reconcile(context.Background(), healthCheckDeployment(nil), false, "default", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, err.(v1alpha2.COAError).State)
+	assert.Equal(t, 0, summary.SuccessCount)
+	assert.False(t, summary.AllAssignedDeployed)
+	assert.Equal(t, v1alpha2.HealthCheckFailed.String(), summary.TargetResults["T1"].Status)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, summary.TargetResults["T1"].ComponentResults["a"].Status)
+	assert.Equal(t, "0 of 1 replica(s) ready", summary.TargetResults["T1"].ComponentResults["a"].Message)
+}
+
+func TestMockApplyProbeFailed(t *testing.T) {
+	provider := &mock.MockTargetProvider{}
+	provider.Init(mock.MockTargetProviderConfig{ID: uuid.New().String()})
+	manager := newHealthCheckManager(provider, true)
+
+	summary, err := manager.Reconcile(context.Background(), healthCheckDeployment(map[string]interface{}{
+		model.ProbeCommand: "exit 1",
+	}), false, "default", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.HealthCheckFailed.String(), summary.TargetResults["T1"].Status)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, summary.TargetResults["T1"].ComponentResults["a"].Status)
+}
+
+func TestMockApplyProbeSucceeded(t *testing.T) {
+	provider := &mock.MockTargetProvider{}
+	provider.Init(mock.MockTargetProviderConfig{ID: uuid.New().String()})
+	manager := newHealthCheckManager(provider, true)
+
+	summary, err := manager.Reconcile(context.Background(), healthCheckDeployment(map[string]interface{}{
+		model.ProbeCommand: "exit 0",
+	}), false, "default", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, summary.SuccessCount)
+}
+
+func TestMockApplyHealthCheckDisabled(t *testing.T) {
+	provider := &healthCheckableProvider{healthyAfter: -1}
+	provider.Init(mock.MockTargetProviderConfig{ID: uuid.New().String()})
+	manager := newHealthCheckManager(provider, true)
+	manager.HealthCheckEnabled = false
+
+	summary, err := manager.Reconcile(context.Background(), healthCheckDeployment(map[string]interface{}{
+		model.ProbeCommand: "exit 1",
+	}), false, "default", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, summary.SuccessCount)
+	assert.Equal(t, 0, provider.checks)
+}
+
+func TestMockApplyCommandProbeNotAllowed(t *testing.T) {
+	provider := &mock.MockTargetProvider{}
+	provider.Init(mock.MockTargetProviderConfig{ID: uuid.New().String()})
+	manager := newHealthCheckManager(provider, false)
+
+	summary, err := manager.Reconcile(context.Background(), healthCheckDeployment(map[string]interface{}{
+		model.ProbeCommand: "exit 0",
+	}), false, "default", "")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.HealthCheckFailed.String(), summary.TargetResults["T1"].Status)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, summary.TargetResults["T1"].ComponentResults["a"].Status)
+	assert.Contains(t, summary.TargetResults["T1"].ComponentResults["a"].Message, "healthCheck.allowCommandProbes")
+}
@@ -0,0 +1,245 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	sp "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers"
+	tgt "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	coa_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+)
+
+// deploymentPlan is a deployment planned against the current state. Reconcile runs its steps and Plan previews them.
+type deploymentPlan struct {
+	Deployment           model.DeploymentSpec
+	ConfigReads          *coa_utils.ConfigReads
+	PreviousDesiredState *SolutionManagerDeploymentState
+	CurrentState         model.DeploymentState
+	CurrentComponents    []model.ComponentSpec
+	MergedState          model.DeploymentState
+	Plan                 model.DeploymentPlan
+}
+
+// planDeployment evaluates a deployment, merges it with the previously deployed and the current states, and plans
+// the steps to reach the merged state. When planning fails, it also returns a message for the deployment summary.
+// The catalog properties read while evaluating the deployment are returned even when the evaluation fails.
+func (s *SolutionManager) planDeployment(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) (deploymentPlan, string, error) {
+	ret := deploymentPlan{}
+	var err error
+	if s.VendorContext != nil && s.VendorContext.EvaluationContext != nil {
+		context := s.VendorContext.EvaluationContext.Clone()
+		context.DeploymentSpec = deployment
+		context.Value = deployment
+		context.Component = ""
+		context.Namespace = namespace
+		context.ConfigReads = &coa_utils.ConfigReads{}
+		ret.ConfigReads = context.ConfigReads
+		deployment, err = api_utils.EvaluateDeployment(*context)
+	}
+	if err != nil {
+		if !remove {
+			log.Errorf(" M (Solution): failed to evaluate deployment spec: %+v", err)
+			return ret, "failed to evaluate deployment spec: " + err.Error(), err
+		}
+		log.Infof(" M (Solution): skipped failure to evaluate deployment spec: %+v", err)
+	}
+	ret.Deployment = deployment
+
+	ret.PreviousDesiredState = s.getPreviousState(ctx, deployment.Instance.ObjectMeta.Name, namespace)
+
+	currentDesiredState, err := NewDeploymentState(deployment)
+	if err != nil {
+		log.Errorf(" M (Solution): failed to create target manager state from deployment spec: %+v", err)
+		return ret, "failed to create target manager state from deployment spec: " + err.Error(), err
+	}
+	ret.CurrentState, ret.CurrentComponents, err = s.Get(ctx, deployment, targetName)
+	if err != nil {
+		log.Errorf(" M (Solution): failed to get current state: %+v", err)
+		return ret, "failed to get current state: " + err.Error(), err
+	}
+	desiredState := currentDesiredState
+	if ret.PreviousDesiredState != nil {
+		desiredState = MergeDeploymentStates(&ret.PreviousDesiredState.State, currentDesiredState)
+	}
+	if remove {
+		desiredState.MarkRemoveAll()
+	}
+
+	ret.MergedState = MergeDeploymentStates(&ret.CurrentState, desiredState)
+	ret.Plan, err = PlanForDeployment(deployment, ret.MergedState)
+	if err != nil {
+		log.Errorf(" M (Solution): failed to plan for deployment: %+v", err)
+		return ret, "failed to plan for deployment: " + err.Error(), err
+	}
+	return ret, "", nil
+}
+
+// runsStep tells if the step is run by this solution manager
+func (s *SolutionManager) runsStep(step model.DeploymentStep, targetName string) bool {
+	if s.IsTarget && !api_utils.ContainsString(s.TargetNames, step.Target) {
+		return false
+	}
+	return targetName == "" || targetName == step.Target
+}
+
+// providerForStep returns the target provider that runs a step. Providers configured on the solution manager
+// override the providers of the targets.
+func (s *SolutionManager) providerForStep(step model.DeploymentStep, deployment model.DeploymentSpec, previousDesiredState *SolutionManagerDeploymentState) (tgt.ITargetProvider, error) {
+	role := step.Role
+	if role == "container" {
+		role = "instance"
+	}
+	if override, ok := s.TargetProviders[role]; ok && override != nil {
+		return override, nil
+	}
+	targetSpec := s.getTargetStateForStep(step, deployment, previousDesiredState)
+	provider, err := sp.CreateProviderForTargetRole(s.Context, step.Role, targetSpec, nil)
+	if err != nil {
+		log.Errorf(" M (Solution): failed to create provider: %+v", err)
+		return nil, err
+	}
+	return provider.(tgt.ITargetProvider), nil
+}
+
+// setAgent points the deployment metadata at the agent of the step's target, if the target has one
+func setAgent(col map[string]string, target model.TargetState) {
+	agent := findAgent(target)
+	if agent != "" {
+		col[ENV_NAME] = agent
+	} else {
+		delete(col, ENV_NAME)
+	}
+}
+
+// Plan returns the steps Reconcile would run for a deployment. The deployment is planned against the current
+// state the same way Reconcile plans it, and each step that would run is applied in dry-run mode so that the
+// target providers can validate it. Nothing is deployed and no state is stored.
+func (s *SolutionManager) Plan(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) (model.PlanPreview, error) {
+	iCtx, span := observability.StartSpan("Solution Manager", ctx, &map[string]string{
+		"method": "Plan",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	log.Infof(" M (Solution): planning deployment.InstanceName: %s, deployment.SolutionName: %s, remove: %t, namespace: %s, targetName: %s, traceId: %s",
+		deployment.Instance.ObjectMeta.Name,
+		deployment.SolutionName,
+		remove,
+		namespace,
+		targetName,
+		span.SpanContext().TraceID().String())
+
+	ret := model.PlanPreview{
+		IsRemoval: remove,
+		Steps:     make([]model.PreviewStep, 0),
+	}
+
+	var planned deploymentPlan
+	planned, _, err = s.planDeployment(iCtx, deployment, remove, namespace, targetName)
+	if err != nil {
+		return ret, err
+	}
+	deployment = planned.Deployment
+	previousDesiredState := planned.PreviousDesiredState
+
+	// components are compared with the components of the last deployment, or with the components reported by
+	// the providers when the instance hasn't been deployed before
+	deployedState := planned.CurrentState
+	deployedComponents := planned.CurrentComponents
+	if previousDesiredState != nil {
+		deployedState.TargetComponent = make(map[string]string)
+		for k, v := range planned.CurrentState.TargetComponent {
+			deployedState.TargetComponent[k] = v
+		}
+		deployedState = MergeDeploymentStates(&previousDesiredState.State, deployedState)
+		deployedComponents = previousDesiredState.State.Components
+	}
+
+	col := api_utils.MergeCollection(deployment.Solution.Spec.Metadata, deployment.Instance.Spec.Metadata)
+	dep := deployment
+	dep.Instance.Spec.Metadata = col
+
+	for _, step := range planned.Plan.Steps {
+		if !s.runsStep(step, targetName) {
+			continue
+		}
+
+		dep.ActiveTarget = step.Target
+		setAgent(col, deployment.Targets[step.Target])
+		var targetProvider tgt.ITargetProvider
+		targetProvider, err = s.providerForStep(step, deployment, previousDesiredState)
+		if err != nil {
+			return ret, err
+		}
+
+		previewStep := model.PreviewStep{
+			Target:     step.Target,
+			Role:       step.Role,
+			Components: previewComponents(iCtx, step, targetProvider, deployedState, deployedComponents, remove),
+		}
+		if previousDesiredState != nil {
+			previewStep.Skipped = s.canSkipStep(iCtx, step, step.Target, targetProvider, previousDesiredState.State.Components, deployedState)
+		}
+		if !previewStep.Skipped {
+			if _, stepErr := targetProvider.Apply(iCtx, dep, step, true); stepErr != nil {
+				log.Infof(" M (Solution): step on target %s failed in dry-run mode: %+v", step.Target, stepErr)
+				previewStep.Error = stepErr.Error()
+			}
+		}
+		ret.Steps = append(ret.Steps, previewStep)
+	}
+
+	return ret, nil
+}
+
+// previewComponents describes the components of a step, and why they are updated or deleted. In the deployed
+// state, components that were deployed before but aren't reported by the providers anymore are marked with "-".
+func previewComponents(ctx context.Context, step model.DeploymentStep, provider tgt.ITargetProvider, deployedState model.DeploymentState, deployedComponents []model.ComponentSpec, remove bool) []model.PreviewComponent {
+	rule := provider.GetValidationRule(ctx)
+	ret := make([]model.PreviewComponent, 0, len(step.Components))
+	for _, c := range step.Components {
+		preview := model.PreviewComponent{
+			Action:    c.Action,
+			Component: c.Component,
+		}
+		key := fmt.Sprintf("%s::%s", c.Component.Name, step.Target)
+		role := deployedState.TargetComponent[key]
+		if role == "" || (c.Action == model.ComponentUpdate && strings.HasPrefix(role, "-")) {
+			preview.Changed = c.Action == model.ComponentUpdate
+			preview.Reasons = []string{"component is not deployed on the target"}
+			ret = append(ret, preview)
+			continue
+		}
+		for i := range deployedComponents {
+			if deployedComponents[i].Name == c.Component.Name {
+				current := deployedComponents[i]
+				preview.Current = &current
+				break
+			}
+		}
+		if c.Action == model.ComponentDelete {
+			preview.Changed = true
+			if remove {
+				preview.Reasons = []string{"instance is removed"}
+			} else {
+				preview.Reasons = []string{"component is removed from the deployment"}
+			}
+		} else if preview.Current != nil {
+			preview.Reasons = rule.GetComponentChanges(*preview.Current, c.Component)
+			preview.Changed = len(preview.Reasons) > 0
+		}
+		ret = append(ret, preview)
+	}
+	return ret
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package solution
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMockPlan(t *testing.T) {
+	id := uuid.New().String()
+	deployment := model.DeploymentSpec{
+		Instance: model.InstanceState{
+			ObjectMeta: model.ObjectMeta{
+				Name: "instance1",
+			},
+			Spec: &model.InstanceSpec{},
+		},
+		Solution: model.SolutionState{
+			Spec: &model.SolutionSpec{
+				Components: []model.ComponentSpec{
+					{
+						Name: "a",
+						Type: "mock",
+					},
+					{
+						Name: "b",
+						Type: "mock",
+					},
+				},
+			},
+		},
+		Assignments: map[string]string{
+			"T1": "{a}{b}",
+		},
+		Targets: map[string]model.TargetState{
+			"T1": {
+				Spec: &model.TargetSpec{
+					Topologies: []model.TopologySpec{
+						{
+							Bindings: []model.BindingSpec{
+								{
+									Role:     "mock",
+									Provider: "providers.target.mock",
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	targetProvider := &mock.MockTargetProvider{}
+	targetProvider.Init(mock.MockTargetProviderConfig{ID: id, StrictLifecycle: true})
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionManager{
+		TargetProviders: map[string]target.ITargetProvider{
+			"mock": targetProvider,
+		},
+		StateProvider: stateProvider,
+	}
+
+	// nothing is deployed yet
+	preview, err := manager.Plan(context.Background(), deployment, false, "default", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(preview.Steps))
+	assert.Equal(t, "T1", preview.Steps[0].Target)
+	assert.False(t, preview.Steps[0].Skipped)
+	assert.Equal(t, 2, len(preview.Steps[0].Components))
+	for _, c := range preview.Steps[0].Components {
+		assert.Equal(t, model.ComponentUpdate, c.Action)
+		assert.True(t, c.Changed)
+		assert.Equal(t, []string{"component is not deployed on the target"}, c.Reasons)
+	}
+	_, components, err := manager.Get(context.Background(), deployment, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+
+	// nothing has changed after the deployment
+	_, err = manager.Reconcile(context.Background(), deployment, false, "default", "")
+	assert.Nil(t, err)
+	preview, err = manager.Plan(context.Background(), deployment, false, "default", "")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(preview.Steps))
+	assert.True(t, preview.Steps[0].Skipped)
+	for _, c := range preview.Steps[0].Components {
+		assert.False(t, c.Changed)
+		assert.NotNil(t, c.Current)
+	}
+
+	// a component removed from the solution is deleted
+	updated := deployment
+	updated.Solution.Spec = &model.SolutionSpec{
+		Components: deployment.Solution.Spec.Components[:1],
+	}
+	updated.Assignments = map[string]string{
+		"T1": "{a}",
+	}
+	preview, err = manager.Plan(context.Background(), updated, false, "default", "")
+	assert.Nil(t, err)
+	actions := make(map[string]model.ComponentAction)
+	for _, step := range preview.Steps {
+		for _, c := range step.Components {
+			actions[c.Component.Name] = c.Action
+			if c.Component.Name == "b" {
+				assert.False(t, step.Skipped)
+				assert.True(t, c.Changed)
+				assert.Equal(t, []string{"component is removed from the deployment"}, c.Reasons)
+			}
+		}
+	}
+	assert.Equal(t, model.ComponentDelete, actions["b"])
+	_, components, err = manager.Get(context.Background(), deployment, "")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(components))
+
+	// all components are deleted with the instance
+	preview, err = manager.Plan(context.Background(), deployment, true, "default", "")
+	assert.Nil(t, err)
+	assert.True(t, preview.IsRemoval)
+	for _, step := range preview.Steps {
+		for _, c := range step.Components {
+			assert.Equal(t, model.ComponentDelete, c.Action)
+			assert.Equal(t, []string{"instance is removed"}, c.Reasons)
+		}
+	}
+}
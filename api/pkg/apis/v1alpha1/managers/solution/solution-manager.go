@@ -17,6 +17,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/eclipse-symphony/symphony/api/constants"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/solution/metrics"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	sp "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers"
@@ -31,6 +32,7 @@ import (
 	config "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config"
 	secret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret"
 	states "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	coa_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 )
 
@@ -60,6 +62,15 @@ type SolutionManager struct {
 	SecretProvider  secret.ISecretProvider
 	IsTarget        bool
 	TargetNames     []string
+	// HealthCheckEnabled makes Apply wait for applied components to become healthy. It's off by default.
+	HealthCheckEnabled bool
+	// AllowCommandProbes allows probe.command, which runs shell commands on the host of the solution manager.
+	// It's off by default.
+	AllowCommandProbes bool
+	// HealthCheckTimeout is how long to wait for applied components to become healthy
+	HealthCheckTimeout time.Duration
+	// HealthCheckInterval is how often health checks are repeated while waiting
+	HealthCheckInterval time.Duration
 }
 
 type SolutionManagerDeploymentState struct {
@@ -119,6 +130,35 @@ func (s *SolutionManager) Init(context *contexts.VendorContext, config managers.
 
 	s.TargetNames = strings.Split(targetNames, ",")
 
+	if v, ok := config.Properties["healthCheck.enabled"]; ok {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return v1alpha2.NewCOAError(err, "'healthCheck.enabled' must be a boolean", v1alpha2.BadConfig)
+		}
+		s.HealthCheckEnabled = enabled
+	}
+	if v, ok := config.Properties["healthCheck.allowCommandProbes"]; ok {
+		allowed, err := strconv.ParseBool(v)
+		if err != nil {
+			return v1alpha2.NewCOAError(err, "'healthCheck.allowCommandProbes' must be a boolean", v1alpha2.BadConfig)
+		}
+		s.AllowCommandProbes = allowed
+	}
+	if v, ok := config.Properties["healthCheck.timeoutInSec"]; ok {
+		timeout, err := strconv.Atoi(v)
+		if err != nil || timeout <= 0 {
+			return v1alpha2.NewCOAError(err, "'healthCheck.timeoutInSec' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.HealthCheckTimeout = time.Duration(timeout) * time.Second
+	}
+	if v, ok := config.Properties["healthCheck.intervalInSec"]; ok {
+		interval, err := strconv.Atoi(v)
+		if err != nil || interval <= 0 {
+			return v1alpha2.NewCOAError(err, "'healthCheck.intervalInSec' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.HealthCheckInterval = time.Duration(interval) * time.Second
+	}
+
 	if s.IsTarget {
 		if len(s.TargetNames) == 0 {
 			return errors.New("target mode is set but target name is not set")
@@ -217,6 +257,29 @@ func (s *SolutionManager) sendHeartbeat(id string, namespace string, remove bool
 	}
 }
 
+// publishCatalogDependencies publishes the catalog properties an instance read through $config() while its deployment
+// was evaluated, so the instance is redeployed when they change. Removed instances and instances labeled
+// symphony/catalog-redeploy: "false" are published without dependencies. Targets are deployed as target-runtime-
+// instances that don't exist as instances, so they aren't published.
+func (s *SolutionManager) publishCatalogDependencies(instance model.ObjectMeta, namespace string, remove bool, reads *coa_utils.ConfigReads) {
+	if instance.Name == "" || strings.HasPrefix(instance.Name, constants.TargetRuntimePrefix) {
+		return
+	}
+	dependencies := model.CatalogDependencies{
+		Instance:  instance.Name,
+		Namespace: namespace,
+	}
+	if !remove && instance.Labels[constants.CatalogRedeployMetaKey] != "false" {
+		dependencies.Catalogs = reads.Objects()
+	}
+	s.VendorContext.Publish("catalog-dependencies", v1alpha2.Event{
+		Metadata: map[string]string{
+			"namespace": namespace,
+		},
+		Body: dependencies,
+	})
+}
+
 func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.DeploymentSpec, remove bool, namespace string, targetName string) (model.SummarySpec, error) {
 	lock.Lock()
 	defer lock.Unlock()
@@ -265,57 +328,22 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 		metrics.GetOperationType,
 	)
 
-	if s.VendorContext != nil && s.VendorContext.EvaluationContext != nil {
-		context := s.VendorContext.EvaluationContext.Clone()
-		context.DeploymentSpec = deployment
-		context.Value = deployment
-		context.Component = ""
-		context.Namespace = namespace
-		deployment, err = api_utils.EvaluateDeployment(*context)
-	}
-
-	if err != nil {
-		if remove {
-			log.Infof(" M (Solution): skipped failure to evaluate deployment spec: %+v", err)
-		} else {
-			summary.SummaryMessage = "failed to evaluate deployment spec: " + err.Error()
-			log.Errorf(" M (Solution): failed to evaluate deployment spec: %+v", err)
-			return summary, err
-		}
-	}
-
-	previousDesiredState := s.getPreviousState(iCtx, deployment.Instance.ObjectMeta.Name, namespace)
-
-	var currentDesiredState, currentState model.DeploymentState
-	currentDesiredState, err = NewDeploymentState(deployment)
-	if err != nil {
-		summary.SummaryMessage = "failed to create target manager state from deployment spec: " + err.Error()
-		log.Errorf(" M (Solution): failed to create target manager state from deployment spec: %+v", err)
-		return summary, err
-	}
-	currentState, _, err = s.Get(iCtx, deployment, targetName)
-	if err != nil {
-		summary.SummaryMessage = "failed to get current state: " + err.Error()
-		log.Errorf(" M (Solution): failed to get current state: %+v", err)
-		return summary, err
-	}
-	desiredState := currentDesiredState
-	if previousDesiredState != nil {
-		desiredState = MergeDeploymentStates(&previousDesiredState.State, currentDesiredState)
-	}
-
-	if remove {
-		desiredState.MarkRemoveAll()
+	instance := deployment.Instance.ObjectMeta
+	var planned deploymentPlan
+	var failure string
+	planned, failure, err = s.planDeployment(iCtx, deployment, remove, namespace, targetName)
+	if planned.ConfigReads != nil {
+		s.publishCatalogDependencies(instance, namespace, remove, planned.ConfigReads)
 	}
-
-	mergedState := MergeDeploymentStates(&currentState, desiredState)
-	var plan model.DeploymentPlan
-	plan, err = PlanForDeployment(deployment, mergedState)
 	if err != nil {
-		summary.SummaryMessage = "failed to plan for deployment: " + err.Error()
-		log.Errorf(" M (Solution): failed to plan for deployment: %+v", err)
+		summary.SummaryMessage = failure
 		return summary, err
 	}
+	deployment = planned.Deployment
+	previousDesiredState := planned.PreviousDesiredState
+	currentState := planned.CurrentState
+	mergedState := planned.MergedState
+	plan := planned.Plan
 
 	planBytes, _ := json.Marshal(plan)
 	log.Debugf(" M (Solution): deployment plan: %s", string(planBytes))
@@ -334,47 +362,24 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 	planSuccessCount := 0
 	for _, step := range plan.Steps {
 		log.Debugf(" M (Solution): processing step: %+v", step)
-		if s.IsTarget && !api_utils.ContainsString(s.TargetNames, step.Target) {
-			continue
-		}
-
-		if targetName != "" && targetName != step.Target {
+		if !s.runsStep(step, targetName) {
 			continue
 		}
 
 		plannedCount++
 
 		dep.ActiveTarget = step.Target
-		agent := findAgent(deployment.Targets[step.Target])
-		if agent != "" {
-			col[ENV_NAME] = agent
-		} else {
-			delete(col, ENV_NAME)
-		}
-		var override tgt.ITargetProvider
-		role := step.Role
-		if role == "container" {
-			role = "instance"
-		}
-		if v, ok := s.TargetProviders[role]; ok {
-			override = v
-		}
-		var provider providers.IProvider
-		if override == nil {
-			targetSpec := s.getTargetStateForStep(step, deployment, previousDesiredState)
-			provider, err = sp.CreateProviderForTargetRole(s.Context, step.Role, targetSpec, override)
-			if err != nil {
-				summary.SummaryMessage = "failed to create provider:" + err.Error()
-				log.Errorf(" M (Solution): failed to create provider: %+v", err)
-				return summary, err
-			}
-		} else {
-			provider = override
+		setAgent(col, deployment.Targets[step.Target])
+		var provider tgt.ITargetProvider
+		provider, err = s.providerForStep(step, deployment, previousDesiredState)
+		if err != nil {
+			summary.SummaryMessage = "failed to create provider:" + err.Error()
+			return summary, err
 		}
 
 		if previousDesiredState != nil {
 			testState := MergeDeploymentStates(&previousDesiredState.State, currentState)
-			if s.canSkipStep(iCtx, step, step.Target, provider.(tgt.ITargetProvider), previousDesiredState.State.Components, testState) {
+			if s.canSkipStep(iCtx, step, step.Target, provider, previousDesiredState.State.Components, testState) {
 				targetResult[step.Target] = 1
 				planSuccessCount++
 				continue
@@ -410,7 +415,18 @@ func (s *SolutionManager) Reconcile(ctx context.Context, deployment model.Deploy
 		// }
 
 		for i := 0; i < retryCount; i++ {
-			componentResults, stepError = (provider.(tgt.ITargetProvider)).Apply(iCtx, dep, step, false)
+			componentResults, stepError = provider.Apply(iCtx, dep, step, false)
+			if stepError == nil {
+				// the step is only successful once the updated components are healthy
+				componentResults, stepError = s.waitForHealthy(iCtx, provider, dep, step, componentResults)
+				if stepError != nil {
+					targetResult[step.Target] = 0
+					summary.AllAssignedDeployed = false
+					targetResultMessage := fmt.Sprintf("Components are not healthy after %s, err: %s", deploymentType, stepError.Error())
+					summary.UpdateTargetResult(step.Target, model.TargetResultSpec{Status: v1alpha2.HealthCheckFailed.String(), Message: targetResultMessage, ComponentResults: componentResults})
+					break
+				}
+			}
 			if stepError == nil {
 				targetResult[step.Target] = 1
 				summary.AllAssignedDeployed = plannedCount == planSuccessCount
@@ -8,12 +8,19 @@ package solution
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/api/constants"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	coa_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
@@ -691,3 +698,52 @@ func TestMockApplyWithError(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, 0, summary.SuccessCount)
 }
+
+func TestPublishCatalogDependencies(t *testing.T) {
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendorContext := &contexts.VendorContext{}
+	vendorContext.Init(&pubSubProvider)
+	published := make(chan model.CatalogDependencies, 10)
+	vendorContext.Subscribe("catalog-dependencies", func(topic string, event v1alpha2.Event) error {
+		var dependencies model.CatalogDependencies
+		jData, _ := json.Marshal(event.Body)
+		json.Unmarshal(jData, &dependencies)
+		published <- dependencies
+		return nil
+	})
+	manager := SolutionManager{}
+	manager.VendorContext = vendorContext
+
+	reads := &coa_utils.ConfigReads{}
+	reads.Record("config1", "image")
+	manager.publishCatalogDependencies(model.ObjectMeta{Name: "instance1"}, "default", false, reads)
+	manager.publishCatalogDependencies(model.ObjectMeta{Name: "instance2"}, "default", true, reads)
+	manager.publishCatalogDependencies(model.ObjectMeta{
+		Name:   "instance3",
+		Labels: map[string]string{constants.CatalogRedeployMetaKey: "false"},
+	}, "default", false, reads)
+	// target deployments aren't instances, so they aren't redeployed as instances
+	manager.publishCatalogDependencies(model.ObjectMeta{Name: constants.TargetRuntimePrefix + "target1"}, "default", false, reads)
+
+	received := map[string]model.CatalogDependencies{}
+	for len(received) < 3 {
+		select {
+		case dependencies := <-published:
+			received[dependencies.Instance] = dependencies
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "catalog dependencies weren't published")
+			return
+		}
+	}
+	assert.Equal(t, map[string][]string{"config1": {"image"}}, received["instance1"].Catalogs)
+	assert.Equal(t, "default", received["instance1"].Namespace)
+	// removed and opted out instances don't depend on catalogs
+	assert.Empty(t, received["instance2"].Catalogs)
+	assert.Empty(t, received["instance3"].Catalogs)
+	select {
+	case dependencies := <-published:
+		assert.Fail(t, "unexpected catalog dependencies of "+dependencies.Instance)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -10,8 +10,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
@@ -22,9 +24,16 @@ import (
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 )
 
+// ISchemaValidator checks properties against a schema catalog. It's implemented by the catalogs manager.
+type ISchemaValidator interface {
+	ValidateProperties(ctx context.Context, schemaName string, namespace string, properties map[string]interface{}, location string) (api_utils.SchemaResult, error)
+}
+
 type SolutionsManager struct {
 	managers.Manager
 	StateProvider states.IStateProvider
+	// SchemaValidator is optional, it checks component properties against the schema catalogs of the components
+	SchemaValidator ISchemaValidator
 }
 
 func (s *SolutionsManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -61,6 +70,58 @@ func (t *SolutionsManager) DeleteState(ctx context.Context, name string, namespa
 	return err
 }
 
+// ValidateUpsert checks the name and components of a solution, including the component properties that have a schema
+func (t *SolutionsManager) ValidateUpsert(ctx context.Context, name string, state model.SolutionState) error {
+	if err := api_utils.ValidateObjectName(name, state.ObjectMeta); err != nil {
+		return err
+	}
+	if state.Spec == nil {
+		return nil
+	}
+	if err := api_utils.ValidateComponents(state.Spec.Components); err != nil {
+		return err
+	}
+	namespace := state.ObjectMeta.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return t.validateComponentSchemas(ctx, namespace, state.Spec.Components)
+}
+
+// validateComponentSchemas checks the properties of the components that refer to a schema catalog with the schema
+// metadata. Nothing is checked without a schema validator.
+func (t *SolutionsManager) validateComponentSchemas(ctx context.Context, namespace string, components []model.ComponentSpec) error {
+	if t.SchemaValidator == nil {
+		return nil
+	}
+	errors := make(map[string]api_utils.RuleResult)
+	for i, component := range components {
+		schemaName, ok := component.Metadata["schema"]
+		if !ok {
+			continue
+		}
+		res, err := t.SchemaValidator.ValidateProperties(ctx, schemaName, namespace, component.Properties, fmt.Sprintf("/spec/components/%d/properties", i))
+		if err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to validate component %s", component.Name), v1alpha2.BadRequest)
+		}
+		for k, v := range res.Errors {
+			if !strings.HasPrefix(k, "/") {
+				// rule set errors are keyed by property name
+				k = fmt.Sprintf("/spec/components/%d/properties/%s", i, k)
+			}
+			errors[k] = v
+		}
+		if !res.Valid && len(res.Errors) == 0 {
+			errors[fmt.Sprintf("/spec/components/%d/properties", i)] = api_utils.RuleResult{Valid: false, Error: "properties don't match schema " + schemaName}
+		}
+	}
+	if len(errors) > 0 {
+		jData, _ := json.Marshal(errors)
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("solution doesn't match its component schemas: %s", string(jData)), v1alpha2.BadRequest)
+	}
+	return nil
+}
+
 func (t *SolutionsManager) UpsertState(ctx context.Context, name string, state model.SolutionState) error {
 	ctx, span := observability.StartSpan("Solutions Manager", ctx, &map[string]string{
 		"method": "UpsertState",
@@ -68,8 +129,8 @@ func (t *SolutionsManager) UpsertState(ctx context.Context, name string, state m
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	if state.ObjectMeta.Name != "" && state.ObjectMeta.Name != name {
-		return v1alpha2.NewCOAError(nil, fmt.Sprintf("Name in metadata (%s) does not match name in request (%s)", state.ObjectMeta.Name, name), v1alpha2.BadRequest)
+	if err = t.ValidateUpsert(ctx, name, state); err != nil {
+		return err
 	}
 	state.ObjectMeta.FixNames(name)
 
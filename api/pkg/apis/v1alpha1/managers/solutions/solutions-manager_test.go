@@ -11,6 +11,8 @@ import (
 	"testing"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/stretchr/testify/assert"
 )
@@ -36,3 +38,59 @@ func TestCreateGetDeleteSolutionsState(t *testing.T) {
 	spec, err = manager.GetState(context.Background(), "test", "default")
 	assert.NotNil(t, err)
 }
+
+func TestUpsertInvalidSolution(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionsManager{
+		StateProvider: stateProvider,
+	}
+	solution := model.SolutionState{
+		Spec: &model.SolutionSpec{
+			Components: []model.ComponentSpec{{Name: "a"}, {Name: "a"}},
+		},
+	}
+	err := manager.ValidateUpsert(context.Background(), "test", solution)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadRequest, err.(v1alpha2.COAError).State)
+	// an actual upsert fails the same way and doesn't store the solution
+	assert.Equal(t, err, manager.UpsertState(context.Background(), "test", solution))
+	_, err = manager.GetState(context.Background(), "test", "default")
+	assert.NotNil(t, err)
+}
+
+type portSchemaValidator struct{}
+
+func (v portSchemaValidator) ValidateProperties(ctx context.Context, schemaName string, namespace string, properties map[string]interface{}, location string) (api_utils.SchemaResult, error) {
+	if _, ok := properties["port"].(float64); ok {
+		return api_utils.SchemaResult{Valid: true}, nil
+	}
+	return api_utils.SchemaResult{Valid: false, Errors: map[string]api_utils.RuleResult{
+		location + "/port": {Valid: false, Error: "port must be a number"},
+	}}, nil
+}
+
+func TestUpsertSolutionComponentSchemas(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := SolutionsManager{
+		StateProvider:   stateProvider,
+		SchemaValidator: portSchemaValidator{},
+	}
+	solution := model.SolutionState{
+		Spec: &model.SolutionSpec{
+			Components: []model.ComponentSpec{
+				{Name: "a"},
+				{Name: "b", Metadata: map[string]string{"schema": "ports"}, Properties: map[string]interface{}{"port": "http"}},
+			},
+		},
+	}
+	err := manager.UpsertState(context.Background(), "test", solution)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadRequest, err.(v1alpha2.COAError).State)
+	assert.Contains(t, err.Error(), "/spec/components/1/properties/port")
+
+	solution.Spec.Components[1].Properties["port"] = float64(80)
+	err = manager.UpsertState(context.Background(), "test", solution)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,318 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package staging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+	"github.com/google/uuid"
+)
+
+const (
+	leasePrefix      = "synclease-"
+	deadLetterPrefix = "deadletter-"
+
+	defaultVisibilityTimeout   = 60 * time.Second
+	defaultMaxDeliveryAttempts = 5
+)
+
+func leaseMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"version":   "v1",
+		"group":     model.FederationGroup,
+		"resource":  "syncleases",
+		"namespace": "default",
+		"kind":      "SyncLease",
+	}
+}
+
+func deadLetterMetadata() map[string]interface{} {
+	return map[string]interface{}{
+		"version":   "v1",
+		"group":     model.FederationGroup,
+		"resource":  "deadletters",
+		"namespace": "default",
+		"kind":      "DeadLetter",
+	}
+}
+
+func (s *StagingManager) visibilityTimeout() time.Duration {
+	if s.VisibilityTimeout <= 0 {
+		return defaultVisibilityTimeout
+	}
+	return s.VisibilityTimeout
+}
+
+func (s *StagingManager) maxDeliveryAttempts() int {
+	if s.MaxDeliveryAttempts <= 0 {
+		return defaultMaxDeliveryAttempts
+	}
+	return s.MaxDeliveryAttempts
+}
+
+// GetABatchForSite returns up to count jobs for a site under a new lease. The jobs are leased in the site's
+// queue until the site acknowledges the batch with AckBatch. Jobs that are not acknowledged within the
+// visibility timeout are delivered again, and jobs that have been delivered the maximum number of times
+// are moved to the site's dead-letter queue.
+func (s *StagingManager) GetABatchForSite(ctx context.Context, site string, count int) (string, []v1alpha2.JobData, error) {
+	ctx, span := observability.StartSpan("Staging Manager", ctx, &map[string]string{
+		"method": "GetABatchForSite",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	s.QueueProvider.Enqueue(Site_Job_Queue, site)
+
+	s.leaseLock.Lock()
+	defer s.leaseLock.Unlock()
+
+	if err = s.removeExpiredLeases(ctx, site); err != nil {
+		return "", nil, err
+	}
+	items := make([]model.SyncLeaseItem, 0)
+	for len(items) < count && s.QueueProvider.Size(site) > 0 {
+		var queueItem queue.QueueItem
+		queueItem, err = s.QueueProvider.DequeueWithLease(site, s.visibilityTimeout())
+		if err != nil {
+			return "", nil, err
+		}
+		job, ok := toJob(queueItem.Element)
+		if !ok {
+			log.Errorf(" M (Staging): dropping an element of site %s that is not a job", site)
+			s.QueueProvider.Ack(site, queueItem.LeaseId)
+			continue
+		}
+		if queueItem.Deliveries > s.maxDeliveryAttempts() {
+			if err = s.deadLetter(ctx, site, job, queueItem.Deliveries-1); err != nil {
+				return "", nil, err
+			}
+			if err = s.QueueProvider.Ack(site, queueItem.LeaseId); err != nil {
+				return "", nil, err
+			}
+			continue
+		}
+		items = append(items, model.SyncLeaseItem{
+			Job:      job,
+			Attempts: queueItem.Deliveries,
+			LeaseId:  queueItem.LeaseId,
+		})
+	}
+	if len(items) == 0 {
+		return "", nil, nil
+	}
+
+	lease := model.SyncLease{
+		Id:      uuid.New().String(),
+		Site:    site,
+		Expires: time.Now().UTC().Add(s.visibilityTimeout()),
+		Items:   items,
+	}
+	jobs := make([]v1alpha2.JobData, 0, len(items))
+	for _, item := range lease.Items {
+		jobs = append(jobs, item.Job)
+	}
+	_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   leasePrefix + lease.Id,
+			Body: resourceBody(leasePrefix+lease.Id, "default", lease),
+		},
+		Metadata: leaseMetadata(),
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	log.Debugf(" M (Staging): leased %d job(s) to site %s with lease %s", len(jobs), site, lease.Id)
+	return lease.Id, jobs, nil
+}
+
+// AckBatch acknowledges that a site has processed the jobs of a lease and returns these jobs. Jobs whose
+// queue leases have expired are not acknowledged, as they are delivered again.
+func (s *StagingManager) AckBatch(ctx context.Context, site string, leaseId string) ([]v1alpha2.JobData, error) {
+	ctx, span := observability.StartSpan("Staging Manager", ctx, &map[string]string{
+		"method": "AckBatch",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	s.leaseLock.Lock()
+	defer s.leaseLock.Unlock()
+
+	var entry states.StateEntry
+	entry, err = s.StateProvider.Get(ctx, states.GetRequest{
+		ID:       leasePrefix + leaseId,
+		Metadata: leaseMetadata(),
+	})
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			err = v1alpha2.NewCOAError(err, fmt.Sprintf("lease %s is not found, it may have been redelivered", leaseId), v1alpha2.NotFound)
+		}
+		return nil, err
+	}
+	var lease model.SyncLease
+	if err = readResourceSpec(entry.Body, &lease); err != nil {
+		return nil, err
+	}
+	if lease.Site != site {
+		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("lease %s doesn't belong to site %s", leaseId, site), v1alpha2.BadRequest)
+		return nil, err
+	}
+	jobs := make([]v1alpha2.JobData, 0, len(lease.Items))
+	for _, item := range lease.Items {
+		if err = s.QueueProvider.Ack(site, item.LeaseId); err != nil {
+			if v1alpha2.IsNotFound(err) {
+				log.Infof(" M (Staging): job %s of lease %s has expired and will be delivered again", item.Job.Id, leaseId)
+				continue
+			}
+			return nil, err
+		}
+		jobs = append(jobs, item.Job)
+	}
+	err = s.StateProvider.Delete(ctx, states.DeleteRequest{
+		ID:       leasePrefix + leaseId,
+		Metadata: leaseMetadata(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 && len(lease.Items) > 0 {
+		err = v1alpha2.NewCOAError(nil, fmt.Sprintf("lease %s has expired, it will be redelivered", leaseId), v1alpha2.NotFound)
+		return nil, err
+	}
+	log.Debugf(" M (Staging): site %s acknowledged lease %s", site, leaseId)
+	return jobs, nil
+}
+
+// ListDeadLetters returns the jobs that couldn't be delivered to a site. An empty site lists the
+// dead letters of all sites.
+func (s *StagingManager) ListDeadLetters(ctx context.Context, site string) ([]model.DeadLetter, error) {
+	ctx, span := observability.StartSpan("Staging Manager", ctx, &map[string]string{
+		"method": "ListDeadLetters",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	var entries []states.StateEntry
+	entries, _, err = s.StateProvider.List(ctx, states.ListRequest{
+		Metadata: deadLetterMetadata(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]model.DeadLetter, 0)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.ID, deadLetterPrefix) {
+			continue
+		}
+		var letter model.DeadLetter
+		if err = readResourceSpec(entry.Body, &letter); err != nil {
+			return nil, err
+		}
+		if site == "" || letter.Site == site {
+			ret = append(ret, letter)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].DeadTime.Before(ret[j].DeadTime)
+	})
+	return ret, nil
+}
+
+func (s *StagingManager) listLeases(ctx context.Context, site string) ([]model.SyncLease, error) {
+	entries, _, err := s.StateProvider.List(ctx, states.ListRequest{
+		Metadata: leaseMetadata(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]model.SyncLease, 0)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.ID, leasePrefix) {
+			continue
+		}
+		var lease model.SyncLease
+		if err = readResourceSpec(entry.Body, &lease); err != nil {
+			return nil, err
+		}
+		if lease.Site == site {
+			ret = append(ret, lease)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Expires.Before(ret[j].Expires)
+	})
+	return ret, nil
+}
+
+// removeExpiredLeases forgets a site's expired batches. Their jobs are returned to the site's queue by the
+// queue provider once their queue leases expire.
+func (s *StagingManager) removeExpiredLeases(ctx context.Context, site string) error {
+	leases, err := s.listLeases(ctx, site)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	for _, lease := range leases {
+		if lease.Expires.After(now) {
+			break
+		}
+		log.Infof(" M (Staging): lease %s of site %s has expired, its jobs will be delivered again", lease.Id, site)
+		err = s.StateProvider.Delete(ctx, states.DeleteRequest{
+			ID:       leasePrefix + lease.Id,
+			Metadata: leaseMetadata(),
+		})
+		if err != nil && !v1alpha2.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StagingManager) deadLetter(ctx context.Context, site string, job v1alpha2.JobData, attempts int) error {
+	log.Errorf(" M (Staging): job %s couldn't be delivered to site %s after %d attempts, moving it to the dead-letter queue", job.Id, site, attempts)
+	letter := model.DeadLetter{
+		Id:       uuid.New().String(),
+		Site:     site,
+		Job:      job,
+		Attempts: attempts,
+		DeadTime: time.Now().UTC(),
+	}
+	_, err := s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   deadLetterPrefix + letter.Id,
+			Body: resourceBody(deadLetterPrefix+letter.Id, "default", letter),
+		},
+		Metadata: deadLetterMetadata(),
+	})
+	return err
+}
+
+// toJob converts a queue element to a job. Elements read back by a durable queue provider are decoded JSON.
+func toJob(element interface{}) (v1alpha2.JobData, bool) {
+	if job, ok := element.(v1alpha2.JobData); ok {
+		return job, true
+	}
+	var job v1alpha2.JobData
+	jData, err := json.Marshal(element)
+	if err != nil {
+		return job, false
+	}
+	if err = json.Unmarshal(jData, &job); err != nil || job.Id == "" {
+		return job, false
+	}
+	return job, true
+}
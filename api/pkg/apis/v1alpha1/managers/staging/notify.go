@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package staging
+
+// Watch returns a channel that is signaled when new jobs are queued for a site, and a function that stops
+// the watch. Signals are coalesced: a watcher that is busy sees at most one pending signal.
+func (s *StagingManager) Watch(site string) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+	s.watchLock.Lock()
+	if s.watchers == nil {
+		s.watchers = make(map[string]map[chan struct{}]struct{})
+	}
+	if s.watchers[site] == nil {
+		s.watchers[site] = make(map[chan struct{}]struct{})
+	}
+	s.watchers[site][ch] = struct{}{}
+	s.watchLock.Unlock()
+	return ch, func() {
+		s.watchLock.Lock()
+		defer s.watchLock.Unlock()
+		delete(s.watchers[site], ch)
+		if len(s.watchers[site]) == 0 {
+			delete(s.watchers, site)
+		}
+	}
+}
+
+func (s *StagingManager) notify(site string) {
+	s.watchLock.Lock()
+	defer s.watchLock.Unlock()
+	for ch := range s.watchers[site] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
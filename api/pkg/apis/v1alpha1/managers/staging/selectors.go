@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package staging
+
+import (
+	"context"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+)
+
+// getSiteSpec reads the spec of a site from the registry. A site that isn't registered gets all catalogs.
+func (s *StagingManager) getSiteSpec(ctx context.Context, site string) (model.SiteSpec, error) {
+	state, err := utils.GetSite(
+		ctx,
+		s.VendorContext.SiteInfo.CurrentSite.BaseUrl,
+		site,
+		s.VendorContext.SiteInfo.CurrentSite.Username,
+		s.VendorContext.SiteInfo.CurrentSite.Password)
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return model.SiteSpec{}, nil
+		}
+		return model.SiteSpec{}, err
+	}
+	if state.Spec == nil {
+		return model.SiteSpec{}, nil
+	}
+	return *state.Spec, nil
+}
+
+// queueDeselected queues the deletion of a catalog that has been synced to a site but no longer matches the
+// site's catalog selectors. It returns true if a deletion is queued. The synced generation is forgotten, so
+// that the catalog is synced again if it matches the selectors later.
+func (s *StagingManager) queueDeselected(ctx context.Context, site string, catalog model.CatalogState) (bool, error) {
+	cacheId := site + "-" + catalog.ObjectMeta.Name
+	_, err := s.StateProvider.Get(ctx, states.GetRequest{
+		ID:       cacheId,
+		Metadata: catalogCacheMetadata(catalog.ObjectMeta.Namespace),
+	})
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	namespace := catalog.ObjectMeta.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	log.Debugf(" M (Staging): catalog %s no longer matches the selectors of site %s, queuing its deletion", catalog.ObjectMeta.Name, site)
+	err = s.QueueProvider.Enqueue(site, v1alpha2.JobData{
+		Id:     catalog.ObjectMeta.Name,
+		Scope:  namespace,
+		Action: v1alpha2.JobDelete,
+	})
+	if err != nil {
+		return false, err
+	}
+	err = s.StateProvider.Delete(ctx, states.DeleteRequest{
+		ID:       cacheId,
+		Metadata: catalogCacheMetadata(catalog.ObjectMeta.Namespace),
+	})
+	if err != nil && !v1alpha2.IsNotFound(err) {
+		return true, err
+	}
+	return true, nil
+}
@@ -9,6 +9,9 @@ package staging
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
@@ -28,8 +31,13 @@ var log = logger.NewLogger("coa.runtime")
 
 type StagingManager struct {
 	managers.Manager
-	QueueProvider queue.IQueueProvider
-	StateProvider states.IStateProvider
+	QueueProvider       queue.IQueueProvider
+	StateProvider       states.IStateProvider
+	VisibilityTimeout   time.Duration
+	MaxDeliveryAttempts int
+	leaseLock           sync.Mutex
+	watchLock           sync.Mutex
+	watchers            map[string]map[chan struct{}]struct{}
 }
 
 const Site_Job_Queue = "site-job-queue"
@@ -51,6 +59,20 @@ func (s *StagingManager) Init(context *contexts.VendorContext, config managers.M
 	} else {
 		return err
 	}
+	if v, ok := config.Properties["lease.visibilityTimeoutInSec"]; ok {
+		timeout, err := strconv.Atoi(v)
+		if err != nil || timeout <= 0 {
+			return v1alpha2.NewCOAError(err, "'lease.visibilityTimeoutInSec' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.VisibilityTimeout = time.Duration(timeout) * time.Second
+	}
+	if v, ok := config.Properties["lease.maxDeliveryAttempts"]; ok {
+		attempts, err := strconv.Atoi(v)
+		if err != nil || attempts <= 0 {
+			return v1alpha2.NewCOAError(err, "'lease.maxDeliveryAttempts' must be a positive integer", v1alpha2.BadConfig)
+		}
+		s.MaxDeliveryAttempts = attempts
+	}
 	return nil
 }
 func (s *StagingManager) Enabled() bool {
@@ -74,6 +96,12 @@ func (s *StagingManager) Poll() []error {
 		return []error{err}
 	}
 	siteId := site.(string)
+	queued := false
+	defer func() {
+		if queued {
+			s.notify(siteId)
+		}
+	}()
 	var catalogs []model.CatalogState
 	catalogs, err = utils.GetCatalogs(
 		ctx,
@@ -86,16 +114,27 @@ func (s *StagingManager) Poll() []error {
 		observ_utils.CloseSpanWithError(span, &err)
 		return []error{err}
 	}
+	var siteSpec model.SiteSpec
+	siteSpec, err = s.getSiteSpec(ctx, siteId)
+	if err != nil {
+		log.Errorf(" M (Staging): Failed to get site %s: %s", siteId, err.Error())
+		observ_utils.CloseSpanWithError(span, &err)
+		return []error{err}
+	}
 	for _, catalog := range catalogs {
+		if !siteSpec.SelectsCatalog(catalog) {
+			var deselected bool
+			deselected, err = s.queueDeselected(ctx, siteId, catalog)
+			if err != nil {
+				log.Errorf(" M (Staging): Failed to queue deletion of catalog %s: %s", catalog.ObjectMeta.Name, err.Error())
+			}
+			queued = queued || deselected
+			continue
+		}
 		cacheId := siteId + "-" + catalog.ObjectMeta.Name
 		getRequest := states.GetRequest{
-			ID: cacheId,
-			Metadata: map[string]interface{}{
-				"version":   "v1",
-				"group":     model.FederationGroup,
-				"resource":  "catalogs",
-				"namespace": catalog.ObjectMeta.Namespace,
-			},
+			ID:       cacheId,
+			Metadata: catalogCacheMetadata(catalog.ObjectMeta.Namespace),
 		}
 		var entry states.StateEntry
 		entry, err = s.StateProvider.Get(ctx, getRequest)
@@ -110,22 +149,24 @@ func (s *StagingManager) Poll() []error {
 			Action: v1alpha2.JobUpdate,
 			Body:   catalog,
 		})
+		queued = true
 		_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
 			Value: states.StateEntry{
 				ID:   cacheId,
 				Body: catalog.Spec.Generation,
 			},
-			Metadata: map[string]interface{}{
-				"version":   "v1",
-				"group":     model.FederationGroup,
-				"resource":  "catalogs",
-				"namespace": catalog.ObjectMeta.Namespace,
-			},
+			Metadata: catalogCacheMetadata(catalog.ObjectMeta.Namespace),
 		})
 		if err != nil {
 			log.Errorf(" M (Staging): Failed to record catalog %s: %s", catalog.ObjectMeta.Name, err.Error())
 		}
 	}
+	err = s.queueTombstones(ctx, siteId)
+	if err != nil {
+		log.Errorf(" M (Staging): Failed to queue catalog deletions: %s", err.Error())
+		observ_utils.CloseSpanWithError(span, &err)
+		return []error{err}
+	}
 	return nil
 }
 func (s *StagingManager) Reconcil() []error {
@@ -147,30 +188,10 @@ func (s *StagingManager) HandleJobEvent(ctx context.Context, event v1alpha2.Even
 		return err
 	}
 	s.QueueProvider.Enqueue(Site_Job_Queue, event.Metadata["site"])
-	return s.QueueProvider.Enqueue(event.Metadata["site"], job)
-}
-func (s *StagingManager) GetABatchForSite(site string, count int) ([]v1alpha2.JobData, error) {
-	//TODO: this should return a group of jobs as optimization
-	s.QueueProvider.Enqueue(Site_Job_Queue, site)
-	if s.QueueProvider.Size(site) == 0 {
-		return nil, nil
-	}
-	items := []v1alpha2.JobData{}
-	itemCount := 0
-	for {
-		queueElement, err := s.QueueProvider.Dequeue(site)
-		if err != nil {
-			return nil, err
-		}
-		if job, ok := queueElement.(v1alpha2.JobData); ok {
-			items = append(items, job)
-			itemCount++
-		} else {
-			s.QueueProvider.Enqueue(site, queueElement)
-		}
-		if itemCount == count || s.QueueProvider.Size(site) == 0 {
-			break
-		}
+	err = s.QueueProvider.Enqueue(event.Metadata["site"], job)
+	if err != nil {
+		return err
 	}
-	return items, nil
+	s.notify(event.Metadata["site"])
+	return nil
 }
@@ -11,11 +11,15 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
+	diskqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/disk"
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
@@ -61,6 +65,186 @@ func TestPoll(t *testing.T) {
 	assert.NotNil(t, item)
 }
 
+func TestPollQueuesTombstones(t *testing.T) {
+	ts := InitializeMockSymphonyAPI()
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	manager.VendorContext = &contexts.VendorContext{
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "fake",
+			CurrentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+	}
+	err := manager.RecordTombstone(context.Background(), "catalog2", "")
+	assert.Nil(t, err)
+	_, err = stateProvider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   "fake-catalog2",
+			Body: "1",
+		},
+		Metadata: catalogCacheMetadata("default"),
+	})
+	assert.Nil(t, err)
+
+	queueProvider.Enqueue("site-job-queue", "fake")
+	errList := manager.Poll()
+	assert.Nil(t, errList)
+
+	jobData, err := queueProvider.Dequeue("fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "catalog1", jobData.(v1alpha2.JobData).Id)
+	jobData, err = queueProvider.Dequeue("fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "catalog2", jobData.(v1alpha2.JobData).Id)
+	assert.Equal(t, "default", jobData.(v1alpha2.JobData).Scope)
+	assert.Equal(t, v1alpha2.JobDelete, jobData.(v1alpha2.JobData).Action)
+
+	_, err = stateProvider.Get(context.Background(), states.GetRequest{
+		ID:       "fake-catalog2",
+		Metadata: catalogCacheMetadata("default"),
+	})
+	assert.True(t, v1alpha2.IsNotFound(err))
+
+	// a pending deletion is not queued again
+	queueProvider.Enqueue("site-job-queue", "fake")
+	errList = manager.Poll()
+	assert.Nil(t, errList)
+	_, err = queueProvider.Dequeue("fake")
+	assert.NotNil(t, err)
+}
+
+func TestPollWithCatalogSelectors(t *testing.T) {
+	selectors := []model.CatalogSelector{{Labels: map[string]string{"tier": "factory"}}}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/catalogs/registry":
+			response = []model.CatalogState{{
+				ObjectMeta: model.ObjectMeta{Name: "catalog1", Labels: map[string]string{"tier": "factory"}},
+				Spec:       &model.CatalogSpec{Generation: "1"},
+			}, {
+				ObjectMeta: model.ObjectMeta{Name: "catalog2"},
+				Spec:       &model.CatalogSpec{Generation: "1"},
+			}}
+		case "/federation/registry/fake":
+			response = model.SiteState{
+				Id:   "fake",
+				Spec: &model.SiteSpec{Name: "fake", CatalogSelectors: selectors},
+			}
+		default:
+			response = AuthResponse{AccessToken: "test-token", TokenType: "Bearer"}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer ts.Close()
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := StagingManager{
+		StateProvider: stateProvider,
+		QueueProvider: queueProvider,
+	}
+	manager.VendorContext = &contexts.VendorContext{
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "hq",
+			CurrentSite: v1alpha2.SiteConnection{
+				BaseUrl: ts.URL + "/",
+			},
+		},
+	}
+
+	queueProvider.Enqueue("site-job-queue", "fake")
+	errList := manager.Poll()
+	assert.Nil(t, errList)
+	assert.Equal(t, 1, queueProvider.Size("fake"))
+	jobData, err := queueProvider.Dequeue("fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "catalog1", jobData.(v1alpha2.JobData).Id)
+	assert.Equal(t, v1alpha2.JobUpdate, jobData.(v1alpha2.JobData).Action)
+
+	// the selectors change: catalog1 is deleted from the site and catalog2 is synced
+	selectors = []model.CatalogSelector{{Names: []string{"catalog2"}}}
+	queueProvider.Enqueue("site-job-queue", "fake")
+	errList = manager.Poll()
+	assert.Nil(t, errList)
+	assert.Equal(t, 2, queueProvider.Size("fake"))
+	jobData, err = queueProvider.Dequeue("fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "catalog1", jobData.(v1alpha2.JobData).Id)
+	assert.Equal(t, "default", jobData.(v1alpha2.JobData).Scope)
+	assert.Equal(t, v1alpha2.JobDelete, jobData.(v1alpha2.JobData).Action)
+	jobData, err = queueProvider.Dequeue("fake")
+	assert.Nil(t, err)
+	assert.Equal(t, "catalog2", jobData.(v1alpha2.JobData).Id)
+	assert.Equal(t, v1alpha2.JobUpdate, jobData.(v1alpha2.JobData).Action)
+
+	// nothing changes
+	queueProvider.Enqueue("site-job-queue", "fake")
+	errList = manager.Poll()
+	assert.Nil(t, errList)
+	assert.Equal(t, 0, queueProvider.Size("fake"))
+}
+
+func TestAcknowledgeTombstone(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	manager := StagingManager{
+		StateProvider: stateProvider,
+	}
+	err := manager.RecordTombstone(context.Background(), "catalog1", "default")
+	assert.Nil(t, err)
+
+	err = manager.AcknowledgeTombstone(context.Background(), "catalog1", "default", "fake", []string{"fake", "other"})
+	assert.Nil(t, err)
+	tombstones, err := manager.ListTombstones(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(tombstones))
+	assert.Equal(t, []string{"fake"}, tombstones[0].AckedSites)
+
+	err = manager.AcknowledgeTombstone(context.Background(), "catalog1", "default", "other", []string{"fake", "other"})
+	assert.Nil(t, err)
+	tombstones, err = manager.ListTombstones(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(tombstones))
+
+	// acknowledging a removed tombstone is a no-op
+	err = manager.AcknowledgeTombstone(context.Background(), "catalog1", "default", "other", []string{"fake", "other"})
+	assert.Nil(t, err)
+}
+
+func TestRemoveTombstone(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	manager := StagingManager{
+		StateProvider: stateProvider,
+	}
+	err := manager.RecordTombstone(context.Background(), "catalog1", "default")
+	assert.Nil(t, err)
+	err = manager.RemoveTombstone(context.Background(), "catalog1", "default")
+	assert.Nil(t, err)
+	tombstones, err := manager.ListTombstones(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(tombstones))
+
+	err = manager.RemoveTombstone(context.Background(), "catalog1", "default")
+	assert.Nil(t, err)
+}
+
 func TestHandleJobEvent(t *testing.T) {
 	ts := InitializeMockSymphonyAPI()
 	queueProvider := &memoryqueue.MemoryQueueProvider{}
@@ -104,6 +288,41 @@ func TestHandleJobEvent(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, "fake", site.(string))
 }
+func TestWatch(t *testing.T) {
+	manager, _ := newLeaseTestManager()
+	watch, cancel := manager.Watch("fake")
+	other, cancelOther := manager.Watch("other")
+	defer cancelOther()
+
+	for i := 0; i < 2; i++ {
+		err := manager.HandleJobEvent(context.Background(), v1alpha2.Event{
+			Metadata: map[string]string{
+				"site": "fake",
+			},
+			Body: v1alpha2.JobData{
+				Id:     "catalog1",
+				Action: v1alpha2.JobUpdate,
+			},
+		})
+		assert.Nil(t, err)
+	}
+	// signals are coalesced
+	select {
+	case <-watch:
+	default:
+		assert.Fail(t, "watcher wasn't signaled")
+	}
+	select {
+	case <-watch:
+		assert.Fail(t, "watcher was signaled twice")
+	case <-other:
+		assert.Fail(t, "watcher of another site was signaled")
+	default:
+	}
+
+	cancel()
+	assert.Equal(t, 1, len(manager.watchers))
+}
 func TestGetABatchForSite(t *testing.T) {
 	queueProvider := &memoryqueue.MemoryQueueProvider{}
 	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
@@ -124,17 +343,179 @@ func TestGetABatchForSite(t *testing.T) {
 		Id:     "catalog2",
 		Action: v1alpha2.JobUpdate,
 	})
-	jobs, err := manager.GetABatchForSite("fake", 1)
+	leaseId, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 1)
 	assert.Nil(t, err)
+	assert.NotEqual(t, "", leaseId)
 	assert.Equal(t, 1, len(jobs))
 	assert.Equal(t, "catalog1", jobs[0].Id)
 	assert.Equal(t, v1alpha2.JobUpdate, jobs[0].Action)
 
-	jobs, err = manager.GetABatchForSite("fake", 1)
+	leaseId, jobs, err = manager.GetABatchForSite(context.Background(), "fake", 1)
 	assert.Nil(t, err)
+	assert.NotEqual(t, "", leaseId)
 	assert.Equal(t, 1, len(jobs))
 	assert.Equal(t, "catalog2", jobs[0].Id)
 	assert.Equal(t, v1alpha2.JobUpdate, jobs[0].Action)
+
+	leaseId, jobs, err = manager.GetABatchForSite(context.Background(), "fake", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "", leaseId)
+	assert.Equal(t, 0, len(jobs))
+}
+
+func newLeaseTestManager() (*StagingManager, *memoryqueue.MemoryQueueProvider) {
+	queueProvider := &memoryqueue.MemoryQueueProvider{}
+	queueProvider.Init(memoryqueue.MemoryQueueProviderConfig{})
+
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	return &StagingManager{
+		StateProvider:       stateProvider,
+		QueueProvider:       queueProvider,
+		VisibilityTimeout:   50 * time.Millisecond,
+		MaxDeliveryAttempts: 2,
+	}, queueProvider
+}
+
+func TestAckBatch(t *testing.T) {
+	manager, queueProvider := newLeaseTestManager()
+	queueProvider.Enqueue("fake", v1alpha2.JobData{
+		Id:     "catalog1",
+		Action: v1alpha2.JobUpdate,
+	})
+	leaseId, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+
+	_, err = manager.AckBatch(context.Background(), "other", leaseId)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadRequest, err.(v1alpha2.COAError).State)
+
+	acked, err := manager.AckBatch(context.Background(), "fake", leaseId)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(acked))
+	assert.Equal(t, "catalog1", acked[0].Id)
+
+	// an acknowledged batch is not delivered again
+	time.Sleep(100 * time.Millisecond)
+	leaseId, jobs, err = manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, "", leaseId)
+	assert.Equal(t, 0, len(jobs))
+
+	_, err = manager.AckBatch(context.Background(), "fake", "unknown")
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestGetABatchForSiteRedelivery(t *testing.T) {
+	manager, queueProvider := newLeaseTestManager()
+	queueProvider.Enqueue("fake", v1alpha2.JobData{
+		Id:     "catalog1",
+		Action: v1alpha2.JobUpdate,
+	})
+	leaseId, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+
+	// the leased job is invisible until the lease expires
+	queueProvider.Enqueue("fake", v1alpha2.JobData{
+		Id:     "catalog2",
+		Action: v1alpha2.JobUpdate,
+	})
+	_, jobs, err = manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "catalog2", jobs[0].Id)
+
+	time.Sleep(100 * time.Millisecond)
+	redeliveredLeaseId, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.NotEqual(t, leaseId, redeliveredLeaseId)
+	assert.Equal(t, 2, len(jobs))
+	assert.Equal(t, "catalog1", jobs[0].Id)
+	assert.Equal(t, "catalog2", jobs[1].Id)
+
+	// the expired lease can't be acknowledged after it has been redelivered
+	_, err = manager.AckBatch(context.Background(), "fake", leaseId)
+	assert.True(t, v1alpha2.IsNotFound(err))
+	acked, err := manager.AckBatch(context.Background(), "fake", redeliveredLeaseId)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(acked))
+}
+
+func TestGetABatchForSiteDeadLetter(t *testing.T) {
+	manager, queueProvider := newLeaseTestManager()
+	queueProvider.Enqueue("fake", v1alpha2.JobData{
+		Id:     "catalog1",
+		Action: v1alpha2.JobUpdate,
+	})
+	for i := 0; i < 2; i++ {
+		_, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(jobs))
+		time.Sleep(100 * time.Millisecond)
+	}
+	leaseId, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, "", leaseId)
+	assert.Equal(t, 0, len(jobs))
+
+	letters, err := manager.ListDeadLetters(context.Background(), "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(letters))
+	assert.Equal(t, "catalog1", letters[0].Job.Id)
+	assert.Equal(t, 2, letters[0].Attempts)
+
+	letters, err = manager.ListDeadLetters(context.Background(), "other")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(letters))
+}
+
+func TestGetABatchForSiteAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	queueProvider := &diskqueue.DiskQueueProvider{}
+	err := queueProvider.Init(diskqueue.DiskQueueProviderConfig{Dir: dir})
+	assert.Nil(t, err)
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	manager := StagingManager{
+		StateProvider:     stateProvider,
+		QueueProvider:     queueProvider,
+		VisibilityTimeout: 50 * time.Millisecond,
+	}
+	err = manager.HandleJobEvent(context.Background(), v1alpha2.Event{
+		Metadata: map[string]string{
+			"site": "fake",
+		},
+		Body: v1alpha2.JobData{
+			Id:     "catalog1",
+			Action: v1alpha2.JobUpdate,
+		},
+	})
+	assert.Nil(t, err)
+	_, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Nil(t, queueProvider.Close())
+
+	// the unacknowledged job survives the restart and is delivered again
+	queueProvider = &diskqueue.DiskQueueProvider{}
+	err = queueProvider.Init(diskqueue.DiskQueueProviderConfig{Dir: dir})
+	assert.Nil(t, err)
+	defer queueProvider.Close()
+	manager.QueueProvider = queueProvider
+	time.Sleep(100 * time.Millisecond)
+	leaseId, jobs, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(jobs))
+	assert.Equal(t, "catalog1", jobs[0].Id)
+	assert.Equal(t, v1alpha2.JobUpdate, jobs[0].Action)
+
+	_, err = manager.AckBatch(context.Background(), "fake", leaseId)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, queueProvider.Length("fake", queue.All))
 }
 
 type AuthResponse struct {
@@ -175,3 +556,84 @@ func InitializeMockSymphonyAPI() *httptest.Server {
 	}))
 	return ts
 }
+
+func TestTombstonesStoredAsResources(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+
+	manager := StagingManager{
+		StateProvider: stateProvider,
+	}
+	err := manager.RecordTombstone(context.Background(), "catalog1", "default")
+	assert.Nil(t, err)
+	entry, err := stateProvider.Get(context.Background(), states.GetRequest{
+		ID:       tombstonePrefix + "catalog1",
+		Metadata: tombstoneMetadata("default"),
+	})
+	assert.Nil(t, err)
+	jData, _ := json.Marshal(entry.Body)
+	var body map[string]interface{}
+	err = json.Unmarshal(jData, &body)
+	assert.Nil(t, err)
+	assert.Equal(t, tombstonePrefix+"catalog1", body["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "catalog1", body["spec"].(map[string]interface{})["name"])
+
+	// tombstones stored before they were shaped like resources are still read
+	_, err = stateProvider.Upsert(context.Background(), states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   tombstonePrefix + "catalog2",
+			Body: model.CatalogTombstone{Name: "catalog2", Namespace: "default", AckedSites: []string{"fake"}},
+		},
+		Metadata: tombstoneMetadata("default"),
+	})
+	assert.Nil(t, err)
+	tombstone, err := manager.getTombstone(context.Background(), "catalog2", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "catalog2", tombstone.Name)
+	assert.Equal(t, []string{"fake"}, tombstone.AckedSites)
+}
+
+func TestLeasesStoredAsResources(t *testing.T) {
+	manager, queueProvider := newLeaseTestManager()
+	queueProvider.Enqueue("fake", v1alpha2.JobData{
+		Id:     "catalog1",
+		Action: v1alpha2.JobUpdate,
+	})
+	leaseId, _, err := manager.GetABatchForSite(context.Background(), "fake", 10)
+	assert.Nil(t, err)
+	entry, err := manager.StateProvider.Get(context.Background(), states.GetRequest{
+		ID:       leasePrefix + leaseId,
+		Metadata: leaseMetadata(),
+	})
+	assert.Nil(t, err)
+	jData, _ := json.Marshal(entry.Body)
+	var body map[string]interface{}
+	err = json.Unmarshal(jData, &body)
+	assert.Nil(t, err)
+	assert.Equal(t, leasePrefix+leaseId, body["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "fake", body["spec"].(map[string]interface{})["site"])
+
+	err = manager.deadLetter(context.Background(), "fake", v1alpha2.JobData{Id: "catalog2", Action: v1alpha2.JobUpdate}, 2)
+	assert.Nil(t, err)
+	entries, _, err := manager.StateProvider.List(context.Background(), states.ListRequest{
+		Metadata: deadLetterMetadata(),
+	})
+	assert.Nil(t, err)
+	found := false
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.ID, deadLetterPrefix) {
+			found = true
+			jData, _ := json.Marshal(entry.Body)
+			var body map[string]interface{}
+			err = json.Unmarshal(jData, &body)
+			assert.Nil(t, err)
+			assert.Equal(t, entry.ID, body["metadata"].(map[string]interface{})["name"])
+			assert.Equal(t, "catalog2", body["spec"].(map[string]interface{})["job"].(map[string]interface{})["id"])
+		}
+	}
+	assert.True(t, found)
+	letters, err := manager.ListDeadLetters(context.Background(), "fake")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(letters))
+	assert.Equal(t, "catalog2", letters[0].Job.Id)
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package staging
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
+)
+
+const tombstonePrefix = "tombstone-"
+
+func tombstoneMetadata(namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":   "v1",
+		"group":     model.FederationGroup,
+		"resource":  "catalogtombstones",
+		"namespace": namespace,
+		"kind":      "CatalogTombstone",
+	}
+}
+
+func catalogCacheMetadata(namespace string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":   "v1",
+		"group":     model.FederationGroup,
+		"resource":  "catalogs",
+		"namespace": namespace,
+	}
+}
+
+// resourceBody shapes a stored record like a custom resource, so that the Kubernetes state provider can keep it
+func resourceBody(name string, namespace string, spec interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"metadata": model.ObjectMeta{Name: name, Namespace: namespace},
+		"spec":     spec,
+	}
+}
+
+// readResourceSpec reads the spec of a record stored with resourceBody. Records stored before they were shaped
+// like custom resources are read as they are.
+func readResourceSpec(body interface{}, spec interface{}) error {
+	var record struct {
+		Spec json.RawMessage `json:"spec"`
+	}
+	jData, _ := json.Marshal(body)
+	if err := json.Unmarshal(jData, &record); err != nil {
+		return err
+	}
+	if len(record.Spec) == 0 || string(record.Spec) == "null" {
+		return json.Unmarshal(jData, spec)
+	}
+	return json.Unmarshal(record.Spec, spec)
+}
+
+// RecordTombstone records that a catalog has been deleted, so that the deletion is synced to child sites
+func (s *StagingManager) RecordTombstone(ctx context.Context, name string, namespace string) error {
+	ctx, span := observability.StartSpan("Staging Manager", ctx, &map[string]string{
+		"method": "RecordTombstone",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	if namespace == "" {
+		namespace = "default"
+	}
+	log.Debugf(" M (Staging): recording tombstone for catalog %s in namespace %s", name, namespace)
+	_, err = s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID: tombstonePrefix + name,
+			Body: resourceBody(tombstonePrefix+name, namespace, model.CatalogTombstone{
+				Name:        name,
+				Namespace:   namespace,
+				DeletedTime: time.Now().UTC(),
+			}),
+		},
+		Metadata: tombstoneMetadata(namespace),
+	})
+	return err
+}
+
+// RemoveTombstone removes the tombstone of a catalog, when a catalog with the same name is created again
+func (s *StagingManager) RemoveTombstone(ctx context.Context, name string, namespace string) error {
+	if namespace == "" {
+		namespace = "default"
+	}
+	err := s.StateProvider.Delete(ctx, states.DeleteRequest{
+		ID:       tombstonePrefix + name,
+		Metadata: tombstoneMetadata(namespace),
+	})
+	if err != nil && !v1alpha2.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// ListTombstones returns the tombstones of catalogs in all namespaces
+func (s *StagingManager) ListTombstones(ctx context.Context) ([]model.CatalogTombstone, error) {
+	entries, _, err := s.StateProvider.List(ctx, states.ListRequest{
+		Metadata: tombstoneMetadata(""),
+	})
+	if err != nil {
+		return nil, err
+	}
+	ret := make([]model.CatalogTombstone, 0)
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.ID, tombstonePrefix) {
+			continue
+		}
+		var tombstone model.CatalogTombstone
+		if err = readResourceSpec(entry.Body, &tombstone); err != nil {
+			return nil, err
+		}
+		ret = append(ret, tombstone)
+	}
+	return ret, nil
+}
+
+func (s *StagingManager) getTombstone(ctx context.Context, name string, namespace string) (model.CatalogTombstone, error) {
+	var tombstone model.CatalogTombstone
+	entry, err := s.StateProvider.Get(ctx, states.GetRequest{
+		ID:       tombstonePrefix + name,
+		Metadata: tombstoneMetadata(namespace),
+	})
+	if err != nil {
+		return tombstone, err
+	}
+	err = readResourceSpec(entry.Body, &tombstone)
+	return tombstone, err
+}
+
+func (s *StagingManager) saveTombstone(ctx context.Context, tombstone model.CatalogTombstone) error {
+	_, err := s.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   tombstonePrefix + tombstone.Name,
+			Body: resourceBody(tombstonePrefix+tombstone.Name, tombstone.Namespace, tombstone),
+		},
+		Metadata: tombstoneMetadata(tombstone.Namespace),
+	})
+	return err
+}
+
+// queueTombstones queues the deletions a site hasn't received yet. The synced generation of a deleted
+// catalog is forgotten, so that a catalog that is created again with the same name is synced again.
+func (s *StagingManager) queueTombstones(ctx context.Context, site string) error {
+	tombstones, err := s.ListTombstones(ctx)
+	if err != nil {
+		return err
+	}
+	queued := false
+	defer func() {
+		if queued {
+			s.notify(site)
+		}
+	}()
+	for _, tombstone := range tombstones {
+		if utils.ContainsString(tombstone.AckedSites, site) || utils.ContainsString(tombstone.PendingSites, site) {
+			continue
+		}
+		err = s.QueueProvider.Enqueue(site, v1alpha2.JobData{
+			Id:     tombstone.Name,
+			Scope:  tombstone.Namespace,
+			Action: v1alpha2.JobDelete,
+		})
+		if err != nil {
+			return err
+		}
+		queued = true
+		tombstone.PendingSites = append(tombstone.PendingSites, site)
+		if err = s.saveTombstone(ctx, tombstone); err != nil {
+			return err
+		}
+		err = s.StateProvider.Delete(ctx, states.DeleteRequest{
+			ID:       site + "-" + tombstone.Name,
+			Metadata: catalogCacheMetadata(tombstone.Namespace),
+		})
+		if err != nil && !v1alpha2.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// AcknowledgeTombstone records that a site has received the deletion of a catalog. Once all the given
+// sites have acknowledged it, the tombstone is removed.
+func (s *StagingManager) AcknowledgeTombstone(ctx context.Context, name string, namespace string, site string, sites []string) error {
+	ctx, span := observability.StartSpan("Staging Manager", ctx, &map[string]string{
+		"method": "AcknowledgeTombstone",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	if namespace == "" {
+		namespace = "default"
+	}
+	var tombstone model.CatalogTombstone
+	tombstone, err = s.getTombstone(ctx, name, namespace)
+	if err != nil {
+		if v1alpha2.IsNotFound(err) {
+			err = nil
+		}
+		return err
+	}
+	pending := make([]string, 0)
+	for _, p := range tombstone.PendingSites {
+		if p != site {
+			pending = append(pending, p)
+		}
+	}
+	tombstone.PendingSites = pending
+	if !utils.ContainsString(tombstone.AckedSites, site) {
+		tombstone.AckedSites = append(tombstone.AckedSites, site)
+	}
+
+	for _, registered := range sites {
+		if !utils.ContainsString(tombstone.AckedSites, registered) {
+			err = s.saveTombstone(ctx, tombstone)
+			return err
+		}
+	}
+	log.Debugf(" M (Staging): all sites have acknowledged the deletion of catalog %s, removing tombstone", name)
+	err = s.RemoveTombstone(ctx, name, namespace)
+	return err
+}
@@ -8,7 +8,11 @@ package sync
 
 import (
 	"context"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
@@ -16,10 +20,24 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+)
+
+var log = logger.NewLogger("coa.runtime")
+
+const (
+	minPushBackoff = time.Second
+	maxPushBackoff = time.Minute
 )
 
 type SyncManager struct {
 	managers.Manager
+	pushStarted   atomic.Bool
+	pushConnected atomic.Bool
+	// pushLock guards pushCancel and pushStopped, as Shutdown can run while Poll starts the push channel
+	pushLock    sync.Mutex
+	pushCancel  context.CancelFunc
+	pushStopped bool
 }
 
 func (s *SyncManager) Init(context *contexts.VendorContext, config managers.ManagerConfig, providers map[string]providers.IProvider) error {
@@ -35,6 +53,12 @@ func (s *SyncManager) Init(context *contexts.VendorContext, config managers.Mana
 func (s *SyncManager) Enabled() bool {
 	return s.Config.Properties["sync.enabled"] == "true"
 }
+
+// PushEnabled returns whether the site keeps a push channel open to its parent site. Polling remains the
+// fallback while the channel is down.
+func (s *SyncManager) PushEnabled() bool {
+	return s.Config.Properties["push.enabled"] == "true"
+}
 func (s *SyncManager) Poll() []error {
 	ctx, span := observability.StartSpan("Sync Manager", context.Background(), &map[string]string{
 		"method": "Poll",
@@ -44,6 +68,14 @@ func (s *SyncManager) Poll() []error {
 	if s.VendorContext.SiteInfo.ParentSite.BaseUrl == "" {
 		return nil
 	}
+	if s.PushEnabled() {
+		if s.pushStarted.CompareAndSwap(false, true) {
+			s.startPush()
+		}
+		if s.pushConnected.Load() {
+			return nil
+		}
+	}
 	batch, err := utils.GetABatchForSite(
 		ctx,
 		s.VendorContext.SiteInfo.ParentSite.BaseUrl,
@@ -53,36 +85,145 @@ func (s *SyncManager) Poll() []error {
 	if err != nil {
 		return []error{err}
 	}
+	return s.processBatch(ctx, batch)
+}
+
+// startPush opens the push channel in the background, unless the manager has been shut down
+func (s *SyncManager) startPush() {
+	s.pushLock.Lock()
+	defer s.pushLock.Unlock()
+	if s.pushStopped {
+		return
+	}
+	pushCtx, cancel := context.WithCancel(context.Background())
+	s.pushCancel = cancel
+	go s.push(pushCtx)
+}
+
+// push keeps the push channel to the parent site open, reconnecting with a backoff when it breaks
+func (s *SyncManager) push(ctx context.Context) {
+	backoff := minPushBackoff
+	for {
+		stream, err := utils.OpenBatchStream(
+			ctx,
+			s.VendorContext.SiteInfo.ParentSite.BaseUrl,
+			s.VendorContext.SiteInfo.SiteId,
+			s.VendorContext.SiteInfo.ParentSite.Username,
+			s.VendorContext.SiteInfo.ParentSite.Password)
+		if err == nil {
+			log.Infof(" M (Sync): push channel to the parent site is connected")
+			s.pushConnected.Store(true)
+			backoff = minPushBackoff
+			for {
+				var batch model.SyncPackage
+				batch, err = stream.Next()
+				if err != nil {
+					break
+				}
+				for _, e := range s.processBatch(ctx, batch) {
+					log.Errorf(" M (Sync): failed to process a pushed batch: %s", e.Error())
+				}
+			}
+			stream.Close()
+			s.pushConnected.Store(false)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.Errorf(" M (Sync): push channel to the parent site is down, polling until it reconnects in %v: %s", backoff, err.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxPushBackoff {
+			backoff = maxPushBackoff
+		}
+	}
+}
+
+// processBatch publishes the objects of a batch and acknowledges it
+func (s *SyncManager) processBatch(ctx context.Context, batch model.SyncPackage) []error {
+	var err error
+	errs := make([]error, 0)
 	if batch.Catalogs != nil {
 		for _, catalog := range batch.Catalogs {
-			s.Context.Publish("catalog-sync", v1alpha2.Event{
+			err = s.Context.Publish("catalog-sync", v1alpha2.Event{
 				Metadata: map[string]string{
 					"objectType": catalog.Spec.Type,
 					"origin":     batch.Origin,
 				},
 				Body: v1alpha2.JobData{
 					Id:     catalog.ObjectMeta.Name,
-					Action: v1alpha2.JobUpdate, //TODO: handle deletion, this probably requires BetBachForSites return flags
+					Action: v1alpha2.JobUpdate,
 					Body:   catalog,
 				},
 			})
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	for _, deleted := range batch.DeletedCatalogs {
+		err = s.Context.Publish("catalog-sync", v1alpha2.Event{
+			Metadata: map[string]string{
+				"origin": batch.Origin,
+			},
+			Body: v1alpha2.JobData{
+				Id:     deleted.Name,
+				Scope:  deleted.Namespace,
+				Action: v1alpha2.JobDelete,
+				Body: model.CatalogState{
+					ObjectMeta: deleted,
+				},
+			},
+		})
+		if err != nil {
+			errs = append(errs, err)
 		}
 	}
 	if batch.Jobs != nil {
 		for _, job := range batch.Jobs {
-			s.Context.Publish("remote-job", v1alpha2.Event{
+			err = s.Context.Publish("remote-job", v1alpha2.Event{
 				Metadata: map[string]string{
 					"origin": batch.Origin,
 				},
 				Body: job,
 			})
+			if err != nil {
+				errs = append(errs, err)
+			}
 		}
 	}
-	if err != nil {
-		return []error{err}
+	if len(errs) > 0 {
+		// the batch isn't acknowledged, so the parent delivers it again after the visibility timeout
+		return errs
+	}
+	if batch.LeaseId != "" {
+		err = utils.AckBatchForSite(
+			ctx,
+			s.VendorContext.SiteInfo.ParentSite.BaseUrl,
+			s.VendorContext.SiteInfo.SiteId,
+			batch.LeaseId,
+			s.VendorContext.SiteInfo.ParentSite.Username,
+			s.VendorContext.SiteInfo.ParentSite.Password)
+		if err != nil {
+			return []error{err}
+		}
 	}
 	return nil
 }
 func (s *SyncManager) Reconcil() []error {
 	return nil
 }
+func (s *SyncManager) Shutdown(ctx context.Context) error {
+	s.pushLock.Lock()
+	defer s.pushLock.Unlock()
+	s.pushStopped = true
+	if s.pushCancel != nil {
+		s.pushCancel()
+		s.pushCancel = nil
+	}
+	return nil
+}
@@ -7,12 +7,15 @@
 package sync
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
@@ -201,3 +204,219 @@ func TestPoll(t *testing.T) {
 	assert.Equal(t, "catalog1", catalog1.ObjectMeta.Name)
 	assert.Equal(t, "job1", job1.Id)
 }
+
+func TestPollDeletedCatalogs(t *testing.T) {
+	siteId := "fake"
+	ackedLease := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/federation/sync/" + siteId:
+			if r.Method == http.MethodPost {
+				ackedLease = r.URL.Query().Get("lease")
+				return
+			}
+			response = model.SyncPackage{
+				LeaseId: "lease1",
+				DeletedCatalogs: []model.ObjectMeta{
+					{
+						Name:      "catalog1",
+						Namespace: "default",
+					},
+				},
+				Origin: "batch-origin",
+			}
+		case "/users/auth":
+			response = AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+			}
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer ts.Close()
+
+	manager := SyncManager{}
+	vendorContext := &contexts.VendorContext{
+		EvaluationContext: &coa_utils.EvaluationContext{},
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: siteId,
+			ParentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+		Logger: logger.NewLogger("coa.runtime"),
+	}
+	vendorContext.PubsubProvider = &memory.InMemoryPubSubProvider{}
+	vendorContext.PubsubProvider.Init(memory.InMemoryPubSubConfig{})
+	err := manager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"sync.enabled": "true",
+		},
+	}, nil)
+	assert.Nil(t, err)
+
+	sig := make(chan v1alpha2.Event)
+	vendorContext.Subscribe("catalog-sync", func(topic string, event v1alpha2.Event) error {
+		sig <- event
+		return nil
+	})
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+
+	event := <-sig
+	assert.Equal(t, "batch-origin", event.Metadata["origin"])
+	jobData := event.Body.(v1alpha2.JobData)
+	assert.Equal(t, v1alpha2.JobDelete, jobData.Action)
+	assert.Equal(t, "catalog1", jobData.Id)
+	assert.Equal(t, "default", jobData.Scope)
+	assert.Equal(t, "lease1", ackedLease)
+}
+
+func TestPushChannel(t *testing.T) {
+	siteId := "fake"
+	var polls atomic.Int32
+	acked := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/federation/stream/" + siteId:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			json.NewEncoder(w).Encode(model.SyncPackage{
+				LeaseId: "lease2",
+				Jobs: []v1alpha2.JobData{
+					{
+						Id:     "job2",
+						Action: v1alpha2.JobRun,
+					},
+				},
+				Origin: "batch-origin",
+			})
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			return
+		case "/federation/sync/" + siteId:
+			if r.Method == http.MethodPost {
+				acked <- r.URL.Query().Get("lease")
+				return
+			}
+			polls.Add(1)
+			json.NewEncoder(w).Encode(model.SyncPackage{})
+		case "/users/auth":
+			json.NewEncoder(w).Encode(AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+			})
+		}
+	}))
+	defer ts.Close()
+
+	manager := SyncManager{}
+	vendorContext := &contexts.VendorContext{
+		EvaluationContext: &coa_utils.EvaluationContext{},
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: siteId,
+			ParentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+		Logger: logger.NewLogger("coa.runtime"),
+	}
+	vendorContext.PubsubProvider = &memory.InMemoryPubSubProvider{}
+	vendorContext.PubsubProvider.Init(memory.InMemoryPubSubConfig{})
+	err := manager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"sync.enabled": "true",
+			"push.enabled": "true",
+		},
+	}, nil)
+	assert.Nil(t, err)
+	assert.True(t, manager.PushEnabled())
+	defer manager.Shutdown(context.Background())
+
+	sig := make(chan v1alpha2.JobData, 1)
+	vendorContext.Subscribe("remote-job", func(topic string, event v1alpha2.Event) error {
+		sig <- event.Body.(v1alpha2.JobData)
+		return nil
+	})
+
+	errs := manager.Poll()
+	assert.Nil(t, errs)
+
+	select {
+	case job := <-sig:
+		assert.Equal(t, "job2", job.Id)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "pushed job wasn't published")
+	}
+	select {
+	case lease := <-acked:
+		assert.Equal(t, "lease2", lease)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "pushed batch wasn't acknowledged")
+	}
+
+	// polling stops while the push channel is connected
+	assert.True(t, manager.pushConnected.Load())
+	before := polls.Load()
+	errs = manager.Poll()
+	assert.Nil(t, errs)
+	assert.Equal(t, before, polls.Load())
+}
+
+func TestShutdownWhilePolling(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/users/auth":
+			json.NewEncoder(w).Encode(AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+			})
+		default:
+			json.NewEncoder(w).Encode(model.SyncPackage{})
+		}
+	}))
+	defer ts.Close()
+
+	manager := SyncManager{}
+	vendorContext := &contexts.VendorContext{
+		EvaluationContext: &coa_utils.EvaluationContext{},
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: "fake",
+			ParentSite: v1alpha2.SiteConnection{
+				BaseUrl:  ts.URL + "/",
+				Username: "admin",
+				Password: "",
+			},
+		},
+		Logger: logger.NewLogger("coa.runtime"),
+	}
+	vendorContext.PubsubProvider = &memory.InMemoryPubSubProvider{}
+	vendorContext.PubsubProvider.Init(memory.InMemoryPubSubConfig{})
+	err := manager.Init(vendorContext, managers.ManagerConfig{
+		Properties: map[string]string{
+			"sync.enabled": "true",
+			"push.enabled": "true",
+		},
+	}, nil)
+	assert.Nil(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		manager.Poll()
+		close(done)
+	}()
+	err = manager.Shutdown(context.Background())
+	assert.Nil(t, err)
+	<-done
+
+	// the push channel isn't started once the manager is shut down
+	manager.Poll()
+	manager.pushLock.Lock()
+	defer manager.pushLock.Unlock()
+	assert.Nil(t, manager.pushCancel)
+}
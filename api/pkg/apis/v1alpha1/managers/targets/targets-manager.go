@@ -9,10 +9,9 @@ package targets
 import (
 	"context"
 	"encoding/json"
-	"fmt"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
-	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	api_utils "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -64,6 +63,17 @@ func (t *TargetsManager) DeleteSpec(ctx context.Context, name string, namespace
 	return err
 }
 
+// ValidateUpsert checks the name and components of a target
+func (t *TargetsManager) ValidateUpsert(ctx context.Context, name string, state model.TargetState) error {
+	if err := api_utils.ValidateObjectName(name, state.ObjectMeta); err != nil {
+		return err
+	}
+	if state.Spec == nil {
+		return nil
+	}
+	return api_utils.ValidateComponents(state.Spec.Components)
+}
+
 func (t *TargetsManager) UpsertState(ctx context.Context, name string, state model.TargetState) error {
 	ctx, span := observability.StartSpan("Targets Manager", ctx, &map[string]string{
 		"method": "UpsertSpec",
@@ -71,8 +81,8 @@ func (t *TargetsManager) UpsertState(ctx context.Context, name string, state mod
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
-	if state.ObjectMeta.Name != "" && state.ObjectMeta.Name != name {
-		return v1alpha2.NewCOAError(nil, fmt.Sprintf("Name in metadata (%s) does not match name in request (%s)", state.ObjectMeta.Name, name), v1alpha2.BadRequest)
+	if err = t.ValidateUpsert(ctx, name, state); err != nil {
+		return err
 	}
 	state.ObjectMeta.FixNames(name)
 
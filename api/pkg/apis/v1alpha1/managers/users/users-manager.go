@@ -126,3 +126,73 @@ func (t *UsersManager) CheckUser(ctx context.Context, name string, password stri
 	log.Debugf(" M (Users) : authentication failed, traceId: %s", span.SpanContext().TraceID().String())
 	return nil, false
 }
+
+// ListUsers returns the stored users with their password hashes
+func (t *UsersManager) ListUsers(ctx context.Context) ([]UserState, error) {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "ListUsers",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof(" M (Users): ListUsers, traceId: %s", span.SpanContext().TraceID().String())
+
+	var entries []states.StateEntry
+	entries, _, err = t.StateProvider.List(ctx, states.ListRequest{})
+	if err != nil {
+		log.Debugf(" M (Users) : failed to list users %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	ret := make([]UserState, 0, len(entries))
+	for _, entry := range entries {
+		var userState UserState
+		bytes, _ := json.Marshal(entry.Body)
+		err = json.Unmarshal(bytes, &userState)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, userState)
+	}
+	return ret, nil
+}
+
+// GetUserState returns a stored user with its password hash
+func (t *UsersManager) GetUserState(ctx context.Context, name string) (UserState, error) {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "GetUserState",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof(" M (Users): GetUserState name %s, traceId: %s", name, span.SpanContext().TraceID().String())
+
+	var entry states.StateEntry
+	entry, err = t.StateProvider.Get(ctx, states.GetRequest{ID: name})
+	if err != nil {
+		log.Debugf(" M (Users) : failed to get user %v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return UserState{}, err
+	}
+	var userState UserState
+	bytes, _ := json.Marshal(entry.Body)
+	err = json.Unmarshal(bytes, &userState)
+	return userState, err
+}
+
+// UpsertUserState stores a user with its password hash, such as a user returned by ListUsers
+func (t *UsersManager) UpsertUserState(ctx context.Context, user UserState) error {
+	ctx, span := observability.StartSpan("Users Manager", ctx, &map[string]string{
+		"method": "UpsertUserState",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof(" M (Users): UpsertUserState name %s, traceId: %s", user.Id, span.SpanContext().TraceID().String())
+
+	_, err = t.StateProvider.Upsert(ctx, states.UpsertRequest{
+		Value: states.StateEntry{
+			ID:   user.Id,
+			Body: user,
+		},
+	})
+	if err != nil {
+		log.Debugf(" M (Users) : failed to upsert user %v, traceId: %s", err, span.SpanContext().TraceID().String())
+	}
+	return err
+}
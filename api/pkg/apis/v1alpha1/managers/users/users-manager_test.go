@@ -81,3 +81,31 @@ func TestUpsertAndCheck(t *testing.T) {
 	err = manager.DeleteUser(context.Background(), "test")
 	assert.Nil(t, err)
 }
+
+func TestListAndUpsertUserState(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	manager := UsersManager{
+		StateProvider: stateProvider,
+	}
+	roles := []string{"testrole"}
+	err := manager.UpsertUser(context.Background(), "test", "password", roles)
+	assert.Nil(t, err)
+	list, err := manager.ListUsers(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(list))
+	assert.Equal(t, "test", list[0].Id)
+	assert.NotEqual(t, "password", list[0].PasswordHash)
+
+	// a user restored from its state keeps its password
+	restoreProvider := &memorystate.MemoryStateProvider{}
+	restoreProvider.Init(memorystate.MemoryStateProviderConfig{})
+	restored := UsersManager{
+		StateProvider: restoreProvider,
+	}
+	err = restored.UpsertUserState(context.Background(), list[0])
+	assert.Nil(t, err)
+	rolescheck, res := restored.CheckUser(context.Background(), "test", "password")
+	assert.True(t, res)
+	assert.Equal(t, roles, rolescheck)
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import "time"
+
+// BackupArchiveVersion is the version of the backup archive format. Archives of other versions can't be restored.
+const BackupArchiveVersion = 1
+
+// BackupArchive is an export of the objects of a Symphony control plane, in all namespaces
+type BackupArchive struct {
+	Version     int               `json:"version"`
+	SiteId      string            `json:"siteId,omitempty"`
+	CreatedAt   time.Time         `json:"createdAt"`
+	Sites       []SiteState       `json:"sites,omitempty"`
+	Users       []BackupUser      `json:"users,omitempty"`
+	Targets     []TargetState     `json:"targets,omitempty"`
+	Devices     []DeviceState     `json:"devices,omitempty"`
+	Models      []ModelState      `json:"models,omitempty"`
+	Skills      []SkillState      `json:"skills,omitempty"`
+	Solutions   []SolutionState   `json:"solutions,omitempty"`
+	Catalogs    []CatalogState    `json:"catalogs,omitempty"`
+	Campaigns   []CampaignState   `json:"campaigns,omitempty"`
+	Instances   []InstanceState   `json:"instances,omitempty"`
+	Activations []ActivationState `json:"activations,omitempty"`
+	// DeploymentStates are the deployment states and summaries the solution manager keeps for instances and targets
+	DeploymentStates []BackupDeploymentState `json:"deploymentStates,omitempty"`
+}
+
+// BackupUser is a user with its password hash, as the password itself isn't stored
+type BackupUser struct {
+	Id           string   `json:"id"`
+	PasswordHash string   `json:"passwordHash,omitempty"`
+	Roles        []string `json:"roles,omitempty"`
+}
+
+// BackupDeploymentState is a state entry of the solution manager
+type BackupDeploymentState struct {
+	Namespace string      `json:"namespace"`
+	Id        string      `json:"id"`
+	Body      interface{} `json:"body"`
+}
+
+// ConflictPolicy decides what restoring does with an object that already exists
+type ConflictPolicy string
+
+const (
+	// ConflictSkip keeps the existing object, it's the default policy
+	ConflictSkip ConflictPolicy = "skip"
+	// ConflictOverwrite replaces the existing object
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	// ConflictFail restores nothing when any object already exists
+	ConflictFail ConflictPolicy = "fail"
+)
+
+// RestoreResult is the result of restoring each object of an archive
+type RestoreResult struct {
+	Objects []RestoreObjectResult `json:"objects"`
+}
+
+type RestoreObjectResult struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	// Result is created, overwritten, skipped, conflict or failed
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
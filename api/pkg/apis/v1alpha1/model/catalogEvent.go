@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// CatalogChangedEvent is the body of the events published on the catalog-changed topic when a catalog is created,
+// updated, rolled back or deleted
+type CatalogChangedEvent struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Type      string `json:"type,omitempty"`
+	// Action is update, rollback or delete, like the action of catalog revisions
+	Action string `json:"action"`
+	// Keys are the changed properties. AllKeys is set instead when the catalog is deleted or when a part of its spec
+	// other than the properties changed, such as its parent.
+	Keys    []string `json:"keys,omitempty"`
+	AllKeys bool     `json:"allKeys,omitempty"`
+}
+
+// Affects tells if a change affects any of the given keys of the catalog. An empty key stands for the whole catalog.
+func (e CatalogChangedEvent) Affects(keys []string) bool {
+	for _, key := range keys {
+		if key == "" || e.AllKeys {
+			return true
+		}
+		for _, k := range e.Keys {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewCatalogChangedEvent makes the event of a catalog change from the diff of the catalog specs
+func NewCatalogChangedEvent(name string, namespace string, catalogType string, action string, changes []CatalogChange) CatalogChangedEvent {
+	ret := CatalogChangedEvent{
+		Name:      name,
+		Namespace: namespace,
+		Type:      catalogType,
+		Action:    action,
+		AllKeys:   action == CatalogRevisionDelete,
+	}
+	keys := map[string]bool{}
+	for _, change := range changes {
+		segments := strings.SplitN(strings.TrimPrefix(change.Path, "/"), "/", 3)
+		if segments[0] != "properties" {
+			ret.AllKeys = true
+			continue
+		}
+		if len(segments) == 1 {
+			// all properties were added or removed
+			ret.AllKeys = true
+			continue
+		}
+		keys[strings.ReplaceAll(strings.ReplaceAll(segments[1], "~1", "/"), "~0", "~")] = true
+	}
+	if !ret.AllKeys {
+		for k := range keys {
+			ret.Keys = append(ret.Keys, k)
+		}
+		sort.Strings(ret.Keys)
+	}
+	return ret
+}
+
+// CatalogDependencies is the body of the events published on the catalog-dependencies topic when an instance is
+// deployed. Catalogs are the catalog properties the instance read through $config(), keyed by catalog name. An empty
+// property stands for the whole catalog.
+type CatalogDependencies struct {
+	Instance  string              `json:"instance"`
+	Namespace string              `json:"namespace"`
+	Catalogs  map[string][]string `json:"catalogs,omitempty"`
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// CatalogRevisionUpdate records a catalog that was created or updated
+	CatalogRevisionUpdate = "update"
+	// CatalogRevisionRollback records a catalog that was rolled back to an earlier revision
+	CatalogRevisionRollback = "rollback"
+	// CatalogRevisionDelete records a catalog that was deleted
+	CatalogRevisionDelete = "delete"
+)
+
+// CatalogRevision is an immutable record of a change to a catalog. Revisions are numbered from 1 for each catalog.
+type CatalogRevision struct {
+	Revision int    `json:"revision"`
+	Action   string `json:"action"`
+	// SourceRevision is the revision a rollback restored
+	SourceRevision int       `json:"sourceRevision,omitempty"`
+	Generation     string    `json:"generation,omitempty"`
+	Author         string    `json:"author,omitempty"`
+	Time           time.Time `json:"time"`
+	// Diff lists the changes from the previous revision
+	Diff []CatalogChange `json:"diff,omitempty"`
+	// Spec is the catalog spec after the change, it's empty for deletions
+	Spec *CatalogSpec `json:"spec,omitempty"`
+}
+
+// CatalogChange is a change of a value in a catalog spec, at a JSON Pointer path such as /properties/replicas
+type CatalogChange struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path"`
+	Old   interface{} `json:"old,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// DiffCatalogSpecs returns the changes between two catalog specs, either of which can be nil. Nested objects are
+// compared key by key, other values as a whole. The generation isn't compared, and an empty objectRef is
+// the same as none.
+func DiffCatalogSpecs(old *CatalogSpec, new *CatalogSpec) []CatalogChange {
+	changes := make([]CatalogChange, 0)
+	diffValues("", catalogSpecToMap(old), catalogSpecToMap(new), &changes)
+	return changes
+}
+
+func catalogSpecToMap(spec *CatalogSpec) map[string]interface{} {
+	ret := map[string]interface{}{}
+	if spec == nil {
+		return ret
+	}
+	copy := *spec
+	copy.Generation = ""
+	data, _ := json.Marshal(copy)
+	json.Unmarshal(data, &ret)
+	// objectRef is always serialized, an empty one isn't reported as a change
+	if reflect.DeepEqual(copy.ObjectRef, ObjectRef{}) {
+		delete(ret, "objectRef")
+	}
+	return ret
+}
+
+func diffValues(path string, old interface{}, new interface{}, changes *[]CatalogChange) {
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := make([]string, 0)
+		for k := range oldMap {
+			keys = append(keys, k)
+		}
+		for k := range newMap {
+			if _, ok := oldMap[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			childPath := path + "/" + escapePointerToken(k)
+			oldValue, inOld := oldMap[k]
+			newValue, inNew := newMap[k]
+			switch {
+			case !inOld:
+				*changes = append(*changes, CatalogChange{Op: "add", Path: childPath, Value: newValue})
+			case !inNew:
+				*changes = append(*changes, CatalogChange{Op: "remove", Path: childPath, Old: oldValue})
+			default:
+				diffValues(childPath, oldValue, newValue, changes)
+			}
+		}
+		return
+	}
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, CatalogChange{Op: "replace", Path: path, Old: old, Value: new})
+	}
+}
+
+func escapePointerToken(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -170,3 +170,57 @@ func TestGetTo(t *testing.T) {
 	catalog.Spec = nil
 	assert.Equal(t, catalog.GetTo(), "")
 }
+func TestDiffCatalogSpecs(t *testing.T) {
+	old := &CatalogSpec{
+		Type:       "config",
+		Generation: "1",
+		Properties: map[string]interface{}{
+			"image":  "nginx",
+			"limits": map[string]interface{}{"cpu": "1", "memory": "1Gi"},
+			"a/b":    true,
+		},
+	}
+	new := &CatalogSpec{
+		Type:       "config",
+		Generation: "2",
+		Properties: map[string]interface{}{
+			"image":  "nginx:1.25",
+			"limits": map[string]interface{}{"cpu": "2"},
+			"ports":  []interface{}{80, 443},
+		},
+	}
+	changes := DiffCatalogSpecs(old, new)
+	assert.Equal(t, []CatalogChange{
+		{Op: "remove", Path: "/properties/a~1b", Old: true},
+		{Op: "replace", Path: "/properties/image", Old: "nginx", Value: "nginx:1.25"},
+		{Op: "replace", Path: "/properties/limits/cpu", Old: "1", Value: "2"},
+		{Op: "remove", Path: "/properties/limits/memory", Old: "1Gi"},
+		{Op: "add", Path: "/properties/ports", Value: []interface{}{float64(80), float64(443)}},
+	}, changes)
+
+	assert.Equal(t, 0, len(DiffCatalogSpecs(old, old)))
+	assert.Contains(t, DiffCatalogSpecs(old, nil), CatalogChange{Op: "remove", Path: "/type", Old: "config"})
+	assert.Contains(t, DiffCatalogSpecs(nil, new), CatalogChange{Op: "add", Path: "/type", Value: "config"})
+}
+
+func TestNewCatalogChangedEvent(t *testing.T) {
+	event := NewCatalogChangedEvent("config1", "default", "config", CatalogRevisionUpdate, []CatalogChange{
+		{Op: "remove", Path: "/properties/a~1b", Old: true},
+		{Op: "replace", Path: "/properties/limits/cpu", Old: "1", Value: "2"},
+		{Op: "remove", Path: "/properties/limits/memory", Old: "1Gi"},
+	})
+	assert.Equal(t, []string{"a/b", "limits"}, event.Keys)
+	assert.False(t, event.AllKeys)
+	assert.True(t, event.Affects([]string{"image", "limits"}))
+	assert.True(t, event.Affects([]string{""}))
+	assert.False(t, event.Affects([]string{"image"}))
+
+	event = NewCatalogChangedEvent("config1", "default", "config", CatalogRevisionUpdate, []CatalogChange{
+		{Op: "replace", Path: "/parentName", Old: "parent1", Value: "parent2"},
+	})
+	assert.True(t, event.AllKeys)
+	assert.True(t, event.Affects([]string{"image"}))
+
+	event = NewCatalogChangedEvent("config1", "default", "config", CatalogRevisionDelete, nil)
+	assert.True(t, event.AllKeys)
+}
@@ -25,6 +25,10 @@ const (
 	AppPackage     = "app.package"
 	AppImage       = "app.image"
 	ContainerImage = "container.image"
+	// Component properties that describe how to verify a component is healthy after it's applied
+	ProbeHTTP    = "probe.http"
+	ProbeTCP     = "probe.tcp"
+	ProbeCommand = "probe.command"
 )
 
 func (c ModelSpec) DeepEquals(other IDeepEquals) (bool, error) {
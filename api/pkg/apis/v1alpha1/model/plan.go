@@ -35,6 +35,31 @@ type ComponentStep struct {
 	Component ComponentSpec   `json:"component"`
 }
 
+// PlanPreview describes what reconciling a deployment would do, without applying or storing anything
+type PlanPreview struct {
+	IsRemoval bool          `json:"isRemoval,omitempty"`
+	Steps     []PreviewStep `json:"steps"`
+}
+
+type PreviewStep struct {
+	Target string `json:"target"`
+	Role   string `json:"role"`
+	// Skipped is true when reconciling skips the step, as none of its components have changed
+	Skipped    bool               `json:"skipped,omitempty"`
+	Components []PreviewComponent `json:"components"`
+	// Error is the error the target provider returns when the step is applied in dry-run mode
+	Error string `json:"error,omitempty"`
+}
+
+type PreviewComponent struct {
+	Action    ComponentAction `json:"action"`
+	Component ComponentSpec   `json:"component"`
+	// Current is the component as it was last deployed, if it's deployed
+	Current *ComponentSpec `json:"current,omitempty"`
+	Changed bool           `json:"changed"`
+	Reasons []string       `json:"reasons,omitempty"`
+}
+
 type TargetDesc struct {
 	Name string
 	Spec TargetSpec
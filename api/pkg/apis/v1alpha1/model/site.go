@@ -8,6 +8,8 @@ package model
 
 import (
 	"errors"
+	"path"
+	"reflect"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 )
@@ -18,13 +20,29 @@ type SiteState struct {
 	Spec     *SiteSpec              `json:"spec,omitempty"`
 	Status   *SiteStatus            `json:"status,omitempty"`
 }
+
+// +kubebuilder:object:generate=true
 type SiteTargetStatus struct {
-	State  v1alpha2.State `json:"state,omitempty"`
-	Reason string         `json:"reason,omitempty"`
+	State     v1alpha2.State `json:"state,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Namespace string         `json:"namespace,omitempty"`
+	// Site is the site the target belongs to
+	Site string `json:"site,omitempty"`
+	// Path lists the site IDs from the site holding the status down to Site
+	Path []string `json:"path,omitempty"`
 }
+
+// +kubebuilder:object:generate=true
 type SiteInstanceStatus struct {
-	State  v1alpha2.State `json:"state,omitempty"`
-	Reason string         `json:"reason,omitempty"`
+	State     v1alpha2.State `json:"state,omitempty"`
+	Reason    string         `json:"reason,omitempty"`
+	Name      string         `json:"name,omitempty"`
+	Namespace string         `json:"namespace,omitempty"`
+	// Site is the site the instance runs on
+	Site string `json:"site,omitempty"`
+	// Path lists the site IDs from the site holding the status down to Site
+	Path []string `json:"path,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -33,6 +51,15 @@ type SiteStatus struct {
 	TargetStatuses   map[string]SiteTargetStatus   `json:"targetStatuses,omitempty"`
 	InstanceStatuses map[string]SiteInstanceStatus `json:"instanceStatuses,omitempty"`
 	LastReported     string                        `json:"lastReported,omitempty"`
+	// ConnectivityHistory lists the latest online and offline transitions of the site, oldest first
+	ConnectivityHistory []SiteConnectivity `json:"connectivityHistory,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type SiteConnectivity struct {
+	IsOnline bool   `json:"isOnline"`
+	Time     string `json:"time"`
+	Reason   string `json:"reason,omitempty"`
 }
 
 // +kubebuilder:object:generate=true
@@ -41,6 +68,68 @@ type SiteSpec struct {
 	IsSelf     bool              `json:"isSelf,omitempty"`
 	PublicKey  string            `json:"secretHash,omitempty"`
 	Properties map[string]string `json:"properties,omitempty"`
+	// CatalogSelectors select the catalogs synced to the site. A catalog is synced if it matches any of the
+	// selectors, all catalogs are synced if there are no selectors.
+	CatalogSelectors []CatalogSelector `json:"catalogSelectors,omitempty"`
+}
+
+// +kubebuilder:object:generate=true
+type CatalogSelector struct {
+	// Type matches the catalog type, any type if empty
+	Type string `json:"type,omitempty"`
+	// Namespace matches the catalog namespace, any namespace if empty
+	Namespace string `json:"namespace,omitempty"`
+	// Names are patterns matching the catalog name, such as "plant-*", any name if empty
+	Names []string `json:"names,omitempty"`
+	// Labels must all be present on the catalog with the same values
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Matches checks if a catalog satisfies all the conditions of the selector
+func (c CatalogSelector) Matches(catalog CatalogState) bool {
+	if c.Type != "" && (catalog.Spec == nil || catalog.Spec.Type != c.Type) {
+		return false
+	}
+	if c.Namespace != "" {
+		namespace := catalog.ObjectMeta.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		if namespace != c.Namespace {
+			return false
+		}
+	}
+	if len(c.Names) > 0 {
+		matched := false
+		for _, pattern := range c.Names {
+			if ok, err := path.Match(pattern, catalog.ObjectMeta.Name); err == nil && ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	for k, v := range c.Labels {
+		if label, ok := catalog.ObjectMeta.Labels[k]; !ok || label != v {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectsCatalog checks if a catalog is synced to the site
+func (s SiteSpec) SelectsCatalog(catalog CatalogState) bool {
+	if len(s.CatalogSelectors) == 0 {
+		return true
+	}
+	for _, selector := range s.CatalogSelectors {
+		if selector.Matches(catalog) {
+			return true
+		}
+	}
+	return false
 }
 
 func (s SiteSpec) DeepEquals(other IDeepEquals) (bool, error) {
@@ -57,5 +146,9 @@ func (s SiteSpec) DeepEquals(other IDeepEquals) (bool, error) {
 		return false, nil
 	}
 
+	if !reflect.DeepEqual(s.CatalogSelectors, otherS.CatalogSelectors) {
+		return false, nil
+	}
+
 	return true, nil
 }
@@ -75,3 +75,48 @@ func TestSiteEqualNil(t *testing.T) {
 	assert.EqualError(t, err, "parameter is not a SiteSpec type")
 	assert.False(t, res)
 }
+
+func TestSiteCatalogSelectorsNotMatch(t *testing.T) {
+	s1 := SiteSpec{
+		Name: "site",
+		CatalogSelectors: []CatalogSelector{
+			{Type: "config"},
+		},
+	}
+	s2 := SiteSpec{
+		Name: "site",
+	}
+	equal, err := s1.DeepEquals(s2)
+	assert.Nil(t, err)
+	assert.False(t, equal)
+}
+
+func TestSiteSelectsCatalog(t *testing.T) {
+	catalog := CatalogState{
+		ObjectMeta: ObjectMeta{
+			Name:   "plant-config-1",
+			Labels: map[string]string{"tier": "factory"},
+		},
+		Spec: &CatalogSpec{Type: "config"},
+	}
+	assert.True(t, SiteSpec{}.SelectsCatalog(catalog))
+	assert.True(t, SiteSpec{CatalogSelectors: []CatalogSelector{
+		{Type: "config", Namespace: "default", Names: []string{"plant-*"}, Labels: map[string]string{"tier": "factory"}},
+	}}.SelectsCatalog(catalog))
+	assert.True(t, SiteSpec{CatalogSelectors: []CatalogSelector{
+		{Type: "asset"},
+		{Names: []string{"other", "plant-config-?"}},
+	}}.SelectsCatalog(catalog))
+	assert.False(t, SiteSpec{CatalogSelectors: []CatalogSelector{
+		{Type: "asset"},
+	}}.SelectsCatalog(catalog))
+	assert.False(t, SiteSpec{CatalogSelectors: []CatalogSelector{
+		{Namespace: "plants"},
+	}}.SelectsCatalog(catalog))
+	assert.False(t, SiteSpec{CatalogSelectors: []CatalogSelector{
+		{Labels: map[string]string{"tier": "cloud"}},
+	}}.SelectsCatalog(catalog))
+	assert.False(t, SiteSpec{CatalogSelectors: []CatalogSelector{
+		{Names: []string{"office-*"}},
+	}}.SelectsCatalog(catalog))
+}
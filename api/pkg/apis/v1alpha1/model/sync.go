@@ -6,10 +6,49 @@
 
 package model
 
-import "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+import (
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
 
 type SyncPackage struct {
-	Origin   string             `json:"origin,omitempty"`
-	Catalogs []CatalogState     `json:"catalogs,omitempty"`
-	Jobs     []v1alpha2.JobData `json:"jobs,omitempty"`
+	Origin          string             `json:"origin,omitempty"`
+	LeaseId         string             `json:"leaseId,omitempty"`
+	Catalogs        []CatalogState     `json:"catalogs,omitempty"`
+	DeletedCatalogs []ObjectMeta       `json:"deletedCatalogs,omitempty"`
+	Jobs            []v1alpha2.JobData `json:"jobs,omitempty"`
+}
+
+// CatalogTombstone records a deleted catalog until all child sites have acknowledged the deletion
+type CatalogTombstone struct {
+	Name         string    `json:"name"`
+	Namespace    string    `json:"namespace,omitempty"`
+	DeletedTime  time.Time `json:"deletedTime"`
+	PendingSites []string  `json:"pendingSites,omitempty"`
+	AckedSites   []string  `json:"ackedSites,omitempty"`
+}
+
+// SyncLease tracks a batch of jobs that has been delivered to a site but not yet acknowledged
+type SyncLease struct {
+	Id      string          `json:"id"`
+	Site    string          `json:"site"`
+	Expires time.Time       `json:"expires"`
+	Items   []SyncLeaseItem `json:"items"`
+}
+
+type SyncLeaseItem struct {
+	Job      v1alpha2.JobData `json:"job"`
+	Attempts int              `json:"attempts"`
+	// LeaseId is the lease of the job in the site's queue
+	LeaseId string `json:"leaseId,omitempty"`
+}
+
+// DeadLetter is a job that couldn't be delivered to a site within the maximum number of attempts
+type DeadLetter struct {
+	Id       string           `json:"id"`
+	Site     string           `json:"site"`
+	Job      v1alpha2.JobData `json:"job"`
+	Attempts int              `json:"attempts"`
+	DeadTime time.Time        `json:"deadTime"`
 }
@@ -9,6 +9,7 @@ package model
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
@@ -74,7 +75,9 @@ func (v ValidationRule) Validate(components []ComponentSpec) error {
 	return nil
 }
 
-func detectChanges(properties []PropertyDesc, oldName string, newName string, oldValues map[string]interface{}, newValues map[string]interface{}) bool {
+// detectChanges returns the names of the change detection properties that differ between the old and new values
+func detectChanges(properties []PropertyDesc, oldName string, newName string, oldValues map[string]interface{}, newValues map[string]interface{}) []string {
+	ret := make([]string, 0)
 	for _, p := range properties {
 		if strings.Contains(p.Name, "*") {
 			escapedPattern := regexp.QuoteMeta(p.Name)
@@ -82,27 +85,32 @@ func detectChanges(properties []PropertyDesc, oldName string, newName string, ol
 			regexpPattern := strings.ReplaceAll(escapedPattern, `\*`, ".*")
 			// Compile the regular expression
 			regexpObject := regexp.MustCompile("^" + regexpPattern + "$")
+			keys := make([]string, 0)
 			for k := range oldValues {
 				if regexpObject.MatchString(k) {
-					if compareProperties(p, oldValues, newValues, k) {
-						return true
-					}
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if compareProperties(p, oldValues, newValues, k) {
+					ret = append(ret, k)
 				}
 			}
 		} else {
 			if p.IsComponentName {
 				if !compareStrings(oldName, newName, p.IgnoreCase, p.PrefixMatch) {
-					return true
+					ret = append(ret, p.Name)
 				}
 			} else {
 				if compareProperties(p, oldValues, newValues, p.Name) {
-					return true
+					ret = append(ret, p.Name)
 				}
 			}
 		}
 	}
 
-	return false
+	return ret
 }
 func convertMapStringToStringInterface(m map[string]string) map[string]interface{} {
 	newMap := make(map[string]interface{})
@@ -113,13 +121,20 @@ func convertMapStringToStringInterface(m map[string]string) map[string]interface
 }
 
 func (v ValidationRule) IsComponentChanged(old ComponentSpec, new ComponentSpec) bool {
-	if detectChanges(v.ComponentValidationRule.ChangeDetectionProperties, old.Name, new.Name, old.Properties, new.Properties) {
-		return true
+	return len(v.GetComponentChanges(old, new)) > 0
+}
+
+// GetComponentChanges returns the reasons a component is considered changed by the change detection rules,
+// or an empty slice when it's not
+func (v ValidationRule) GetComponentChanges(old ComponentSpec, new ComponentSpec) []string {
+	ret := make([]string, 0)
+	for _, p := range detectChanges(v.ComponentValidationRule.ChangeDetectionProperties, old.Name, new.Name, old.Properties, new.Properties) {
+		ret = append(ret, fmt.Sprintf("property '%s' changed", p))
 	}
-	if detectChanges(v.ComponentValidationRule.ChangeDetectionMetadata, old.Name, new.Name,
+	for _, p := range detectChanges(v.ComponentValidationRule.ChangeDetectionMetadata, old.Name, new.Name,
 		convertMapStringToStringInterface(old.Metadata),
 		convertMapStringToStringInterface(new.Metadata)) {
-		return true
+		ret = append(ret, fmt.Sprintf("metadata '%s' changed", p))
 	}
 	if v.AllowSidecar {
 		touchCount := 0
@@ -127,8 +142,8 @@ func (v ValidationRule) IsComponentChanged(old ComponentSpec, new ComponentSpec)
 			foundOld := false
 			for _, oldSidecar := range old.Sidecars {
 				if sidecar.Name == oldSidecar.Name {
-					if detectChanges(v.SidecarValidationRule.ChangeDetectionProperties, oldSidecar.Name, sidecar.Name, oldSidecar.Properties, sidecar.Properties) {
-						return true
+					for _, p := range detectChanges(v.SidecarValidationRule.ChangeDetectionProperties, oldSidecar.Name, sidecar.Name, oldSidecar.Properties, sidecar.Properties) {
+						ret = append(ret, fmt.Sprintf("sidecar '%s' property '%s' changed", sidecar.Name, p))
 					}
 					foundOld = true
 					touchCount++
@@ -136,14 +151,14 @@ func (v ValidationRule) IsComponentChanged(old ComponentSpec, new ComponentSpec)
 				}
 			}
 			if !foundOld {
-				return true
+				ret = append(ret, fmt.Sprintf("sidecar '%s' is added", sidecar.Name))
 			}
 		}
 		if touchCount != len(old.Sidecars) {
-			return true
+			ret = append(ret, "sidecars are removed")
 		}
 	}
-	return false
+	return ret
 }
 func compareStrings(a, b string, ignoreCase bool, prefixMatch bool) bool {
 	ta := a
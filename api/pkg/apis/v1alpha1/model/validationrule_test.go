@@ -757,3 +757,63 @@ func TestValidateChangeDetectionWithCustomComparator(t *testing.T) {
 	}
 	assert.True(t, rule.IsComponentChanged(oldComponent, newComponent))
 }
+
+func TestGetComponentChanges(t *testing.T) {
+	validationRule := ValidationRule{
+		AllowSidecar: true,
+		ComponentValidationRule: ComponentValidationRule{
+			ChangeDetectionProperties: []PropertyDesc{
+				{Name: "container.*"},
+				{Name: "helm.chart"},
+			},
+			ChangeDetectionMetadata: []PropertyDesc{
+				{Name: "owner"},
+			},
+		},
+		SidecarValidationRule: ComponentValidationRule{
+			ChangeDetectionProperties: []PropertyDesc{
+				{Name: "container.image"},
+			},
+		},
+	}
+	old := ComponentSpec{
+		Name: "a",
+		Properties: map[string]interface{}{
+			"container.image": "a:1",
+			"container.ports": "80",
+			"helm.chart":      "c",
+		},
+		Metadata: map[string]string{
+			"owner": "x",
+		},
+		Sidecars: []SidecarSpec{
+			{Name: "s1", Properties: map[string]interface{}{"container.image": "s:1"}},
+			{Name: "s2", Properties: map[string]interface{}{"container.image": "s:1"}},
+		},
+	}
+	new := ComponentSpec{
+		Name: "a",
+		Properties: map[string]interface{}{
+			"container.image": "a:2",
+			"container.ports": "80",
+			"helm.chart":      "c",
+		},
+		Metadata: map[string]string{
+			"owner": "y",
+		},
+		Sidecars: []SidecarSpec{
+			{Name: "s1", Properties: map[string]interface{}{"container.image": "s:2"}},
+			{Name: "s3", Properties: map[string]interface{}{"container.image": "s:1"}},
+		},
+	}
+	assert.Equal(t, []string{
+		"property 'container.image' changed",
+		"metadata 'owner' changed",
+		"sidecar 's1' property 'container.image' changed",
+		"sidecar 's3' is added",
+		"sidecars are removed",
+	}, validationRule.GetComponentChanges(old, new))
+	assert.True(t, validationRule.IsComponentChanged(old, new))
+	assert.Empty(t, validationRule.GetComponentChanges(old, old))
+	assert.False(t, validationRule.IsComponentChanged(old, old))
+}
@@ -35,6 +35,33 @@ func (in *BindingSpec) DeepCopy() *BindingSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogSelector) DeepCopyInto(out *CatalogSelector) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogSelector.
+func (in *CatalogSelector) DeepCopy() *CatalogSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ComponentError) DeepCopyInto(out *ComponentError) {
 	*out = *in
@@ -345,6 +372,41 @@ func (in *RouteSpec) DeepCopy() *RouteSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SiteConnectivity) DeepCopyInto(out *SiteConnectivity) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteConnectivity.
+func (in *SiteConnectivity) DeepCopy() *SiteConnectivity {
+	if in == nil {
+		return nil
+	}
+	out := new(SiteConnectivity)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SiteInstanceStatus) DeepCopyInto(out *SiteInstanceStatus) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteInstanceStatus.
+func (in *SiteInstanceStatus) DeepCopy() *SiteInstanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SiteInstanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SiteSpec) DeepCopyInto(out *SiteSpec) {
 	*out = *in
@@ -355,6 +417,13 @@ func (in *SiteSpec) DeepCopyInto(out *SiteSpec) {
 			(*out)[key] = val
 		}
 	}
+	if in.CatalogSelectors != nil {
+		in, out := &in.CatalogSelectors, &out.CatalogSelectors
+		*out = make([]CatalogSelector, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteSpec.
@@ -374,16 +443,21 @@ func (in *SiteStatus) DeepCopyInto(out *SiteStatus) {
 		in, out := &in.TargetStatuses, &out.TargetStatuses
 		*out = make(map[string]SiteTargetStatus, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
 	if in.InstanceStatuses != nil {
 		in, out := &in.InstanceStatuses, &out.InstanceStatuses
 		*out = make(map[string]SiteInstanceStatus, len(*in))
 		for key, val := range *in {
-			(*out)[key] = val
+			(*out)[key] = *val.DeepCopy()
 		}
 	}
+	if in.ConnectivityHistory != nil {
+		in, out := &in.ConnectivityHistory, &out.ConnectivityHistory
+		*out = make([]SiteConnectivity, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteStatus.
@@ -396,6 +470,26 @@ func (in *SiteStatus) DeepCopy() *SiteStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SiteTargetStatus) DeepCopyInto(out *SiteTargetStatus) {
+	*out = *in
+	if in.Path != nil {
+		in, out := &in.Path, &out.Path
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SiteTargetStatus.
+func (in *SiteTargetStatus) DeepCopy() *SiteTargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SiteTargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SkillPackageSpec) DeepCopyInto(out *SkillPackageSpec) {
 	*out = *in
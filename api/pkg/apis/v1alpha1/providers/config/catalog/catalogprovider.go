@@ -10,6 +10,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -88,8 +90,40 @@ func CatalogConfigProviderConfigFromMap(properties map[string]string) (CatalogCo
 	ret.Password = password
 	return ret, nil
 }
-func (m *CatalogConfigProvider) unwindOverrides(override string, field string, namespace string) (string, error) {
-	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, override, m.Config.User, m.Config.Password, namespace)
+
+// getCatalog gets the current spec of a catalog, or the spec of a revision when the object is pinned with
+// <name>@<revision>. Parents are always read at their current revision.
+func (m *CatalogConfigProvider) getCatalog(object string, namespace string) (model.CatalogState, error) {
+	if i := strings.LastIndex(object, "@"); i > 0 {
+		name := object[:i]
+		revision, err := strconv.Atoi(strings.TrimSuffix(object[i+1:], ">"))
+		if err != nil {
+			return model.CatalogState{}, v1alpha2.NewCOAError(err, fmt.Sprintf("invalid catalog revision in '%s'", object), v1alpha2.BadRequest)
+		}
+		catalogRevision, err := utils.GetCatalogRevision(context.TODO(), m.Config.BaseUrl, strings.TrimPrefix(name, "<"), revision, m.Config.User, m.Config.Password, namespace)
+		if err != nil {
+			return model.CatalogState{}, err
+		}
+		if catalogRevision.Spec == nil {
+			return model.CatalogState{}, v1alpha2.NewCOAError(nil, fmt.Sprintf("revision %d of catalog '%s' is a deletion", revision, name), v1alpha2.NotFound)
+		}
+		return model.CatalogState{
+			ObjectMeta: model.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       catalogRevision.Spec,
+		}, nil
+	}
+	return utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, namespace)
+}
+
+// recordRead records a read of a catalog field in the evaluation context, unless the catalog is pinned to a revision
+func (m *CatalogConfigProvider) recordRead(object string, field string, localcontext interface{}) {
+	if ltx, ok := localcontext.(coa_utils.EvaluationContext); ok && strings.LastIndex(object, "@") <= 0 {
+		ltx.ConfigReads.Record(strings.TrimSuffix(strings.TrimPrefix(object, "<"), ">"), field)
+	}
+}
+func (m *CatalogConfigProvider) unwindOverrides(override string, field string, namespace string, localcontext interface{}) (string, error) {
+	m.recordRead(override, field, localcontext)
+	catalog, err := m.getCatalog(override, namespace)
 	if err != nil {
 		return "", err
 	}
@@ -97,14 +131,15 @@ func (m *CatalogConfigProvider) unwindOverrides(override string, field string, n
 		return v.(string), nil
 	}
 	if catalog.Spec.ParentName != "" {
-		return m.unwindOverrides(catalog.Spec.ParentName, field, namespace)
+		return m.unwindOverrides(catalog.Spec.ParentName, field, namespace, localcontext)
 	}
 	return "", v1alpha2.NewCOAError(nil, fmt.Sprintf("field '%s' is not found in configuration '%s'", field, override), v1alpha2.NotFound)
 }
 func (m *CatalogConfigProvider) Read(object string, field string, localcontext interface{}) (interface{}, error) {
 	namespace := m.getNamespaceFromContext(localcontext)
 
-	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, namespace)
+	m.recordRead(object, field, localcontext)
+	catalog, err := m.getCatalog(object, namespace)
 	if err != nil {
 		return "", err
 	}
@@ -114,7 +149,7 @@ func (m *CatalogConfigProvider) Read(object string, field string, localcontext i
 	}
 
 	if catalog.Spec.ParentName != "" {
-		overrid, err := m.unwindOverrides(catalog.Spec.ParentName, field, namespace)
+		overrid, err := m.unwindOverrides(catalog.Spec.ParentName, field, namespace, localcontext)
 		if err != nil {
 			return "", err
 		} else {
@@ -128,7 +163,8 @@ func (m *CatalogConfigProvider) Read(object string, field string, localcontext i
 func (m *CatalogConfigProvider) ReadObject(object string, localcontext interface{}) (map[string]interface{}, error) {
 	namespace := m.getNamespaceFromContext(localcontext)
 
-	catalog, err := utils.GetCatalog(context.TODO(), m.Config.BaseUrl, object, m.Config.User, m.Config.Password, namespace)
+	m.recordRead(object, "", localcontext)
+	catalog, err := m.getCatalog(object, namespace)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +212,7 @@ func (m *CatalogConfigProvider) traceValue(v interface{}, localcontext interface
 				context.Properties = ltx.Properties
 				context.Component = ltx.Component
 				context.Namespace = ltx.Namespace
+				context.ConfigReads = ltx.ConfigReads
 				if ltx.DeploymentSpec != nil {
 					context.DeploymentSpec = ltx.DeploymentSpec
 				}
@@ -282,3 +282,150 @@ func TestSetandRemoveObject(t *testing.T) {
 	err = provider.RemoveObject("catalog1")
 	assert.Nil(t, err)
 }
+
+func TestReadRevision(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/catalogs/registry/catalog1":
+			response = model.CatalogState{
+				ObjectMeta: model.ObjectMeta{
+					Name: "catalog1",
+				},
+				Spec: &model.CatalogSpec{
+					Properties: map[string]interface{}{
+						"image": "nginx:1.25",
+					},
+				},
+			}
+		case "/catalogs/registry/catalog1/revisions/2":
+			response = model.CatalogRevision{
+				Revision: 2,
+				Action:   model.CatalogRevisionUpdate,
+				Spec: &model.CatalogSpec{
+					Properties: map[string]interface{}{
+						"image": "nginx:1.24",
+					},
+				},
+			}
+		case "/catalogs/registry/catalog1/revisions/3":
+			response = model.CatalogRevision{
+				Revision: 3,
+				Action:   model.CatalogRevisionDelete,
+			}
+		default:
+			response = AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+				Username:    "test-user",
+				Roles:       []string{"role1", "role2"},
+			}
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer ts.Close()
+
+	provider := CatalogConfigProvider{}
+	err := provider.Init(CatalogConfigProviderConfig{BaseUrl: ts.URL + "/", User: "admin", Password: ""})
+	provider.Context = &contexts.ManagerContext{
+		VencorContext: &contexts.VendorContext{
+			EvaluationContext: &utils.EvaluationContext{},
+		},
+	}
+	assert.Nil(t, err)
+
+	res, err := provider.Read("catalog1", "image", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx:1.25", res)
+
+	res, err = provider.Read("catalog1@2", "image", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx:1.24", res)
+
+	obj, err := provider.ReadObject("<catalog1@2>", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "nginx:1.24", obj["image"])
+
+	_, err = provider.Read("catalog1@3", "image", nil)
+	assert.True(t, v1alpha2.IsNotFound(err))
+
+	_, err = provider.Read("catalog1@latest", "image", nil)
+	assert.NotNil(t, err)
+}
+
+func TestReadRecordsConfigReads(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/catalogs/registry/catalog1":
+			response = model.CatalogState{
+				ObjectMeta: model.ObjectMeta{
+					Name: "catalog1",
+				},
+				Spec: &model.CatalogSpec{
+					ParentName: "parent",
+					Properties: map[string]interface{}{
+						"image": "nginx",
+					},
+				},
+			}
+		case "/catalogs/registry/parent":
+			response = model.CatalogState{
+				ObjectMeta: model.ObjectMeta{
+					Name: "parent",
+				},
+				Spec: &model.CatalogSpec{
+					Properties: map[string]interface{}{
+						"port": "80",
+					},
+				},
+			}
+		case "/catalogs/registry/catalog2/revisions/1":
+			response = model.CatalogRevision{
+				Revision: 1,
+				Action:   model.CatalogRevisionUpdate,
+				Spec: &model.CatalogSpec{
+					Properties: map[string]interface{}{
+						"image": "nginx",
+					},
+				},
+			}
+		default:
+			response = AuthResponse{
+				AccessToken: "test-token",
+				TokenType:   "Bearer",
+				Username:    "test-user",
+				Roles:       []string{"role1", "role2"},
+			}
+		}
+
+		json.NewEncoder(w).Encode(response)
+	}))
+	defer ts.Close()
+
+	provider := CatalogConfigProvider{}
+	err := provider.Init(CatalogConfigProviderConfig{BaseUrl: ts.URL + "/", User: "admin", Password: ""})
+	provider.Context = &contexts.ManagerContext{
+		VencorContext: &contexts.VendorContext{
+			EvaluationContext: &utils.EvaluationContext{},
+		},
+	}
+	assert.Nil(t, err)
+
+	reads := &utils.ConfigReads{}
+	ctx := utils.EvaluationContext{ConfigReads: reads}
+	_, err = provider.Read("catalog1", "image", ctx)
+	assert.Nil(t, err)
+	_, err = provider.Read("catalog1", "port", ctx)
+	assert.Nil(t, err)
+	_, err = provider.ReadObject("<parent>", ctx)
+	assert.Nil(t, err)
+	_, err = provider.Read("catalog2@1", "image", ctx)
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string][]string{
+		"catalog1": {"image", "port"},
+		"parent":   {"", "port"},
+	}, reads.Objects())
+}
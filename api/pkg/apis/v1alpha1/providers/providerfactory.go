@@ -39,6 +39,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/proxy"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/script"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/staging"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/wasm"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/win10/sideload"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
@@ -47,13 +48,16 @@ import (
 	mockledger "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/ledger/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/probe/rtsp"
 	mempubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	mqttpubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/mqtt"
 	reidspubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/redis"
+	diskqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/disk"
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
 	cvref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/customvision"
 	httpref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/http"
 	k8sref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/k8s"
 	httpreporter "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reporter/http"
 	k8sreporter "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reporter/k8s"
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
 	mocksecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/httpstate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
@@ -253,6 +257,12 @@ func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.I
 		if err == nil {
 			return mProvider, nil
 		}
+	case "providers.target.wasm":
+		mProvider := &wasm.WasmTargetProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
 	case "providers.config.mock":
 		mProvider := &mockconfig.MockConfigProvider{}
 		err = mProvider.Init(config)
@@ -271,6 +281,12 @@ func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.I
 		if err == nil {
 			return mProvider, nil
 		}
+	case "providers.secret.file":
+		mProvider := &filesecret.FileSecretProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
 	case "providers.pubsub.memory":
 		mProvider := &mempubsub.InMemoryPubSubProvider{}
 		err = mProvider.Init(config)
@@ -283,6 +299,12 @@ func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.I
 		if err == nil {
 			return mProvider, nil
 		}
+	case "providers.pubsub.mqtt":
+		mProvider := &mqttpubsub.MQTTPubSubProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
 	case "providers.stage.mock":
 		mProvider := &mockstage.MockStageProvider{}
 		err = mProvider.Init(config)
@@ -349,6 +371,12 @@ func (s SymphonyProviderFactory) CreateProvider(providerType string, config cp.I
 		if err == nil {
 			return mProvider, nil
 		}
+	case "providers.queue.disk":
+		mProvider := &diskqueue.DiskQueueProvider{}
+		err = mProvider.Init(config)
+		if err == nil {
+			return mProvider, nil
+		}
 	case "providers.graph.memory":
 		mProvider := &memorygraph.MemoryGraphProvider{}
 		err = mProvider.Init(config)
@@ -488,6 +516,14 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 					}
 					provider.Context = context
 					return provider, nil
+				case "providers.target.wasm":
+					provider := &wasm.WasmTargetProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
 				case "providers.state.memory":
 					provider := &memorystate.MemoryStateProvider{}
 					err := provider.InitWithMap(binding.Config)
@@ -615,6 +651,14 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 					}
 					provider.Context = context
 					return provider, nil
+				case "providers.secret.file":
+					provider := &filesecret.FileSecretProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
 				case "providers.stage.mock":
 					provider := &mockstage.MockStageProvider{}
 					err := provider.InitWithMap(binding.Config)
@@ -703,6 +747,14 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 					}
 					provider.Context = context
 					return provider, nil
+				case "providers.queue.disk":
+					provider := &diskqueue.DiskQueueProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
 				case "providers.graph.memory":
 					provider := &memorygraph.MemoryGraphProvider{}
 					err := provider.InitWithMap(binding.Config)
@@ -727,6 +779,14 @@ func CreateProviderForTargetRole(context *contexts.ManagerContext, role string,
 					}
 					provider.Context = context
 					return provider, nil
+				case "providers.pubsub.mqtt":
+					provider := &mqttpubsub.MQTTPubSubProvider{}
+					err := provider.InitWithMap(binding.Config)
+					if err != nil {
+						return nil, err
+					}
+					provider.Context = context
+					return provider, nil
 				}
 
 			}
@@ -8,6 +8,7 @@ package providers
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -38,21 +39,26 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/proxy"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/script"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/staging"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/wasm"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/win10/sideload"
 	mockconfig "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/config/mock"
 	mockledger "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/ledger/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/probe/rtsp"
 	mempubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
+	mqttpubsub "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/mqtt"
+	diskqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/disk"
 	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
 	cvref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/customvision"
 	httpref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/http"
 	k8sref "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reference/k8s"
 	httpreporter "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reporter/http"
 	k8sreporter "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/reporter/k8s"
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
 	mocksecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/mock"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/httpstate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/uploader/azure/blob"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt/mqtttest"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -192,6 +198,10 @@ func TestCreateProvider(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*tgtmock.MockTargetProvider))
 
+	provider, err = providerfactory.CreateProvider("providers.target.wasm", wasm.WasmTargetProviderConfig{})
+	assert.Nil(t, err)
+	assert.NotNil(t, *provider.(*wasm.WasmTargetProvider))
+
 	if getTestMiniKubeEnabled == "" {
 		t.Log("Skipping providers.target.configmap test as TEST_MINIKUBE_ENABLED is not set")
 	} else {
@@ -212,9 +222,19 @@ func TestCreateProvider(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*mocksecret.MockSecretProvider))
 
+	secretKey, err := filesecret.GenerateKey()
+	assert.Nil(t, err)
+	t.Setenv(filesecret.DefaultKeyEnv, secretKey)
+	provider, err = providerfactory.CreateProvider("providers.secret.file", filesecret.FileSecretProviderConfig{
+		FilePath:           filepath.Join(t.TempDir(), "secrets.json"),
+		ReloadIntervalInMs: -1,
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, provider.(*filesecret.FileSecretProvider))
+
 	provider, err = providerfactory.CreateProvider("providers.pubsub.memory", mempubsub.InMemoryPubSubConfig{})
 	assert.Nil(t, err)
-	assert.NotNil(t, *provider.(*mempubsub.InMemoryPubSubProvider))
+	assert.NotNil(t, provider.(*mempubsub.InMemoryPubSubProvider))
 
 	provider, err = providerfactory.CreateProvider("providers.stage.mock", mockstage.MockStageProviderConfig{})
 	assert.Nil(t, err)
@@ -258,11 +278,27 @@ func TestCreateProvider(t *testing.T) {
 
 	provider, err = providerfactory.CreateProvider("providers.queue.memory", memoryqueue.MemoryQueueProviderConfig{})
 	assert.Nil(t, err)
-	assert.NotNil(t, *provider.(*memoryqueue.MemoryQueueProvider))
+	assert.NotNil(t, provider.(*memoryqueue.MemoryQueueProvider))
+
+	provider, err = providerfactory.CreateProvider("providers.queue.disk", diskqueue.DiskQueueProviderConfig{Dir: t.TempDir()})
+	assert.Nil(t, err)
+	assert.NotNil(t, provider.(*diskqueue.DiskQueueProvider))
+	provider.(*diskqueue.DiskQueueProvider).Close()
 
 	provider, err = providerfactory.CreateProvider("providers.graph.memory", memorygraph.MemoryGraphProviderConfig{})
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*memorygraph.MemoryGraphProvider))
+
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{})
+	assert.Nil(t, err)
+	defer broker.Close()
+	provider, err = providerfactory.CreateProvider("providers.pubsub.mqtt", mqttpubsub.MQTTPubSubProviderConfig{
+		BrokerAddress: broker.Address,
+		ClientID:      "symphony-api",
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, provider.(*mqttpubsub.MQTTPubSubProvider))
+	provider.(*mqttpubsub.MQTTPubSubProvider).Close()
 }
 
 func TestCreateProviderForTargetRole(t *testing.T) {
@@ -399,6 +435,13 @@ func TestCreateProviderForTargetRole(t *testing.T) {
 							Provider: "providers.target.mock",
 							Config:   map[string]string{},
 						},
+						{
+							Role:     "wasm",
+							Provider: "providers.target.wasm",
+							Config: map[string]string{
+								"name": "wasm",
+							},
+						},
 						{
 							Role:     "azureiotedge",
 							Provider: "providers.target.azure.iotedge",
@@ -671,6 +714,10 @@ func TestCreateProviderForTargetRole(t *testing.T) {
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*tgtmock.MockTargetProvider))
 
+	provider, err = CreateProviderForTargetRole(nil, "wasm", targetState, nil)
+	assert.Nil(t, err)
+	assert.NotNil(t, *provider.(*wasm.WasmTargetProvider))
+
 	provider, err = CreateProviderForTargetRole(nil, "azureiotedge", targetState, nil)
 	assert.Nil(t, err)
 	assert.NotNil(t, *provider.(*iotedge.IoTEdgeTargetProvider))
@@ -693,7 +740,7 @@ func TestCreateProviderForTargetRole(t *testing.T) {
 
 	provider, err = CreateProviderForTargetRole(nil, "memoryqueue", targetState, nil)
 	assert.Nil(t, err)
-	assert.NotNil(t, *provider.(*memoryqueue.MemoryQueueProvider))
+	assert.NotNil(t, provider.(*memoryqueue.MemoryQueueProvider))
 
 	provider, err = CreateProviderForTargetRole(nil, "memorygraph", targetState, nil)
 	assert.Nil(t, err)
@@ -741,7 +788,7 @@ func TestCreateProviderForTargetRole(t *testing.T) {
 
 	provider, err = CreateProviderForTargetRole(nil, "mempubsub", targetState, nil)
 	assert.Nil(t, err)
-	assert.NotNil(t, *provider.(*mempubsub.InMemoryPubSubProvider))
+	assert.NotNil(t, provider.(*mempubsub.InMemoryPubSubProvider))
 
 	provider, err = CreateProviderForTargetRole(nil, "httpreporter", targetState, nil)
 	assert.Nil(t, err)
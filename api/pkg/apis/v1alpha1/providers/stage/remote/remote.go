@@ -9,8 +9,13 @@ package remote
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -22,7 +27,27 @@ import (
 var rmtLock sync.Mutex
 var log = logger.NewLogger("coa.runtime")
 
+const (
+	// OfflinePolicyWait waits until an offline site comes back online, or fails the stage once the offline wait expires
+	OfflinePolicyWait = "wait"
+	// OfflinePolicyFail fails the stage right away if the site is offline
+	OfflinePolicyFail = "fail"
+	// OfflinePolicyIgnore sends the stage regardless of the liveness of the site
+	OfflinePolicyIgnore = "ignore"
+
+	defaultOfflineWait = 60 * time.Second
+)
+
+// sitePollInterval is how often the liveness of an offline site is checked while waiting for it
+var sitePollInterval = 5 * time.Second
+
 type RemoteStageProviderConfig struct {
+	// BaseUrl, User and Password are used to look up the liveness of sites. They default to the current site.
+	BaseUrl          string `json:"baseUrl,omitempty"`
+	User             string `json:"user,omitempty"`
+	Password         string `json:"password,omitempty"`
+	OfflinePolicy    string `json:"offlinePolicy,omitempty"`
+	OfflineWaitInSec int    `json:"offlineWaitInSec,omitempty"`
 }
 type RemoteStageProvider struct {
 	Config        RemoteStageProviderConfig
@@ -38,6 +63,14 @@ func (m *RemoteStageProvider) Init(config providers.IProviderConfig) error {
 	if err != nil {
 		return err
 	}
+	switch mockConfig.OfflinePolicy {
+	case "", OfflinePolicyWait, OfflinePolicyFail, OfflinePolicyIgnore:
+	default:
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("offlinePolicy '%s' is not supported", mockConfig.OfflinePolicy), v1alpha2.BadConfig)
+	}
+	if mockConfig.OfflineWaitInSec < 0 {
+		return v1alpha2.NewCOAError(nil, "offlineWaitInSec can't be negative", v1alpha2.BadConfig)
+	}
 	m.Config = mockConfig
 	return nil
 }
@@ -61,7 +94,19 @@ func (i *RemoteStageProvider) InitWithMap(properties map[string]string) error {
 	return i.Init(config)
 }
 func MockStageProviderConfigFromMap(properties map[string]string) (RemoteStageProviderConfig, error) {
-	ret := RemoteStageProviderConfig{}
+	ret := RemoteStageProviderConfig{
+		BaseUrl:       properties["baseUrl"],
+		User:          properties["user"],
+		Password:      properties["password"],
+		OfflinePolicy: properties["offlinePolicy"],
+	}
+	if v, ok := properties["offlineWaitInSec"]; ok {
+		wait, err := strconv.Atoi(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "offlineWaitInSec must be an integer", v1alpha2.BadConfig)
+		}
+		ret.OfflineWaitInSec = wait
+	}
 	return ret, nil
 }
 func (i *RemoteStageProvider) SetOutputsContext(outputs map[string]map[string]interface{}) {
@@ -86,6 +131,12 @@ func (i *RemoteStageProvider) Process(ctx context.Context, mgrContext contexts.M
 		return nil, false, err
 	}
 
+	err = i.waitForSite(ctx, mgrContext, v.(string))
+	if err != nil {
+		log.Errorf("  P (Remote Processor): %v", err)
+		return nil, false, err
+	}
+
 	err = mgrContext.Publish("remote", v1alpha2.Event{
 		Metadata: map[string]string{
 			"site":       v.(string),
@@ -108,3 +159,56 @@ func (i *RemoteStageProvider) Process(ctx context.Context, mgrContext contexts.M
 
 	return outputs, true, nil
 }
+
+// waitForSite checks that a site is online before a stage is sent to it. Depending on the offline policy, an
+// offline site fails the stage right away or is waited for until the offline wait expires. A stage for a site that
+// isn't registered is sent right away, like before liveness was tracked.
+func (i *RemoteStageProvider) waitForSite(ctx context.Context, mgrContext contexts.ManagerContext, site string) error {
+	if i.Config.OfflinePolicy == OfflinePolicyIgnore {
+		return nil
+	}
+	baseUrl, user, password := i.Config.BaseUrl, i.Config.User, i.Config.Password
+	if baseUrl == "" {
+		baseUrl = mgrContext.SiteInfo.CurrentSite.BaseUrl
+		user = mgrContext.SiteInfo.CurrentSite.Username
+		password = mgrContext.SiteInfo.CurrentSite.Password
+	}
+	if baseUrl == "" {
+		// the liveness of sites can't be looked up
+		return nil
+	}
+	wait := defaultOfflineWait
+	if i.Config.OfflineWaitInSec > 0 {
+		wait = time.Duration(i.Config.OfflineWaitInSec) * time.Second
+	}
+	deadline := time.Now().Add(wait)
+	for {
+		state, err := utils.GetSite(ctx, baseUrl, site, user, password)
+		if err != nil {
+			if v1alpha2.IsNotFound(err) {
+				// the liveness of a site that isn't registered isn't tracked, the stage is queued for it
+				return nil
+			}
+			return err
+		}
+		if isOnline(state) {
+			return nil
+		}
+		if i.Config.OfflinePolicy == OfflinePolicyFail {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("site %s is offline", site), v1alpha2.InternalError)
+		}
+		if time.Now().After(deadline) {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("site %s is still offline after %v", site, wait), v1alpha2.TimedOut)
+		}
+		log.Infof("  P (Remote Processor): site %s is offline, waiting for it to come back online", site)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sitePollInterval):
+		}
+	}
+}
+
+func isOnline(site model.SiteState) bool {
+	return site.Status != nil && site.Status.IsOnline
+}
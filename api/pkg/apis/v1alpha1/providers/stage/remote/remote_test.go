@@ -9,8 +9,13 @@ package remote
 import (
 	"context"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/memory"
@@ -61,3 +66,190 @@ func TestRemoteProcess(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Equal(t, "Bad Request: no site found in inputs", err.Error())
 }
+
+func TestRemoteInitFromMapBadOfflinePolicy(t *testing.T) {
+	provider := RemoteStageProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"offlinePolicy": "retry",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+
+	err = provider.InitWithMap(map[string]string{
+		"offlineWaitInSec": "soon",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+// initializeMockSiteRegistry serves a child site that comes online after it has been looked up onlineAfter times
+func initializeMockSiteRegistry(onlineAfter int32) (*httptest.Server, *atomic.Int32) {
+	var lookups atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		switch r.URL.Path {
+		case "/users/auth":
+			response = map[string]interface{}{
+				"accessToken": "test-token",
+				"tokenType":   "Bearer",
+			}
+		case "/federation/registry/child":
+			count := lookups.Add(1)
+			response = model.SiteState{
+				Id: "child",
+				Status: &model.SiteStatus{
+					IsOnline: count > onlineAfter,
+				},
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(response)
+	}))
+	return ts, &lookups
+}
+
+func newRemoteTestContext(baseUrl string) (contexts.ManagerContext, chan v1alpha2.Event) {
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	ctx := contexts.ManagerContext{}
+	ctx.Init(nil, &pubSubProvider)
+	ctx.SiteInfo = v1alpha2.SiteInfo{
+		SiteId: "hq",
+		CurrentSite: v1alpha2.SiteConnection{
+			BaseUrl:  baseUrl,
+			Username: "admin",
+		},
+	}
+	sent := make(chan v1alpha2.Event, 1)
+	ctx.Subscribe("remote", func(topic string, event v1alpha2.Event) error {
+		sent <- event
+		return nil
+	})
+	return ctx, sent
+}
+
+func TestRemoteProcessOfflineSiteFails(t *testing.T) {
+	ts, _ := initializeMockSiteRegistry(10)
+	defer ts.Close()
+	ctx, sent := newRemoteTestContext(ts.URL + "/")
+	provider := RemoteStageProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"offlinePolicy": OfflinePolicyFail,
+	})
+	assert.Nil(t, err)
+
+	_, _, err = provider.Process(context.Background(), ctx, map[string]interface{}{
+		"__site": "child",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, "Internal Error: site child is offline", err.Error())
+	select {
+	case <-sent:
+		assert.Fail(t, "stage was sent to an offline site")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+}
+
+func TestRemoteProcessUnregisteredSite(t *testing.T) {
+	testCases := []string{OfflinePolicyWait, OfflinePolicyFail}
+	for _, policy := range testCases {
+		t.Run(policy, func(t *testing.T) {
+			ts, lookups := initializeMockSiteRegistry(100)
+			defer ts.Close()
+			ctx, sent := newRemoteTestContext(ts.URL + "/")
+			provider := RemoteStageProvider{}
+			err := provider.InitWithMap(map[string]string{
+				"offlinePolicy":    policy,
+				"offlineWaitInSec": "1",
+			})
+			assert.Nil(t, err)
+
+			// the stage is queued for a site that isn't registered, without waiting for it
+			_, _, err = provider.Process(context.Background(), ctx, map[string]interface{}{
+				"__site": "unknown",
+			})
+			assert.Nil(t, err)
+			assert.Equal(t, int32(0), lookups.Load())
+			select {
+			case event := <-sent:
+				assert.Equal(t, "unknown", event.Metadata["site"])
+			case <-time.After(5 * time.Second):
+				assert.Fail(t, "stage wasn't sent")
+			}
+		})
+	}
+}
+
+func TestRemoteProcessWaitsForOfflineSite(t *testing.T) {
+	interval := sitePollInterval
+	sitePollInterval = 10 * time.Millisecond
+	defer func() { sitePollInterval = interval }()
+
+	ts, lookups := initializeMockSiteRegistry(2)
+	defer ts.Close()
+	ctx, sent := newRemoteTestContext(ts.URL + "/")
+	provider := RemoteStageProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"offlinePolicy":    OfflinePolicyWait,
+		"offlineWaitInSec": "5",
+	})
+	assert.Nil(t, err)
+
+	_, _, err = provider.Process(context.Background(), ctx, map[string]interface{}{
+		"__site": "child",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(3), lookups.Load())
+	select {
+	case event := <-sent:
+		assert.Equal(t, "child", event.Metadata["site"])
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "stage wasn't sent")
+	}
+}
+
+func TestRemoteProcessWaitExpires(t *testing.T) {
+	interval := sitePollInterval
+	sitePollInterval = 100 * time.Millisecond
+	defer func() { sitePollInterval = interval }()
+
+	ts, _ := initializeMockSiteRegistry(100)
+	defer ts.Close()
+	ctx, _ := newRemoteTestContext(ts.URL + "/")
+	provider := RemoteStageProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"offlineWaitInSec": "1",
+	})
+	assert.Nil(t, err)
+
+	_, _, err = provider.Process(context.Background(), ctx, map[string]interface{}{
+		"__site": "child",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.TimedOut, err.(v1alpha2.COAError).State)
+}
+
+func TestRemoteProcessIgnoresLiveness(t *testing.T) {
+	ts, lookups := initializeMockSiteRegistry(100)
+	defer ts.Close()
+	ctx, sent := newRemoteTestContext(ts.URL + "/")
+	provider := RemoteStageProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"offlinePolicy": OfflinePolicyIgnore,
+	})
+	assert.Nil(t, err)
+
+	_, _, err = provider.Process(context.Background(), ctx, map[string]interface{}{
+		"__site": "child",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), lookups.Load())
+	select {
+	case <-sent:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "stage wasn't sent")
+	}
+}
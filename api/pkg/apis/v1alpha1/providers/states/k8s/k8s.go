@@ -198,6 +198,19 @@ func (s *K8sStateProvider) Upsert(ctx context.Context, entry states.UpsertReques
 
 	j, _ := json.Marshal(entry.Value.Body)
 	item, err := s.DynamicClient.Resource(resourceId).Namespace(namespace).Get(ctx, entry.Value.ID, metav1.GetOptions{})
+	if entry.ETag != nil {
+		// the object is only written if its generation is still the given ETag, an empty ETag means it didn't
+		// exist. The resource version read here makes the API server reject the update if it changed since.
+		current := ""
+		if err == nil {
+			current = strconv.FormatInt(item.GetGeneration(), 10)
+		}
+		if current != *entry.ETag {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("object '%s' has changed, its ETag is '%s' instead of '%s'", entry.Value.ID, current, *entry.ETag), v1alpha2.Conflict)
+			sLog.Errorf("  P (K8s State): failed to upsert object: %v", err)
+			return "", err
+		}
+	}
 	if err != nil {
 		template := fmt.Sprintf(`{"apiVersion":"%s/v1", "kind": "%s", "metadata": {}}`, group, kind)
 		var unc *unstructured.Unstructured
@@ -228,6 +241,9 @@ func (s *K8sStateProvider) Upsert(ctx context.Context, entry states.UpsertReques
 		_, err = s.DynamicClient.Resource(resourceId).Namespace(namespace).Create(ctx, unc, metav1.CreateOptions{})
 		if err != nil {
 			sLog.Errorf("  P (K8s State): failed to create object: %v", err)
+			if entry.ETag != nil && k8s_errors.IsAlreadyExists(err) {
+				err = v1alpha2.NewCOAError(err, fmt.Sprintf("object '%s' has been created meanwhile", entry.Value.ID), v1alpha2.Conflict)
+			}
 			return "", err
 		}
 		//Note: state is ignored for new object
@@ -258,6 +274,9 @@ func (s *K8sStateProvider) Upsert(ctx context.Context, entry states.UpsertReques
 			_, err = s.DynamicClient.Resource(resourceId).Namespace(namespace).Update(ctx, item, metav1.UpdateOptions{})
 			if err != nil {
 				sLog.Errorf("  P (K8s State): failed to update object: %v", err)
+				if entry.ETag != nil && k8s_errors.IsConflict(err) {
+					err = v1alpha2.NewCOAError(err, fmt.Sprintf("object '%s' has changed", entry.Value.ID), v1alpha2.Conflict)
+				}
 				return "", err
 			}
 		}
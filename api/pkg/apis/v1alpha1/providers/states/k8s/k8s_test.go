@@ -16,8 +16,13 @@ import (
 	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dfake "k8s.io/client-go/dynamic/fake"
 )
 
 func TestK8sStateProviderConfigFromMapNil(t *testing.T) {
@@ -1040,3 +1045,55 @@ func TestTargetUpSertWithStateOnly(t *testing.T) {
 	})
 	assert.Nil(t, err)
 }
+
+func TestUpsertWithETag(t *testing.T) {
+	resource := schema.GroupVersionResource{Group: model.FederationGroup, Version: "v1", Resource: "catalogrevisions"}
+	provider := K8sStateProvider{
+		DynamicClient: dfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+			resource: "CatalogRevisionList",
+		}),
+	}
+	metadata := map[string]interface{}{
+		"namespace": "default",
+		"group":     model.FederationGroup,
+		"version":   "v1",
+		"resource":  "catalogrevisions",
+		"kind":      "CatalogRevision",
+	}
+	upsert := func(etag string, value string) error {
+		_, err := provider.Upsert(context.Background(), states.UpsertRequest{
+			Value: states.StateEntry{
+				ID: "c1",
+				Body: map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "c1", "namespace": "default"},
+					"spec":     map[string]interface{}{"value": value},
+				},
+			},
+			ETag:     &etag,
+			Metadata: metadata,
+		})
+		return err
+	}
+
+	// an empty ETag only creates the object
+	assert.Nil(t, upsert("", "a"))
+	err := upsert("", "b")
+	assert.NotNil(t, err)
+	assert.True(t, v1alpha2.IsConflict(err))
+
+	// the fake client doesn't bump generations, the API server does on spec changes
+	item, err := provider.DynamicClient.Resource(resource).Namespace("default").Get(context.Background(), "c1", metav1.GetOptions{})
+	assert.Nil(t, err)
+	item.SetGeneration(2)
+	_, err = provider.DynamicClient.Resource(resource).Namespace("default").Update(context.Background(), item, metav1.UpdateOptions{})
+	assert.Nil(t, err)
+
+	err = upsert("1", "b")
+	assert.NotNil(t, err)
+	assert.True(t, v1alpha2.IsConflict(err))
+	assert.Nil(t, upsert("2", "b"))
+
+	entry, err := provider.Get(context.Background(), states.GetRequest{ID: "c1", Metadata: metadata})
+	assert.Nil(t, err)
+	assert.Equal(t, "b", entry.Body.(map[string]interface{})["spec"].(map[string]interface{})["value"])
+}
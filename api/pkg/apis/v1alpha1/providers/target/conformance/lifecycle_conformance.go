@@ -0,0 +1,381 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ResultPassed  = "passed"
+	ResultFailed  = "failed"
+	ResultSkipped = "skipped"
+)
+
+// Capabilities describe what a provider claims to support. Checks that depend on a capability
+// the provider doesn't declare are skipped.
+type Capabilities struct {
+	// Stateful providers report deployed components back through Get.
+	Stateful bool `json:"stateful,omitempty"`
+}
+
+// Options configure the lifecycle checks for a specific provider.
+type Options struct {
+	Capabilities Capabilities `json:"capabilities,omitempty"`
+	// Component is deployed by the lifecycle checks. If it's not set, a component is generated
+	// from the required properties and metadata of the provider's validation rule.
+	Component *model.ComponentSpec `json:"component,omitempty"`
+	// FailingComponent passes validation but is expected to fail when it's applied. Partial
+	// failure reporting is skipped if it's not set.
+	FailingComponent *model.ComponentSpec `json:"failingComponent,omitempty"`
+	// Scope is used as the instance scope of the test deployment, default is "default".
+	Scope string `json:"scope,omitempty"`
+	// Observe returns a snapshot of the system the provider manages. Dry runs must not change it.
+	Observe func() interface{} `json:"-"`
+}
+
+type CheckResult struct {
+	Name   string `json:"name"`
+	Level  string `json:"level"`
+	Result string `json:"result"`
+}
+
+// Report is the conformance result of a provider, as published by the conformance runner.
+type Report struct {
+	Provider string        `json:"provider"`
+	Checks   []CheckResult `json:"checks"`
+}
+
+func (r Report) Passed() bool {
+	for _, c := range r.Checks {
+		if c.Result == ResultFailed {
+			return false
+		}
+	}
+	return true
+}
+
+func testComponent[P target.ITargetProvider](p P, options Options) model.ComponentSpec {
+	if options.Component != nil {
+		return copyComponent(*options.Component)
+	}
+	ret := model.ComponentSpec{
+		Name:       "conformance-1",
+		Properties: map[string]interface{}{},
+		Metadata:   map[string]string{},
+	}
+	rule := p.GetValidationRule(context.Background())
+	ret.Type = rule.RequiredComponentType
+	for _, property := range rule.ComponentValidationRule.RequiredProperties {
+		ret.Properties[property] = "dummy property"
+	}
+	for _, metadata := range rule.ComponentValidationRule.RequiredMetadata {
+		ret.Metadata[metadata] = "dummy metadata"
+	}
+	return ret
+}
+
+func copyComponent(component model.ComponentSpec) model.ComponentSpec {
+	ret := component
+	ret.Properties = make(map[string]interface{}, len(component.Properties))
+	for k, v := range component.Properties {
+		ret.Properties[k] = v
+	}
+	ret.Metadata = make(map[string]string, len(component.Metadata))
+	for k, v := range component.Metadata {
+		ret.Metadata[k] = v
+	}
+	return ret
+}
+
+func testDeployment(options Options, components ...model.ComponentSpec) model.DeploymentSpec {
+	scope := options.Scope
+	if scope == "" {
+		scope = "default"
+	}
+	return model.DeploymentSpec{
+		Instance: model.InstanceState{
+			ObjectMeta: model.ObjectMeta{
+				Name: "conformance-instance",
+			},
+			Spec: &model.InstanceSpec{
+				Scope:    scope,
+				Solution: "conformance-solution",
+			},
+		},
+		Solution: model.SolutionState{
+			ObjectMeta: model.ObjectMeta{
+				Name: "conformance-solution",
+			},
+			Spec: &model.SolutionSpec{
+				Components: components,
+			},
+		},
+		ComponentStartIndex: 0,
+		ComponentEndIndex:   len(components),
+	}
+}
+
+func testStep(action model.ComponentAction, components ...model.ComponentSpec) model.DeploymentStep {
+	ret := model.DeploymentStep{
+		Components: make([]model.ComponentStep, 0, len(components)),
+	}
+	for _, c := range components {
+		ret.Components = append(ret.Components, model.ComponentStep{
+			Action:    action,
+			Component: c,
+		})
+	}
+	return ret
+}
+
+func isSuccessState(state v1alpha2.State) bool {
+	return state == v1alpha2.OK || state == v1alpha2.Updated || state == v1alpha2.Deleted || state == v1alpha2.Untouched
+}
+
+func findComponent(components []model.ComponentSpec, name string) (model.ComponentSpec, bool) {
+	for _, c := range components {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return model.ComponentSpec{}, false
+}
+
+func getComponent[P target.ITargetProvider](t *testing.T, p P, deployment model.DeploymentSpec, component model.ComponentSpec) (model.ComponentSpec, bool) {
+	current, err := p.Get(context.Background(), deployment, testStep(model.ComponentUpdate, component).Components)
+	assert.Nil(t, err)
+	return findComponent(current, component.Name)
+}
+
+func applyComponents[P target.ITargetProvider](t *testing.T, p P, deployment model.DeploymentSpec, action model.ComponentAction, components ...model.ComponentSpec) map[string]model.ComponentResultSpec {
+	ret, err := p.Apply(context.Background(), deployment, testStep(action, components...), false)
+	assert.Nil(t, err)
+	if action == model.ComponentUpdate {
+		for _, c := range components {
+			if result, ok := ret[c.Name]; ok {
+				assert.True(t, isSuccessState(result.Status), "expected component %s to succeed, but got %v: %s", c.Name, result.Status, result.Message)
+			}
+		}
+	}
+	return ret
+}
+
+// ApplyThenGet checks that an applied component is reported by Get without detected changes.
+func ApplyThenGet[P target.ITargetProvider](t *testing.T, p P, options Options) {
+	if !options.Capabilities.Stateful {
+		t.Skip("provider doesn't report deployed components")
+	}
+	component := testComponent(p, options)
+	deployment := testDeployment(options, component)
+	defer p.Apply(context.Background(), deployment, testStep(model.ComponentDelete, component), false)
+
+	applyComponents(t, p, deployment, model.ComponentUpdate, component)
+
+	current, found := getComponent(t, p, deployment, component)
+	assert.True(t, found, "expected Get to report component %s after Apply", component.Name)
+	if found {
+		rule := p.GetValidationRule(context.Background())
+		assert.False(t, rule.IsComponentChanged(current, component), "expected no changes to be detected between the applied and the reported component")
+	}
+}
+
+// IdempotentReApply checks that applying the same component twice succeeds and leaves a single copy.
+func IdempotentReApply[P target.ITargetProvider](t *testing.T, p P, options Options) {
+	component := testComponent(p, options)
+	deployment := testDeployment(options, component)
+	defer p.Apply(context.Background(), deployment, testStep(model.ComponentDelete, component), false)
+
+	applyComponents(t, p, deployment, model.ComponentUpdate, component)
+	applyComponents(t, p, deployment, model.ComponentUpdate, component)
+
+	if options.Capabilities.Stateful {
+		current, err := p.Get(context.Background(), deployment, testStep(model.ComponentUpdate, component).Components)
+		assert.Nil(t, err)
+		count := 0
+		for _, c := range current {
+			if c.Name == component.Name {
+				count++
+			}
+		}
+		assert.Equal(t, 1, count, "expected exactly one copy of component %s after re-apply", component.Name)
+	}
+}
+
+// DeleteComponent checks that ComponentDelete steps remove a component, and that deleting a
+// component that is already gone succeeds.
+func DeleteComponent[P target.ITargetProvider](t *testing.T, p P, options Options) {
+	component := testComponent(p, options)
+	deployment := testDeployment(options, component)
+
+	applyComponents(t, p, deployment, model.ComponentUpdate, component)
+	applyComponents(t, p, deployment, model.ComponentDelete, component)
+
+	if options.Capabilities.Stateful {
+		_, found := getComponent(t, p, deployment, component)
+		assert.False(t, found, "expected Get not to report component %s after delete", component.Name)
+	}
+
+	applyComponents(t, p, deployment, model.ComponentDelete, component)
+}
+
+// DryRunNoSideEffects checks that a dry run doesn't change the managed system.
+func DryRunNoSideEffects[P target.ITargetProvider](t *testing.T, p P, options Options) {
+	component := testComponent(p, options)
+	deployment := testDeployment(options, component)
+
+	var before interface{}
+	if options.Observe != nil {
+		before = options.Observe()
+	}
+
+	_, err := p.Apply(context.Background(), deployment, testStep(model.ComponentUpdate, component), true)
+	assert.Nil(t, err)
+
+	if options.Observe != nil {
+		assert.Equal(t, before, options.Observe(), "expected dry run not to change the managed system")
+	}
+	if options.Capabilities.Stateful {
+		_, found := getComponent(t, p, deployment, component)
+		assert.False(t, found, "expected Get not to report component %s after a dry run", component.Name)
+	}
+}
+
+// PartialFailureReporting checks that a failed component is reported with a failure state in
+// the component results, alongside the components that succeeded.
+func PartialFailureReporting[P target.ITargetProvider](t *testing.T, p P, options Options) {
+	if options.FailingComponent == nil {
+		t.Skip("no failing component is configured")
+	}
+	component := testComponent(p, options)
+	failing := copyComponent(*options.FailingComponent)
+	deployment := testDeployment(options, component, failing)
+	defer p.Apply(context.Background(), deployment, testStep(model.ComponentDelete, component, failing), false)
+
+	ret, _ := p.Apply(context.Background(), deployment, testStep(model.ComponentUpdate, component, failing), false)
+	result, ok := ret[failing.Name]
+	assert.True(t, ok, "expected a result for failing component %s", failing.Name)
+	if ok {
+		assert.False(t, isSuccessState(result.Status), "expected component %s to fail, but got %v", failing.Name, result.Status)
+		assert.NotEmpty(t, result.Message, "expected a message for failing component %s", failing.Name)
+	}
+	if result, ok := ret[component.Name]; ok {
+		assert.True(t, isSuccessState(result.Status), "expected component %s to succeed, but got %v: %s", component.Name, result.Status, result.Message)
+	}
+}
+
+// ChangeDetection checks that the provider's validation rule finds no changes between identical
+// components, and detects a change to each of its change detection properties and metadata.
+// Wildcards that don't match a key of the test component and missing values that are skipped
+// when missing aren't checked.
+func ChangeDetection[P target.ITargetProvider](t *testing.T, p P, options Options) {
+	rule := p.GetValidationRule(context.Background())
+	component := testComponent(p, options)
+
+	assert.False(t, rule.IsComponentChanged(component, copyComponent(component)), "expected no changes between identical components")
+
+	for _, desc := range rule.ComponentValidationRule.ChangeDetectionProperties {
+		changed := copyComponent(component)
+		if !changeValue(desc, &changed, changed.Properties) {
+			continue
+		}
+		assert.True(t, rule.IsComponentChanged(component, changed), "expected a change to property '%s' to be detected", desc.Name)
+	}
+	for _, desc := range rule.ComponentValidationRule.ChangeDetectionMetadata {
+		changed := copyComponent(component)
+		metadata := make(map[string]interface{}, len(changed.Metadata))
+		for k, v := range changed.Metadata {
+			metadata[k] = v
+		}
+		if !changeValue(desc, &changed, metadata) {
+			continue
+		}
+		for k, v := range metadata {
+			changed.Metadata[k] = fmt.Sprintf("%v", v)
+		}
+		assert.True(t, rule.IsComponentChanged(component, changed), "expected a change to metadata '%s' to be detected", desc.Name)
+	}
+}
+
+// changeValue changes the value described by desc. It returns false if there's nothing to change,
+// for example when a wildcard doesn't match any existing key.
+func changeValue(desc model.PropertyDesc, component *model.ComponentSpec, values map[string]interface{}) bool {
+	if desc.IsComponentName {
+		component.Name = component.Name + "-changed"
+		return true
+	}
+	key := desc.Name
+	if strings.Contains(desc.Name, "*") {
+		pattern := regexp.MustCompile("^" + strings.ReplaceAll(regexp.QuoteMeta(desc.Name), `\*`, ".*") + "$")
+		key = ""
+		for k := range values {
+			if pattern.MatchString(k) {
+				key = k
+				break
+			}
+		}
+		if key == "" {
+			return false
+		}
+	}
+	if v, ok := values[key]; ok {
+		data, _ := json.Marshal(v)
+		values[key] = string(data) + "-changed"
+	} else {
+		if desc.SkipIfMissing {
+			return false
+		}
+		values[key] = "changed"
+	}
+	return true
+}
+
+// RunSuite runs the basic and lifecycle conformance checks and returns the results as a report.
+func RunSuite[P target.ITargetProvider](t *testing.T, name string, p P, options Options) Report {
+	report := Report{
+		Provider: name,
+		Checks:   make([]CheckResult, 0),
+	}
+	run := func(level string, check string, f func(t *testing.T)) {
+		result := ResultPassed
+		passed := t.Run(fmt.Sprintf("Level=%s/%s", level, check), func(t *testing.T) {
+			defer func() {
+				if t.Skipped() {
+					result = ResultSkipped
+				}
+			}()
+			f(t)
+		})
+		if !passed {
+			result = ResultFailed
+		}
+		report.Checks = append(report.Checks, CheckResult{
+			Name:   check,
+			Level:  level,
+			Result: result,
+		})
+	}
+	run("Basic", "RequiredPropertiesAndMetadata", func(t *testing.T) { RequiredPropertiesAndMetadata(t, p) })
+	run("Basic", "AnyRequiredPropertiesMissing", func(t *testing.T) { AnyRequiredPropertiesMissing(t, p) })
+	run("Lifecycle", "ApplyThenGet", func(t *testing.T) { ApplyThenGet(t, p, options) })
+	run("Lifecycle", "IdempotentReApply", func(t *testing.T) { IdempotentReApply(t, p, options) })
+	run("Lifecycle", "DeleteComponent", func(t *testing.T) { DeleteComponent(t, p, options) })
+	run("Lifecycle", "DryRunNoSideEffects", func(t *testing.T) { DryRunNoSideEffects(t, p, options) })
+	run("Lifecycle", "PartialFailureReporting", func(t *testing.T) { PartialFailureReporting(t, p, options) })
+	run("Lifecycle", "ChangeDetection", func(t *testing.T) { ChangeDetection(t, p, options) })
+	return report
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package helm
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/kube"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// CheckHealth reports the updated components as healthy when their releases are deployed and all the
+// resources of the releases are ready, like `helm install --wait` would
+func (i *HelmTargetProvider) CheckHealth(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep) (map[string]model.ComponentResultSpec, error) {
+	ctx, span := observability.StartSpan(
+		"Helm Target Provider",
+		ctx,
+		&map[string]string{
+			"method": "CheckHealth",
+		},
+	)
+	var err error
+	defer utils.CloseSpanWithError(span, &err)
+	sLog.Debugf("  P (Helm Target): checking health: %s - %s, traceId: %s", deployment.Instance.Spec.Scope, deployment.Instance.ObjectMeta.Name, span.SpanContext().TraceID().String())
+
+	ret := make(map[string]model.ComponentResultSpec)
+	updated := step.GetUpdatedComponents()
+	if len(updated) == 0 {
+		return ret, nil
+	}
+
+	var actionConfig *action.Configuration
+	actionConfig, err = i.createActionConfig(ctx, deployment.Instance.Spec.Scope)
+	if err != nil {
+		sLog.Errorf("  P (Helm Target): failed to create action config: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	checker, err := newReadyChecker(actionConfig)
+	if err != nil {
+		sLog.Errorf("  P (Helm Target): failed to create Kubernetes client: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	for _, component := range updated {
+		ret[component.Name], err = releaseHealth(ctx, actionConfig, checker, component.Name)
+		if err != nil {
+			sLog.Errorf("  P (Helm Target): failed to check health of release %s: %+v, traceId: %s", component.Name, err, span.SpanContext().TraceID().String())
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+func newReadyChecker(actionConfig *action.Configuration) (*kube.ReadyChecker, error) {
+	clientSet, err := actionConfig.KubernetesClientSet()
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to create Kubernetes client", providerName), v1alpha2.HelmActionFailed)
+	}
+	checker := kube.NewReadyChecker(clientSet, sLog.Debugf, kube.PausedAsReady(true), kube.CheckJobs(true))
+	return &checker, nil
+}
+
+// releaseHealth checks the last revision of a release. Releases are named after their components.
+func releaseHealth(ctx context.Context, actionConfig *action.Configuration, checker *kube.ReadyChecker, name string) (model.ComponentResultSpec, error) {
+	rel, err := action.NewGet(actionConfig).Run(name)
+	if err != nil {
+		if errors.Is(err, driver.ErrReleaseNotFound) {
+			return model.ComponentResultSpec{
+				Status:  v1alpha2.HealthCheckFailed,
+				Message: fmt.Sprintf("release %s not found", name),
+			}, nil
+		}
+		return model.ComponentResultSpec{}, v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to get release %s", providerName, name), v1alpha2.HelmActionFailed)
+	}
+	if rel.Info == nil || rel.Info.Status != release.StatusDeployed {
+		status := release.StatusUnknown
+		if rel.Info != nil {
+			status = rel.Info.Status
+		}
+		return model.ComponentResultSpec{
+			Status:  v1alpha2.HealthCheckFailed,
+			Message: fmt.Sprintf("release %s is %s", name, status),
+		}, nil
+	}
+	resources, err := actionConfig.KubeClient.Build(bytes.NewBufferString(rel.Manifest), false)
+	if err != nil {
+		return model.ComponentResultSpec{}, v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to read the resources of release %s", providerName, name), v1alpha2.HelmActionFailed)
+	}
+	for _, resource := range resources {
+		ready, err := checker.IsReady(ctx, resource)
+		if err != nil {
+			return model.ComponentResultSpec{}, v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to check resource %s of release %s", providerName, resource.Name, name), v1alpha2.HelmActionFailed)
+		}
+		if !ready {
+			return model.ComponentResultSpec{
+				Status:  v1alpha2.HealthCheckFailed,
+				Message: fmt.Sprintf("%s %s of release %s is not ready", resource.Mapping.GroupVersionKind.Kind, resource.Name, name),
+			}, nil
+		}
+	}
+	return model.ComponentResultSpec{
+		Status: v1alpha2.OK,
+	}, nil
+}
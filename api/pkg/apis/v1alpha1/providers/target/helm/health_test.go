@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package helm
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/kube"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReleaseHealth(t *testing.T) {
+	actionConfig := &action.Configuration{
+		Releases:   storage.Init(driver.NewMemory()),
+		KubeClient: &kubefake.PrintingKubeClient{Out: io.Discard},
+		Log:        sLog.Debugf,
+	}
+	for name, status := range map[string]release.Status{
+		"deployed": release.StatusDeployed,
+		"failed":   release.StatusFailed,
+		"pending":  release.StatusPendingUpgrade,
+	} {
+		err := actionConfig.Releases.Create(&release.Release{
+			Name:    name,
+			Version: 1,
+			Info:    &release.Info{Status: status},
+		})
+		assert.Nil(t, err)
+	}
+	checker := kube.NewReadyChecker(fake.NewSimpleClientset(), sLog.Debugf)
+
+	testCases := []struct {
+		release string
+		state   v1alpha2.State
+		message string
+	}{
+		{release: "deployed", state: v1alpha2.OK},
+		{release: "failed", state: v1alpha2.HealthCheckFailed, message: "release failed is failed"},
+		{release: "pending", state: v1alpha2.HealthCheckFailed, message: "release pending is pending-upgrade"},
+		{release: "missing", state: v1alpha2.HealthCheckFailed, message: "release missing not found"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.release, func(t *testing.T) {
+			result, err := releaseHealth(context.Background(), actionConfig, &checker, tc.release)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.state, result.Status)
+			assert.Equal(t, tc.message, result.Message)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/k8s/projectors"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CheckHealth reports the updated components as healthy when the deployment that runs them is rolled out
+// and all its replicas are available
+func (i *K8sTargetProvider) CheckHealth(ctx context.Context, dep model.DeploymentSpec, step model.DeploymentStep) (map[string]model.ComponentResultSpec, error) {
+	ctx, span := observability.StartSpan("K8s Target Provider", ctx, &map[string]string{
+		"method": "CheckHealth",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	log.Debugf("  P (K8s Target Provider): checking health: %s - %s, traceId: %s", dep.Instance.Spec.Scope, dep.Instance.ObjectMeta.Name, span.SpanContext().TraceID().String())
+
+	ret := make(map[string]model.ComponentResultSpec)
+	updated := step.GetUpdatedComponents()
+	if len(updated) == 0 {
+		return ret, nil
+	}
+
+	namespace := dep.Instance.Spec.Scope
+	name := dep.Instance.ObjectMeta.Name
+	switch i.Config.DeploymentStrategy {
+	case "", SINGLE_POD, CANARY, BLUE_GREEN:
+		if i.Config.DeploymentStrategy == BLUE_GREEN {
+			var slot string
+			slot, err = i.activeSlot(ctx, namespace, name, dep.Instance.Spec.Metadata)
+			if err != nil {
+				return nil, err
+			}
+			if slot != "" {
+				name = projectors.SlotName(name, slot)
+			}
+		}
+		var result model.ComponentResultSpec
+		result, err = i.deploymentHealth(ctx, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		for _, component := range updated {
+			ret[component.Name] = result
+		}
+	case SERVICES, SERVICES_NS:
+		if i.Config.DeploymentStrategy == SERVICES_NS {
+			namespace = name
+		}
+		for _, component := range updated {
+			ret[component.Name], err = i.deploymentHealth(ctx, namespace, component.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ret, nil
+}
+
+func (i *K8sTargetProvider) deploymentHealth(ctx context.Context, namespace string, name string) (model.ComponentResultSpec, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	deployment, err := i.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return model.ComponentResultSpec{
+				Status:  v1alpha2.HealthCheckFailed,
+				Message: fmt.Sprintf("deployment %s not found", name),
+			}, nil
+		}
+		return model.ComponentResultSpec{}, err
+	}
+	message := rolloutMessage(deployment)
+	if message != "" {
+		return model.ComponentResultSpec{
+			Status:  v1alpha2.HealthCheckFailed,
+			Message: message,
+		}, nil
+	}
+	return model.ComponentResultSpec{
+		Status: v1alpha2.OK,
+	}, nil
+}
+
+// rolloutMessage tells why a deployment isn't rolled out yet, or returns an empty string when it is. Like
+// `kubectl rollout status`, the rollout of the latest spec must have been observed, and all replicas must run the
+// latest spec and be available, so that replicas of the previous spec don't count
+func rolloutMessage(deployment *appsv1.Deployment) string {
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	switch {
+	case deployment.Status.ObservedGeneration < deployment.Generation:
+		return fmt.Sprintf("deployment %s hasn't observed generation %d yet", deployment.Name, deployment.Generation)
+	case deployment.Status.UpdatedReplicas != replicas:
+		return fmt.Sprintf("deployment %s has %d of %d replica(s) updated", deployment.Name, deployment.Status.UpdatedReplicas, replicas)
+	case deployment.Status.AvailableReplicas != replicas:
+		return fmt.Sprintf("deployment %s has %d of %d replica(s) available", deployment.Name, deployment.Status.AvailableReplicas, replicas)
+	}
+	return ""
+}
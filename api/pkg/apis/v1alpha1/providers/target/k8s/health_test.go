@@ -0,0 +1,93 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package k8s
+
+import (
+	"context"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCheckHealthSinglePod(t *testing.T) {
+	provider, client := newProgressiveProvider(SINGLE_POD, false)
+	deployment := progressiveDeployment()
+	step := progressiveStep("nginx:1.0", model.ComponentUpdate)
+
+	ret, err := provider.CheckHealth(context.Background(), deployment, step)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, ret["web"].Status)
+	assert.Equal(t, "deployment instance not found", ret["web"].Message)
+
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	ret, err = provider.CheckHealth(context.Background(), deployment, step)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, ret["web"].Status)
+	assert.Equal(t, "deployment instance has 0 of 1 replica(s) updated", ret["web"].Message)
+
+	testCases := []struct {
+		name    string
+		status  appsv1.DeploymentStatus
+		state   v1alpha2.State
+		message string
+	}{
+		{
+			name:    "generation not observed",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 1, ReadyReplicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			state:   v1alpha2.HealthCheckFailed,
+			message: "deployment instance hasn't observed generation 2 yet",
+		},
+		{
+			name:    "previous replicas",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: 0, AvailableReplicas: 1},
+			state:   v1alpha2.HealthCheckFailed,
+			message: "deployment instance has 0 of 1 replica(s) updated",
+		},
+		{
+			name:    "replicas not available",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: 1, AvailableReplicas: 0},
+			state:   v1alpha2.HealthCheckFailed,
+			message: "deployment instance has 0 of 1 replica(s) available",
+		},
+		{
+			name:   "rolled out",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 1, UpdatedReplicas: 1, AvailableReplicas: 1},
+			state:  v1alpha2.OK,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := client.AppsV1().Deployments("default").Get(context.Background(), "instance", metav1.GetOptions{})
+			assert.Nil(t, err)
+			d.Generation = 2
+			d.Status = tc.status
+			_, err = client.AppsV1().Deployments("default").Update(context.Background(), d, metav1.UpdateOptions{})
+			assert.Nil(t, err)
+			ret, err := provider.CheckHealth(context.Background(), deployment, step)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.state, ret["web"].Status)
+			assert.Equal(t, tc.message, ret["web"].Message)
+		})
+	}
+}
+
+func TestCheckHealthBlueGreen(t *testing.T) {
+	provider, _ := newProgressiveProvider(BLUE_GREEN, true)
+	deployment := progressiveDeployment()
+	step := progressiveStep("nginx:1.0", model.ComponentUpdate)
+
+	_, err := provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+	ret, err := provider.CheckHealth(context.Background(), deployment, step)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.OK, ret["web"].Status)
+}
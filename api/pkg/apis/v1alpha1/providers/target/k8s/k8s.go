@@ -46,6 +46,8 @@ const (
 	SERVICES      string = "services"
 	SERVICES_NS   string = "ns-services"
 	SERVICES_HNS  string = "hns-services" //TODO: future versions
+	CANARY        string = "canary"
+	BLUE_GREEN    string = "blue-green"
 	componentName string = "P (K8s Target Provider)"
 )
 
@@ -60,6 +62,8 @@ type K8sTargetProviderConfig struct {
 	DeleteEmptyNamespace bool   `json:"deleteEmptyNamespace"`
 	RetryCount           int    `json:"retryCount"`
 	RetryIntervalInSec   int    `json:"retryIntervalInSec"`
+	CanaryWeight         int    `json:"canaryWeight,omitempty"`
+	ProgressTimeoutInSec int    `json:"progressTimeoutInSec,omitempty"`
 }
 
 type K8sTargetProvider struct {
@@ -97,8 +101,8 @@ func K8sTargetProviderConfigFromMap(properties map[string]string) (K8sTargetProv
 		}
 	}
 	if v, ok := properties["deploymentStrategy"]; ok && v != "" {
-		if v != SERVICES && v != SINGLE_POD && v != SERVICES_NS {
-			return ret, v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid deployment strategy. Expected: %s (default), %s or %s", SINGLE_POD, SERVICES, SERVICES_NS), v1alpha2.BadConfig)
+		if v != SERVICES && v != SINGLE_POD && v != SERVICES_NS && v != CANARY && v != BLUE_GREEN {
+			return ret, v1alpha2.NewCOAError(nil, fmt.Sprintf("invalid deployment strategy. Expected: %s (default), %s, %s, %s or %s", SINGLE_POD, SERVICES, SERVICES_NS, CANARY, BLUE_GREEN), v1alpha2.BadConfig)
 		}
 		ret.DeploymentStrategy = v
 	} else {
@@ -132,6 +136,24 @@ func K8sTargetProviderConfigFromMap(properties map[string]string) (K8sTargetProv
 	} else {
 		ret.RetryIntervalInSec = 2
 	}
+	if v, ok := properties["canaryWeight"]; ok && v != "" {
+		ival, err := strconv.Atoi(v)
+		if err != nil || ival <= 0 || ival >= 100 {
+			return ret, v1alpha2.NewCOAError(err, "invalid value in the 'canaryWeight' setting of K8s reference provider, expected an integer between 1 and 99", v1alpha2.BadConfig)
+		}
+		ret.CanaryWeight = ival
+	} else {
+		ret.CanaryWeight = defaultCanaryWeight
+	}
+	if v, ok := properties["progressTimeoutInSec"]; ok && v != "" {
+		ival, err := strconv.Atoi(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid int value in the 'progressTimeoutInSec' setting of K8s reference provider", v1alpha2.BadConfig)
+		}
+		ret.ProgressTimeoutInSec = ival
+	} else {
+		ret.ProgressTimeoutInSec = defaultProgressTimeoutInSec
+	}
 	return ret, nil
 }
 func (i *K8sTargetProvider) InitWithMap(properties map[string]string) error {
@@ -295,8 +317,22 @@ func (i *K8sTargetProvider) Get(ctx context.Context, dep model.DeploymentSpec, r
 	var components []model.ComponentSpec
 
 	switch i.Config.DeploymentStrategy {
-	case "", SINGLE_POD:
-		components, err = i.getDeployment(ctx, dep.Instance.Spec.Scope, dep.Instance.ObjectMeta.Name)
+	case "", SINGLE_POD, CANARY, BLUE_GREEN:
+		name := dep.Instance.ObjectMeta.Name
+		if i.Config.DeploymentStrategy == BLUE_GREEN {
+			var slot string
+			slot, err = i.activeSlot(ctx, dep.Instance.Spec.Scope, name, dep.Instance.Spec.Metadata)
+			if err != nil {
+				log.Debugf("  P (K8s Target Provider): failed to get active slot - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+				err = v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to get active slot", componentName), v1alpha2.GetComponentSpecFailed)
+				return nil, err
+			}
+			if slot == "" {
+				return nil, nil
+			}
+			name = projectors.SlotName(name, slot)
+		}
+		components, err = i.getDeployment(ctx, dep.Instance.Spec.Scope, name)
 		if err != nil {
 			log.Debugf("  P (K8s Target Provider): failed to get - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
 			err = v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to get components from deployment spec", componentName), v1alpha2.GetComponentSpecFailed)
@@ -571,30 +607,11 @@ func (i *K8sTargetProvider) deployComponents(ctx context.Context, span trace.Spa
 		namespace = "default"
 	}
 
-	deployment, err := componentsToDeployment(namespace, name, metadata, components, instanceName)
-	if projector != nil {
-		err = projector.ProjectDeployment(namespace, name, metadata, components, deployment)
-		if err != nil {
-			log.Debugf("  P (K8s Target Provider): failed to project deployment: %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
-			return err
-		}
-	}
+	deployment, service, err := projectComponents(namespace, name, metadata, components, projector, instanceName)
 	if err != nil {
 		log.Debugf("  P (K8s Target Provider): failed to apply: %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
 		return err
 	}
-	service, err := metadataToService(namespace, name, metadata)
-	if err != nil {
-		log.Debugf("  P (K8s Target Provider): failed to apply (convert): %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
-		return err
-	}
-	if projector != nil {
-		err = projector.ProjectService(namespace, name, metadata, service)
-		if err != nil {
-			log.Debugf("  P (K8s Target Provider): failed to project service: %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
-			return err
-		}
-	}
 
 	log.Debug("  P (K8s Target Provider): checking namespace")
 	err = i.createNamespace(ctx, namespace)
@@ -620,6 +637,35 @@ func (i *K8sTargetProvider) deployComponents(ctx context.Context, span trace.Spa
 	}
 	return nil
 }
+
+// projectComponents converts components into a deployment and an optional service, and runs both
+// through the projector
+func projectComponents(namespace string, name string, metadata map[string]string, components []model.ComponentSpec, projector IK8sProjector, instanceName string) (*v1.Deployment, *apiv1.Service, error) {
+	deployment, err := componentsToDeployment(namespace, name, metadata, components, instanceName)
+	if projector != nil && err == nil {
+		err = projector.ProjectDeployment(namespace, name, metadata, components, deployment)
+		if err != nil {
+			log.Debugf("  P (K8s Target Provider): failed to project deployment: %s", err.Error())
+			return nil, nil, err
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	service, err := metadataToService(namespace, name, metadata)
+	if err != nil {
+		log.Debugf("  P (K8s Target Provider): failed to apply (convert): %s", err.Error())
+		return nil, nil, err
+	}
+	if projector != nil {
+		err = projector.ProjectService(namespace, name, metadata, service)
+		if err != nil {
+			log.Debugf("  P (K8s Target Provider): failed to project service: %s", err.Error())
+			return nil, nil, err
+		}
+	}
+	return deployment, service, nil
+}
 func (i *K8sTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
 	return model.ValidationRule{
 		AllowSidecar: i.Config.DeploymentStrategy == SERVICES,
@@ -709,6 +755,47 @@ func (i *K8sTargetProvider) Apply(ctx context.Context, dep model.DeploymentSpec,
 				}
 			}
 		}
+	case CANARY, BLUE_GREEN:
+		updated := step.GetUpdatedComponents()
+		if len(updated) > 0 {
+			var message string
+			if i.Config.DeploymentStrategy == CANARY {
+				message, err = i.deployCanary(ctx, dep.Instance.Spec.Scope, dep.Instance.ObjectMeta.Name, dep.Instance.Spec.Metadata, components, projector, dep.Instance.ObjectMeta.Name)
+			} else {
+				message, err = i.deployBlueGreen(ctx, dep.Instance.Spec.Scope, dep.Instance.ObjectMeta.Name, dep.Instance.Spec.Metadata, components, projector, dep.Instance.ObjectMeta.Name)
+			}
+			status := v1alpha2.Updated
+			if err != nil {
+				status = v1alpha2.UpdateFailed
+				message = err.Error()
+			}
+			for _, component := range updated {
+				ret[component.Name] = model.ComponentResultSpec{
+					Status:  status,
+					Message: message,
+				}
+			}
+			if err != nil {
+				log.Debugf("  P (K8s Target Provider): failed to apply components: %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+				err = v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to deploy components", componentName), v1alpha2.K8sDeploymentFailed)
+				return ret, err
+			}
+		}
+		deleted := step.GetDeletedComponents()
+		if len(deleted) > 0 {
+			err = i.removeProgressive(ctx, dep.Instance.Spec.Scope, dep.Instance.ObjectMeta.Name, dep.Instance.Spec.Metadata)
+			if err != nil {
+				log.Debugf("  P (K8s Target Provider): failed to remove components: %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+				err = v1alpha2.NewCOAError(err, fmt.Sprintf("%s: failed to remove k8s deployment", componentName), v1alpha2.K8sRemoveDeploymentFailed)
+				return ret, err
+			}
+			if i.Config.DeleteEmptyNamespace {
+				err = i.removeNamespace(ctx, dep.Instance.Spec.Scope, i.Config.RetryCount, i.Config.RetryIntervalInSec)
+				if err != nil {
+					log.Debugf("  P (K8s Target Provider): failed to remove namespace: %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+				}
+			}
+		}
 	case SERVICES, SERVICES_NS:
 		updated := step.GetUpdatedComponents()
 		if len(updated) > 0 {
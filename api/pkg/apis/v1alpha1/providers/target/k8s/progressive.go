@@ -0,0 +1,310 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/k8s/projectors"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	v1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultCanaryWeight         = 20
+	defaultProgressTimeoutInSec = 120
+)
+
+// readinessPollInterval is how often a deployment is checked while waiting for it to become ready
+var readinessPollInterval = time.Second
+
+// chainedProjector runs a list of projectors in order, skipping nil ones
+type chainedProjector []IK8sProjector
+
+func (c chainedProjector) ProjectDeployment(scope string, name string, metadata map[string]string, components []model.ComponentSpec, deployment *v1.Deployment) error {
+	for _, projector := range c {
+		if projector != nil {
+			if err := projector.ProjectDeployment(scope, name, metadata, components, deployment); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+func (c chainedProjector) ProjectService(scope string, name string, metadata map[string]string, service *apiv1.Service) error {
+	for _, projector := range c {
+		if projector != nil {
+			if err := projector.ProjectService(scope, name, metadata, service); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (i *K8sTargetProvider) progressTimeout() time.Duration {
+	if i.Config.ProgressTimeoutInSec <= 0 {
+		return time.Duration(defaultProgressTimeoutInSec) * time.Second
+	}
+	return time.Duration(i.Config.ProgressTimeoutInSec) * time.Second
+}
+
+func (i *K8sTargetProvider) canaryWeight() int {
+	if i.Config.CanaryWeight <= 0 {
+		return defaultCanaryWeight
+	}
+	return i.Config.CanaryWeight
+}
+
+// deployCanary runs the new version of the components next to the stable deployment with the configured
+// weight. Once the canary pods are ready, the stable deployment is promoted to the new version and the
+// canary is removed. If the canary doesn't become ready in time, it's removed and the stable deployment
+// is left untouched. Returns a message that describes the outcome.
+func (i *K8sTargetProvider) deployCanary(ctx context.Context, namespace string, name string, metadata map[string]string, components []model.ComponentSpec, projector IK8sProjector, instanceName string) (string, error) {
+	ctx, span := observability.StartSpan("K8s Target Provider", ctx, &map[string]string{
+		"method": "deployCanary",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof("  P (K8s Target Provider): deployCanary namespace - %s, name - %s, traceId: %s", namespace, name, span.SpanContext().TraceID().String())
+
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	stable, err := i.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !k8s_errors.IsNotFound(err) {
+			return "", err
+		}
+		// nothing to compare with, so the first version is deployed directly
+		_, deploySpan := observability.StartSpan("K8s Target Provider", ctx, &map[string]string{
+			"method": "deployComponents",
+		})
+		err = i.deployComponents(ctx, deploySpan, namespace, name, metadata, components, projector, instanceName)
+		if err != nil {
+			return "", err
+		}
+		return "deployed without canary as there is no stable deployment", nil
+	}
+
+	stableReplicas := int32(1)
+	if stable.Spec.Replicas != nil {
+		stableReplicas = *stable.Spec.Replicas
+	}
+	weight := i.canaryWeight()
+	canary, service, err := projectComponents(namespace, name, metadata, components, chainedProjector{projector, &projectors.CanaryProjector{
+		Weight:         weight,
+		StableReplicas: stableReplicas,
+	}}, instanceName)
+	if err != nil {
+		return "", err
+	}
+	log.Debugf("  P (K8s Target Provider): creating canary %s with %d replica(s), traceId: %s", canary.Name, *canary.Spec.Replicas, span.SpanContext().TraceID().String())
+	err = i.upsertDeployment(ctx, namespace, canary.Name, canary)
+	if err != nil {
+		return "", err
+	}
+	if service != nil {
+		err = i.upsertService(ctx, namespace, service.Name, service)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	err = i.waitForDeploymentReady(ctx, namespace, canary.Name, i.progressTimeout())
+	if err != nil {
+		log.Infof("  P (K8s Target Provider): aborting canary %s - %s, traceId: %s", canary.Name, err.Error(), span.SpanContext().TraceID().String())
+		if removeErr := i.removeDeployment(ctx, namespace, canary.Name); removeErr != nil {
+			log.Errorf("  P (K8s Target Provider): failed to remove canary %s - %s, traceId: %s", canary.Name, removeErr.Error(), span.SpanContext().TraceID().String())
+		}
+		err = v1alpha2.NewCOAError(err, fmt.Sprintf("canary aborted: %d canary replica(s) at %d%% weight didn't become ready", *canary.Spec.Replicas, weight), v1alpha2.K8sDeploymentFailed)
+		return "", err
+	}
+
+	log.Infof("  P (K8s Target Provider): promoting canary %s, traceId: %s", canary.Name, span.SpanContext().TraceID().String())
+	promoted, _, err := projectComponents(namespace, name, metadata, components, projector, instanceName)
+	if err != nil {
+		return "", err
+	}
+	err = i.upsertDeployment(ctx, namespace, name, promoted)
+	if err != nil {
+		return "", err
+	}
+	err = i.removeDeployment(ctx, namespace, canary.Name)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("canary promoted after %d canary replica(s) at %d%% weight became ready", *canary.Spec.Replicas, weight), nil
+}
+
+// deployBlueGreen deploys the new version of the components to the inactive slot. Once its pods are
+// ready, the service is switched to the new slot and the previous deployment is removed. If the new
+// slot doesn't become ready in time, it's removed and the service keeps pointing to the active slot.
+// Returns a message that describes the outcome.
+func (i *K8sTargetProvider) deployBlueGreen(ctx context.Context, namespace string, name string, metadata map[string]string, components []model.ComponentSpec, projector IK8sProjector, instanceName string) (string, error) {
+	ctx, span := observability.StartSpan("K8s Target Provider", ctx, &map[string]string{
+		"method": "deployBlueGreen",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+	log.Infof("  P (K8s Target Provider): deployBlueGreen namespace - %s, name - %s, traceId: %s", namespace, name, span.SpanContext().TraceID().String())
+
+	if namespace == "" {
+		namespace = "default"
+	}
+
+	active, err := i.activeSlot(ctx, namespace, name, metadata)
+	if err != nil {
+		return "", err
+	}
+	slot := projectors.OtherSlot(active)
+	deployment, service, err := projectComponents(namespace, name, metadata, components, chainedProjector{projector, &projectors.BlueGreenProjector{
+		Slot: slot,
+	}}, instanceName)
+	if err != nil {
+		return "", err
+	}
+
+	err = i.createNamespace(ctx, namespace)
+	if err != nil {
+		return "", err
+	}
+	log.Debugf("  P (K8s Target Provider): deploying to %s slot, traceId: %s", slot, span.SpanContext().TraceID().String())
+	err = i.upsertDeployment(ctx, namespace, deployment.Name, deployment)
+	if err != nil {
+		return "", err
+	}
+
+	err = i.waitForDeploymentReady(ctx, namespace, deployment.Name, i.progressTimeout())
+	if err != nil {
+		log.Infof("  P (K8s Target Provider): aborting blue-green switch to %s - %s, traceId: %s", deployment.Name, err.Error(), span.SpanContext().TraceID().String())
+		if removeErr := i.removeDeployment(ctx, namespace, deployment.Name); removeErr != nil {
+			log.Errorf("  P (K8s Target Provider): failed to remove %s - %s, traceId: %s", deployment.Name, removeErr.Error(), span.SpanContext().TraceID().String())
+		}
+		err = v1alpha2.NewCOAError(err, fmt.Sprintf("blue-green switch aborted: %s slot didn't become ready", slot), v1alpha2.K8sDeploymentFailed)
+		return "", err
+	}
+
+	if service != nil {
+		log.Debugf("  P (K8s Target Provider): switching service %s to %s slot, traceId: %s", service.Name, slot, span.SpanContext().TraceID().String())
+		err = i.upsertService(ctx, namespace, service.Name, service)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	// remove the previous slot, or the deployment created before switching to the blue-green strategy
+	previous := name
+	if active != "" {
+		previous = projectors.SlotName(name, active)
+	}
+	err = i.removeDeployment(ctx, namespace, previous)
+	if err != nil {
+		return "", err
+	}
+	if active == "" {
+		return fmt.Sprintf("deployed to %s slot", slot), nil
+	}
+	return fmt.Sprintf("switched from %s slot to %s slot", active, slot), nil
+}
+
+// activeSlot returns the blue-green slot that currently serves the named deployment, or an empty
+// string if there is none. When both slots exist, like after an interrupted switch, the service
+// selector decides.
+func (i *K8sTargetProvider) activeSlot(ctx context.Context, namespace string, name string, metadata map[string]string) (string, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	slots := make([]string, 0)
+	for _, slot := range []string{projectors.BlueSlot, projectors.GreenSlot} {
+		_, err := i.Client.AppsV1().Deployments(namespace).Get(ctx, projectors.SlotName(name, slot), metav1.GetOptions{})
+		if err != nil {
+			if k8s_errors.IsNotFound(err) {
+				continue
+			}
+			return "", err
+		}
+		slots = append(slots, slot)
+	}
+	switch len(slots) {
+	case 0:
+		return "", nil
+	case 1:
+		return slots[0], nil
+	}
+	serviceName := name
+	if v, ok := metadata["service.name"]; ok && v != "" {
+		serviceName = v
+	}
+	svc, err := i.Client.CoreV1().Services(namespace).Get(ctx, serviceName, metav1.GetOptions{})
+	if err != nil {
+		if k8s_errors.IsNotFound(err) {
+			return projectors.BlueSlot, nil
+		}
+		return "", err
+	}
+	if slot, ok := svc.Spec.Selector[projectors.SlotLabel]; ok && slot != "" {
+		return slot, nil
+	}
+	return projectors.BlueSlot, nil
+}
+
+// waitForDeploymentReady waits until a deployment is rolled out, or the timeout is reached
+func (i *K8sTargetProvider) waitForDeploymentReady(ctx context.Context, namespace string, name string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		deployment, err := i.Client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		message := rolloutMessage(deployment)
+		if message == "" {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("%s after %s", message, timeout), v1alpha2.K8sDeploymentFailed)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(readinessPollInterval):
+		}
+	}
+}
+
+// removeProgressive removes the service and all deployments created by the canary and blue-green strategies
+func (i *K8sTargetProvider) removeProgressive(ctx context.Context, namespace string, name string, metadata map[string]string) error {
+	serviceName := name
+	if v, ok := metadata["service.name"]; ok && v != "" {
+		serviceName = v
+	}
+	err := i.removeService(ctx, namespace, serviceName)
+	if err != nil {
+		return err
+	}
+	for _, deployment := range []string{
+		name,
+		projectors.CanaryName(name),
+		projectors.SlotName(name, projectors.BlueSlot),
+		projectors.SlotName(name, projectors.GreenSlot),
+	} {
+		err = i.removeDeployment(ctx, namespace, deployment)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/k8s/projectors"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	k8s_errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// newProgressiveProvider creates a provider on a fake client. When ready is set, deployments report
+// all replicas ready as soon as they are created or updated.
+func newProgressiveProvider(strategy string, ready bool) (*K8sTargetProvider, *fake.Clientset) {
+	readinessPollInterval = 10 * time.Millisecond
+	client := fake.NewSimpleClientset()
+	if ready {
+		markReady := func(action k8stesting.Action) (bool, runtime.Object, error) {
+			if deployment, ok := action.(k8stesting.CreateAction).GetObject().(*appsv1.Deployment); ok {
+				deployment.Status.ReadyReplicas = *deployment.Spec.Replicas
+				deployment.Status.UpdatedReplicas = *deployment.Spec.Replicas
+				deployment.Status.AvailableReplicas = *deployment.Spec.Replicas
+			}
+			return false, nil, nil
+		}
+		client.PrependReactor("create", "deployments", markReady)
+		client.PrependReactor("update", "deployments", markReady)
+	}
+	provider := &K8sTargetProvider{
+		Config: K8sTargetProviderConfig{
+			DeploymentStrategy:   strategy,
+			CanaryWeight:         50,
+			ProgressTimeoutInSec: 1,
+		},
+		Client: client,
+	}
+	return provider, client
+}
+
+func progressiveDeployment() model.DeploymentSpec {
+	return model.DeploymentSpec{
+		Instance: model.InstanceState{
+			ObjectMeta: model.ObjectMeta{
+				Name: "instance",
+			},
+			Spec: &model.InstanceSpec{
+				Scope: "default",
+				Metadata: map[string]string{
+					"service.ports": "[{\"name\":\"port8888\",\"port\":8888}]",
+				},
+			},
+		},
+	}
+}
+
+func progressiveStep(image string, action model.ComponentAction) model.DeploymentStep {
+	return model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: action,
+				Component: model.ComponentSpec{
+					Name: "web",
+					Properties: map[string]interface{}{
+						model.ContainerImage: image,
+					},
+				},
+			},
+		},
+	}
+}
+
+func deploymentImage(t *testing.T, client *fake.Clientset, name string) string {
+	deployment, err := client.AppsV1().Deployments("default").Get(context.Background(), name, metav1.GetOptions{})
+	assert.Nil(t, err)
+	if err != nil {
+		return ""
+	}
+	return deployment.Spec.Template.Spec.Containers[0].Image
+}
+
+func deploymentExists(client *fake.Clientset, name string) bool {
+	_, err := client.AppsV1().Deployments("default").Get(context.Background(), name, metav1.GetOptions{})
+	return !k8s_errors.IsNotFound(err)
+}
+
+func TestK8sTargetProviderConfigFromMapProgressive(t *testing.T) {
+	config, err := K8sTargetProviderConfigFromMap(map[string]string{
+		"deploymentStrategy":   "canary",
+		"canaryWeight":         "10",
+		"progressTimeoutInSec": "30",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, CANARY, config.DeploymentStrategy)
+	assert.Equal(t, 10, config.CanaryWeight)
+	assert.Equal(t, 30, config.ProgressTimeoutInSec)
+
+	config, err = K8sTargetProviderConfigFromMap(map[string]string{
+		"deploymentStrategy": "blue-green",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, defaultCanaryWeight, config.CanaryWeight)
+	assert.Equal(t, defaultProgressTimeoutInSec, config.ProgressTimeoutInSec)
+
+	_, err = K8sTargetProviderConfigFromMap(map[string]string{
+		"canaryWeight": "100",
+	})
+	assert.NotNil(t, err)
+	_, err = K8sTargetProviderConfigFromMap(map[string]string{
+		"progressTimeoutInSec": "soon",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestCanaryPromote(t *testing.T) {
+	provider, client := newProgressiveProvider(CANARY, true)
+	deployment := progressiveDeployment()
+
+	// the first version is deployed directly
+	ret, err := provider.Apply(context.Background(), deployment, progressiveStep("nginx:1.0", model.ComponentUpdate), false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Updated, ret["web"].Status)
+	assert.Equal(t, "nginx:1.0", deploymentImage(t, client, "instance"))
+
+	ret, err = provider.Apply(context.Background(), deployment, progressiveStep("nginx:2.0", model.ComponentUpdate), false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Updated, ret["web"].Status)
+	assert.Contains(t, ret["web"].Message, "canary promoted")
+	assert.Equal(t, "nginx:2.0", deploymentImage(t, client, "instance"))
+	assert.False(t, deploymentExists(client, projectors.CanaryName("instance")))
+
+	// the canary ran next to the stable deployment before it was promoted
+	created := 0
+	for _, action := range client.Actions() {
+		if create, ok := action.(k8stesting.CreateAction); ok {
+			if d, ok := create.GetObject().(*appsv1.Deployment); ok && d.Name == projectors.CanaryName("instance") {
+				created++
+				assert.Equal(t, projectors.CanaryTrack, d.Spec.Template.ObjectMeta.Labels[projectors.TrackLabel])
+				assert.Equal(t, "instance", d.Spec.Template.ObjectMeta.Labels["app"])
+			}
+		}
+	}
+	assert.Equal(t, 1, created)
+
+	components, err := provider.Get(context.Background(), deployment, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, "nginx:2.0", components[0].Properties[model.ContainerImage])
+}
+
+func TestCanaryAbort(t *testing.T) {
+	// canary pods never become ready
+	provider, client := newProgressiveProvider(CANARY, false)
+	deployment := progressiveDeployment()
+	_, err := provider.Apply(context.Background(), deployment, progressiveStep("nginx:1.0", model.ComponentUpdate), false)
+	assert.Nil(t, err)
+
+	ret, err := provider.Apply(context.Background(), deployment, progressiveStep("nginx:2.0", model.ComponentUpdate), false)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.UpdateFailed, ret["web"].Status)
+	assert.Contains(t, ret["web"].Message, "canary aborted")
+	assert.Equal(t, "nginx:1.0", deploymentImage(t, client, "instance"))
+	assert.False(t, deploymentExists(client, projectors.CanaryName("instance")))
+}
+
+func TestBlueGreenSwitch(t *testing.T) {
+	provider, client := newProgressiveProvider(BLUE_GREEN, true)
+	deployment := progressiveDeployment()
+
+	ret, err := provider.Apply(context.Background(), deployment, progressiveStep("nginx:1.0", model.ComponentUpdate), false)
+	assert.Nil(t, err)
+	assert.Equal(t, "deployed to blue slot", ret["web"].Message)
+	assert.Equal(t, "nginx:1.0", deploymentImage(t, client, "instance-blue"))
+	service, err := client.CoreV1().Services("default").Get(context.Background(), "instance", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, projectors.BlueSlot, service.Spec.Selector[projectors.SlotLabel])
+
+	ret, err = provider.Apply(context.Background(), deployment, progressiveStep("nginx:2.0", model.ComponentUpdate), false)
+	assert.Nil(t, err)
+	assert.Equal(t, v1alpha2.Updated, ret["web"].Status)
+	assert.Equal(t, "switched from blue slot to green slot", ret["web"].Message)
+	assert.Equal(t, "nginx:2.0", deploymentImage(t, client, "instance-green"))
+	assert.False(t, deploymentExists(client, "instance-blue"))
+	service, err = client.CoreV1().Services("default").Get(context.Background(), "instance", metav1.GetOptions{})
+	assert.Nil(t, err)
+	assert.Equal(t, projectors.GreenSlot, service.Spec.Selector[projectors.SlotLabel])
+
+	components, err := provider.Get(context.Background(), deployment, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, "nginx:2.0", components[0].Properties[model.ContainerImage])
+
+	_, err = provider.Apply(context.Background(), deployment, progressiveStep("nginx:2.0", model.ComponentDelete), false)
+	assert.Nil(t, err)
+	assert.False(t, deploymentExists(client, "instance-green"))
+	components, err = provider.Get(context.Background(), deployment, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+}
+
+func TestBlueGreenAbort(t *testing.T) {
+	provider, client := newProgressiveProvider(BLUE_GREEN, false)
+	deployment := progressiveDeployment()
+
+	ret, err := provider.Apply(context.Background(), deployment, progressiveStep("nginx:1.0", model.ComponentUpdate), false)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.UpdateFailed, ret["web"].Status)
+	assert.Contains(t, ret["web"].Message, "blue-green switch aborted")
+	assert.False(t, deploymentExists(client, "instance-blue"))
+	_, err = client.CoreV1().Services("default").Get(context.Background(), "instance", metav1.GetOptions{})
+	assert.True(t, k8s_errors.IsNotFound(err))
+}
+
+func TestWaitForDeploymentReady(t *testing.T) {
+	testCases := []struct {
+		name    string
+		status  appsv1.DeploymentStatus
+		message string
+	}{
+		{
+			name:    "generation not observed",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 1, ReadyReplicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+			message: "deployment web hasn't observed generation 2 yet",
+		},
+		{
+			name:    "previous replicas ready",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 1, AvailableReplicas: 2},
+			message: "deployment web has 1 of 2 replica(s) updated",
+		},
+		{
+			name:    "replicas not available",
+			status:  appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 2, AvailableReplicas: 1},
+			message: "deployment web has 1 of 2 replica(s) available",
+		},
+		{
+			name:   "rolled out",
+			status: appsv1.DeploymentStatus{ObservedGeneration: 2, ReadyReplicas: 2, UpdatedReplicas: 2, AvailableReplicas: 2},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider, client := newProgressiveProvider(CANARY, false)
+			replicas := int32(2)
+			_, err := client.AppsV1().Deployments("default").Create(context.Background(), &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Generation: 2},
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status:     tc.status,
+			}, metav1.CreateOptions{})
+			assert.Nil(t, err)
+			err = provider.waitForDeploymentReady(context.Background(), "default", "web", 30*time.Millisecond)
+			if tc.message == "" {
+				assert.Nil(t, err)
+				return
+			}
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), tc.message)
+		})
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package projectors
+
+import (
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	v1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+const (
+	SlotLabel = "symphony.slot"
+	BlueSlot  = "blue"
+	GreenSlot = "green"
+)
+
+// SlotName returns the name of the deployment that runs the named deployment in the given slot.
+func SlotName(name string, slot string) string {
+	return name + "-" + slot
+}
+
+// OtherSlot returns the slot to deploy to when the given slot is active.
+func OtherSlot(slot string) string {
+	if slot == BlueSlot {
+		return GreenSlot
+	}
+	return BlueSlot
+}
+
+// BlueGreenProjector places a deployment in a blue or green slot, and points the service to the
+// pods of that slot.
+type BlueGreenProjector struct {
+	Slot string
+}
+
+func (p *BlueGreenProjector) ProjectDeployment(scope string, name string, metadata map[string]string, components []model.ComponentSpec, deployment *v1.Deployment) error {
+	if p.Slot != BlueSlot && p.Slot != GreenSlot {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("slot must be %s or %s, but is '%s'", BlueSlot, GreenSlot, p.Slot), v1alpha2.BadConfig)
+	}
+	deployment.ObjectMeta.Name = SlotName(name, p.Slot)
+	addLabel(deployment, SlotLabel, p.Slot)
+	return nil
+}
+func (p *BlueGreenProjector) ProjectService(scope string, name string, metadata map[string]string, service *apiv1.Service) error {
+	if service == nil {
+		return nil
+	}
+	if service.Spec.Selector == nil {
+		service.Spec.Selector = make(map[string]string)
+	}
+	service.Spec.Selector[SlotLabel] = p.Slot
+	return nil
+}
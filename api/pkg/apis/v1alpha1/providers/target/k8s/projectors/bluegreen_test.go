@@ -0,0 +1,54 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package projectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+)
+
+func TestOtherSlot(t *testing.T) {
+	assert.Equal(t, BlueSlot, OtherSlot(""))
+	assert.Equal(t, GreenSlot, OtherSlot(BlueSlot))
+	assert.Equal(t, BlueSlot, OtherSlot(GreenSlot))
+}
+
+func TestBlueGreenProjectDeployment(t *testing.T) {
+	projector := &BlueGreenProjector{Slot: GreenSlot}
+	deployment := appsv1.Deployment{}
+	err := projector.ProjectDeployment("default", "name", nil, nil, &deployment)
+	assert.Nil(t, err)
+	assert.Equal(t, "name-green", deployment.Name)
+	assert.Equal(t, GreenSlot, deployment.Spec.Selector.MatchLabels[SlotLabel])
+	assert.Equal(t, GreenSlot, deployment.Spec.Template.ObjectMeta.Labels[SlotLabel])
+}
+
+func TestBlueGreenProjectDeploymentBadSlot(t *testing.T) {
+	projector := &BlueGreenProjector{Slot: "red"}
+	deployment := appsv1.Deployment{}
+	err := projector.ProjectDeployment("default", "name", nil, nil, &deployment)
+	assert.NotNil(t, err)
+}
+
+func TestBlueGreenProjectService(t *testing.T) {
+	projector := &BlueGreenProjector{Slot: BlueSlot}
+	service := apiv1.Service{
+		Spec: apiv1.ServiceSpec{
+			Selector: map[string]string{"app": "name"},
+		},
+	}
+	err := projector.ProjectService("default", "name", nil, &service)
+	assert.Nil(t, err)
+	assert.Equal(t, "name", service.Spec.Selector["app"])
+	assert.Equal(t, BlueSlot, service.Spec.Selector[SlotLabel])
+
+	err = projector.ProjectService("default", "name", nil, nil)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package projectors
+
+import (
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	v1 "k8s.io/api/apps/v1"
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	TrackLabel  = "symphony.track"
+	CanaryTrack = "canary"
+)
+
+// CanaryName returns the name of the canary deployment that runs next to the named deployment.
+func CanaryName(name string) string {
+	return name + "-" + CanaryTrack
+}
+
+// CanaryReplicas returns the number of canary replicas that receive about weight percent of the
+// traffic when running next to the given number of stable replicas. At least one replica is used.
+func CanaryReplicas(stableReplicas int32, weight int) int32 {
+	if weight <= 0 || weight >= 100 {
+		return 1
+	}
+	replicas := (int64(stableReplicas)*int64(weight) + int64(100-weight) - 1) / int64(100-weight)
+	if replicas < 1 {
+		return 1
+	}
+	return int32(replicas)
+}
+
+// CanaryProjector turns a deployment into a canary deployment. Canary pods keep the "app" label so
+// the service spreads the traffic over both the stable and the canary pods, and the weight (in
+// percent) decides how many canary replicas are created next to the stable ones.
+type CanaryProjector struct {
+	Weight         int
+	StableReplicas int32
+}
+
+func (p *CanaryProjector) ProjectDeployment(scope string, name string, metadata map[string]string, components []model.ComponentSpec, deployment *v1.Deployment) error {
+	if p.Weight <= 0 || p.Weight >= 100 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("canary weight must be between 1 and 99, but is %d", p.Weight), v1alpha2.BadConfig)
+	}
+	deployment.ObjectMeta.Name = CanaryName(name)
+	addLabel(deployment, TrackLabel, CanaryTrack)
+	replicas := CanaryReplicas(p.StableReplicas, p.Weight)
+	deployment.Spec.Replicas = &replicas
+	return nil
+}
+func (p *CanaryProjector) ProjectService(scope string, name string, metadata map[string]string, service *apiv1.Service) error {
+	return nil
+}
+
+// addLabel adds a label to the selector and the pod template of a deployment
+func addLabel(deployment *v1.Deployment, key string, value string) {
+	if deployment.Spec.Selector == nil {
+		deployment.Spec.Selector = &metav1.LabelSelector{}
+	}
+	if deployment.Spec.Selector.MatchLabels == nil {
+		deployment.Spec.Selector.MatchLabels = make(map[string]string)
+	}
+	deployment.Spec.Selector.MatchLabels[key] = value
+	if deployment.Spec.Template.ObjectMeta.Labels == nil {
+		deployment.Spec.Template.ObjectMeta.Labels = make(map[string]string)
+	}
+	deployment.Spec.Template.ObjectMeta.Labels[key] = value
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package projectors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCanaryReplicas(t *testing.T) {
+	assert.Equal(t, int32(1), CanaryReplicas(1, 20))
+	assert.Equal(t, int32(1), CanaryReplicas(4, 20))
+	assert.Equal(t, int32(2), CanaryReplicas(5, 20))
+	assert.Equal(t, int32(3), CanaryReplicas(3, 50))
+	assert.Equal(t, int32(1), CanaryReplicas(0, 20))
+}
+
+func TestCanaryProjectDeployment(t *testing.T) {
+	projector := &CanaryProjector{Weight: 50, StableReplicas: 3}
+	deployment := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "name"},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "name"},
+			},
+		},
+	}
+	err := projector.ProjectDeployment("default", "name", nil, nil, &deployment)
+	assert.Nil(t, err)
+	assert.Equal(t, "name-canary", deployment.Name)
+	assert.Equal(t, int32(3), *deployment.Spec.Replicas)
+	assert.Equal(t, "name", deployment.Spec.Selector.MatchLabels["app"])
+	assert.Equal(t, CanaryTrack, deployment.Spec.Selector.MatchLabels[TrackLabel])
+	assert.Equal(t, CanaryTrack, deployment.Spec.Template.ObjectMeta.Labels[TrackLabel])
+
+	err = projector.ProjectService("default", "name", nil, nil)
+	assert.Nil(t, err)
+}
+
+func TestCanaryProjectDeploymentBadWeight(t *testing.T) {
+	projector := &CanaryProjector{Weight: 100, StableReplicas: 1}
+	deployment := appsv1.Deployment{}
+	err := projector.ProjectDeployment("default", "name", nil, nil, &deployment)
+	assert.NotNil(t, err)
+}
@@ -9,6 +9,7 @@ package mock
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 	"sync"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -21,6 +22,9 @@ import (
 
 type MockTargetProviderConfig struct {
 	ID string `json:"id"`
+	// StrictLifecycle makes dry runs leave the components unchanged and deletions of unknown components no-ops, like
+	// the lifecycle conformance suite expects
+	StrictLifecycle bool `json:"strictLifecycle,omitempty"`
 }
 type MockTargetProvider struct {
 	Config  MockTargetProviderConfig
@@ -77,6 +81,13 @@ func (i *MockTargetProvider) InitWithMap(properties map[string]string) error {
 func MockTargetProviderConfigFromMap(properties map[string]string) (MockTargetProviderConfig, error) {
 	ret := MockTargetProviderConfig{}
 	ret.ID = properties["id"]
+	if v, ok := properties["strictLifecycle"]; ok {
+		strict, err := strconv.ParseBool(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "'strictLifecycle' must be a boolean", v1alpha2.BadConfig)
+		}
+		ret.StrictLifecycle = strict
+	}
 	return ret, nil
 }
 func (m *MockTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
@@ -114,6 +125,10 @@ func (m *MockTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 	var err error = nil
 	defer observ_utils.CloseSpanWithError(span, &err)
 
+	if isDryRun && m.Config.StrictLifecycle {
+		return nil, nil
+	}
+
 	mLock.Lock()
 	defer mLock.Unlock()
 	if cache[m.Config.ID] == nil {
@@ -130,7 +145,7 @@ func (m *MockTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 				break
 			}
 		}
-		if !found {
+		if !found && (c.Action != model.ComponentDelete || !m.Config.StrictLifecycle) {
 			cache[m.Config.ID] = append(cache[m.Config.ID], c.Component)
 		}
 	}
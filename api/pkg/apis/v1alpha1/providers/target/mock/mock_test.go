@@ -8,6 +8,7 @@ package mock
 
 import (
 	"context"
+	"strconv"
 	"testing"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -79,3 +80,51 @@ func TestMockTargetProviderGet(t *testing.T) {
 	_, err = provider.Get(context.Background(), model.DeploymentSpec{}, nil)
 	assert.Nil(t, err)
 }
+
+func TestMockTargetProviderStrictLifecycle(t *testing.T) {
+	testCases := []struct {
+		name       string
+		strict     bool
+		dryRun     int
+		deleteOnly int
+	}{
+		{name: "default", dryRun: 1, deleteOnly: 1},
+		{name: "strict", strict: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &MockTargetProvider{}
+			err := provider.InitWithMap(map[string]string{"id": "lifecycle-" + tc.name, "strictLifecycle": strconv.FormatBool(tc.strict)})
+			assert.Nil(t, err)
+			references := []model.ComponentStep{{Component: model.ComponentSpec{Name: "name"}}}
+
+			// a dry run
+			_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, model.DeploymentStep{
+				Components: []model.ComponentStep{{Action: model.ComponentUpdate, Component: model.ComponentSpec{Name: "name"}}},
+			}, true)
+			assert.Nil(t, err)
+			components, err := provider.Get(context.Background(), model.DeploymentSpec{}, references)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.dryRun, len(components))
+
+			// deleting a component that isn't there, twice in a row with the default lifecycle
+			_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, model.DeploymentStep{
+				Components: []model.ComponentStep{{Action: model.ComponentDelete, Component: model.ComponentSpec{Name: "name"}}},
+			}, false)
+			assert.Nil(t, err)
+			_, err = provider.Apply(context.Background(), model.DeploymentSpec{}, model.DeploymentStep{
+				Components: []model.ComponentStep{{Action: model.ComponentDelete, Component: model.ComponentSpec{Name: "name"}}},
+			}, false)
+			assert.Nil(t, err)
+			components, err = provider.Get(context.Background(), model.DeploymentSpec{}, references)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.deleteOnly, len(components))
+		})
+	}
+}
+
+func TestInitWithMapBadStrictLifecycle(t *testing.T) {
+	provider := MockTargetProvider{}
+	err := provider.InitWithMap(map[string]string{"strictLifecycle": "sometimes"})
+	assert.NotNil(t, err)
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
 	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	mqttutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	gmqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/google/uuid"
@@ -38,8 +39,13 @@ type MQTTTargetProviderConfig struct {
 	TimeoutSeconds     int    `json:"timeoutSeconds,omitempty"`
 	KeepAliveSeconds   int    `json:"keepAliveSeconds,omitempty"`
 	PingTimeoutSeconds int    `json:"pingTimeoutSeconds,omitempty"`
+	// Route is the full route of the instances endpoint served by the remote binding
+	Route string `json:"route,omitempty"`
+	mqttutils.ConnectionConfig
 }
 
+const defaultRoute = "solution/instances"
+
 var lock sync.Mutex
 
 type ProxyResponse struct {
@@ -114,7 +120,25 @@ func MQTTTargetProviderConfigFromMap(properties map[string]string) (MQTTTargetPr
 	if ret.TimeoutSeconds <= 0 {
 		ret.TimeoutSeconds = 8
 	}
-	return ret, nil
+	if v, ok := properties["route"]; ok && v != "" {
+		ret.Route = v
+	} else {
+		ret.Route = defaultRoute
+	}
+	connectionConfig, err := mqttutils.ConnectionConfigFromMap(properties)
+	if err != nil {
+		return ret, err
+	}
+	ret.ConnectionConfig = connectionConfig
+	return ret, ret.validate()
+}
+
+// validate checks the settings that depend on each other
+func (c MQTTTargetProviderConfig) validate() error {
+	if c.CleanSession != nil && !*c.CleanSession && c.ClientID == "" {
+		return v1alpha2.NewCOAError(nil, "'clientID' is required when 'cleanSession' is false in MQTT provider config", v1alpha2.BadRequest)
+	}
+	return nil
 }
 
 func (i *MQTTTargetProvider) InitWithMap(properties map[string]string) error {
@@ -150,11 +174,18 @@ func (i *MQTTTargetProvider) Init(config providers.IProviderConfig) error {
 		return err
 	}
 	i.Config = updateConfig
-	id := uuid.New()
-	opts := gmqtt.NewClientOptions().AddBroker(i.Config.BrokerAddress).SetClientID(id.String())
+	// a persistent session is bound to the client ID, so only use a random ID for clean sessions
+	clientID := uuid.New().String()
+	if i.Config.CleanSession != nil && !*i.Config.CleanSession {
+		clientID = i.Config.ClientID
+	}
+	opts, err := mqttutils.NewClientOptions(i.Config.BrokerAddress, clientID, i.Config.ConnectionConfig, true)
+	if err != nil {
+		sLog.Errorf("  P (MQTT Target): invalid MQTT connection config - %+v", err)
+		return err
+	}
 	opts.SetKeepAlive(time.Duration(i.Config.KeepAliveSeconds) * time.Second)
 	opts.SetPingTimeout(time.Duration(i.Config.PingTimeoutSeconds) * time.Second)
-	opts.CleanSession = true
 	i.MQTTClient = gmqtt.NewClient(opts)
 	if token := i.MQTTClient.Connect(); token.Wait() && token.Error() != nil {
 		sLog.Errorf("  P (MQTT Target): faild to connect to MQTT broker - %+v", err)
@@ -167,7 +198,7 @@ func (i *MQTTTargetProvider) Init(config providers.IProviderConfig) error {
 	i.NeedsRemoveChan = make(chan ProxyResponse)
 	i.ApplyChan = make(chan ProxyResponse)
 
-	if token := i.MQTTClient.Subscribe(i.Config.ResponseTopic, 0, func(client gmqtt.Client, msg gmqtt.Message) {
+	if token := i.MQTTClient.Subscribe(i.Config.ResponseTopic, i.Config.GetQoS(), func(client gmqtt.Client, msg gmqtt.Message) {
 		var response v1alpha2.COAResponse
 		json.Unmarshal(msg.Payload(), &response)
 		proxyResponse := ProxyResponse{
@@ -226,7 +257,13 @@ func toMQTTTargetProviderConfig(config providers.IProviderConfig) (MQTTTargetPro
 	if ret.TimeoutSeconds <= 0 {
 		ret.TimeoutSeconds = 8
 	}
-	return ret, err
+	if ret.Route == "" {
+		ret.Route = defaultRoute
+	}
+	if err != nil {
+		return ret, err
+	}
+	return ret, ret.validate()
 }
 
 func (i *MQTTTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
@@ -239,7 +276,7 @@ func (i *MQTTTargetProvider) Get(ctx context.Context, deployment model.Deploymen
 
 	data, _ := json.Marshal(deployment)
 	request := v1alpha2.COARequest{
-		Route:  "instances",
+		Route:  i.Config.Route,
 		Method: "GET",
 		Body:   data,
 		Metadata: map[string]string{
@@ -249,7 +286,7 @@ func (i *MQTTTargetProvider) Get(ctx context.Context, deployment model.Deploymen
 	}
 	data, _ = json.Marshal(request)
 
-	if token := i.MQTTClient.Publish(i.Config.RequestTopic, 0, false, data); token.Wait() && token.Error() != nil {
+	if token := i.MQTTClient.Publish(i.Config.RequestTopic, i.Config.GetQoS(), false, data); token.Wait() && token.Error() != nil {
 		sLog.Errorf("  P (MQTT Target): failed to getting artifacts - %s, traceId: %s", token.Error(), span.SpanContext().TraceID().String())
 		err = token.Error()
 		return nil, err
@@ -295,7 +332,7 @@ func (i *MQTTTargetProvider) Remove(ctx context.Context, deployment model.Deploy
 
 	data, _ := json.Marshal(deployment)
 	request := v1alpha2.COARequest{
-		Route:  "instances",
+		Route:  i.Config.Route,
 		Method: "DELETE",
 		Body:   data,
 		Metadata: map[string]string{
@@ -305,7 +342,7 @@ func (i *MQTTTargetProvider) Remove(ctx context.Context, deployment model.Deploy
 	}
 	data, _ = json.Marshal(request)
 
-	if token := i.MQTTClient.Publish(i.Config.RequestTopic, 0, false, data); token.Wait() && token.Error() != nil {
+	if token := i.MQTTClient.Publish(i.Config.RequestTopic, i.Config.GetQoS(), false, data); token.Wait() && token.Error() != nil {
 		err = token.Error()
 		return err
 	}
@@ -354,7 +391,7 @@ func (i *MQTTTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 	if len(components) > 0 {
 
 		request := v1alpha2.COARequest{
-			Route:  "instances",
+			Route:  i.Config.Route,
 			Method: "POST",
 			Body:   data,
 			Metadata: map[string]string{
@@ -364,7 +401,7 @@ func (i *MQTTTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 		}
 		data, _ = json.Marshal(request)
 
-		if token := i.MQTTClient.Publish(i.Config.RequestTopic, 0, false, data); token.Wait() && token.Error() != nil {
+		if token := i.MQTTClient.Publish(i.Config.RequestTopic, i.Config.GetQoS(), false, data); token.Wait() && token.Error() != nil {
 			err = token.Error()
 			return ret, err
 		}
@@ -398,7 +435,7 @@ func (i *MQTTTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 	components = step.GetDeletedComponents()
 	if len(components) > 0 {
 		request := v1alpha2.COARequest{
-			Route:  "instances",
+			Route:  i.Config.Route,
 			Method: "DELETE",
 			Body:   data,
 			Metadata: map[string]string{
@@ -407,7 +444,7 @@ func (i *MQTTTargetProvider) Apply(ctx context.Context, deployment model.Deploym
 		}
 		data, _ = json.Marshal(request)
 
-		if token := i.MQTTClient.Publish(i.Config.RequestTopic, 0, false, data); token.Wait() && token.Error() != nil {
+		if token := i.MQTTClient.Publish(i.Config.RequestTopic, i.Config.GetQoS(), false, data); token.Wait() && token.Error() != nil {
 			err = token.Error()
 			return ret, err
 		}
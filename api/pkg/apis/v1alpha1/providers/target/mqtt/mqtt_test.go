@@ -16,6 +16,8 @@ import (
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	mqttutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt/mqtttest"
 	gmqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/stretchr/testify/assert"
 )
@@ -676,3 +678,168 @@ func TestConformanceSuite(t *testing.T) {
 	// assert.Nil(t, err) okay if provider is not fully initialized
 	conformance.ConformanceSuite(t, provider)
 }
+
+// startResponder acknowledges every request on the request topic, standing in for a remote agent
+func startResponder(t *testing.T, config MQTTTargetProviderConfig) {
+	opts, err := mqttutils.NewClientOptions(config.BrokerAddress, "test-responder", config.ConnectionConfig, true)
+	assert.Nil(t, err)
+	c := gmqtt.NewClient(opts)
+	token := c.Connect()
+	token.Wait()
+	assert.Nil(t, token.Error())
+	t.Cleanup(func() { c.Disconnect(100) })
+
+	token = c.Subscribe(config.RequestTopic, config.GetQoS(), func(client gmqtt.Client, msg gmqtt.Message) {
+		var request v1alpha2.COARequest
+		json.Unmarshal(msg.Payload(), &request)
+		response := v1alpha2.COAResponse{
+			State:    v1alpha2.OK,
+			Metadata: request.Metadata,
+		}
+		if request.Route != "solution/instances" {
+			// like the MQTT binding, requests are served by their full route
+			response.State = v1alpha2.NotFound
+		} else if request.Method == "GET" {
+			response.Body, _ = json.Marshal([]model.ComponentSpec{{Name: "c1"}})
+		} else {
+			response.Body, _ = json.Marshal(model.SummarySpec{})
+		}
+		data, _ := json.Marshal(response)
+		client.Publish(config.ResponseTopic, config.GetQoS(), false, data).Wait()
+	})
+	token.Wait()
+	assert.Nil(t, token.Error())
+}
+
+func TestInitWithMapConnectionConfig(t *testing.T) {
+	config, err := MQTTTargetProviderConfigFromMap(map[string]string{
+		"name":          "me",
+		"brokerAddress": "tcp://127.0.0.1:1883",
+		"clientID":      "coa-test2",
+		"requestTopic":  "coa-request",
+		"responseTopic": "coa-response",
+		"useTLS":        "true",
+		"username":      "admin",
+		"qos":           "1",
+		"cleanSession":  "false",
+	})
+	assert.Nil(t, err)
+	assert.True(t, config.UseTLS)
+	assert.Equal(t, "admin", config.Username)
+	assert.Equal(t, byte(1), config.GetQoS())
+	assert.False(t, *config.CleanSession)
+	assert.Equal(t, "solution/instances", config.Route)
+
+	_, err = MQTTTargetProviderConfigFromMap(map[string]string{
+		"name":          "me",
+		"brokerAddress": "tcp://127.0.0.1:1883",
+		"clientID":      "coa-test2",
+		"requestTopic":  "coa-request",
+		"responseTopic": "coa-response",
+		"qos":           "5",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestEmbeddedBrokerApplyGet(t *testing.T) {
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{
+		Users: map[string]string{"symphony": "secret"},
+	})
+	assert.Nil(t, err)
+	defer broker.Close()
+
+	config := MQTTTargetProviderConfig{
+		Name:          "me",
+		BrokerAddress: broker.Address,
+		ClientID:      "coa-embedded",
+		RequestTopic:  "coa-request-embedded",
+		ResponseTopic: "coa-response-embedded",
+		ConnectionConfig: mqttutils.ConnectionConfig{
+			Username: "symphony",
+			Password: "secret",
+			QoS:      1,
+		},
+	}
+	startResponder(t, config)
+
+	provider := MQTTTargetProvider{}
+	err = provider.Init(config)
+	assert.Nil(t, err)
+	defer provider.MQTTClient.Disconnect(100)
+
+	deployment := model.DeploymentSpec{
+		Instance: model.InstanceState{
+			Spec: &model.InstanceSpec{},
+		},
+	}
+	_, err = provider.Apply(context.Background(), deployment, model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action:    model.ComponentUpdate,
+				Component: model.ComponentSpec{Name: "c1"},
+			},
+		},
+	}, false)
+	assert.Nil(t, err)
+	components, err := provider.Get(context.Background(), deployment, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, "c1", components[0].Name)
+}
+
+func TestEmbeddedBrokerBadCredentials(t *testing.T) {
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{
+		Users: map[string]string{"symphony": "secret"},
+	})
+	assert.Nil(t, err)
+	defer broker.Close()
+
+	provider := MQTTTargetProvider{}
+	err = provider.Init(MQTTTargetProviderConfig{
+		Name:          "me",
+		BrokerAddress: broker.Address,
+		ClientID:      "coa-embedded",
+		RequestTopic:  "coa-request-embedded",
+		ResponseTopic: "coa-response-embedded",
+		ConnectionConfig: mqttutils.ConnectionConfig{
+			Username: "symphony",
+			Password: "wrong",
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestPersistentSessionRequiresClientID(t *testing.T) {
+	cleanSession := false
+	testCases := []struct {
+		name   string
+		config func() (MQTTTargetProviderConfig, error)
+	}{
+		{name: "map", config: func() (MQTTTargetProviderConfig, error) {
+			return MQTTTargetProviderConfigFromMap(map[string]string{
+				"brokerAddress": "tcp://127.0.0.1:1883",
+				"clientID":      "",
+				"requestTopic":  "coa-request",
+				"responseTopic": "coa-response",
+				"cleanSession":  "false",
+			})
+		}},
+		{name: "typed", config: func() (MQTTTargetProviderConfig, error) {
+			return toMQTTTargetProviderConfig(MQTTTargetProviderConfig{
+				BrokerAddress:    "tcp://127.0.0.1:1883",
+				RequestTopic:     "coa-request",
+				ResponseTopic:    "coa-response",
+				ConnectionConfig: mqttutils.ConnectionConfig{CleanSession: &cleanSession},
+			})
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.config()
+			assert.NotNil(t, err)
+			coaErr, ok := err.(v1alpha2.COAError)
+			assert.True(t, ok)
+			assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+		})
+	}
+}
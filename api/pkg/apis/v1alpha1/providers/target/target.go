@@ -22,3 +22,12 @@ type ITargetProvider interface {
 	// apply components to a target
 	Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error)
 }
+
+// IHealthCheckable is implemented by target providers that can verify that applied components are actually
+// ready, for instance when all replicas of a deployment are up. The solution manager calls it after Apply
+// until all components are healthy or the health check timeout is reached.
+type IHealthCheckable interface {
+	// check the health of the updated components in a step. Components that aren't healthy are reported with
+	// the HealthCheckFailed state and a message explaining why
+	CheckHealth(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep) (map[string]model.ComponentResultSpec, error)
+}
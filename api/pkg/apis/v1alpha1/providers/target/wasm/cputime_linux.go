@@ -0,0 +1,37 @@
+//go:build linux
+
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package wasm
+
+import (
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const cpuTimeSupported = true
+
+// threadCPUClock reads the CPU time consumed by one OS thread
+type threadCPUClock struct {
+	clockID int32
+}
+
+// newThreadCPUClock returns the CPU clock of the calling thread, which must be locked to its goroutine
+func newThreadCPUClock() (threadCPUClock, error) {
+	// MAKE_THREAD_CPUCLOCK(tid, CPUCLOCK_SCHED) of the Linux clock ABI
+	return threadCPUClock{clockID: int32(^syscall.Gettid()<<3 | 6)}, nil
+}
+
+func (c threadCPUClock) elapsed() (time.Duration, error) {
+	var ts unix.Timespec
+	if err := unix.ClockGettime(c.clockID, &ts); err != nil {
+		return 0, err
+	}
+	return time.Duration(ts.Nano()), nil
+}
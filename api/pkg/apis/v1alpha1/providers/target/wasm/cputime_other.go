@@ -0,0 +1,26 @@
+//go:build !linux
+
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package wasm
+
+import (
+	"errors"
+	"time"
+)
+
+const cpuTimeSupported = false
+
+type threadCPUClock struct{}
+
+func newThreadCPUClock() (threadCPUClock, error) {
+	return threadCPUClock{}, errors.New("thread CPU time isn't supported on this platform")
+}
+
+func (c threadCPUClock) elapsed() (time.Duration, error) {
+	return 0, errors.New("thread CPU time isn't supported on this platform")
+}
@@ -0,0 +1,555 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+const (
+	loggerName = "providers.target.wasm"
+
+	WasmModule       = "wasm.module"
+	WasmDigest       = "wasm.digest"
+	WasmArgs         = "wasm.args"
+	WasmMounts       = "wasm.mounts"
+	WasmMemoryLimit  = "wasm.memoryLimit"
+	WasmCPUTimeLimit = "wasm.cpuTimeLimit"
+	WasmRestarts     = "wasm.restarts"
+
+	wasmPageSize = 64 * 1024
+	// maxMemoryLimit is the memory limit in MiB of the 65536 pages a module can address at most
+	maxMemoryLimit    = 4096
+	maxRestartBackoff = 30 * time.Second
+	// downloadTimeout and maxModuleSize bound the download of modules from http(s) locations
+	downloadTimeout = 2 * time.Minute
+	maxModuleSize   = 256 * 1024 * 1024
+	// cpuTimePollInterval is how often the CPU time of a module with a CPU time limit is checked
+	cpuTimePollInterval = 5 * time.Millisecond
+)
+
+var errCPUTimeLimitExceeded = errors.New("module exceeded its CPU time limit")
+
+var httpClient = &http.Client{Timeout: downloadTimeout}
+
+var sLog = logger.NewLogger(loggerName)
+
+// modules keeps the supervised module instances of all provider instances. Target providers
+// are re-created for every reconcile, so running modules are tracked at the package level.
+var (
+	modules map[string]*moduleInstance
+	mLock   sync.Mutex
+)
+
+type WasmTargetProviderConfig struct {
+	Name string `json:"name"`
+	// RestartBackoff is the initial delay before a failed module is restarted. It doubles on
+	// each consecutive failure, up to 30 seconds.
+	RestartBackoff string `json:"restartBackoff,omitempty"`
+}
+
+type WasmTargetProvider struct {
+	Config  WasmTargetProviderConfig
+	Context *contexts.ManagerContext
+	backoff time.Duration
+}
+
+type moduleInstance struct {
+	Component model.ComponentSpec
+	Digest    string
+	Restarts  int
+	LastError string
+	// Exited is set when the module completed or was stopped for exceeding its CPU time limit
+	Exited bool
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func WasmTargetProviderConfigFromMap(properties map[string]string) (WasmTargetProviderConfig, error) {
+	ret := WasmTargetProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = v
+	}
+	if v, ok := properties["restartBackoff"]; ok {
+		ret.RestartBackoff = v
+	}
+	return ret, nil
+}
+func (w *WasmTargetProvider) InitWithMap(properties map[string]string) error {
+	config, err := WasmTargetProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return w.Init(config)
+}
+func (w *WasmTargetProvider) SetContext(ctx *contexts.ManagerContext) {
+	w.Context = ctx
+}
+
+func (w *WasmTargetProvider) Init(config providers.IProviderConfig) error {
+	_, span := observability.StartSpan("Wasm Target Provider", context.TODO(), &map[string]string{
+		"method": "Init",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Info("  P (Wasm Target): Init()")
+
+	wasmConfig, err := toWasmTargetProviderConfig(config)
+	if err != nil {
+		sLog.Errorf("  P (Wasm Target): expected WasmTargetProviderConfig: %+v", err)
+		return err
+	}
+	w.Config = wasmConfig
+
+	w.backoff = time.Second
+	if w.Config.RestartBackoff != "" {
+		w.backoff, err = time.ParseDuration(w.Config.RestartBackoff)
+		if err != nil {
+			sLog.Errorf("  P (Wasm Target): invalid restartBackoff: %+v", err)
+			err = v1alpha2.NewCOAError(err, "invalid restartBackoff", v1alpha2.BadConfig)
+			return err
+		}
+	}
+
+	mLock.Lock()
+	defer mLock.Unlock()
+	if modules == nil {
+		modules = make(map[string]*moduleInstance)
+	}
+	return nil
+}
+func toWasmTargetProviderConfig(config providers.IProviderConfig) (WasmTargetProviderConfig, error) {
+	ret := WasmTargetProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+// moduleKey identifies the module of a component of an instance
+func (w *WasmTargetProvider) moduleKey(deployment model.DeploymentSpec, name string) string {
+	return w.Config.Name + "/" + deployment.Instance.ObjectMeta.Namespace + "/" + deployment.Instance.ObjectMeta.Name + "/" + name
+}
+
+func (w *WasmTargetProvider) Get(ctx context.Context, deployment model.DeploymentSpec, references []model.ComponentStep) ([]model.ComponentSpec, error) {
+	_, span := observability.StartSpan("Wasm Target Provider", ctx, &map[string]string{
+		"method": "Get",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (Wasm Target): getting artifacts: %s - %s, traceId: %s", deployment.Instance.Spec.Scope, deployment.Instance.ObjectMeta.Name, span.SpanContext().TraceID().String())
+
+	mLock.Lock()
+	defer mLock.Unlock()
+
+	ret := make([]model.ComponentSpec, 0)
+	for _, reference := range references {
+		instance, ok := modules[w.moduleKey(deployment, reference.Component.Name)]
+		if !ok || instance.Exited {
+			// exited modules aren't deployed anymore, so the next reconcile runs them again
+			continue
+		}
+		component := model.ComponentSpec{
+			Name:       instance.Component.Name,
+			Type:       instance.Component.Type,
+			Properties: make(map[string]interface{}),
+		}
+		for k, v := range instance.Component.Properties {
+			component.Properties[k] = v
+		}
+		component.Properties[WasmDigest] = instance.Digest
+		component.Properties[WasmRestarts] = strconv.Itoa(instance.Restarts)
+		ret = append(ret, component)
+	}
+	return ret, nil
+}
+
+func (w *WasmTargetProvider) Apply(ctx context.Context, deployment model.DeploymentSpec, step model.DeploymentStep, isDryRun bool) (map[string]model.ComponentResultSpec, error) {
+	ctx, span := observability.StartSpan("Wasm Target Provider", ctx, &map[string]string{
+		"method": "Apply",
+	})
+	var err error = nil
+	defer observ_utils.CloseSpanWithError(span, &err)
+
+	sLog.Infof("  P (Wasm Target): applying artifacts: %s - %s, traceId: %s", deployment.Instance.Spec.Scope, deployment.Instance.ObjectMeta.Name, span.SpanContext().TraceID().String())
+
+	injections := &model.ValueInjections{
+		InstanceId: deployment.Instance.ObjectMeta.Name,
+		SolutionId: deployment.Instance.Spec.Solution,
+		TargetId:   deployment.ActiveTarget,
+	}
+
+	components := step.GetComponents()
+	err = w.GetValidationRule(ctx).Validate(components)
+	if err != nil {
+		sLog.Errorf("  P (Wasm Target): failed to validate components: %+v, traceId: %s", err, span.SpanContext().TraceID().String())
+		return nil, err
+	}
+	if isDryRun {
+		err = nil
+		return nil, nil
+	}
+
+	ret := step.PrepareResultMap()
+	for _, component := range step.Components {
+		if component.Action == model.ComponentUpdate {
+			var spec moduleSpec
+			spec, err = readModuleSpec(component.Component, injections)
+			if err == nil {
+				err = w.startModule(w.moduleKey(deployment, component.Component.Name), component.Component, spec)
+			}
+			if err != nil {
+				ret[component.Component.Name] = model.ComponentResultSpec{
+					Status:  v1alpha2.UpdateFailed,
+					Message: err.Error(),
+				}
+				sLog.Errorf("  P (Wasm Target): failed to start module %s: %+v, traceId: %s", component.Component.Name, err, span.SpanContext().TraceID().String())
+				return ret, err
+			}
+			ret[component.Component.Name] = model.ComponentResultSpec{
+				Status:  v1alpha2.Updated,
+				Message: "",
+			}
+		} else {
+			w.stopModule(w.moduleKey(deployment, component.Component.Name))
+			ret[component.Component.Name] = model.ComponentResultSpec{
+				Status:  v1alpha2.Deleted,
+				Message: "",
+			}
+		}
+	}
+	return ret, nil
+}
+
+func (*WasmTargetProvider) GetValidationRule(ctx context.Context) model.ValidationRule {
+	return model.ValidationRule{
+		AllowSidecar: false,
+		ComponentValidationRule: model.ComponentValidationRule{
+			RequiredProperties:    []string{WasmModule},
+			OptionalProperties:    []string{WasmDigest, WasmArgs, WasmMounts, WasmMemoryLimit, WasmCPUTimeLimit},
+			RequiredComponentType: "",
+			RequiredMetadata:      []string{},
+			OptionalMetadata:      []string{},
+			ChangeDetectionProperties: []model.PropertyDesc{
+				{Name: WasmModule, IgnoreCase: false, SkipIfMissing: false},
+				{Name: WasmDigest, IgnoreCase: true, SkipIfMissing: true},
+				{Name: WasmArgs, IgnoreCase: false, SkipIfMissing: true},
+				{Name: WasmMounts, IgnoreCase: false, SkipIfMissing: true},
+				{Name: WasmMemoryLimit, IgnoreCase: false, SkipIfMissing: true},
+				{Name: WasmCPUTimeLimit, IgnoreCase: false, SkipIfMissing: true},
+				{Name: "env.*", IgnoreCase: false, SkipIfMissing: true},
+			},
+		},
+	}
+}
+
+// moduleMount is a host directory preopened for a module
+type moduleMount struct {
+	HostPath  string `json:"hostPath"`
+	GuestPath string `json:"guestPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+type moduleSpec struct {
+	Module       string
+	Digest       string
+	Args         []string
+	Env          map[string]string
+	Mounts       []moduleMount
+	MemoryPages  uint32
+	CPUTimeLimit time.Duration
+}
+
+func readModuleSpec(component model.ComponentSpec, injections *model.ValueInjections) (moduleSpec, error) {
+	ret := moduleSpec{
+		Module: model.ReadPropertyCompat(component.Properties, WasmModule, injections),
+		Digest: strings.ToLower(model.ReadPropertyCompat(component.Properties, WasmDigest, injections)),
+		Env:    make(map[string]string),
+	}
+	if args := model.ReadPropertyCompat(component.Properties, WasmArgs, injections); args != "" {
+		if err := json.Unmarshal([]byte(args), &ret.Args); err != nil {
+			return ret, v1alpha2.NewCOAError(err, fmt.Sprintf("%s must be a JSON array of strings", WasmArgs), v1alpha2.BadRequest)
+		}
+	}
+	if mounts, ok := component.Properties[WasmMounts]; ok {
+		// mounts can be given as a JSON string or as a list of objects
+		data, ok := mounts.(string)
+		if !ok {
+			jData, _ := json.Marshal(mounts)
+			data = string(jData)
+		}
+		if err := json.Unmarshal([]byte(model.ResolveString(data, injections)), &ret.Mounts); err != nil {
+			return ret, v1alpha2.NewCOAError(err, fmt.Sprintf("%s must be an array of {hostPath, guestPath, readOnly} objects", WasmMounts), v1alpha2.BadRequest)
+		}
+		for _, mount := range ret.Mounts {
+			if mount.HostPath == "" || mount.GuestPath == "" {
+				return ret, v1alpha2.NewCOAError(nil, fmt.Sprintf("%s entries must have a hostPath and a guestPath", WasmMounts), v1alpha2.BadRequest)
+			}
+		}
+	}
+	if limit := model.ReadPropertyCompat(component.Properties, WasmMemoryLimit, injections); limit != "" {
+		mb, err := strconv.ParseUint(limit, 10, 32)
+		if err != nil || mb == 0 || mb > maxMemoryLimit {
+			return ret, v1alpha2.NewCOAError(err, fmt.Sprintf("%s must be a number of MiB between 1 and %d", WasmMemoryLimit, maxMemoryLimit), v1alpha2.BadRequest)
+		}
+		ret.MemoryPages = uint32(mb * 1024 * 1024 / wasmPageSize)
+	}
+	if limit := model.ReadPropertyCompat(component.Properties, WasmCPUTimeLimit, injections); limit != "" {
+		duration, err := time.ParseDuration(limit)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, fmt.Sprintf("%s must be a duration", WasmCPUTimeLimit), v1alpha2.BadRequest)
+		}
+		if !cpuTimeSupported {
+			return ret, v1alpha2.NewCOAError(nil, fmt.Sprintf("%s isn't supported on this platform", WasmCPUTimeLimit), v1alpha2.BadRequest)
+		}
+		ret.CPUTimeLimit = duration
+	}
+	for k := range component.Properties {
+		if strings.HasPrefix(k, "env.") {
+			ret.Env[strings.TrimPrefix(k, "env.")] = model.ReadPropertyCompat(component.Properties, k, injections)
+		}
+	}
+	return ret, nil
+}
+
+func fetchModule(location string) ([]byte, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		resp, err := httpClient.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to download module %s: %s", location, resp.Status)
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxModuleSize+1))
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > maxModuleSize {
+			return nil, fmt.Errorf("module %s is larger than %d bytes", location, maxModuleSize)
+		}
+		return data, nil
+	}
+	return os.ReadFile(strings.TrimPrefix(location, "file://"))
+}
+
+func verifyDigest(data []byte, expected string) (string, error) {
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	if expected != "" && expected != digest && "sha256:"+expected != digest {
+		return digest, v1alpha2.NewCOAError(nil, fmt.Sprintf("module digest mismatch, expected %s but got %s", expected, digest), v1alpha2.BadRequest)
+	}
+	return digest, nil
+}
+
+func (w *WasmTargetProvider) startModule(key string, component model.ComponentSpec, spec moduleSpec) error {
+	data, err := fetchModule(spec.Module)
+	if err != nil {
+		return err
+	}
+	digest, err := verifyDigest(data, spec.Digest)
+	if err != nil {
+		return err
+	}
+
+	// compile once up front so that invalid modules are reported by Apply instead of the supervisor
+	runtimeConfig := wazero.NewRuntimeConfig().WithCloseOnContextDone(true)
+	if spec.MemoryPages > 0 {
+		runtimeConfig = runtimeConfig.WithMemoryLimitPages(spec.MemoryPages)
+	}
+	probe := wazero.NewRuntimeWithConfig(context.Background(), runtimeConfig)
+	_, err = probe.CompileModule(context.Background(), data)
+	probe.Close(context.Background())
+	if err != nil {
+		return v1alpha2.NewCOAError(err, "failed to compile module", v1alpha2.BadRequest)
+	}
+
+	w.stopModule(key)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	instance := &moduleInstance{
+		Component: component,
+		Digest:    digest,
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+	mLock.Lock()
+	modules[key] = instance
+	mLock.Unlock()
+
+	go w.supervise(ctx, instance, runtimeConfig, data, spec)
+	return nil
+}
+
+func (w *WasmTargetProvider) stopModule(key string) {
+	mLock.Lock()
+	instance, ok := modules[key]
+	if ok {
+		delete(modules, key)
+	}
+	mLock.Unlock()
+	if ok {
+		instance.cancel()
+		<-instance.done
+	}
+}
+
+// supervise runs the module until it is stopped, restarting it with an exponential backoff
+// whenever it fails. A module that exits with code 0 is considered complete, and a module that
+// exceeds its CPU time limit is stopped, neither is restarted.
+func (w *WasmTargetProvider) supervise(ctx context.Context, instance *moduleInstance, runtimeConfig wazero.RuntimeConfig, data []byte, spec moduleSpec) {
+	defer close(instance.done)
+	backoff := w.backoff
+	for {
+		err := runModule(ctx, runtimeConfig, data, instance.Component.Name, spec)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil || errors.Is(err, errCPUTimeLimitExceeded) {
+			mLock.Lock()
+			instance.Exited = true
+			if err != nil {
+				instance.LastError = err.Error()
+			}
+			mLock.Unlock()
+			if err != nil {
+				sLog.Errorf("  P (Wasm Target): module %s stopped: %+v", instance.Component.Name, err)
+			} else {
+				sLog.Infof("  P (Wasm Target): module %s completed", instance.Component.Name)
+			}
+			return
+		}
+		mLock.Lock()
+		instance.Restarts++
+		instance.LastError = err.Error()
+		mLock.Unlock()
+		sLog.Errorf("  P (Wasm Target): module %s failed, restarting in %s: %+v", instance.Component.Name, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > maxRestartBackoff {
+			backoff = maxRestartBackoff
+		}
+	}
+}
+
+func runModule(ctx context.Context, runtimeConfig wazero.RuntimeConfig, data []byte, name string, spec moduleSpec) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	wasmRuntime := wazero.NewRuntimeWithConfig(ctx, runtimeConfig)
+	defer wasmRuntime.Close(context.Background())
+
+	wasi_snapshot_preview1.MustInstantiate(ctx, wasmRuntime)
+
+	compiled, err := wasmRuntime.CompileModule(ctx, data)
+	if err != nil {
+		return err
+	}
+
+	fsConfig := wazero.NewFSConfig()
+	for _, mount := range spec.Mounts {
+		if mount.ReadOnly {
+			fsConfig = fsConfig.WithReadOnlyDirMount(mount.HostPath, mount.GuestPath)
+		} else {
+			fsConfig = fsConfig.WithDirMount(mount.HostPath, mount.GuestPath)
+		}
+	}
+
+	moduleConfig := wazero.NewModuleConfig().
+		WithName(name).
+		WithArgs(append([]string{name}, spec.Args...)...).
+		WithFSConfig(fsConfig).
+		WithStdout(os.Stdout).
+		WithStderr(os.Stderr).
+		WithSysWalltime().
+		WithSysNanotime().
+		WithSysNanosleep()
+	for k, v := range spec.Env {
+		moduleConfig = moduleConfig.WithEnv(k, v)
+	}
+
+	var exceeded atomic.Bool
+	if spec.CPUTimeLimit > 0 {
+		// the module runs on this goroutine, so locking it to its thread lets the thread's CPU clock
+		// measure the CPU time of the module
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		clock, err := newThreadCPUClock()
+		if err != nil {
+			return err
+		}
+		start, err := clock.elapsed()
+		if err != nil {
+			return err
+		}
+		go watchCPUTime(ctx, clock, start+spec.CPUTimeLimit, func() {
+			exceeded.Store(true)
+			cancel()
+		})
+	}
+
+	_, err = wasmRuntime.InstantiateModule(ctx, compiled, moduleConfig)
+	if exceeded.Load() {
+		return errCPUTimeLimitExceeded
+	}
+	return err
+}
+
+// watchCPUTime calls exceed once the clock passes the deadline, unless the context is done first
+func watchCPUTime(ctx context.Context, clock threadCPUClock, deadline time.Duration, exceed func()) {
+	ticker := time.NewTicker(cpuTimePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now, err := clock.elapsed()
+			if err != nil {
+				sLog.Errorf("  P (Wasm Target): failed to read module CPU time: %+v", err)
+			}
+			if err != nil || now > deadline {
+				exceed()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,418 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+// minimal modules exporting memory and a _start function with the given body
+var (
+	header      = []byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00, 0x01, 0x04, 0x01, 0x60, 0x00, 0x00, 0x03, 0x02, 0x01, 0x00, 0x05, 0x03, 0x01, 0x00, 0x01, 0x07, 0x13, 0x02, 0x06, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x00, 0x00, 0x06, 0x6d, 0x65, 0x6d, 0x6f, 0x72, 0x79, 0x02, 0x00}
+	loopForever = append(append([]byte{}, header...), 0x0a, 0x09, 0x01, 0x07, 0x00, 0x03, 0x40, 0x0c, 0x00, 0x0b, 0x0b)
+	trap        = append(append([]byte{}, header...), 0x0a, 0x05, 0x01, 0x03, 0x00, 0x00, 0x0b)
+	complete    = append(append([]byte{}, header...), 0x0a, 0x04, 0x01, 0x02, 0x00, 0x0b)
+)
+
+func writeModule(t *testing.T, data []byte) (string, string) {
+	path := filepath.Join(t.TempDir(), "module.wasm")
+	err := os.WriteFile(path, data, 0644)
+	assert.Nil(t, err)
+	sum := sha256.Sum256(data)
+	return path, "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func testDeployment() model.DeploymentSpec {
+	return testInstanceDeployment("instance")
+}
+
+func testInstanceDeployment(name string) model.DeploymentSpec {
+	return model.DeploymentSpec{
+		Instance: model.InstanceState{
+			ObjectMeta: model.ObjectMeta{
+				Name: name,
+			},
+			Spec: &model.InstanceSpec{
+				Scope: "default",
+			},
+		},
+	}
+}
+
+func TestInitWithMap(t *testing.T) {
+	provider := WasmTargetProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"name":           "wasm",
+		"restartBackoff": "10ms",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Millisecond, provider.backoff)
+}
+
+func TestInitWithBadBackoff(t *testing.T) {
+	provider := WasmTargetProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"restartBackoff": "soon",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestApplyGetDelete(t *testing.T) {
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{Name: "apply"})
+	assert.Nil(t, err)
+
+	path, digest := writeModule(t, loopForever)
+	component := model.ComponentSpec{
+		Name: "looper",
+		Properties: map[string]interface{}{
+			WasmModule:      path,
+			WasmDigest:      digest,
+			WasmMemoryLimit: "1",
+			WasmArgs:        "[\"--verbose\"]",
+			"env.GREETING":  "hello",
+		},
+	}
+	deployment := testDeployment()
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action:    model.ComponentUpdate,
+				Component: component,
+			},
+		},
+	}
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+
+	components, err := provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+	assert.Equal(t, digest, components[0].Properties[WasmDigest])
+	assert.False(t, provider.GetValidationRule(context.Background()).IsComponentChanged(components[0], component))
+
+	step.Components[0].Action = model.ComponentDelete
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+
+	components, err = provider.Get(context.Background(), deployment, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+}
+
+func TestApplyDigestMismatch(t *testing.T) {
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{Name: "mismatch"})
+	assert.Nil(t, err)
+
+	path, _ := writeModule(t, loopForever)
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: model.ComponentUpdate,
+				Component: model.ComponentSpec{
+					Name: "looper",
+					Properties: map[string]interface{}{
+						WasmModule: path,
+						WasmDigest: "sha256:0000",
+					},
+				},
+			},
+		},
+	}
+	_, err = provider.Apply(context.Background(), testDeployment(), step, false)
+	assert.NotNil(t, err)
+}
+
+func TestApplyInvalidModule(t *testing.T) {
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{Name: "invalid"})
+	assert.Nil(t, err)
+
+	path, _ := writeModule(t, []byte("not a module"))
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: model.ComponentUpdate,
+				Component: model.ComponentSpec{
+					Name: "broken",
+					Properties: map[string]interface{}{
+						WasmModule: path,
+					},
+				},
+			},
+		},
+	}
+	_, err = provider.Apply(context.Background(), testDeployment(), step, false)
+	assert.NotNil(t, err)
+}
+
+func TestRestartOnFailure(t *testing.T) {
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{Name: "restart", RestartBackoff: "10ms"})
+	assert.Nil(t, err)
+
+	path, _ := writeModule(t, trap)
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: model.ComponentUpdate,
+				Component: model.ComponentSpec{
+					Name: "trapper",
+					Properties: map[string]interface{}{
+						WasmModule: path,
+					},
+				},
+			},
+		},
+	}
+	deployment := testDeployment()
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		components, err := provider.Get(context.Background(), deployment, step.Components)
+		return err == nil && len(components) == 1 && components[0].Properties[WasmRestarts] != "0"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	step.Components[0].Action = model.ComponentDelete
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+}
+
+func TestCPUTimeLimit(t *testing.T) {
+	if !cpuTimeSupported {
+		t.Skip("thread CPU time isn't supported on this platform")
+	}
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{Name: "cpu", RestartBackoff: "10ms"})
+	assert.Nil(t, err)
+
+	path, _ := writeModule(t, loopForever)
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: model.ComponentUpdate,
+				Component: model.ComponentSpec{
+					Name: "looper",
+					Properties: map[string]interface{}{
+						WasmModule:       path,
+						WasmCPUTimeLimit: "20ms",
+					},
+				},
+			},
+		},
+	}
+	deployment := testDeployment()
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+
+	// a module that exceeds its CPU time limit is stopped and isn't reported as deployed
+	assert.Eventually(t, func() bool {
+		components, err := provider.Get(context.Background(), deployment, step.Components)
+		return err == nil && len(components) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	mLock.Lock()
+	instance := modules[provider.moduleKey(deployment, "looper")]
+	assert.Equal(t, 0, instance.Restarts)
+	assert.Equal(t, errCPUTimeLimitExceeded.Error(), instance.LastError)
+	mLock.Unlock()
+
+	step.Components[0].Action = model.ComponentDelete
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+}
+
+func TestCompletedModule(t *testing.T) {
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{Name: "complete"})
+	assert.Nil(t, err)
+
+	path, _ := writeModule(t, complete)
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: model.ComponentUpdate,
+				Component: model.ComponentSpec{
+					Name: "job",
+					Properties: map[string]interface{}{
+						WasmModule: path,
+					},
+				},
+			},
+		},
+	}
+	deployment := testDeployment()
+	_, err = provider.Apply(context.Background(), deployment, step, false)
+	assert.Nil(t, err)
+
+	assert.Eventually(t, func() bool {
+		components, err := provider.Get(context.Background(), deployment, step.Components)
+		return err == nil && len(components) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestModulesPerInstance(t *testing.T) {
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{Name: "instances"})
+	assert.Nil(t, err)
+
+	path, _ := writeModule(t, loopForever)
+	step := model.DeploymentStep{
+		Components: []model.ComponentStep{
+			{
+				Action: model.ComponentUpdate,
+				Component: model.ComponentSpec{
+					Name: "looper",
+					Properties: map[string]interface{}{
+						WasmModule: path,
+					},
+				},
+			},
+		},
+	}
+	deployment1 := testInstanceDeployment("instance1")
+	deployment2 := testInstanceDeployment("instance2")
+	_, err = provider.Apply(context.Background(), deployment1, step, false)
+	assert.Nil(t, err)
+	_, err = provider.Apply(context.Background(), deployment2, step, false)
+	assert.Nil(t, err)
+
+	// removing the component of one instance keeps the module of the other one
+	step.Components[0].Action = model.ComponentDelete
+	_, err = provider.Apply(context.Background(), deployment1, step, false)
+	assert.Nil(t, err)
+	components, err := provider.Get(context.Background(), deployment1, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(components))
+	components, err = provider.Get(context.Background(), deployment2, step.Components)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(components))
+
+	_, err = provider.Apply(context.Background(), deployment2, step, false)
+	assert.Nil(t, err)
+}
+
+func TestReadModuleSpecMounts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mounts   interface{}
+		expected []moduleMount
+		invalid  bool
+	}{
+		{
+			name:     "json string",
+			mounts:   `[{"hostPath": "C:\\data", "guestPath": "/data", "readOnly": true}]`,
+			expected: []moduleMount{{HostPath: "C:\\data", GuestPath: "/data", ReadOnly: true}},
+		},
+		{
+			name:     "list of objects",
+			mounts:   []interface{}{map[string]interface{}{"hostPath": "/var/lib/app:v2", "guestPath": "/app"}},
+			expected: []moduleMount{{HostPath: "/var/lib/app:v2", GuestPath: "/app"}},
+		},
+		{
+			name:    "legacy string",
+			mounts:  `["/data:/data:ro"]`,
+			invalid: true,
+		},
+		{
+			name:    "missing guest path",
+			mounts:  `[{"hostPath": "/data"}]`,
+			invalid: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := readModuleSpec(model.ComponentSpec{
+				Properties: map[string]interface{}{
+					WasmModule: "module.wasm",
+					WasmMounts: tc.mounts,
+				},
+			}, nil)
+			if tc.invalid {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expected, spec.Mounts)
+		})
+	}
+}
+
+func TestReadModuleSpecMemoryLimit(t *testing.T) {
+	testCases := []struct {
+		name    string
+		limit   string
+		pages   uint32
+		invalid bool
+	}{
+		{name: "smallest", limit: "1", pages: 16},
+		{name: "largest", limit: "4096", pages: 65536},
+		{name: "too large", limit: "4097", invalid: true},
+		{name: "overflows pages", limit: "4294967295", invalid: true},
+		{name: "zero", limit: "0", invalid: true},
+		{name: "not a number", limit: "1GiB", invalid: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, err := readModuleSpec(model.ComponentSpec{
+				Properties: map[string]interface{}{
+					WasmModule:      "module.wasm",
+					WasmMemoryLimit: tc.limit,
+				},
+			}, nil)
+			if tc.invalid {
+				assert.NotNil(t, err)
+				coaErr, ok := err.(v1alpha2.COAError)
+				assert.True(t, ok)
+				assert.Equal(t, v1alpha2.BadRequest, coaErr.State)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.pages, spec.MemoryPages)
+		})
+	}
+}
+
+func TestFetchModuleFromURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/module.wasm" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(complete)
+	}))
+	defer ts.Close()
+
+	data, err := fetchModule(ts.URL + "/module.wasm")
+	assert.Nil(t, err)
+	assert.Equal(t, complete, data)
+
+	_, err = fetchModule(ts.URL + "/missing.wasm")
+	assert.NotNil(t, err)
+}
+
+func TestConformanceSuite(t *testing.T) {
+	provider := &WasmTargetProvider{}
+	err := provider.Init(WasmTargetProviderConfig{})
+	assert.Nil(t, err)
+	conformance.ConformanceSuite(t, provider)
+}
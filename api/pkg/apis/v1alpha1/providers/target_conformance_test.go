@@ -0,0 +1,301 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package providers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/configmap"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/conformance"
+	targethttp "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/http"
+	tgtmock "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mock"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/mqtt"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/script"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/target/staging"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt/mqtttest"
+	gmqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// conformanceEntry describes a provider configuration the conformance runner is pointed at. Entries
+// can be supplied in a JSON file through the TEST_CONFORMANCE_CONFIG environment variable.
+type conformanceEntry struct {
+	Name    string                 `json:"name"`
+	Type    string                 `json:"type"`
+	Config  map[string]interface{} `json:"config"`
+	Options conformance.Options    `json:"options"`
+}
+
+// runConformance runs the conformance suite against a provider and publishes the report to the
+// folder set by the TEST_CONFORMANCE_REPORT_DIR environment variable, if any.
+func runConformance(t *testing.T, name string, provider target.ITargetProvider, options conformance.Options) {
+	report := conformance.RunSuite(t, name, provider, options)
+	for _, check := range report.Checks {
+		t.Logf("%s: Level=%s/%s %s", name, check.Level, check.Name, check.Result)
+	}
+	if folder := os.Getenv("TEST_CONFORMANCE_REPORT_DIR"); folder != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		err := os.WriteFile(filepath.Join(folder, name+".json"), data, 0644)
+		assert.Nil(t, err)
+	}
+}
+
+func TestTargetProviderConformanceFromConfig(t *testing.T) {
+	configFile := os.Getenv("TEST_CONFORMANCE_CONFIG")
+	if configFile == "" {
+		t.Skip("Skipping because TEST_CONFORMANCE_CONFIG environment variable is not set")
+	}
+	data, err := os.ReadFile(configFile)
+	assert.Nil(t, err)
+	var entries []conformanceEntry
+	err = json.Unmarshal(data, &entries)
+	assert.Nil(t, err)
+
+	factory := SymphonyProviderFactory{}
+	for _, entry := range entries {
+		provider, err := factory.CreateProvider(entry.Type, entry.Config)
+		assert.Nil(t, err)
+		targetProvider, ok := provider.(target.ITargetProvider)
+		assert.True(t, ok, "%s is not a target provider", entry.Type)
+		if ok {
+			runConformance(t, entry.Name, targetProvider, entry.Options)
+		}
+	}
+}
+
+func TestMockTargetProviderConformance(t *testing.T) {
+	factory := SymphonyProviderFactory{}
+	provider, err := factory.CreateProvider("providers.target.mock", tgtmock.MockTargetProviderConfig{ID: "conformance", StrictLifecycle: true})
+	assert.Nil(t, err)
+	runConformance(t, "mock", provider.(target.ITargetProvider), conformance.Options{
+		Capabilities: conformance.Capabilities{Stateful: true},
+	})
+}
+
+const (
+	conformanceApplyScript = `#!/bin/bash
+state="$(dirname "$0")/state.json"
+[ -f "$state" ] || echo '[]' > "$state"
+jq -s '(.[1] | map(.name)) as $names | [.[0][] | select(.name as $n | $names | index($n) | not)] + .[1]' "$state" "$2" > "$state.tmp" && mv "$state.tmp" "$state"
+jq 'map({key: .name, value: {status: 8004, message: ""}}) | from_entries' "$2" > ${1%.*}-output.${1##*.}
+`
+	conformanceRemoveScript = `#!/bin/bash
+state="$(dirname "$0")/state.json"
+[ -f "$state" ] || echo '[]' > "$state"
+jq -s '(.[1] | map(.name)) as $names | [.[0][] | select(.name as $n | $names | index($n) | not)]' "$state" "$2" > "$state.tmp" && mv "$state.tmp" "$state"
+jq 'map({key: .name, value: {status: 8005, message: ""}}) | from_entries' "$2" > ${1%.*}-output.${1##*.}
+`
+	conformanceGetScript = `#!/bin/bash
+state="$(dirname "$0")/state.json"
+[ -f "$state" ] || echo '[]' > "$state"
+jq -s '(.[1] | map(.component.name)) as $names | [.[0][] | select(.name as $n | $names | index($n))]' "$state" "$2" > ${1%.*}-output.${1##*.}
+`
+)
+
+func TestScriptTargetProviderConformance(t *testing.T) {
+	if _, err := os.Stat("/usr/bin/jq"); err != nil {
+		t.Skip("Skipping because jq is not installed")
+	}
+	folder := t.TempDir()
+	for name, content := range map[string]string{
+		"apply.sh":  conformanceApplyScript,
+		"remove.sh": conformanceRemoveScript,
+		"get.sh":    conformanceGetScript,
+	} {
+		err := os.WriteFile(filepath.Join(folder, name), []byte(content), 0755)
+		assert.Nil(t, err)
+	}
+
+	factory := SymphonyProviderFactory{}
+	provider, err := factory.CreateProvider("providers.target.script", script.ScriptProviderConfig{
+		ApplyScript:   "apply.sh",
+		RemoveScript:  "remove.sh",
+		GetScript:     "get.sh",
+		ScriptFolder:  folder,
+		StagingFolder: folder,
+		ScriptEngine:  "bash",
+	})
+	assert.Nil(t, err)
+	runConformance(t, "script", provider.(target.ITargetProvider), conformance.Options{
+		Capabilities: conformance.Capabilities{Stateful: true},
+		Observe: func() interface{} {
+			data, _ := os.ReadFile(filepath.Join(folder, "state.json"))
+			return string(data)
+		},
+	})
+}
+
+func TestHttpTargetProviderConformance(t *testing.T) {
+	var lock sync.Mutex
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		lock.Unlock()
+		if r.URL.Path == "/fail" {
+			http.Error(w, "request failed", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	factory := SymphonyProviderFactory{}
+	provider, err := factory.CreateProvider("providers.target.http", targethttp.HttpTargetProviderConfig{Name: "http"})
+	assert.Nil(t, err)
+	runConformance(t, "http", provider.(target.ITargetProvider), conformance.Options{
+		Component: &model.ComponentSpec{
+			Name: "conformance-1",
+			Properties: map[string]interface{}{
+				"http.url": server.URL + "/ok",
+			},
+		},
+		FailingComponent: &model.ComponentSpec{
+			Name: "conformance-2",
+			Properties: map[string]interface{}{
+				"http.url": server.URL + "/fail",
+			},
+		},
+		Observe: func() interface{} {
+			lock.Lock()
+			defer lock.Unlock()
+			return requests
+		},
+	})
+}
+
+func TestMQTTTargetProviderConformance(t *testing.T) {
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{})
+	assert.Nil(t, err)
+	defer broker.Close()
+
+	// a responder that acknowledges every request, standing in for a remote agent
+	opts := gmqtt.NewClientOptions().AddBroker(broker.Address).SetClientID("conformance-responder")
+	client := gmqtt.NewClient(opts)
+	token := client.Connect()
+	token.Wait()
+	assert.Nil(t, token.Error())
+	defer client.Disconnect(100)
+	token = client.Subscribe("conformance-request", 0, func(client gmqtt.Client, msg gmqtt.Message) {
+		var request v1alpha2.COARequest
+		json.Unmarshal(msg.Payload(), &request)
+		body := []byte("[]")
+		if request.Metadata["call-context"] == "TargetProvider-Apply" {
+			body, _ = json.Marshal(model.SummarySpec{})
+		}
+		data, _ := json.Marshal(v1alpha2.COAResponse{
+			State:    v1alpha2.OK,
+			Body:     body,
+			Metadata: request.Metadata,
+		})
+		client.Publish("conformance-response", 0, false, data)
+	})
+	token.Wait()
+	assert.Nil(t, token.Error())
+
+	factory := SymphonyProviderFactory{}
+	provider, err := factory.CreateProvider("providers.target.mqtt", mqtt.MQTTTargetProviderConfig{
+		Name:          "mqtt",
+		BrokerAddress: broker.Address,
+		ClientID:      "conformance",
+		RequestTopic:  "conformance-request",
+		ResponseTopic: "conformance-response",
+	})
+	assert.Nil(t, err)
+	runConformance(t, "mqtt", provider.(target.ITargetProvider), conformance.Options{})
+}
+
+// fakeCatalogAPI serves the subset of the Symphony API used by the staging provider.
+type fakeCatalogAPI struct {
+	lock     sync.Mutex
+	catalogs map[string]string
+}
+
+func (f *fakeCatalogAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if r.URL.Path == "/users/auth" {
+		w.Write([]byte(`{"accessToken":"conformance"}`))
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/catalogs/registry/")
+	switch r.Method {
+	case http.MethodGet:
+		if data, ok := f.catalogs[name]; ok {
+			w.Write([]byte(data))
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	case http.MethodPost:
+		data, _ := io.ReadAll(r.Body)
+		f.catalogs[name] = string(data)
+	}
+}
+
+func (f *fakeCatalogAPI) snapshot() interface{} {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	ret := make(map[string]string, len(f.catalogs))
+	for k, v := range f.catalogs {
+		ret[k] = v
+	}
+	return ret
+}
+
+func TestStagingTargetProviderConformance(t *testing.T) {
+	api := &fakeCatalogAPI{catalogs: make(map[string]string)}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	factory := SymphonyProviderFactory{}
+	provider, err := factory.CreateProvider("providers.target.staging", staging.StagingTargetProviderConfig{Name: "staging", TargetName: "conformance"})
+	assert.Nil(t, err)
+	provider.(*staging.StagingTargetProvider).Context = &contexts.ManagerContext{
+		SiteInfo: v1alpha2.SiteInfo{
+			CurrentSite: v1alpha2.SiteConnection{
+				BaseUrl: server.URL + "/",
+			},
+		},
+	}
+	runConformance(t, "staging", provider.(target.ITargetProvider), conformance.Options{
+		Observe: api.snapshot,
+	})
+}
+
+func TestConfigMapTargetProviderConformance(t *testing.T) {
+	client := fake.NewSimpleClientset()
+	provider := &configmap.ConfigMapTargetProvider{
+		Config: configmap.ConfigMapTargetProviderConfig{Name: "configmap"},
+		Client: client,
+	}
+	runConformance(t, "configmap", provider, conformance.Options{
+		Capabilities: conformance.Capabilities{Stateful: true},
+		Component: &model.ComponentSpec{
+			Name: "conformance-1",
+			Type: "config",
+			Properties: map[string]interface{}{
+				"setting": "value",
+			},
+		},
+		Observe: func() interface{} {
+			return len(client.Fake.Actions())
+		},
+	})
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// probeAttemptTimeout caps a single probe attempt so that a hanging endpoint doesn't use up the whole
+// health check timeout
+const probeAttemptTimeout = 5 * time.Second
+
+// HasProbe returns true if the component declares any probe properties
+func HasProbe(component model.ComponentSpec) bool {
+	for _, key := range []string{model.ProbeHTTP, model.ProbeTCP, model.ProbeCommand} {
+		if probeProperty(component, key) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCommandProbe returns true if the component declares a command probe
+func HasCommandProbe(component model.ComponentSpec) bool {
+	return probeProperty(component, model.ProbeCommand) != ""
+}
+
+// probeProperty reads a probe property as is, as probe commands and URLs must not be interpreted as values
+func probeProperty(component model.ComponentSpec, key string) string {
+	if v, ok := component.Properties[key]; ok && v != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return ""
+}
+
+// ProbeComponent runs the probes declared on a component once and returns an error if any of them fails.
+// An HTTP probe succeeds on a 2xx or 3xx response, a TCP probe succeeds if a connection can be opened to the
+// "host:port" address, and a command probe succeeds if the command exits with 0. Command probes run shell
+// commands on the local host, so they're refused unless allowCommand is set.
+func ProbeComponent(ctx context.Context, component model.ComponentSpec, allowCommand bool) error {
+	ctx, cancel := context.WithTimeout(ctx, probeAttemptTimeout)
+	defer cancel()
+
+	if url := probeProperty(component, model.ProbeHTTP); url != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("invalid HTTP probe '%s'", url), v1alpha2.BadConfig)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("HTTP probe '%s' failed", url), v1alpha2.HealthCheckFailed)
+		}
+		resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("HTTP probe '%s' returned %d", url, resp.StatusCode), v1alpha2.HealthCheckFailed)
+		}
+	}
+	if address := probeProperty(component, model.ProbeTCP); address != "" {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("TCP probe '%s' failed", address), v1alpha2.HealthCheckFailed)
+		}
+		conn.Close()
+	}
+	if command := probeProperty(component, model.ProbeCommand); command != "" {
+		if !allowCommand {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("command probe '%s' isn't allowed", command), v1alpha2.BadConfig)
+		}
+		output, err := exec.CommandContext(ctx, "sh", "-c", command).CombinedOutput()
+		if err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("command probe '%s' failed: %s", command, string(output)), v1alpha2.HealthCheckFailed)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func probeComponent(key string, value string) model.ComponentSpec {
+	return model.ComponentSpec{
+		Name: "com1",
+		Properties: map[string]interface{}{
+			key: value,
+		},
+	}
+}
+
+func TestHasProbe(t *testing.T) {
+	assert.False(t, HasProbe(model.ComponentSpec{Name: "com1"}))
+	assert.True(t, HasProbe(probeComponent(model.ProbeTCP, "localhost:80")))
+}
+
+func TestProbeHTTP(t *testing.T) {
+	healthy := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	err := ProbeComponent(context.Background(), probeComponent(model.ProbeHTTP, server.URL), false)
+	assert.Nil(t, err)
+	healthy = false
+	err = ProbeComponent(context.Background(), probeComponent(model.ProbeHTTP, server.URL), false)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, err.(v1alpha2.COAError).State)
+}
+
+func TestProbeTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	address := listener.Addr().String()
+
+	err = ProbeComponent(context.Background(), probeComponent(model.ProbeTCP, address), false)
+	assert.Nil(t, err)
+	listener.Close()
+	err = ProbeComponent(context.Background(), probeComponent(model.ProbeTCP, address), false)
+	assert.NotNil(t, err)
+}
+
+func TestProbeCommand(t *testing.T) {
+	err := ProbeComponent(context.Background(), probeComponent(model.ProbeCommand, "[ 1 -eq 1 ]"), true)
+	assert.Nil(t, err)
+	err = ProbeComponent(context.Background(), probeComponent(model.ProbeCommand, "exit 1"), true)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.HealthCheckFailed, err.(v1alpha2.COAError).State)
+}
+
+func TestProbeCommandNotAllowed(t *testing.T) {
+	assert.True(t, HasCommandProbe(probeComponent(model.ProbeCommand, "exit 0")))
+	err := ProbeComponent(context.Background(), probeComponent(model.ProbeCommand, "exit 0"), false)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
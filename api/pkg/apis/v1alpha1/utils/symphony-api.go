@@ -7,15 +7,19 @@
 package utils
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/constants"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -31,6 +35,9 @@ var (
 	symphonyAPIAddressBase = os.Getenv(constants.SymphonyAPIUrlEnvName)
 	useSAToken             = os.Getenv(constants.UseServiceAccountTokenEnvName)
 	apiCertPath            = os.Getenv(constants.ApiCertEnvName)
+	// StreamHeartbeatInterval is how often a parent site writes a heartbeat to an idle push channel. A child
+	// site considers a channel that stays silent for three intervals as broken.
+	StreamHeartbeatInterval = 15 * time.Second
 )
 
 type authRequest struct {
@@ -129,6 +136,25 @@ func GetSites(context context.Context, baseUrl string, user string, password str
 
 	return ret, nil
 }
+func GetSite(context context.Context, baseUrl string, site string, user string, password string) (model.SiteState, error) {
+	ret := model.SiteState{}
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return ret, err
+	}
+
+	response, err := callRestAPI(context, baseUrl, "federation/registry/"+url.QueryEscape(site), "GET", nil, token)
+	if err != nil {
+		return ret, err
+	}
+
+	err = json.Unmarshal(response, &ret)
+	if err != nil {
+		return ret, err
+	}
+
+	return ret, nil
+}
 func SyncActivationStatus(context context.Context, baseUrl string, user string, password string, status model.ActivationStatus) error {
 	token, err := auth(context, baseUrl, user, password)
 
@@ -212,6 +238,35 @@ func GetCatalog(context context.Context, baseUrl string, catalog string, user st
 	}
 	return ret, nil
 }
+
+// GetCatalogRevision returns a revision of a catalog, kept by the catalogs manager when revisions are enabled
+func GetCatalogRevision(context context.Context, baseUrl string, catalog string, revision int, user string, password string, namespace string) (model.CatalogRevision, error) {
+	ret := model.CatalogRevision{}
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return ret, err
+	}
+
+	catalogName := catalog
+	if strings.HasPrefix(catalogName, "<") && strings.HasSuffix(catalogName, ">") {
+		catalogName = catalogName[1 : len(catalogName)-1]
+	}
+
+	path := "catalogs/registry/" + url.QueryEscape(catalogName) + "/revisions/" + strconv.Itoa(revision)
+	if namespace != "" {
+		path = path + "?namespace=" + url.QueryEscape(namespace)
+	}
+	response, err := callRestAPI(context, baseUrl, path, "GET", nil, token)
+	if err != nil {
+		return ret, err
+	}
+
+	err = json.Unmarshal(response, &ret)
+	if err != nil {
+		return ret, err
+	}
+	return ret, nil
+}
 func GetCampaign(context context.Context, baseUrl string, campaign string, user string, password string, namespace string) (model.CampaignState, error) {
 	ret := model.CampaignState{}
 	token, err := auth(context, baseUrl, user, password)
@@ -269,6 +324,93 @@ func GetABatchForSite(context context.Context, baseUrl string, site string, user
 	}
 	return ret, nil
 }
+func AckBatchForSite(context context.Context, baseUrl string, site string, leaseId string, user string, password string) error {
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return err
+	}
+
+	_, err = callRestAPI(context, baseUrl, "federation/sync/"+url.QueryEscape(site)+"?lease="+url.QueryEscape(leaseId), "POST", nil, token)
+	return err
+}
+
+// BatchStream is an open push channel of a site
+type BatchStream struct {
+	site   string
+	ctx    context.Context
+	cancel context.CancelFunc
+	body   io.ReadCloser
+	reader *bufio.Reader
+	idle   *time.Timer
+}
+
+// OpenBatchStream opens the push channel of a site, over which the parent site pushes the site's batches as
+// soon as they are queued
+func OpenBatchStream(ctx context.Context, baseUrl string, site string, user string, password string) (*BatchStream, error) {
+	token, err := auth(ctx, baseUrl, user, password)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := http.NewRequestWithContext(ctx, "GET", baseUrl+"federation/stream/"+url.QueryEscape(site)+"?count=10", nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	observ_utils.PropagateSpanContextToHttpRequestHeader(req)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := (&http.Client{}).Do(req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		cancel()
+		return nil, v1alpha2.FromHTTPResponseCode(resp.StatusCode, bodyBytes)
+	}
+	return &BatchStream{
+		site:   site,
+		ctx:    ctx,
+		cancel: cancel,
+		body:   resp.Body,
+		reader: bufio.NewReader(resp.Body),
+		// the parent writes heartbeats to an idle channel, a silent channel is broken
+		idle: time.AfterFunc(3*StreamHeartbeatInterval, cancel),
+	}, nil
+}
+
+// Next blocks until the next batch is pushed. It fails once the channel is broken.
+func (b *BatchStream) Next() (model.SyncPackage, error) {
+	var batch model.SyncPackage
+	for {
+		line, err := b.reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF || b.ctx.Err() != nil {
+				err = v1alpha2.NewCOAError(err, fmt.Sprintf("push channel of site %s is closed", b.site), v1alpha2.InternalError)
+			}
+			return batch, err
+		}
+		b.idle.Reset(3 * StreamHeartbeatInterval)
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		err = json.Unmarshal(line, &batch)
+		return batch, err
+	}
+}
+
+// Close closes the push channel
+func (b *BatchStream) Close() error {
+	b.idle.Stop()
+	b.cancel()
+	return b.body.Close()
+}
 func GetActivation(context context.Context, baseUrl string, activation string, user string, password string) (model.ActivationState, error) {
 	ret := model.ActivationState{}
 	token, err := auth(context, baseUrl, user, password)
@@ -611,7 +753,7 @@ func MatchTargets(instance model.InstanceState, targets []model.TargetState) []m
 }
 
 func CreateSymphonyDeploymentFromTarget(target model.TargetState, namespace string) (model.DeploymentSpec, error) {
-	key := fmt.Sprintf("%s-%s", "target-runtime", target.ObjectMeta.Name)
+	key := constants.TargetRuntimePrefix + target.ObjectMeta.Name
 	scope := target.Spec.Scope
 	if scope == "" {
 		scope = constants.DefaultScope
@@ -776,6 +918,19 @@ func CatalogHook(context context.Context, baseUrl string, user string, password
 	return nil
 }
 
+func CatalogDeleteHook(context context.Context, baseUrl string, user string, password string, name string, namespace string) error {
+	token, err := auth(context, baseUrl, user, password)
+	if err != nil {
+		return err
+	}
+	path := "federation/k8shook?objectType=catalog&delete=true&name=" + url.QueryEscape(name) + "&namespace=" + url.QueryEscape(namespace)
+	_, err = callRestAPI(context, baseUrl, path, "POST", nil, token)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func QueueJob(context context.Context, baseUrl string, user string, password string, id string, namespace string, isDelete bool, isTarget bool) error {
 	token, err := auth(context, baseUrl, user, password)
 	if err != nil {
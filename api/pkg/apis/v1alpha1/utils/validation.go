@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// ValidateObjectName checks the name in the metadata of an object against the name the object is stored under
+func ValidateObjectName(name string, meta model.ObjectMeta) error {
+	if name == "" {
+		return v1alpha2.NewCOAError(nil, "object name is missing", v1alpha2.BadRequest)
+	}
+	if meta.Name != "" && meta.Name != name {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("Name in metadata (%s) does not match name in request (%s)", meta.Name, name), v1alpha2.BadRequest)
+	}
+	return nil
+}
+
+// ValidateComponents checks that all components are named and that no name is used twice
+func ValidateComponents(components []model.ComponentSpec) error {
+	names := make(map[string]bool)
+	for i, component := range components {
+		if component.Name == "" {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("component %d has no name", i), v1alpha2.BadRequest)
+		}
+		if names[component.Name] {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("component %s is defined more than once", component.Name), v1alpha2.BadRequest)
+		}
+		names[component.Name] = true
+	}
+	return nil
+}
+
+// IsValidationError tells if an error returned by a manager is caused by an invalid object rather than by a failure
+// of the manager
+func IsValidationError(err error) bool {
+	if coaErr, ok := err.(v1alpha2.COAError); ok {
+		return coaErr.State == v1alpha2.BadRequest || coaErr.State == v1alpha2.ValidateFailed
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateObjectName(t *testing.T) {
+	testCases := []struct {
+		name    string
+		meta    model.ObjectMeta
+		message string
+	}{
+		{name: "s1", meta: model.ObjectMeta{}},
+		{name: "s1", meta: model.ObjectMeta{Name: "s1"}},
+		{name: "", meta: model.ObjectMeta{Name: "s1"}, message: "object name is missing"},
+		{name: "s1", meta: model.ObjectMeta{Name: "s2"}, message: "Name in metadata (s2) does not match name in request (s1)"},
+	}
+	for _, tc := range testCases {
+		err := ValidateObjectName(tc.name, tc.meta)
+		if tc.message == "" {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), tc.message)
+		assert.True(t, IsValidationError(err))
+	}
+}
+
+func TestValidateComponents(t *testing.T) {
+	testCases := []struct {
+		components []model.ComponentSpec
+		message    string
+	}{
+		{components: nil},
+		{components: []model.ComponentSpec{{Name: "a"}, {Name: "b"}}},
+		{components: []model.ComponentSpec{{Name: "a"}, {}}, message: "component 1 has no name"},
+		{components: []model.ComponentSpec{{Name: "a"}, {Name: "a"}}, message: "component a is defined more than once"},
+	}
+	for _, tc := range testCases {
+		err := ValidateComponents(tc.components)
+		if tc.message == "" {
+			assert.Nil(t, err)
+			continue
+		}
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), tc.message)
+	}
+}
+
+func TestIsValidationError(t *testing.T) {
+	assert.True(t, IsValidationError(v1alpha2.NewCOAError(nil, "invalid", v1alpha2.ValidateFailed)))
+	assert.False(t, IsValidationError(v1alpha2.NewCOAError(nil, "failed", v1alpha2.InternalError)))
+	assert.False(t, IsValidationError(errors.New("failed")))
+}
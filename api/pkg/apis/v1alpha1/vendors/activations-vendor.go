@@ -186,11 +186,14 @@ func (c *ActivationsVendor) onActivations(request v1alpha2.COARequest) v1alpha2.
 			})
 		}
 
+		if isDryRun(request) {
+			return observ_utils.CloseSpanWithCOAResponse(span, dryRunResponse(c.ActivationsManager.ValidateUpsert(ctx, id, activation)))
+		}
 		err = c.ActivationsManager.UpsertState(ctx, id, activation)
 		if err != nil {
 			vLog.Infof("V (Activations Vendor): onActivations failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
 			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
-				State: v1alpha2.InternalError,
+				State: upsertErrorState(err),
 				Body:  []byte(err.Error()),
 			})
 		}
@@ -0,0 +1,800 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	sym_mgr "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/activations"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/campaigns"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/catalogs"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/devices"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/instances"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/models"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/sites"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/skills"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/solution"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/solutions"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/targets"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/users"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+	observ_utils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/valyala/fasthttp"
+)
+
+var bLog = logger.NewLogger("coa.runtime")
+
+const (
+	targetRuntimePrefix = "target-runtime-"
+	// administratorRole is the role required to export and import archives, which hold the users and their roles
+	administratorRole = "administrator"
+)
+
+// BackupVendor exports the objects of the managers it's configured with, and restores them. Objects of kinds
+// without a configured manager are neither exported nor restored.
+type BackupVendor struct {
+	vendors.Vendor
+	SitesManager       *sites.SitesManager
+	UsersManager       *users.UsersManager
+	TargetsManager     *targets.TargetsManager
+	DevicesManager     *devices.DevicesManager
+	ModelsManager      *models.ModelsManager
+	SkillsManager      *skills.SkillsManager
+	SolutionsManager   *solutions.SolutionsManager
+	CatalogsManager    *catalogs.CatalogsManager
+	CampaignsManager   *campaigns.CampaignsManager
+	InstancesManager   *instances.InstancesManager
+	ActivationsManager *activations.ActivationsManager
+	SolutionManager    *solution.SolutionManager
+}
+
+// backupObject is an object of an archive being restored
+type backupObject struct {
+	kind      string
+	name      string
+	namespace string
+	// exists returns true when the object is already stored
+	exists func(ctx context.Context) (bool, error)
+	upsert func(ctx context.Context) error
+}
+
+func (o *BackupVendor) GetInfo() vendors.VendorInfo {
+	return vendors.VendorInfo{
+		Version:  o.Vendor.Version,
+		Name:     "Backup",
+		Producer: "Microsoft",
+	}
+}
+
+// Init looks up the managers of the backup vendor among the singleton managers of the vendors listed before it. The
+// managers are shared with these vendors rather than initialized again, so only their types are configured.
+func (e *BackupVendor) Init(config vendors.VendorConfig, factories []managers.IManagerFactroy, providers map[string]map[string]providers.IProvider, pubsubProvider pubsub.IPubSubProvider) error {
+	managerConfigs := config.Managers
+	config.Managers = nil
+	err := e.Vendor.Init(config, factories, providers, pubsubProvider)
+	if err != nil {
+		return err
+	}
+	for _, m := range managerConfigs {
+		manager := findSingletonManager(factories, m.Type)
+		if manager == nil {
+			return v1alpha2.NewCOAError(nil, fmt.Sprintf("manager '%s' of type '%s' isn't a singleton manager of a vendor listed before the backup vendor", m.Name, m.Type), v1alpha2.BadConfig)
+		}
+		e.Managers = append(e.Managers, manager)
+	}
+	for _, m := range e.Managers {
+		switch c := m.(type) {
+		case *sites.SitesManager:
+			e.SitesManager = c
+		case *users.UsersManager:
+			e.UsersManager = c
+		case *targets.TargetsManager:
+			e.TargetsManager = c
+		case *devices.DevicesManager:
+			e.DevicesManager = c
+		case *models.ModelsManager:
+			e.ModelsManager = c
+		case *skills.SkillsManager:
+			e.SkillsManager = c
+		case *solutions.SolutionsManager:
+			e.SolutionsManager = c
+		case *catalogs.CatalogsManager:
+			e.CatalogsManager = c
+		case *campaigns.CampaignsManager:
+			e.CampaignsManager = c
+		case *instances.InstancesManager:
+			e.InstancesManager = c
+		case *activations.ActivationsManager:
+			e.ActivationsManager = c
+		case *solution.SolutionManager:
+			e.SolutionManager = c
+		}
+	}
+	if len(e.Managers) == 0 {
+		return v1alpha2.NewCOAError(nil, "no managers are supplied to the backup vendor", v1alpha2.MissingConfig)
+	}
+	if e.SolutionsManager != nil && e.CatalogsManager != nil {
+		// imported solutions are checked against their schema catalogs like posted ones
+		e.SolutionsManager.SchemaValidator = e.CatalogsManager
+	}
+	return nil
+}
+
+// findSingletonManager returns the singleton manager of a type created by the factories, if there's one
+func findSingletonManager(factories []managers.IManagerFactroy, managerType string) managers.IManager {
+	for _, factory := range factories {
+		if f, ok := factory.(*sym_mgr.SymphonyManagerFactory); ok {
+			if manager, ok := f.SingletonsCache[managerType]; ok {
+				return manager
+			}
+		}
+	}
+	return nil
+}
+
+func (o *BackupVendor) GetEndpoints() []v1alpha2.Endpoint {
+	route := "backup"
+	if o.Route != "" {
+		route = o.Route
+	}
+	return []v1alpha2.Endpoint{
+		{
+			Methods: []string{fasthttp.MethodGet},
+			Route:   route + "/export",
+			Version: o.Version,
+			Handler: o.onExport,
+		},
+		{
+			Methods: []string{fasthttp.MethodPost},
+			Route:   route + "/import",
+			Version: o.Version,
+			Handler: o.onImport,
+		},
+	}
+}
+
+func (c *BackupVendor) onExport(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Backup Vendor", request.Context, &map[string]string{
+		"method": "onExport",
+	})
+	defer span.End()
+
+	bLog.Infof("V (Backup): onExport, method: %s, traceId: %s", string(request.Method), span.SpanContext().TraceID().String())
+	if resp, ok := checkAdministrator(request.Context); !ok {
+		bLog.Infof("V (Backup): onExport failed - %s, traceId: %s", string(resp.Body), span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, resp)
+	}
+	archive, err := c.export(ctx, request.Parameters["include-password-hashes"] == "true")
+	if err != nil {
+		bLog.Infof("V (Backup): onExport failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.InternalError,
+			Body:  []byte(err.Error()),
+		})
+	}
+	jData, _ := json.Marshal(archive)
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State:       v1alpha2.OK,
+		Body:        jData,
+		ContentType: "application/json",
+	})
+}
+
+func (c *BackupVendor) onImport(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("Backup Vendor", request.Context, &map[string]string{
+		"method": "onImport",
+	})
+	defer span.End()
+
+	bLog.Infof("V (Backup): onImport, method: %s, traceId: %s", string(request.Method), span.SpanContext().TraceID().String())
+	if resp, ok := checkAdministrator(request.Context); !ok {
+		bLog.Infof("V (Backup): onImport failed - %s, traceId: %s", string(resp.Body), span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, resp)
+	}
+	var archive model.BackupArchive
+	err := json.Unmarshal(request.Body, &archive)
+	if err != nil {
+		bLog.Infof("V (Backup): onImport failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.BadRequest,
+			Body:  []byte(err.Error()),
+		})
+	}
+	if archive.Version != model.BackupArchiveVersion {
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.BadRequest,
+			Body:  []byte(fmt.Sprintf("archive version %d is not supported, expected version %d", archive.Version, model.BackupArchiveVersion)),
+		})
+	}
+	policy := model.ConflictPolicy(request.Parameters["conflict"])
+	switch policy {
+	case "":
+		policy = model.ConflictSkip
+	case model.ConflictSkip, model.ConflictOverwrite, model.ConflictFail:
+	default:
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.BadRequest,
+			Body:  []byte(fmt.Sprintf("conflict policy '%s' is not supported, use skip, overwrite or fail", policy)),
+		})
+	}
+	result, err := c.restore(ctx, archive, policy, request.Parameters["deployment-state"] != "false", request.Parameters["redeploy"] == "true")
+	jData, _ := json.Marshal(result)
+	if err != nil {
+		bLog.Infof("V (Backup): onImport failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+		state := v1alpha2.InternalError
+		if coaErr, ok := err.(v1alpha2.COAError); ok {
+			state = coaErr.State
+		}
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       state,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+	}
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State:       v1alpha2.OK,
+		Body:        jData,
+		ContentType: "application/json",
+	})
+}
+
+// checkAdministrator tells if the user of a request may export and import archives, and returns the response to
+// send otherwise. The service accounts of Symphony authenticated by the kubernetes API server are trusted, other
+// users need the administrator role, which is only known when the http binding enables RBAC.
+func checkAdministrator(ctx context.Context) (v1alpha2.COAResponse, bool) {
+	if ctx == nil {
+		return administratorRequired(), false
+	}
+	if serviceAccount, _ := ctx.Value(v1alpha2.AuthenticatedServiceAccount).(bool); serviceAccount {
+		return v1alpha2.COAResponse{}, true
+	}
+	roles, ok := ctx.Value(v1alpha2.AuthenticatedRoles).([]string)
+	if !ok {
+		if _, authenticated := ctx.Value(v1alpha2.AuthenticatedUser).(string); authenticated {
+			return v1alpha2.COAResponse{
+				State: v1alpha2.Unauthorized,
+				Body:  []byte(fmt.Sprintf("the %s role is required to export and import backups, but the roles of users are unknown as enableRBAC isn't set in the JWT middleware of the http binding", administratorRole)),
+			}, false
+		}
+		return administratorRequired(), false
+	}
+	for _, role := range roles {
+		if role == administratorRole {
+			return v1alpha2.COAResponse{}, true
+		}
+	}
+	return administratorRequired(), false
+}
+
+func administratorRequired() v1alpha2.COAResponse {
+	return v1alpha2.COAResponse{
+		State: v1alpha2.Unauthorized,
+		Body:  []byte(fmt.Sprintf("the %s role is required to export and import backups", administratorRole)),
+	}
+}
+
+// export reads the objects of all namespaces from the configured managers. The password hashes of the users are
+// only exported with withPasswordHashes.
+func (c *BackupVendor) export(ctx context.Context, withPasswordHashes bool) (model.BackupArchive, error) {
+	archive := model.BackupArchive{
+		Version:   model.BackupArchiveVersion,
+		SiteId:    c.Context.SiteInfo.SiteId,
+		CreatedAt: time.Now().UTC(),
+	}
+	var err error
+	if c.SitesManager != nil {
+		if archive.Sites, err = c.SitesManager.ListState(ctx); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Sites, func(i, j int) bool { return archive.Sites[i].Id < archive.Sites[j].Id })
+	}
+	if c.UsersManager != nil {
+		var list []users.UserState
+		if list, err = c.UsersManager.ListUsers(ctx); err != nil {
+			return archive, err
+		}
+		for _, user := range list {
+			backupUser := model.BackupUser{Id: user.Id, Roles: user.Roles}
+			if withPasswordHashes {
+				backupUser.PasswordHash = user.PasswordHash
+			}
+			archive.Users = append(archive.Users, backupUser)
+		}
+		sort.Slice(archive.Users, func(i, j int) bool { return archive.Users[i].Id < archive.Users[j].Id })
+	}
+	if c.TargetsManager != nil {
+		if archive.Targets, err = c.TargetsManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Targets, func(i, j int) bool {
+			return objectKey(archive.Targets[i].ObjectMeta) < objectKey(archive.Targets[j].ObjectMeta)
+		})
+	}
+	if c.DevicesManager != nil {
+		if archive.Devices, err = c.DevicesManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Devices, func(i, j int) bool {
+			return objectKey(archive.Devices[i].ObjectMeta) < objectKey(archive.Devices[j].ObjectMeta)
+		})
+	}
+	if c.ModelsManager != nil {
+		if archive.Models, err = c.ModelsManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Models, func(i, j int) bool {
+			return objectKey(archive.Models[i].ObjectMeta) < objectKey(archive.Models[j].ObjectMeta)
+		})
+	}
+	if c.SkillsManager != nil {
+		if archive.Skills, err = c.SkillsManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Skills, func(i, j int) bool {
+			return objectKey(archive.Skills[i].ObjectMeta) < objectKey(archive.Skills[j].ObjectMeta)
+		})
+	}
+	if c.SolutionsManager != nil {
+		if archive.Solutions, err = c.SolutionsManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Solutions, func(i, j int) bool {
+			return objectKey(archive.Solutions[i].ObjectMeta) < objectKey(archive.Solutions[j].ObjectMeta)
+		})
+	}
+	if c.CatalogsManager != nil {
+		if archive.Catalogs, err = c.CatalogsManager.ListState(ctx, "", "", ""); err != nil {
+			return archive, err
+		}
+		// schemas are restored before the catalogs validated against them
+		sort.Slice(archive.Catalogs, func(i, j int) bool {
+			iSchema, jSchema := archive.Catalogs[i].GetType() == "schema", archive.Catalogs[j].GetType() == "schema"
+			if iSchema != jSchema {
+				return iSchema
+			}
+			return objectKey(archive.Catalogs[i].ObjectMeta) < objectKey(archive.Catalogs[j].ObjectMeta)
+		})
+	}
+	if c.CampaignsManager != nil {
+		if archive.Campaigns, err = c.CampaignsManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Campaigns, func(i, j int) bool {
+			return objectKey(archive.Campaigns[i].ObjectMeta) < objectKey(archive.Campaigns[j].ObjectMeta)
+		})
+	}
+	if c.InstancesManager != nil {
+		if archive.Instances, err = c.InstancesManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Instances, func(i, j int) bool {
+			return objectKey(archive.Instances[i].ObjectMeta) < objectKey(archive.Instances[j].ObjectMeta)
+		})
+	}
+	if c.ActivationsManager != nil {
+		if archive.Activations, err = c.ActivationsManager.ListState(ctx, ""); err != nil {
+			return archive, err
+		}
+		sort.Slice(archive.Activations, func(i, j int) bool {
+			return objectKey(archive.Activations[i].ObjectMeta) < objectKey(archive.Activations[j].ObjectMeta)
+		})
+	}
+	if c.SolutionManager != nil {
+		// deployment states are kept per namespace, for the instances and targets deployed in it
+		for _, namespace := range archiveNamespaces(archive) {
+			var states []model.BackupDeploymentState
+			if states, err = c.SolutionManager.ExportDeploymentStates(ctx, namespace); err != nil {
+				return archive, err
+			}
+			sort.Slice(states, func(i, j int) bool { return states[i].Id < states[j].Id })
+			archive.DeploymentStates = append(archive.DeploymentStates, states...)
+		}
+	}
+	return archive, nil
+}
+
+// restore re-creates the objects of an archive in dependency order. With the fail policy, nothing is restored
+// when any object already exists. Deployment states are restored with the instances and targets they belong
+// to, unless withDeploymentState is false. With redeploy, the restored instances and targets are queued for
+// deployment.
+func (c *BackupVendor) restore(ctx context.Context, archive model.BackupArchive, policy model.ConflictPolicy, withDeploymentState bool, redeploy bool) (model.RestoreResult, error) {
+	result := model.RestoreResult{
+		Objects: make([]model.RestoreObjectResult, 0),
+	}
+	objects, err := c.backupObjects(archive)
+	if err != nil {
+		return result, err
+	}
+	existing := make([]bool, len(objects))
+	conflicts := 0
+	for i, object := range objects {
+		if existing[i], err = object.exists(ctx); err != nil {
+			return result, err
+		}
+		if existing[i] {
+			conflicts++
+		}
+	}
+	if policy == model.ConflictFail && conflicts > 0 {
+		for i, object := range objects {
+			if existing[i] {
+				result.Objects = append(result.Objects, restoreResult(object, "conflict", nil))
+			}
+		}
+		return result, v1alpha2.NewCOAError(nil, fmt.Sprintf("%d objects already exist, nothing is restored", conflicts), v1alpha2.Conflict)
+	}
+
+	// restored maps the instances and targets (as target-runtime-<name>) that are restored to their generation
+	// in the archive, to restore their deployment states
+	restored := make(map[string]string)
+	generations := make(map[string]string)
+	failed := 0
+	for i, object := range objects {
+		if existing[i] && policy == model.ConflictSkip {
+			result.Objects = append(result.Objects, restoreResult(object, "skipped", nil))
+			continue
+		}
+		if err = object.upsert(ctx); err != nil {
+			bLog.Errorf("V (Backup): failed to restore %s %s: %+v", object.kind, object.name, err)
+			result.Objects = append(result.Objects, restoreResult(object, "failed", err))
+			failed++
+			continue
+		}
+		action := "created"
+		if existing[i] {
+			action = "overwritten"
+		}
+		result.Objects = append(result.Objects, restoreResult(object, action, nil))
+		switch object.kind {
+		case "Instance":
+			restored[object.namespace+"/"+object.name] = "instance"
+		case "Target":
+			restored[object.namespace+"/"+targetRuntimePrefix+object.name] = "target"
+		}
+	}
+	for _, instance := range archive.Instances {
+		if instance.Spec != nil {
+			generations[instance.ObjectMeta.Namespace+"/"+instance.ObjectMeta.Name] = instance.Spec.Generation
+		}
+	}
+	for _, target := range archive.Targets {
+		if target.Spec != nil {
+			generations[target.ObjectMeta.Namespace+"/"+targetRuntimePrefix+target.ObjectMeta.Name] = target.Spec.Generation
+		}
+	}
+
+	if withDeploymentState && c.SolutionManager != nil {
+		for _, state := range archive.DeploymentStates {
+			owner := state.Namespace + "/" + strings.TrimPrefix(state.Id, "summary-")
+			if _, ok := restored[owner]; !ok {
+				continue
+			}
+			object := backupObject{kind: "DeploymentState", name: state.Id, namespace: state.Namespace}
+			if strings.HasPrefix(state.Id, "summary-") {
+				// the generation of a restored object may change, a summary of its archived generation is
+				// attached to the restored one
+				state.Body = c.updateSummaryGeneration(ctx, owner, generations[owner], state)
+			}
+			if err = c.SolutionManager.ImportDeploymentState(ctx, state); err != nil {
+				result.Objects = append(result.Objects, restoreResult(object, "failed", err))
+				failed++
+				continue
+			}
+			result.Objects = append(result.Objects, restoreResult(object, "created", nil))
+		}
+	}
+
+	if redeploy {
+		keys := make([]string, 0, len(restored))
+		for k := range restored {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			parts := strings.SplitN(key, "/", 2)
+			c.Context.Publish("job", v1alpha2.Event{
+				Metadata: map[string]string{
+					"objectType": restored[key],
+					"namespace":  parts[0],
+				},
+				Body: v1alpha2.JobData{
+					Id:     strings.TrimPrefix(parts[1], targetRuntimePrefix),
+					Action: v1alpha2.JobUpdate,
+					Scope:  parts[0],
+				},
+			})
+		}
+	}
+	if failed > 0 {
+		return result, v1alpha2.NewCOAError(nil, fmt.Sprintf("%d objects failed to be restored", failed), v1alpha2.InternalError)
+	}
+	return result, nil
+}
+
+// updateSummaryGeneration sets the generation of a deployment summary to the current generation of its
+// instance or target, when the summary is for the generation of the object in the archive
+func (c *BackupVendor) updateSummaryGeneration(ctx context.Context, owner string, archived string, state model.BackupDeploymentState) interface{} {
+	var summary model.SummaryResult
+	jData, _ := json.Marshal(state.Body)
+	if err := json.Unmarshal(jData, &summary); err != nil || summary.Generation != archived {
+		return state.Body
+	}
+	parts := strings.SplitN(owner, "/", 2)
+	current := ""
+	if strings.HasPrefix(parts[1], targetRuntimePrefix) {
+		if c.TargetsManager != nil {
+			if target, err := c.TargetsManager.GetState(ctx, strings.TrimPrefix(parts[1], targetRuntimePrefix), parts[0]); err == nil && target.Spec != nil {
+				current = target.Spec.Generation
+			}
+		}
+	} else if c.InstancesManager != nil {
+		if instance, err := c.InstancesManager.GetState(ctx, parts[1], parts[0]); err == nil && instance.Spec != nil {
+			current = instance.Spec.Generation
+		}
+	}
+	if current == "" {
+		return state.Body
+	}
+	summary.Generation = current
+	return summary
+}
+
+// backupObjects returns the objects of an archive in the order they're restored in
+func (c *BackupVendor) backupObjects(archive model.BackupArchive) ([]backupObject, error) {
+	objects := make([]backupObject, 0)
+	missing := make([]string, 0)
+	check := func(kind string, count int, configured bool) bool {
+		if count > 0 && !configured {
+			missing = append(missing, kind)
+		}
+		return configured
+	}
+	if check("sites", len(archive.Sites), c.SitesManager != nil) {
+		for _, site := range archive.Sites {
+			site := site
+			objects = append(objects, backupObject{
+				kind: "Site",
+				name: site.Id,
+				exists: func(ctx context.Context) (bool, error) {
+					_, err := c.SitesManager.GetState(ctx, site.Id)
+					return checkExists(err)
+				},
+				upsert: func(ctx context.Context) error {
+					if site.Spec == nil {
+						return v1alpha2.NewCOAError(nil, "site has no spec", v1alpha2.BadRequest)
+					}
+					return c.SitesManager.UpsertSpec(ctx, site.Id, *site.Spec)
+				},
+			})
+		}
+	}
+	if check("users", len(archive.Users), c.UsersManager != nil) {
+		for _, user := range archive.Users {
+			user := user
+			objects = append(objects, backupObject{
+				kind: "User",
+				name: user.Id,
+				exists: func(ctx context.Context) (bool, error) {
+					list, err := c.UsersManager.ListUsers(ctx)
+					if err != nil {
+						return false, err
+					}
+					for _, u := range list {
+						if u.Id == user.Id {
+							return true, nil
+						}
+					}
+					return false, nil
+				},
+				upsert: func(ctx context.Context) error {
+					if user.PasswordHash == "" {
+						// archives are exported without password hashes by default, the passwords of existing
+						// users are kept
+						current, err := c.UsersManager.GetUserState(ctx, user.Id)
+						if err != nil {
+							if v1alpha2.IsNotFound(err) {
+								return v1alpha2.NewCOAError(nil, "user has no password hash, export the archive with include-password-hashes to restore it", v1alpha2.BadRequest)
+							}
+							return err
+						}
+						user.PasswordHash = current.PasswordHash
+					}
+					return c.UsersManager.UpsertUserState(ctx, users.UserState{Id: user.Id, PasswordHash: user.PasswordHash, Roles: user.Roles})
+				},
+			})
+		}
+	}
+	if check("targets", len(archive.Targets), c.TargetsManager != nil) {
+		for _, target := range archive.Targets {
+			target := target
+			objects = append(objects, newBackupObject("Target", target.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.TargetsManager.GetState(ctx, target.ObjectMeta.Name, target.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.TargetsManager.UpsertState(ctx, target.ObjectMeta.Name, target)
+			}))
+		}
+	}
+	if check("devices", len(archive.Devices), c.DevicesManager != nil) {
+		for _, device := range archive.Devices {
+			device := device
+			objects = append(objects, newBackupObject("Device", device.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.DevicesManager.GetState(ctx, device.ObjectMeta.Name, device.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.DevicesManager.UpsertState(ctx, device.ObjectMeta.Name, device)
+			}))
+		}
+	}
+	if check("models", len(archive.Models), c.ModelsManager != nil) {
+		for _, m := range archive.Models {
+			m := m
+			objects = append(objects, newBackupObject("Model", m.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.ModelsManager.GetState(ctx, m.ObjectMeta.Name, m.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.ModelsManager.UpsertState(ctx, m.ObjectMeta.Name, m)
+			}))
+		}
+	}
+	if check("skills", len(archive.Skills), c.SkillsManager != nil) {
+		for _, skill := range archive.Skills {
+			skill := skill
+			objects = append(objects, newBackupObject("Skill", skill.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.SkillsManager.GetState(ctx, skill.ObjectMeta.Name, skill.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.SkillsManager.UpsertState(ctx, skill.ObjectMeta.Name, skill)
+			}))
+		}
+	}
+	if check("solutions", len(archive.Solutions), c.SolutionsManager != nil) {
+		for _, s := range archive.Solutions {
+			s := s
+			objects = append(objects, newBackupObject("Solution", s.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.SolutionsManager.GetState(ctx, s.ObjectMeta.Name, s.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.SolutionsManager.UpsertState(ctx, s.ObjectMeta.Name, s)
+			}))
+		}
+	}
+	if check("catalogs", len(archive.Catalogs), c.CatalogsManager != nil) {
+		for _, catalog := range archive.Catalogs {
+			catalog := catalog
+			objects = append(objects, newBackupObject("Catalog", catalog.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.CatalogsManager.GetState(ctx, catalog.ObjectMeta.Name, catalog.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.CatalogsManager.UpsertState(ctx, catalog.ObjectMeta.Name, catalog)
+			}))
+		}
+	}
+	if check("campaigns", len(archive.Campaigns), c.CampaignsManager != nil) {
+		for _, campaign := range archive.Campaigns {
+			campaign := campaign
+			objects = append(objects, newBackupObject("Campaign", campaign.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.CampaignsManager.GetState(ctx, campaign.ObjectMeta.Name, campaign.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.CampaignsManager.UpsertState(ctx, campaign.ObjectMeta.Name, campaign)
+			}))
+		}
+	}
+	if check("instances", len(archive.Instances), c.InstancesManager != nil) {
+		for _, instance := range archive.Instances {
+			instance := instance
+			objects = append(objects, newBackupObject("Instance", instance.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.InstancesManager.GetState(ctx, instance.ObjectMeta.Name, instance.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				return c.InstancesManager.UpsertState(ctx, instance.ObjectMeta.Name, instance)
+			}))
+		}
+	}
+	if check("activations", len(archive.Activations), c.ActivationsManager != nil) {
+		for _, activation := range archive.Activations {
+			activation := activation
+			objects = append(objects, newBackupObject("Activation", activation.ObjectMeta, func(ctx context.Context) error {
+				_, err := c.ActivationsManager.GetState(ctx, activation.ObjectMeta.Name, activation.ObjectMeta.Namespace)
+				return err
+			}, func(ctx context.Context) error {
+				// restored activations keep their status and aren't run again
+				if activation.Spec == nil {
+					activation.Spec = &model.ActivationSpec{}
+				}
+				err := c.ActivationsManager.UpsertState(ctx, activation.ObjectMeta.Name, activation)
+				if err == nil && activation.Status != nil {
+					err = c.ActivationsManager.ReportStatus(ctx, activation.ObjectMeta.Name, activation.ObjectMeta.Namespace, *activation.Status)
+				}
+				return err
+			}))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("the archive has %s, which the backup vendor has no managers for", strings.Join(missing, ", ")), v1alpha2.BadRequest)
+	}
+	return objects, nil
+}
+
+func newBackupObject(kind string, meta model.ObjectMeta, get func(ctx context.Context) error, upsert func(ctx context.Context) error) backupObject {
+	namespace := meta.Namespace
+	if namespace == "" {
+		namespace = "default"
+	}
+	return backupObject{
+		kind:      kind,
+		name:      meta.Name,
+		namespace: namespace,
+		exists: func(ctx context.Context) (bool, error) {
+			return checkExists(get(ctx))
+		},
+		upsert: upsert,
+	}
+}
+
+// checkExists turns the error of reading an object into whether the object exists
+func checkExists(err error) (bool, error) {
+	if err == nil {
+		return true, nil
+	}
+	if v1alpha2.IsNotFound(err) {
+		return false, nil
+	}
+	return false, err
+}
+
+func restoreResult(object backupObject, result string, err error) model.RestoreObjectResult {
+	ret := model.RestoreObjectResult{
+		Kind:      object.kind,
+		Name:      object.name,
+		Namespace: object.namespace,
+		Result:    result,
+	}
+	if err != nil {
+		ret.Error = err.Error()
+	}
+	return ret
+}
+
+func objectKey(meta model.ObjectMeta) string {
+	return meta.Namespace + "/" + meta.Name
+}
+
+// archiveNamespaces returns the namespaces of the instances and targets of an archive, and the default namespace
+func archiveNamespaces(archive model.BackupArchive) []string {
+	set := map[string]bool{"default": true}
+	for _, instance := range archive.Instances {
+		if instance.ObjectMeta.Namespace != "" {
+			set[instance.ObjectMeta.Namespace] = true
+		}
+	}
+	for _, target := range archive.Targets {
+		if target.ObjectMeta.Namespace != "" {
+			set[target.ObjectMeta.Namespace] = true
+		}
+	}
+	ret := make([]string, 0, len(set))
+	for namespace := range set {
+		ret = append(ret, namespace)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,346 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package vendors
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	sym_mgr "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/states/memorystate"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
+	"github.com/stretchr/testify/assert"
+	"github.com/valyala/fasthttp"
+)
+
+// createBackupVendor creates the backup vendor the way it's configured: after the vendors owning its singleton
+// managers, with the manager types only
+func createBackupVendor() BackupVendor {
+	managerConfig := func(name string, managerType string) managers.ManagerConfig {
+		return managers.ManagerConfig{
+			Name: name,
+			Type: managerType,
+			Properties: map[string]string{
+				"providers.state": "mem-state",
+				"singleton":       "true",
+			},
+			Providers: map[string]managers.ProviderConfig{
+				"mem-state": {
+					Type:   "providers.state.memory",
+					Config: memorystate.MemoryStateProviderConfig{},
+				},
+			},
+		}
+	}
+	memoryProviders := func(name string) map[string]map[string]providers.IProvider {
+		provider := memorystate.MemoryStateProvider{}
+		provider.Init(memorystate.MemoryStateProviderConfig{})
+		return map[string]map[string]providers.IProvider{
+			name: {
+				"mem-state": &provider,
+			},
+		}
+	}
+	factories := []managers.IManagerFactroy{
+		&sym_mgr.SymphonyManagerFactory{},
+	}
+	solutionsVendor := SolutionsVendor{}
+	solutionsVendor.Init(vendors.VendorConfig{
+		Managers: []managers.ManagerConfig{managerConfig("solutions-manager", "managers.symphony.solutions")},
+	}, factories, memoryProviders("solutions-manager"), nil)
+	instancesVendor := InstancesVendor{}
+	instancesVendor.Init(vendors.VendorConfig{
+		Managers: []managers.ManagerConfig{managerConfig("instances-manager", "managers.symphony.instances")},
+	}, factories, memoryProviders("instances-manager"), nil)
+	usersVendor := UsersVendor{}
+	usersVendor.Init(vendors.VendorConfig{
+		Managers: []managers.ManagerConfig{managerConfig("users-manager", "managers.symphony.users")},
+	}, factories, memoryProviders("users-manager"), nil)
+
+	vendor := BackupVendor{}
+	vendor.Init(vendors.VendorConfig{
+		Properties: map[string]string{
+			"test": "true",
+		},
+		Managers: []managers.ManagerConfig{
+			{Name: "solutions-manager", Type: "managers.symphony.solutions"},
+			{Name: "instances-manager", Type: "managers.symphony.instances"},
+			{Name: "users-manager", Type: "managers.symphony.users"},
+		},
+	}, factories, nil, nil)
+	return vendor
+}
+
+// administratorContext returns the context of a request authenticated with roles
+func administratorContext(roles ...string) context.Context {
+	if len(roles) == 0 {
+		roles = []string{administratorRole}
+	}
+	return context.WithValue(context.Background(), v1alpha2.AuthenticatedRoles, roles)
+}
+
+// serviceAccountContext returns the context of a request authenticated by the kubernetes API server, without roles
+func serviceAccountContext() context.Context {
+	ctx := context.WithValue(context.Background(), v1alpha2.AuthenticatedUser, "system:serviceaccount:default:symphony-api")
+	return context.WithValue(ctx, v1alpha2.AuthenticatedServiceAccount, true)
+}
+
+func createBackupObjects(t *testing.T, vendor BackupVendor) {
+	err := vendor.SolutionsManager.UpsertState(context.Background(), "solution1", model.SolutionState{
+		ObjectMeta: model.ObjectMeta{Name: "solution1", Namespace: "default"},
+		Spec:       &model.SolutionSpec{DisplayName: "solution1"},
+	})
+	assert.Nil(t, err)
+	err = vendor.InstancesManager.UpsertState(context.Background(), "instance1", model.InstanceState{
+		ObjectMeta: model.ObjectMeta{Name: "instance1", Namespace: "ns1"},
+		Spec:       &model.InstanceSpec{Solution: "solution1"},
+	})
+	assert.Nil(t, err)
+}
+
+func exportBackup(t *testing.T, vendor BackupVendor) model.BackupArchive {
+	resp := vendor.onExport(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: administratorContext(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var archive model.BackupArchive
+	err := json.Unmarshal(resp.Body, &archive)
+	assert.Nil(t, err)
+	return archive
+}
+
+func importBackup(vendor BackupVendor, archive model.BackupArchive, policy string) (v1alpha2.COAResponse, model.RestoreResult) {
+	data, _ := json.Marshal(archive)
+	resp := vendor.onImport(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Body:   data,
+		Parameters: map[string]string{
+			"conflict": policy,
+		},
+		Context: administratorContext(),
+	})
+	var result model.RestoreResult
+	json.Unmarshal(resp.Body, &result)
+	return resp, result
+}
+
+func TestBackupEndpoints(t *testing.T) {
+	vendor := createBackupVendor()
+	vendor.Route = "backup"
+	endpoints := vendor.GetEndpoints()
+	assert.Equal(t, 2, len(endpoints))
+}
+
+func TestBackupInfo(t *testing.T) {
+	vendor := createBackupVendor()
+	vendor.Version = "1.0"
+	info := vendor.GetInfo()
+	assert.NotNil(t, info)
+	assert.Equal(t, "1.0", info.Version)
+}
+
+func TestBackupExport(t *testing.T) {
+	vendor := createBackupVendor()
+	createBackupObjects(t, vendor)
+	archive := exportBackup(t, vendor)
+	assert.Equal(t, model.BackupArchiveVersion, archive.Version)
+	assert.Equal(t, 1, len(archive.Solutions))
+	assert.Equal(t, "solution1", archive.Solutions[0].ObjectMeta.Name)
+	assert.Equal(t, 1, len(archive.Instances))
+	assert.Equal(t, "ns1", archive.Instances[0].ObjectMeta.Namespace)
+	assert.Equal(t, 0, len(archive.Targets))
+}
+
+func TestBackupInitWithoutSingleton(t *testing.T) {
+	vendor := BackupVendor{}
+	err := vendor.Init(vendors.VendorConfig{
+		Managers: []managers.ManagerConfig{
+			{Name: "solutions-manager", Type: "managers.symphony.solutions"},
+		},
+	}, []managers.IManagerFactroy{
+		&sym_mgr.SymphonyManagerFactory{},
+	}, nil, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestBackupRequiresAdministrator(t *testing.T) {
+	vendor := createBackupVendor()
+	data, _ := json.Marshal(model.BackupArchive{Version: model.BackupArchiveVersion})
+	testCases := []struct {
+		name    string
+		ctx     context.Context
+		state   v1alpha2.State
+		message string
+	}{
+		{name: "administrator", ctx: administratorContext(), state: v1alpha2.OK},
+		{name: "administrator among roles", ctx: administratorContext("reader", administratorRole), state: v1alpha2.OK},
+		{name: "reader", ctx: administratorContext("reader"), state: v1alpha2.Unauthorized},
+		{name: "no roles", ctx: context.Background(), state: v1alpha2.Unauthorized},
+		{name: "RBAC disabled", ctx: context.WithValue(context.Background(), v1alpha2.AuthenticatedUser, "admin"), state: v1alpha2.Unauthorized, message: "enableRBAC"},
+		{name: "service account", ctx: serviceAccountContext(), state: v1alpha2.OK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := vendor.onExport(v1alpha2.COARequest{
+				Method:  fasthttp.MethodGet,
+				Context: tc.ctx,
+			})
+			assert.Equal(t, tc.state, resp.State)
+			assert.Contains(t, string(resp.Body), tc.message)
+			resp = vendor.onImport(v1alpha2.COARequest{
+				Method:  fasthttp.MethodPost,
+				Body:    data,
+				Context: tc.ctx,
+			})
+			assert.Equal(t, tc.state, resp.State)
+			assert.Contains(t, string(resp.Body), tc.message)
+		})
+	}
+}
+
+func TestBackupPasswordHashes(t *testing.T) {
+	testCases := []struct {
+		name          string
+		include       bool
+		existing      bool
+		state         v1alpha2.State
+		restoredAdmin bool
+	}{
+		{name: "hashes included", include: true, state: v1alpha2.OK, restoredAdmin: true},
+		{name: "hashes left out, existing user", existing: true, state: v1alpha2.OK, restoredAdmin: true},
+		{name: "hashes left out, missing user", state: v1alpha2.InternalError},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			source := createBackupVendor()
+			err := source.UsersManager.UpsertUser(context.Background(), "admin", "s3cr3t", []string{administratorRole})
+			assert.Nil(t, err)
+			parameters := map[string]string{}
+			if tc.include {
+				parameters["include-password-hashes"] = "true"
+			}
+			resp := source.onExport(v1alpha2.COARequest{
+				Method:     fasthttp.MethodGet,
+				Parameters: parameters,
+				Context:    administratorContext(),
+			})
+			assert.Equal(t, v1alpha2.OK, resp.State)
+			var archive model.BackupArchive
+			assert.Nil(t, json.Unmarshal(resp.Body, &archive))
+			assert.Equal(t, 1, len(archive.Users))
+			assert.Equal(t, tc.include, archive.Users[0].PasswordHash != "")
+
+			vendor := createBackupVendor()
+			if tc.existing {
+				err = vendor.UsersManager.UpsertUser(context.Background(), "admin", "s3cr3t", nil)
+				assert.Nil(t, err)
+			}
+			resp, _ = importBackup(vendor, archive, "overwrite")
+			assert.Equal(t, tc.state, resp.State)
+			roles, ok := vendor.UsersManager.CheckUser(context.Background(), "admin", "s3cr3t")
+			assert.Equal(t, tc.restoredAdmin, ok)
+			if tc.restoredAdmin {
+				assert.Equal(t, []string{administratorRole}, roles)
+			}
+		})
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	source := createBackupVendor()
+	createBackupObjects(t, source)
+	archive := exportBackup(t, source)
+
+	vendor := createBackupVendor()
+	resp, result := importBackup(vendor, archive, "")
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	assert.Equal(t, 2, len(result.Objects))
+	// solutions are restored before the instances using them
+	assert.Equal(t, "Solution", result.Objects[0].Kind)
+	assert.Equal(t, "created", result.Objects[0].Result)
+	assert.Equal(t, "Instance", result.Objects[1].Kind)
+	assert.Equal(t, "created", result.Objects[1].Result)
+
+	instance, err := vendor.InstancesManager.GetState(context.Background(), "instance1", "ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, "solution1", instance.Spec.Solution)
+}
+
+func TestBackupRestoreConflictFail(t *testing.T) {
+	vendor := createBackupVendor()
+	createBackupObjects(t, vendor)
+	archive := exportBackup(t, vendor)
+	archive.Solutions[0].Spec.DisplayName = "changed"
+	archive.Solutions = append(archive.Solutions, model.SolutionState{
+		ObjectMeta: model.ObjectMeta{Name: "solution2", Namespace: "default"},
+		Spec:       &model.SolutionSpec{DisplayName: "solution2"},
+	})
+
+	resp, result := importBackup(vendor, archive, "fail")
+	assert.Equal(t, v1alpha2.Conflict, resp.State)
+	assert.Equal(t, 2, len(result.Objects))
+	assert.Equal(t, "conflict", result.Objects[0].Result)
+
+	// nothing is restored
+	_, err := vendor.SolutionsManager.GetState(context.Background(), "solution2", "default")
+	assert.True(t, v1alpha2.IsNotFound(err))
+	solution, err := vendor.SolutionsManager.GetState(context.Background(), "solution1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "solution1", solution.Spec.DisplayName)
+}
+
+func TestBackupRestoreConflictSkip(t *testing.T) {
+	vendor := createBackupVendor()
+	createBackupObjects(t, vendor)
+	archive := exportBackup(t, vendor)
+	archive.Solutions[0].Spec.DisplayName = "changed"
+
+	resp, result := importBackup(vendor, archive, "skip")
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	assert.Equal(t, "skipped", result.Objects[0].Result)
+	solution, err := vendor.SolutionsManager.GetState(context.Background(), "solution1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "solution1", solution.Spec.DisplayName)
+}
+
+func TestBackupRestoreConflictOverwrite(t *testing.T) {
+	vendor := createBackupVendor()
+	createBackupObjects(t, vendor)
+	archive := exportBackup(t, vendor)
+	archive.Solutions[0].Spec.DisplayName = "changed"
+
+	resp, result := importBackup(vendor, archive, "overwrite")
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	assert.Equal(t, "overwritten", result.Objects[0].Result)
+	solution, err := vendor.SolutionsManager.GetState(context.Background(), "solution1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, "changed", solution.Spec.DisplayName)
+}
+
+func TestBackupRestoreBadRequest(t *testing.T) {
+	vendor := createBackupVendor()
+	archive := model.BackupArchive{Version: model.BackupArchiveVersion}
+	resp, _ := importBackup(vendor, archive, "replace")
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+
+	archive.Version = model.BackupArchiveVersion + 1
+	resp, _ = importBackup(vendor, archive, "")
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+
+	// the vendor has no campaigns manager
+	archive.Version = model.BackupArchiveVersion
+	archive.Campaigns = []model.CampaignState{{ObjectMeta: model.ObjectMeta{Name: "campaign1"}}}
+	resp, _ = importBackup(vendor, archive, "")
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+}
@@ -130,11 +130,14 @@ func (c *CampaignsVendor) onCampaigns(request v1alpha2.COARequest) v1alpha2.COAR
 			})
 		}
 
+		if isDryRun(request) {
+			return observ_utils.CloseSpanWithCOAResponse(span, dryRunResponse(c.CampaignsManager.ValidateUpsert(ctx, id, campaign)))
+		}
 		err = c.CampaignsManager.UpsertState(ctx, id, campaign)
 		if err != nil {
 			cLog.Infof("V (Campaigns): onCampaigns failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
 			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
-				State: v1alpha2.InternalError,
+				State: upsertErrorState(err),
 				Body:  []byte(err.Error()),
 			})
 		}
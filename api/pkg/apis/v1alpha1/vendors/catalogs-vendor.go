@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/catalogs"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
@@ -63,6 +64,17 @@ func (e *CatalogsVendor) Init(config vendors.VendorConfig, factories []managers.
 			origin := event.Metadata["origin"]
 			if err == nil {
 				name := fmt.Sprintf("%s-%s", origin, catalog.ObjectMeta.Name)
+				if job.Action == v1alpha2.JobDelete {
+					namespace := catalog.ObjectMeta.Namespace
+					if namespace == "" {
+						namespace = "default"
+					}
+					err := e.CatalogsManager.DeleteState(context.TODO(), name, namespace)
+					if err != nil && !v1alpha2.IsNotFound(err) {
+						return v1alpha2.NewCOAError(err, "failed to delete catalog", v1alpha2.InternalError)
+					}
+					return nil
+				}
 				catalog.ObjectMeta.Name = name
 				if catalog.Spec.ParentName != "" {
 					catalog.Spec.ParentName = fmt.Sprintf("%s-%s", origin, catalog.Spec.ParentName)
@@ -81,6 +93,31 @@ func (e *CatalogsVendor) Init(config vendors.VendorConfig, factories []managers.
 		}
 		return nil
 	})
+	e.Vendor.Context.Subscribe("catalog-dependencies", func(topic string, event v1alpha2.Event) error {
+		var dependencies model.CatalogDependencies
+		jData, _ := json.Marshal(event.Body)
+		err := json.Unmarshal(jData, &dependencies)
+		if err != nil {
+			lLog.Errorf("V (Catalogs Vendor): failed to unmarshal catalog dependencies: %v", err)
+			return err
+		}
+		err = e.CatalogsManager.SetDependencies(context.TODO(), dependencies)
+		if err != nil {
+			lLog.Errorf("V (Catalogs Vendor): failed to store the catalog dependencies of instance %s: %v", dependencies.Instance, err)
+		}
+		return err
+	})
+	e.Vendor.Context.Subscribe("catalog-changed", func(topic string, event v1alpha2.Event) error {
+		var change model.CatalogChangedEvent
+		jData, _ := json.Marshal(event.Body)
+		err := json.Unmarshal(jData, &change)
+		if err != nil {
+			lLog.Errorf("V (Catalogs Vendor): failed to unmarshal catalog change: %v", err)
+			return err
+		}
+		_, err = e.CatalogsManager.QueueRedeploys(context.TODO(), change)
+		return err
+	})
 	return nil
 }
 func (e *CatalogsVendor) GetEndpoints() []v1alpha2.Endpoint {
@@ -96,6 +133,20 @@ func (e *CatalogsVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Handler:    e.onCatalogs,
 			Parameters: []string{"name?"},
 		},
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      route + "/registry/{name}/revisions",
+			Version:    e.Version,
+			Handler:    e.onRevisions,
+			Parameters: []string{"revision?"},
+		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/registry/{name}/rollback",
+			Version:    e.Version,
+			Handler:    e.onRollback,
+			Parameters: []string{"revision"},
+		},
 		{
 			Methods: []string{fasthttp.MethodGet},
 			Route:   route + "/graph",
@@ -117,6 +168,121 @@ func (e *CatalogsVendor) GetEndpoints() []v1alpha2.Endpoint {
 		},
 	}
 }
+
+// revisionErrorState maps an error of the catalog revisions to a response state
+func revisionErrorState(err error) v1alpha2.State {
+	if coaErr, ok := err.(v1alpha2.COAError); ok {
+		switch coaErr.State {
+		case v1alpha2.NotFound:
+			return v1alpha2.NotFound
+		case v1alpha2.BadRequest, v1alpha2.ValidateFailed, v1alpha2.NotImplemented:
+			return v1alpha2.BadRequest
+		}
+	}
+	return v1alpha2.InternalError
+}
+func (e *CatalogsVendor) onRevisions(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	rCtx, span := observability.StartSpan("Catalogs Vendor", request.Context, &map[string]string{
+		"method": "onRevisions",
+	})
+	defer span.End()
+
+	lLog.Infof("V (Catalogs Vendor): onRevisions, method: %s, traceId: %s", string(request.Method), span.SpanContext().TraceID().String())
+
+	namespace, namesapceSupplied := request.Parameters["namespace"]
+	if !namesapceSupplied {
+		namespace = "default"
+	}
+
+	switch request.Method {
+	case fasthttp.MethodGet:
+		id := request.Parameters["__name"]
+		var state interface{}
+		var err error
+		isArray := false
+		if r := request.Parameters["__revision"]; r != "" {
+			revision, convErr := strconv.Atoi(r)
+			if convErr != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(fmt.Sprintf("invalid revision: %s", r)),
+				})
+			}
+			state, err = e.CatalogsManager.GetRevision(rCtx, id, namespace, revision)
+		} else {
+			state, err = e.CatalogsManager.ListRevisions(rCtx, id, namespace)
+			isArray = true
+		}
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: revisionErrorState(err),
+				Body:  []byte(err.Error()),
+			})
+		}
+		jData, _ := utils.FormatObject(state, isArray, request.Parameters["path"], request.Parameters["doc-type"])
+		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+		if request.Parameters["doc-type"] == "yaml" {
+			resp.ContentType = "application/text"
+		}
+		return resp
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+func (e *CatalogsVendor) onRollback(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	rCtx, span := observability.StartSpan("Catalogs Vendor", request.Context, &map[string]string{
+		"method": "onRollback",
+	})
+	defer span.End()
+
+	lLog.Infof("V (Catalogs Vendor): onRollback, method: %s, traceId: %s", string(request.Method), span.SpanContext().TraceID().String())
+
+	namespace, namesapceSupplied := request.Parameters["namespace"]
+	if !namesapceSupplied {
+		namespace = "default"
+	}
+
+	switch request.Method {
+	case fasthttp.MethodPost:
+		id := request.Parameters["__name"]
+		revision, err := strconv.Atoi(request.Parameters["__revision"])
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte(fmt.Sprintf("invalid revision: %s", request.Parameters["__revision"])),
+			})
+		}
+		catalog, err := e.CatalogsManager.Rollback(rCtx, id, namespace, revision)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: revisionErrorState(err),
+				Body:  []byte(err.Error()),
+			})
+		}
+		jData, _ := utils.FormatObject(catalog, false, "", "")
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
 func (e *CatalogsVendor) onStatus(request v1alpha2.COARequest) v1alpha2.COAResponse {
 	rCtx, span := observability.StartSpan("Catalogs Vendor", request.Context, &map[string]string{
 		"method": "onStatus",
@@ -358,10 +524,13 @@ func (e *CatalogsVendor) onCatalogs(request v1alpha2.COARequest) v1alpha2.COARes
 			})
 		}
 
+		if isDryRun(request) {
+			return observ_utils.CloseSpanWithCOAResponse(span, dryRunResponse(e.CatalogsManager.ValidateUpsert(ctx, id, catalog)))
+		}
 		err = e.CatalogsManager.UpsertState(ctx, id, catalog)
 		if err != nil {
 			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
-				State: v1alpha2.InternalError,
+				State: upsertErrorState(err),
 				Body:  []byte(err.Error()),
 			})
 		}
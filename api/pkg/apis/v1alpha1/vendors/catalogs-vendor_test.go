@@ -517,3 +517,182 @@ func TestCatalogSubscribe(t *testing.T) {
 	}
 	assert.Equal(t, v1alpha2.OK, response.State)
 }
+
+func TestCatalogOnRevisionsAndRollback(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	revisionProvider := &memorystate.MemoryStateProvider{}
+	revisionProvider.Init(memorystate.MemoryStateProviderConfig{})
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendor := CatalogsVendor{}
+	err := vendor.Init(vendors.VendorConfig{
+		Route: "catalogs",
+		Managers: []managers.ManagerConfig{
+			{
+				Name: "catalog-manager",
+				Type: "managers.symphony.catalogs",
+				Properties: map[string]string{
+					"providers.state":     "StateProvider",
+					"providers.revisions": "RevisionProvider",
+				},
+			},
+		},
+	}, []managers.IManagerFactroy{
+		&sym_mgr.SymphonyManagerFactory{},
+	}, map[string]map[string]providers.IProvider{
+		"catalog-manager": {
+			"StateProvider":    stateProvider,
+			"RevisionProvider": revisionProvider,
+		},
+	}, &pubSubProvider)
+	assert.Nil(t, err)
+
+	for _, replicas := range []int{1, 2} {
+		catalog := model.CatalogState{
+			ObjectMeta: model.ObjectMeta{Name: "config1"},
+			Spec: &model.CatalogSpec{
+				Type:       "config",
+				Properties: map[string]interface{}{"replicas": replicas},
+			},
+		}
+		data, _ := json.Marshal(catalog)
+		resp := vendor.onCatalogs(v1alpha2.COARequest{
+			Method:     fasthttp.MethodPost,
+			Context:    context.Background(),
+			Body:       data,
+			Parameters: map[string]string{"__name": "config1"},
+		})
+		assert.Equal(t, v1alpha2.OK, resp.State)
+	}
+
+	resp := vendor.onRevisions(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "config1"},
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var revisions []model.CatalogRevision
+	err = json.Unmarshal(resp.Body, &revisions)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(revisions))
+
+	resp = vendor.onRevisions(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "config1", "__revision": "1"},
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var revision model.CatalogRevision
+	err = json.Unmarshal(resp.Body, &revision)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), revision.Spec.Properties["replicas"])
+
+	resp = vendor.onRevisions(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "config1", "__revision": "7"},
+	})
+	assert.Equal(t, v1alpha2.NotFound, resp.State)
+
+	resp = vendor.onRollback(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "config1", "__revision": "latest"},
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+
+	resp = vendor.onRollback(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "config1", "__revision": "1"},
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	var catalog model.CatalogState
+	err = json.Unmarshal(resp.Body, &catalog)
+	assert.Nil(t, err)
+	assert.Equal(t, float64(1), catalog.Spec.Properties["replicas"])
+}
+
+func TestCatalogOnRevisionsNotEnabled(t *testing.T) {
+	vendor := CatalogVendorInit()
+	resp := vendor.onRevisions(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Context:    context.Background(),
+		Parameters: map[string]string{"__name": "config1"},
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+	assert.Contains(t, string(resp.Body), "catalog revisions are not enabled")
+}
+
+func TestCatalogRedeploysDependentInstances(t *testing.T) {
+	stateProvider := &memorystate.MemoryStateProvider{}
+	stateProvider.Init(memorystate.MemoryStateProviderConfig{})
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendor := CatalogsVendor{}
+	err := vendor.Init(vendors.VendorConfig{
+		Route: "catalogs",
+		Managers: []managers.ManagerConfig{
+			{
+				Name: "catalog-manager",
+				Type: "managers.symphony.catalogs",
+				Properties: map[string]string{
+					"providers.state":        "StateProvider",
+					"redeploy.debounceInSec": "0",
+				},
+			},
+		},
+	}, []managers.IManagerFactroy{
+		&sym_mgr.SymphonyManagerFactory{},
+	}, map[string]map[string]providers.IProvider{
+		"catalog-manager": {
+			"StateProvider": stateProvider,
+		},
+	}, &pubSubProvider)
+	assert.Nil(t, err)
+
+	jobs := make(chan v1alpha2.JobData, 10)
+	vendor.Context.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		var job v1alpha2.JobData
+		jData, _ := json.Marshal(event.Body)
+		json.Unmarshal(jData, &job)
+		jobs <- job
+		return nil
+	})
+	vendor.Context.Publish("catalog-dependencies", v1alpha2.Event{
+		Body: model.CatalogDependencies{
+			Instance:  "instance1",
+			Namespace: "default",
+			Catalogs:  map[string][]string{"config1": {"image"}},
+		},
+	})
+
+	// events are delivered asynchronously, so the catalog is changed until the dependency is known
+	for i := 0; i < 10; i++ {
+		catalog := model.CatalogState{
+			ObjectMeta: model.ObjectMeta{Name: "config1"},
+			Spec: &model.CatalogSpec{
+				Type:       "config",
+				Properties: map[string]interface{}{"image": fmt.Sprintf("nginx:1.%d", i)},
+			},
+		}
+		data, _ := json.Marshal(catalog)
+		resp := vendor.onCatalogs(v1alpha2.COARequest{
+			Method:     fasthttp.MethodPost,
+			Body:       data,
+			Parameters: map[string]string{"__name": "config1"},
+			Context:    context.Background(),
+		})
+		assert.Equal(t, v1alpha2.OK, resp.State)
+		select {
+		case job := <-jobs:
+			assert.Equal(t, "instance1", job.Id)
+			assert.Equal(t, v1alpha2.JobUpdate, job.Action)
+			assert.Equal(t, "default", job.Scope)
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	assert.Fail(t, "dependent instance wasn't redeployed")
+}
@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package vendors
+
+import (
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+)
+
+// isDryRun returns true when a POST request asks for an object to be validated without being stored
+func isDryRun(request v1alpha2.COARequest) bool {
+	return request.Parameters["dry-run"] == "true"
+}
+
+// dryRunResponse returns the result of validating an object posted with the dry-run parameter
+func dryRunResponse(err error) v1alpha2.COAResponse {
+	if err != nil {
+		return v1alpha2.COAResponse{
+			State: upsertErrorState(err),
+			Body:  []byte(err.Error()),
+		}
+	}
+	return v1alpha2.COAResponse{
+		State: v1alpha2.OK,
+	}
+}
+
+// upsertErrorState returns the response state of a failed upsert or dry run. Objects rejected by the validation of
+// the managers are bad requests.
+func upsertErrorState(err error) v1alpha2.State {
+	if utils.IsValidationError(err) {
+		return v1alpha2.BadRequest
+	}
+	return v1alpha2.InternalError
+}
@@ -7,9 +7,11 @@
 package vendors
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/catalogs"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/sites"
@@ -79,6 +81,20 @@ func (f *FederationVendor) Init(config vendors.VendorConfig, factories []manager
 		return v1alpha2.NewCOAError(nil, "catalogs manager is not supplied", v1alpha2.MissingConfig)
 	}
 	f.Vendor.Context.Subscribe("catalog", func(topic string, event v1alpha2.Event) error {
+		var job v1alpha2.JobData
+		jData, _ := json.Marshal(event.Body)
+		if err := json.Unmarshal(jData, &job); err == nil {
+			var catalog model.CatalogState
+			jData, _ = json.Marshal(job.Body)
+			json.Unmarshal(jData, &catalog)
+			if job.Action == v1alpha2.JobDelete {
+				// deletions are picked up by the staging manager from the tombstone
+				return f.StagingManager.RecordTombstone(context.TODO(), job.Id, catalog.ObjectMeta.Namespace)
+			}
+			if err := f.StagingManager.RemoveTombstone(context.TODO(), job.Id, catalog.ObjectMeta.Namespace); err != nil {
+				fLog.Errorf("V (Federation): failed to remove tombstone of catalog %s: %v", job.Id, err)
+			}
+		}
 		sites, err := f.SitesManager.ListState(context.TODO())
 		if err != nil {
 			return err
@@ -148,6 +164,13 @@ func (f *FederationVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Handler:    f.onSync,
 			Parameters: []string{"site?"},
 		},
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      route + "/stream",
+			Version:    f.Version,
+			Handler:    f.onStream,
+			Parameters: []string{"site?"},
+		},
 		{
 			Methods:    []string{fasthttp.MethodPost, fasthttp.MethodGet},
 			Route:      route + "/registry",
@@ -162,6 +185,20 @@ func (f *FederationVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Handler:    f.onStatus,
 			Parameters: []string{"name"},
 		},
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      route + "/deadletters",
+			Version:    f.Version,
+			Handler:    f.onDeadLetters,
+			Parameters: []string{"site?"},
+		},
+		{
+			Methods:    []string{fasthttp.MethodGet},
+			Route:      route + "/connectivity",
+			Version:    f.Version,
+			Handler:    f.onConnectivity,
+			Parameters: []string{"name"},
+		},
 		{
 			Methods: []string{fasthttp.MethodPost},
 			Route:   route + "/trail",
@@ -223,7 +260,13 @@ func (f *FederationVendor) onRegistry(request v1alpha2.COARequest) v1alpha2.COAR
 		var err error
 		var state interface{}
 		isArray := false
-		if id == "" {
+		if instance := request.Parameters["instance"]; id == "" && instance != "" {
+			state, err = f.SitesManager.LocateInstance(ctx, instance, request.Parameters["namespace"])
+			isArray = true
+		} else if target := request.Parameters["target"]; id == "" && target != "" {
+			state, err = f.SitesManager.LocateTarget(ctx, target, request.Parameters["namespace"])
+			isArray = true
+		} else if id == "" {
 			state, err = f.SitesManager.ListState(ctx)
 			isArray = true
 		} else {
@@ -307,6 +350,9 @@ func (f *FederationVendor) onSync(request v1alpha2.COARequest) v1alpha2.COARespo
 	tLog.Info("V (Federation): onSync")
 	switch request.Method {
 	case fasthttp.MethodPost:
+		if leaseId, ok := request.Parameters["lease"]; ok {
+			return f.onSyncAck(pCtx, request.Parameters["__site"], leaseId)
+		}
 		var status model.ActivationStatus
 		err := json.Unmarshal(request.Body, &status)
 		if err != nil {
@@ -348,37 +394,267 @@ func (f *FederationVendor) onSync(request v1alpha2.COARequest) v1alpha2.COARespo
 				Body:  []byte(err.Error()),
 			})
 		}
-		batch, err := f.StagingManager.GetABatchForSite(id, intCount)
+		if id != "" {
+			f.SitesManager.RecordHeartbeat(id)
+		}
+		pack, err := f.getSyncPackage(ctx, id, intCount, namespace)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.InternalError,
+				Body:  []byte(err.Error()),
+			})
+		}
+		jData, _ := utils.FormatObject(pack, true, request.Parameters["path"], request.Parameters["doc-type"])
+		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+		if request.Parameters["doc-type"] == "yaml" {
+			resp.ContentType = "application/text"
+		}
+		return resp
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+
+// getSyncPackage leases the next batch of a site and packs it. The package has no lease ID if there is
+// nothing to deliver.
+func (f *FederationVendor) getSyncPackage(ctx context.Context, site string, count int, namespace string) (model.SyncPackage, error) {
+	pack := model.SyncPackage{
+		Origin:          f.Context.SiteInfo.SiteId,
+		Catalogs:        make([]model.CatalogState, 0),
+		DeletedCatalogs: make([]model.ObjectMeta, 0),
+		Jobs:            make([]v1alpha2.JobData, 0),
+	}
+	leaseId, batch, err := f.StagingManager.GetABatchForSite(ctx, site, count)
+	if err != nil {
+		return pack, err
+	}
+	pack.LeaseId = leaseId
+	for _, c := range batch {
+		if c.Action == v1alpha2.JobRun { //TODO: I don't really like this
+			pack.Jobs = append(pack.Jobs, c)
+		} else if c.Action == v1alpha2.JobDelete {
+			pack.DeletedCatalogs = append(pack.DeletedCatalogs, model.ObjectMeta{
+				Name:      c.Id,
+				Namespace: c.Scope,
+			})
+		} else {
+			catalog, err := f.CatalogsManager.GetState(ctx, c.Id, namespace)
+			if err != nil {
+				if v1alpha2.IsNotFound(err) {
+					// the catalog has been deleted since, the deletion is synced from its tombstone
+					continue
+				}
+				return pack, err
+			}
+			pack.Catalogs = append(pack.Catalogs, catalog)
+		}
+	}
+	return pack, nil
+}
+
+// onStream keeps a stream open to a child site and pushes its batches as soon as they are queued, one JSON
+// document per line. Batches are leased and acknowledged exactly like the batches of onSync; empty lines are
+// written as heartbeats while there is nothing to deliver.
+func (f *FederationVendor) onStream(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	_, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
+		"method": "onStream",
+	})
+	defer span.End()
+
+	tLog.Info("V (Federation): onStream")
+	site := request.Parameters["__site"]
+	if site == "" {
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: v1alpha2.BadRequest,
+			Body:  []byte("site is required"),
+		})
+	}
+	count := 10
+	if v, ok := request.Parameters["count"]; ok {
+		var err error
+		count, err = strconv.Atoi(v)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte(err.Error()),
+			})
+		}
+	}
+	namespace, exist := request.Parameters["namespace"]
+	if !exist {
+		namespace = "default"
+	}
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State:       v1alpha2.OK,
+		ContentType: "application/x-ndjson",
+		BodyStream: func(w *bufio.Writer) {
+			f.streamBatches(w, site, count, namespace)
+		},
+	})
+}
+
+// streamBatches writes the batches of a site until the site disconnects. The request context is gone by the
+// time the stream is written, so the stream runs in a context of its own.
+func (f *FederationVendor) streamBatches(w *bufio.Writer, site string, count int, namespace string) {
+	watch, cancel := f.StagingManager.Watch(site)
+	defer cancel()
+	tLog.Infof("V (Federation): site %s connected to the push channel", site)
+	defer tLog.Infof("V (Federation): site %s disconnected from the push channel", site)
+
+	for {
+		// an open channel shows that the site is alive
+		f.SitesManager.RecordHeartbeat(site)
+		pack, err := f.getSyncPackage(context.Background(), site, count, namespace)
+		if err != nil {
+			tLog.Errorf("V (Federation): failed to get a batch for site %s: %v", site, err)
+		} else if pack.LeaseId != "" {
+			jData, _ := json.Marshal(pack)
+			w.Write(jData)
+			w.WriteString("\n")
+			if w.Flush() != nil {
+				// the batch isn't acknowledged, so it's delivered again after the visibility timeout
+				return
+			}
+			continue
+		}
+		select {
+		case <-watch:
+		case <-time.After(utils.StreamHeartbeatInterval):
+			w.WriteString("\n")
+			if w.Flush() != nil {
+				return
+			}
+		}
+	}
+}
+
+// onSyncAck acknowledges a batch delivered to a site. Catalog deletions in the batch are marked as
+// received by the site.
+func (f *FederationVendor) onSyncAck(pCtx context.Context, site string, leaseId string) v1alpha2.COAResponse {
+	ctx, span := observability.StartSpan("onSync-ACK", pCtx, nil)
+	jobs, err := f.StagingManager.AckBatch(ctx, site, leaseId)
+	if err != nil {
+		tLog.Errorf("V (Federation): failed to acknowledge lease %s of site %s: %v", leaseId, site, err)
+		state := v1alpha2.InternalError
+		if coaErr, ok := err.(v1alpha2.COAError); ok {
+			state = coaErr.State
+		}
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State: state,
+			Body:  []byte(err.Error()),
+		})
+	}
+	deletedCatalogs := make([]model.ObjectMeta, 0)
+	for _, job := range jobs {
+		if job.Action == v1alpha2.JobDelete {
+			deletedCatalogs = append(deletedCatalogs, model.ObjectMeta{
+				Name:      job.Id,
+				Namespace: job.Scope,
+			})
+		}
+	}
+	if len(deletedCatalogs) > 0 {
+		err = f.acknowledgeDeletions(ctx, site, deletedCatalogs)
+		if err != nil {
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.InternalError,
+				Body:  []byte(err.Error()),
+			})
+		}
+	}
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State: v1alpha2.OK,
+	})
+}
 
-		pack := model.SyncPackage{
-			Origin: f.Context.SiteInfo.SiteId,
+// acknowledgeDeletions marks catalog deletions as received by a site. Tombstones are removed once all
+// registered child sites have received them.
+func (f *FederationVendor) acknowledgeDeletions(ctx context.Context, site string, deleted []model.ObjectMeta) error {
+	sites, err := f.SitesManager.ListState(ctx)
+	if err != nil {
+		return err
+	}
+	siteNames := make([]string, 0)
+	for _, s := range sites {
+		if s.Spec.Name != f.Vendor.Context.SiteInfo.SiteId {
+			siteNames = append(siteNames, s.Spec.Name)
+		}
+	}
+	for _, catalog := range deleted {
+		err = f.StagingManager.AcknowledgeTombstone(ctx, catalog.Name, catalog.Namespace, site, siteNames)
+		if err != nil {
+			return err
 		}
+	}
+	return nil
+}
+func (f *FederationVendor) onDeadLetters(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	pCtx, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
+		"method": "onDeadLetters",
+	})
+	defer span.End()
 
+	tLog.Info("V (Federation): onDeadLetters")
+	switch request.Method {
+	case fasthttp.MethodGet:
+		ctx, span := observability.StartSpan("onDeadLetters-GET", pCtx, nil)
+		letters, err := f.StagingManager.ListDeadLetters(ctx, request.Parameters["__site"])
 		if err != nil {
 			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
 				State: v1alpha2.InternalError,
 				Body:  []byte(err.Error()),
 			})
 		}
-		catalogs := make([]model.CatalogState, 0)
-		jobs := make([]v1alpha2.JobData, 0)
-		for _, c := range batch {
-			if c.Action == v1alpha2.JobRun { //TODO: I don't really like this
-				jobs = append(jobs, c)
-			} else {
-				catalog, err := f.CatalogsManager.GetState(ctx, c.Id, namespace)
-				if err != nil {
-					return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
-						State: v1alpha2.InternalError,
-						Body:  []byte(err.Error()),
-					})
-				}
-				catalogs = append(catalogs, catalog)
+		jData, _ := utils.FormatObject(letters, true, request.Parameters["path"], request.Parameters["doc-type"])
+		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        jData,
+			ContentType: "application/json",
+		})
+		if request.Parameters["doc-type"] == "yaml" {
+			resp.ContentType = "application/text"
+		}
+		return resp
+	}
+	resp := v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	}
+	observ_utils.UpdateSpanStatusFromCOAResponse(span, resp)
+	return resp
+}
+func (f *FederationVendor) onConnectivity(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	pCtx, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
+		"method": "onConnectivity",
+	})
+	defer span.End()
+
+	tLog.Info("V (Federation): onConnectivity")
+	switch request.Method {
+	case fasthttp.MethodGet:
+		ctx, span := observability.StartSpan("onConnectivity-GET", pCtx, nil)
+		history, err := f.SitesManager.GetConnectivityHistory(ctx, request.Parameters["__name"])
+		if err != nil {
+			state := v1alpha2.InternalError
+			if v1alpha2.IsNotFound(err) {
+				state = v1alpha2.NotFound
 			}
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: state,
+				Body:  []byte(err.Error()),
+			})
 		}
-		pack.Catalogs = catalogs
-		pack.Jobs = jobs
-		jData, _ := utils.FormatObject(pack, true, request.Parameters["path"], request.Parameters["doc-type"])
+		jData, _ := utils.FormatObject(history, true, request.Parameters["path"], request.Parameters["doc-type"])
 		resp := observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
 			State:       v1alpha2.OK,
 			Body:        jData,
@@ -411,7 +687,7 @@ func (f *FederationVendor) onTrail(request v1alpha2.COARequest) v1alpha2.COAResp
 	return resp
 }
 func (f *FederationVendor) onK8sHook(request v1alpha2.COARequest) v1alpha2.COAResponse {
-	_, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
+	pCtx, span := observability.StartSpan("Federation Vendor", request.Context, &map[string]string{
 		"method": "onK8sHook",
 	})
 	defer span.End()
@@ -421,6 +697,23 @@ func (f *FederationVendor) onK8sHook(request v1alpha2.COARequest) v1alpha2.COARe
 	case fasthttp.MethodPost:
 		objectType := request.Parameters["objectType"]
 		if objectType == "catalog" {
+			if request.Parameters["delete"] == "true" {
+				// catalogs deleted through the API already have their revision, this records deletions made with kubectl
+				namespace, exist := request.Parameters["namespace"]
+				if !exist {
+					namespace = "default"
+				}
+				err := f.CatalogsManager.RecordExternalChange(pCtx, request.Parameters["name"], namespace, nil, "")
+				if err != nil {
+					return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+						State: v1alpha2.InternalError,
+						Body:  []byte(err.Error()),
+					})
+				}
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.OK,
+				})
+			}
 			var catalog model.CatalogState
 			err := json.Unmarshal(request.Body, &catalog)
 			if err != nil {
@@ -429,6 +722,24 @@ func (f *FederationVendor) onK8sHook(request v1alpha2.COARequest) v1alpha2.COARe
 					Body:  []byte(err.Error()),
 				})
 			}
+			// the generation of the Kubernetes object is the ETag of the catalog
+			var object struct {
+				Metadata struct {
+					Generation int64 `json:"generation"`
+				} `json:"metadata"`
+			}
+			json.Unmarshal(request.Body, &object)
+			if catalog.ObjectMeta.Namespace == "" {
+				catalog.ObjectMeta.Namespace = "default"
+			}
+			// catalogs changed through the API already have their revision, this records changes made with kubectl
+			err = f.CatalogsManager.RecordExternalChange(pCtx, catalog.ObjectMeta.Name, catalog.ObjectMeta.Namespace, catalog.Spec, strconv.FormatInt(object.Metadata.Generation, 10))
+			if err != nil {
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.InternalError,
+					Body:  []byte(err.Error()),
+				})
+			}
 			err = f.Vendor.Context.Publish("catalog", v1alpha2.Event{
 				Metadata: map[string]string{
 					"objectType": catalog.Spec.Type,
@@ -1,14 +1,17 @@
 package vendors
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
+	"io"
 	"testing"
 	"time"
 
 	sym_mgr "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	memorygraph "github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/providers/graph/memory"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
@@ -43,8 +46,10 @@ func federationVendorInit() FederationVendor {
 
 	queueProvider := &memoryqueue.MemoryQueueProvider{}
 	queueProvider.Init(memoryqueue.MemoryQueueProvider{})
+	stagingStateProvider := &memorystate.MemoryStateProvider{}
+	stagingStateProvider.Init(memorystate.MemoryStateProviderConfig{})
 	stagingProviders := make(map[string]providers.IProvider)
-	stagingProviders["StateProvider"] = stateProvider
+	stagingProviders["StateProvider"] = stagingStateProvider
 	stagingProviders["QueueProvider"] = queueProvider
 
 	siteProviders := make(map[string]providers.IProvider)
@@ -352,7 +357,7 @@ func TestFederationOnSyncGet(t *testing.T) {
 			Action: v1alpha2.JobUpdate,
 		},
 	})
-	for i := 0; i < 30; i++ {
+	for i := 0; i < 3; i++ {
 		requestGet := &v1alpha2.COARequest{
 			Method:  fasthttp.MethodGet,
 			Context: context.Background(),
@@ -361,12 +366,14 @@ func TestFederationOnSyncGet(t *testing.T) {
 				"count":  "1",
 			},
 		}
+		// catalogs that no longer exist are skipped, their deletion is synced from tombstones
 		response = vendor.onSync(*requestGet)
-		if response.State == v1alpha2.InternalError {
-			break
-		} else {
-			time.Sleep(time.Second)
-		}
+		assert.Equal(t, v1alpha2.OK, response.State)
+		var summary model.SyncPackage
+		err = json.Unmarshal(response.Body, &summary)
+		assert.Nil(t, err)
+		assert.Empty(t, summary.Catalogs)
+		time.Sleep(100 * time.Millisecond)
 	}
 
 	var catalogState = model.CatalogState{
@@ -503,3 +510,327 @@ func TestFederationOnK8SHook(t *testing.T) {
 	response = vendor.onK8sHook(*requestPatch)
 	assert.Equal(t, v1alpha2.MethodNotAllowed, response.State)
 }
+
+func TestFederationOnK8sHookRevisions(t *testing.T) {
+	vendor := federationVendorInit()
+	revisionProvider := &memorystate.MemoryStateProvider{}
+	revisionProvider.Init(memorystate.MemoryStateProviderConfig{})
+	vendor.CatalogsManager.RevisionProvider = revisionProvider
+
+	hook := func(parameters map[string]string, spec map[string]interface{}) v1alpha2.COAResponse {
+		parameters["objectType"] = "catalog"
+		b, err := json.Marshal(map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "catalog1", "namespace": "default", "generation": 3},
+			"spec":     spec,
+		})
+		assert.Nil(t, err)
+		return vendor.onK8sHook(v1alpha2.COARequest{
+			Method:     fasthttp.MethodPost,
+			Context:    context.Background(),
+			Parameters: parameters,
+			Body:       b,
+		})
+	}
+	spec := map[string]interface{}{"type": "config", "properties": map[string]interface{}{"replicas": 1}}
+
+	// the same spec is recorded once
+	assert.Equal(t, v1alpha2.OK, hook(map[string]string{}, spec).State)
+	assert.Equal(t, v1alpha2.OK, hook(map[string]string{}, spec).State)
+	revisions, err := vendor.CatalogsManager.ListRevisions(context.Background(), "catalog1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(revisions))
+	assert.Equal(t, model.CatalogRevisionUpdate, revisions[0].Action)
+	assert.Equal(t, "3", revisions[0].Generation)
+
+	response := hook(map[string]string{"delete": "true", "name": "catalog1", "namespace": "default"}, nil)
+	assert.Equal(t, v1alpha2.OK, response.State)
+	revisions, err = vendor.CatalogsManager.ListRevisions(context.Background(), "catalog1", "default")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(revisions))
+	assert.Equal(t, model.CatalogRevisionDelete, revisions[1].Action)
+}
+
+func TestFederationOnSyncDeletedCatalog(t *testing.T) {
+	vendor := federationVendorInit()
+
+	SiteSpec.Name = "test1"
+	err := vendor.SitesManager.UpsertSpec(context.Background(), SiteSpec.Name, SiteSpec)
+	assert.Nil(t, err)
+
+	var catalogState = model.CatalogState{
+		ObjectMeta: model.ObjectMeta{
+			Name: "catalog1",
+		},
+		Spec: &model.CatalogSpec{
+			Type: "catalog",
+		},
+	}
+	err = vendor.CatalogsManager.UpsertState(context.Background(), catalogState.ObjectMeta.Name, catalogState)
+	assert.Nil(t, err)
+	// wait for the update to be queued, so that it isn't handled after the deletion
+	for i := 0; i < 30 && vendor.StagingManager.QueueProvider.Size(SiteSpec.Name) == 0; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	err = vendor.CatalogsManager.DeleteState(context.Background(), catalogState.ObjectMeta.Name, "default")
+	assert.Nil(t, err)
+
+	// the deletion is recorded as a tombstone
+	var tombstones []model.CatalogTombstone
+	for i := 0; i < 30; i++ {
+		tombstones, err = vendor.StagingManager.ListTombstones(context.Background())
+		assert.Nil(t, err)
+		if len(tombstones) == 1 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, 1, len(tombstones))
+	assert.Equal(t, "catalog1", tombstones[0].Name)
+
+	err = vendor.StagingManager.HandleJobEvent(context.Background(), v1alpha2.Event{
+		Metadata: map[string]string{
+			"site": SiteSpec.Name,
+		},
+		Body: v1alpha2.JobData{
+			Id:     "catalog1",
+			Scope:  "default",
+			Action: v1alpha2.JobDelete,
+		},
+	})
+	assert.Nil(t, err)
+	requestGet := &v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__site": SiteSpec.Name,
+			"count":  "10",
+		},
+	}
+	response := vendor.onSync(*requestGet)
+	assert.Equal(t, v1alpha2.OK, response.State)
+	var summary model.SyncPackage
+	err = json.Unmarshal(response.Body, &summary)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(summary.DeletedCatalogs))
+	assert.Equal(t, "catalog1", summary.DeletedCatalogs[0].Name)
+	assert.Equal(t, "default", summary.DeletedCatalogs[0].Namespace)
+
+	assert.NotEqual(t, "", summary.LeaseId)
+
+	// the deletion is only acknowledged with the batch
+	tombstones, err = vendor.StagingManager.ListTombstones(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(tombstones))
+
+	requestAck := &v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__site": SiteSpec.Name,
+			"lease":  summary.LeaseId,
+		},
+	}
+	response = vendor.onSync(*requestAck)
+	assert.Equal(t, v1alpha2.OK, response.State)
+
+	// the only child site has acknowledged the deletion, so the tombstone is removed
+	tombstones, err = vendor.StagingManager.ListTombstones(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(tombstones))
+
+	response = vendor.onSync(*requestAck)
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+}
+
+func TestFederationOnDeadLetters(t *testing.T) {
+	vendor := federationVendorInit()
+	vendor.StagingManager.MaxDeliveryAttempts = 1
+	vendor.StagingManager.VisibilityTimeout = 10 * time.Millisecond
+
+	err := vendor.StagingManager.HandleJobEvent(context.Background(), v1alpha2.Event{
+		Metadata: map[string]string{
+			"site": "test1",
+		},
+		Body: v1alpha2.JobData{
+			Id:     "job1",
+			Action: v1alpha2.JobRun,
+		},
+	})
+	assert.Nil(t, err)
+	requestGet := &v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__site": "test1",
+			"count":  "10",
+		},
+	}
+	response := vendor.onSync(*requestGet)
+	assert.Equal(t, v1alpha2.OK, response.State)
+	time.Sleep(50 * time.Millisecond)
+	response = vendor.onSync(*requestGet)
+	assert.Equal(t, v1alpha2.OK, response.State)
+
+	response = vendor.onDeadLetters(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__site": "test1",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	var letters []model.DeadLetter
+	err = json.Unmarshal(response.Body, &letters)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(letters))
+	assert.Equal(t, "job1", letters[0].Job.Id)
+}
+
+func TestFederationOnStream(t *testing.T) {
+	heartbeat := utils.StreamHeartbeatInterval
+	utils.StreamHeartbeatInterval = 50 * time.Millisecond
+	defer func() { utils.StreamHeartbeatInterval = heartbeat }()
+
+	vendor := federationVendorInit()
+	response := vendor.onStream(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__site": "test1",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	assert.NotNil(t, response.BodyStream)
+
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		response.BodyStream(bufio.NewWriter(writer))
+		close(done)
+	}()
+	lines := bufio.NewReader(reader)
+	// heartbeat of the idle stream
+	line, err := lines.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "\n", line)
+
+	err = vendor.StagingManager.HandleJobEvent(context.Background(), v1alpha2.Event{
+		Metadata: map[string]string{
+			"site": "test1",
+		},
+		Body: v1alpha2.JobData{
+			Id:     "job1",
+			Action: v1alpha2.JobRun,
+		},
+	})
+	assert.Nil(t, err)
+	var pack model.SyncPackage
+	for {
+		line, err = lines.ReadString('\n')
+		assert.Nil(t, err)
+		if line != "\n" {
+			break
+		}
+	}
+	err = json.Unmarshal([]byte(line), &pack)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", pack.LeaseId)
+	assert.Equal(t, 1, len(pack.Jobs))
+	assert.Equal(t, "job1", pack.Jobs[0].Id)
+
+	response = vendor.onSync(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__site": "test1",
+			"lease":  pack.LeaseId,
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+
+	// the stream ends when the site goes away
+	reader.Close()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "stream didn't end after the site disconnected")
+	}
+}
+
+func TestFederationOnStreamWithoutSite(t *testing.T) {
+	vendor := federationVendorInit()
+	response := vendor.onStream(v1alpha2.COARequest{
+		Method:     fasthttp.MethodGet,
+		Context:    context.Background(),
+		Parameters: map[string]string{},
+	})
+	assert.Equal(t, v1alpha2.BadRequest, response.State)
+	assert.Nil(t, response.BodyStream)
+}
+
+func TestFederationOnConnectivity(t *testing.T) {
+	vendor := federationVendorInit()
+	err := vendor.SitesManager.ReportState(context.Background(), model.SiteState{
+		Id:   "child1",
+		Spec: &SiteSpec,
+	})
+	assert.Nil(t, err)
+
+	response := vendor.onConnectivity(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__name": "child1",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	var history []model.SiteConnectivity
+	err = json.Unmarshal(response.Body, &history)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(history))
+	assert.True(t, history[0].IsOnline)
+
+	response = vendor.onConnectivity(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"__name": "unknown",
+		},
+	})
+	assert.Equal(t, v1alpha2.NotFound, response.State)
+}
+
+func TestFederationOnRegistryLocateInstance(t *testing.T) {
+	vendor := federationVendorInit()
+	err := vendor.SitesManager.ReportState(context.Background(), model.SiteState{
+		Id:   "child1",
+		Spec: &SiteSpec,
+		Status: &model.SiteStatus{
+			InstanceStatuses: map[string]model.SiteInstanceStatus{
+				"child2/default/instance1": {
+					State:     v1alpha2.OK,
+					Name:      "instance1",
+					Namespace: "default",
+					Site:      "child2",
+					Path:      []string{"child1", "child2"},
+				},
+			},
+		},
+	})
+	assert.Nil(t, err)
+
+	response := vendor.onRegistry(v1alpha2.COARequest{
+		Method:  fasthttp.MethodGet,
+		Context: context.Background(),
+		Parameters: map[string]string{
+			"instance": "instance1",
+		},
+	})
+	assert.Equal(t, v1alpha2.OK, response.State)
+	var instances []model.SiteInstanceStatus
+	err = json.Unmarshal(response.Body, &instances)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(instances))
+	assert.Equal(t, "child2", instances[0].Site)
+	assert.Equal(t, []string{vendor.Context.SiteInfo.SiteId, "child1", "child2"}, instances[0].Path)
+}
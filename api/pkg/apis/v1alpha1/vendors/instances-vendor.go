@@ -173,11 +173,14 @@ func (c *InstancesVendor) onInstances(request v1alpha2.COARequest) v1alpha2.COAR
 				instance.ObjectMeta.Name = id
 			}
 		}
+		if isDryRun(request) {
+			return observ_utils.CloseSpanWithCOAResponse(span, dryRunResponse(c.InstancesManager.ValidateUpsert(ctx, id, instance)))
+		}
 		err := c.InstancesManager.UpsertState(ctx, id, instance)
 		if err != nil {
 			iLog.Infof("V (Instances): onInstances failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
 			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
-				State: v1alpha2.InternalError,
+				State: upsertErrorState(err),
 				Body:  []byte(err.Error()),
 			})
 		}
@@ -204,6 +204,48 @@ func TestInstancesTargetSelector(t *testing.T) {
 	assert.Equal(t, "value1", instance.Spec.Target.Selector["property1"])
 }
 
+func TestInstancesDryRun(t *testing.T) {
+	vendor := createInstancesVendor()
+	instance := model.InstanceState{
+		ObjectMeta: model.ObjectMeta{
+			Name: "instance2",
+		},
+		Spec: &model.InstanceSpec{
+			Solution: "solution1",
+		},
+	}
+	data, _ := json.Marshal(instance)
+	// dry runs and actual upserts are validated by the same code in the manager
+	for _, dryRun := range []string{"true", "false"} {
+		resp := vendor.onInstances(v1alpha2.COARequest{
+			Method: fasthttp.MethodPost,
+			Body:   data,
+			Parameters: map[string]string{
+				"__name":  "instance1",
+				"dry-run": dryRun,
+			},
+			Context: context.Background(),
+		})
+		assert.Equal(t, v1alpha2.BadRequest, resp.State)
+		assert.Contains(t, string(resp.Body), "does not match name in request")
+	}
+
+	instance.ObjectMeta.Name = "instance1"
+	data, _ = json.Marshal(instance)
+	resp := vendor.onInstances(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Body:   data,
+		Parameters: map[string]string{
+			"__name":  "instance1",
+			"dry-run": "true",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	_, err := vendor.InstancesManager.GetState(context.Background(), "instance1", "default")
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
 func TestInstancesWrongMethod(t *testing.T) {
 	vendor := createInstancesVendor()
 	resp := vendor.onInstances(v1alpha2.COARequest{
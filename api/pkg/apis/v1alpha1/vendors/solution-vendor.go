@@ -14,6 +14,7 @@ import (
 	"github.com/eclipse-symphony/symphony/api/constants"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/solution"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
@@ -72,6 +73,13 @@ func (o *SolutionVendor) GetEndpoints() []v1alpha2.Endpoint {
 			Parameters: []string{"delete?"},
 			Handler:    o.onReconcile,
 		},
+		{
+			Methods:    []string{fasthttp.MethodPost},
+			Route:      route + "/plan",
+			Version:    o.Version,
+			Parameters: []string{"delete?"},
+			Handler:    o.onPlan,
+		},
 		{
 			Methods: []string{fasthttp.MethodGet, fasthttp.MethodPost},
 			Route:   route + "/queue",
@@ -246,6 +254,91 @@ func (c *SolutionVendor) onReconcile(request v1alpha2.COARequest) v1alpha2.COARe
 	})
 }
 
+// completeDeployment creates the deployment of a plan request that has no assignments the same way deployments are
+// created for instances: the targets are matched against the target selector of the instance, and the components
+// of the solution are assigned to the matched targets
+func completeDeployment(deployment model.DeploymentSpec, namespace string) (model.DeploymentSpec, error) {
+	targets := make([]model.TargetState, 0, len(deployment.Targets))
+	for name, target := range deployment.Targets {
+		if target.ObjectMeta.Name == "" {
+			target.ObjectMeta.Name = name
+		}
+		targets = append(targets, target)
+	}
+	return utils.CreateSymphonyDeployment(deployment.Instance, deployment.Solution, utils.MatchTargets(deployment.Instance, targets), nil, namespace)
+}
+
+func (c *SolutionVendor) onPlan(request v1alpha2.COARequest) v1alpha2.COAResponse {
+	rContext, span := observability.StartSpan("Solution Vendor", request.Context, &map[string]string{
+		"method": "onPlan",
+	})
+	defer span.End()
+
+	sLog.Infof("V (Solution): onPlan, method: %s, traceId: %s", request.Method, span.SpanContext().TraceID().String())
+	namespace, exist := request.Parameters["namespace"]
+	if !exist {
+		namespace = constants.DefaultScope
+	}
+	switch request.Method {
+	case fasthttp.MethodPost:
+		ctx, span := observability.StartSpan("onPlan-POST", rContext, nil)
+		defer span.End()
+		var deployment model.DeploymentSpec
+		err := json.Unmarshal(request.Body, &deployment)
+		if err != nil {
+			sLog.Infof("V (Solution): onPlan failed POST - unmarshal request %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte(err.Error()),
+			})
+		}
+		if deployment.Solution.Spec == nil || deployment.Instance.Spec == nil {
+			sLog.Infof("V (Solution): onPlan failed POST - deployment has no solution or instance spec, traceId: %s", span.SpanContext().TraceID().String())
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.BadRequest,
+				Body:  []byte("deployment has no solution or instance spec"),
+			})
+		}
+		if deployment.Assignments == nil {
+			deployment, err = completeDeployment(deployment, namespace)
+			if err != nil {
+				sLog.Infof("V (Solution): onPlan failed POST - assign components %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+				return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+					State: v1alpha2.BadRequest,
+					Body:  []byte(err.Error()),
+				})
+			}
+		}
+		delete := request.Parameters["delete"]
+		targetName := ""
+		if request.Metadata != nil {
+			if v, ok := request.Metadata["active-target"]; ok {
+				targetName = v
+			}
+		}
+		preview, err := c.SolutionManager.Plan(ctx, deployment, delete == "true", namespace, targetName)
+		if err != nil {
+			sLog.Infof("V (Solution): onPlan failed POST - plan %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
+			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+				State: v1alpha2.InternalError,
+				Body:  []byte(err.Error()),
+			})
+		}
+		data, _ := json.Marshal(preview)
+		return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+			State:       v1alpha2.OK,
+			Body:        data,
+			ContentType: "application/json",
+		})
+	}
+	sLog.Infof("V (Solution): onPlan failed - 405 method not allowed, traceId: %s", span.SpanContext().TraceID().String())
+	return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
+		State:       v1alpha2.MethodNotAllowed,
+		Body:        []byte("{\"result\":\"405 - method not allowed\"}"),
+		ContentType: "application/json",
+	})
+}
+
 func (c *SolutionVendor) onApplyDeployment(request v1alpha2.COARequest) v1alpha2.COAResponse {
 	_, span := observability.StartSpan("Solution Vendor", request.Context, &map[string]string{
 		"method": "onApplyDeployment",
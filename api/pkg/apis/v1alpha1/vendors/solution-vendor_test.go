@@ -171,7 +171,7 @@ func TestSolutionEndpoints(t *testing.T) {
 	vendor := createSolutionVendor()
 	vendor.Route = "solution"
 	endpoints := vendor.GetEndpoints()
-	assert.Equal(t, 3, len(endpoints))
+	assert.Equal(t, 4, len(endpoints))
 }
 
 func TestSolutionInfo(t *testing.T) {
@@ -356,6 +356,81 @@ func TestSolutionReconcile(t *testing.T) {
 	json.Unmarshal(resp.Body, &summary)
 	assert.False(t, summary.Skipped)
 }
+func TestSolutionPlan(t *testing.T) {
+	var preview model.PlanPreview
+	vendor := createSolutionVendor()
+
+	deployment := createDeployment2Mocks1Target(uuid.New().String())
+	data, _ := json.Marshal(deployment)
+	resp := vendor.onPlan(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Body:    data,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	err := json.Unmarshal(resp.Body, &preview)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(preview.Steps))
+	assert.False(t, preview.Steps[0].Skipped)
+	assert.Equal(t, 2, len(preview.Steps[0].Components))
+
+	// planning doesn't deploy anything, so the deployment isn't skipped
+	var summary model.SummarySpec
+	resp = vendor.onReconcile(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Body:    data,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	json.Unmarshal(resp.Body, &summary)
+	assert.False(t, summary.Skipped)
+
+	resp = vendor.onPlan(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Body:    data,
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+	json.Unmarshal(resp.Body, &preview)
+	assert.True(t, preview.Steps[0].Skipped)
+
+	resp = vendor.onPlan(v1alpha2.COARequest{
+		Method:  fasthttp.MethodPost,
+		Body:    []byte("{}"),
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+}
+func TestSolutionPlanWithoutAssignments(t *testing.T) {
+	vendor := createSolutionVendor()
+
+	testCases := []struct {
+		name   string
+		target string
+		steps  int
+	}{
+		{name: "matched target", target: "T1", steps: 1},
+		{name: "no matched target", target: "T2", steps: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deployment := createDeployment2Mocks1Target(uuid.New().String())
+			deployment.Assignments = nil
+			deployment.Instance.Spec.Target.Name = tc.target
+			data, _ := json.Marshal(deployment)
+			resp := vendor.onPlan(v1alpha2.COARequest{
+				Method:  fasthttp.MethodPost,
+				Body:    data,
+				Context: context.Background(),
+			})
+			assert.Equal(t, v1alpha2.OK, resp.State)
+			var preview model.PlanPreview
+			err := json.Unmarshal(resp.Body, &preview)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.steps, len(preview.Steps))
+		})
+	}
+}
 func TestSolutionQueue(t *testing.T) {
 	vendor := createSolutionVendor()
 	resp := vendor.onQueue(v1alpha2.COARequest{
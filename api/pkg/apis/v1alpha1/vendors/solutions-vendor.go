@@ -10,6 +10,7 @@ import (
 	"encoding/json"
 
 	"github.com/eclipse-symphony/symphony/api/constants"
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/catalogs"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/managers/solutions"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/utils"
@@ -29,6 +30,9 @@ var uLog = logger.NewLogger("coa.runtime")
 type SolutionsVendor struct {
 	vendors.Vendor
 	SolutionsManager *solutions.SolutionsManager
+	// CatalogsManager is optional, the solutions manager uses it to check component properties against the schema
+	// catalogs of the components
+	CatalogsManager *catalogs.CatalogsManager
 }
 
 func (o *SolutionsVendor) GetInfo() vendors.VendorInfo {
@@ -48,10 +52,16 @@ func (e *SolutionsVendor) Init(config vendors.VendorConfig, factories []managers
 		if c, ok := m.(*solutions.SolutionsManager); ok {
 			e.SolutionsManager = c
 		}
+		if c, ok := m.(*catalogs.CatalogsManager); ok {
+			e.CatalogsManager = c
+		}
 	}
 	if e.SolutionsManager == nil {
 		return v1alpha2.NewCOAError(nil, "solutions manager is not supplied", v1alpha2.MissingConfig)
 	}
+	if e.CatalogsManager != nil {
+		e.SolutionsManager.SchemaValidator = e.CatalogsManager
+	}
 	return nil
 }
 
@@ -156,11 +166,14 @@ func (c *SolutionsVendor) onSolutions(request v1alpha2.COARequest) v1alpha2.COAR
 				solution.ObjectMeta.Name = id
 			}
 		}
+		if isDryRun(request) {
+			return observ_utils.CloseSpanWithCOAResponse(span, dryRunResponse(c.SolutionsManager.ValidateUpsert(ctx, id, solution)))
+		}
 		err := c.SolutionsManager.UpsertState(ctx, id, solution)
 		if err != nil {
 			uLog.Infof("V (Solutions): onSolutions failed - %s, traceId: %s", err.Error(), span.SpanContext().TraceID().String())
 			return observ_utils.CloseSpanWithCOAResponse(span, v1alpha2.COAResponse{
-				State: v1alpha2.InternalError,
+				State: upsertErrorState(err),
 				Body:  []byte(err.Error()),
 			})
 		}
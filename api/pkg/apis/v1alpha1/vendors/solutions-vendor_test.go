@@ -138,3 +138,163 @@ func TestSolutionsOnSolutions(t *testing.T) {
 	})
 	assert.Equal(t, v1alpha2.OK, resp.State)
 }
+
+func TestSolutionsOnSolutionsDryRun(t *testing.T) {
+	vendor := createSolutionsVendor()
+	solution := model.SolutionState{
+		ObjectMeta: model.ObjectMeta{
+			Name: "solution1",
+		},
+		Spec: &model.SolutionSpec{
+			Components: []model.ComponentSpec{{Name: "a"}},
+		},
+	}
+	data, _ := json.Marshal(solution)
+	resp := vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Body:   data,
+		Parameters: map[string]string{
+			"__name":  "solution1",
+			"dry-run": "true",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+
+	// a dry run doesn't store the solution
+	_, err := vendor.SolutionsManager.GetState(context.Background(), "solution1", "default")
+	assert.True(t, v1alpha2.IsNotFound(err))
+
+	solution.Spec.Components = append(solution.Spec.Components, model.ComponentSpec{Name: "a"})
+	data, _ = json.Marshal(solution)
+	resp = vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Body:   data,
+		Parameters: map[string]string{
+			"__name":  "solution1",
+			"dry-run": "true",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+	assert.Contains(t, string(resp.Body), "component a is defined more than once")
+
+	resp = vendor.onSolutions(v1alpha2.COARequest{
+		Method: fasthttp.MethodPost,
+		Body:   data,
+		Parameters: map[string]string{
+			"__name":  "solution2",
+			"dry-run": "true",
+		},
+		Context: context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+}
+
+func TestSolutionsOnSolutionsComponentSchemas(t *testing.T) {
+	solutionsState := memorystate.MemoryStateProvider{}
+	solutionsState.Init(memorystate.MemoryStateProviderConfig{})
+	catalogsState := memorystate.MemoryStateProvider{}
+	catalogsState.Init(memorystate.MemoryStateProviderConfig{})
+	pubSubProvider := memory.InMemoryPubSubProvider{}
+	pubSubProvider.Init(memory.InMemoryPubSubConfig{Name: "test"})
+	vendor := SolutionsVendor{}
+	err := vendor.Init(vendors.VendorConfig{
+		Managers: []managers.ManagerConfig{
+			{
+				Name:       "solutions-manager",
+				Type:       "managers.symphony.solutions",
+				Properties: map[string]string{"providers.state": "mem-state"},
+				Providers: map[string]managers.ProviderConfig{
+					"mem-state": {Type: "providers.state.memory", Config: memorystate.MemoryStateProviderConfig{}},
+				},
+			},
+			{
+				Name:       "catalogs-manager",
+				Type:       "managers.symphony.catalogs",
+				Properties: map[string]string{"providers.state": "mem-state"},
+				Providers: map[string]managers.ProviderConfig{
+					"mem-state": {Type: "providers.state.memory", Config: memorystate.MemoryStateProviderConfig{}},
+				},
+			},
+		},
+	}, []managers.IManagerFactroy{
+		&sym_mgr.SymphonyManagerFactory{},
+	}, map[string]map[string]providers.IProvider{
+		"solutions-manager": {"mem-state": &solutionsState},
+		"catalogs-manager":  {"mem-state": &catalogsState},
+	}, &pubSubProvider)
+	assert.Nil(t, err)
+	assert.NotNil(t, vendor.CatalogsManager)
+
+	var schema interface{}
+	json.Unmarshal([]byte(`{
+		"type": "object",
+		"properties": {
+			"image": {"type": "string"},
+			"replicas": {"type": "integer", "minimum": 1}
+		},
+		"required": ["image"]
+	}`), &schema)
+	err = vendor.CatalogsManager.UpsertState(context.Background(), "container-schema", model.CatalogState{
+		ObjectMeta: model.ObjectMeta{Name: "container-schema"},
+		Spec: &model.CatalogSpec{
+			Type:       "schema",
+			Properties: map[string]interface{}{"spec": schema},
+		},
+	})
+	assert.Nil(t, err)
+
+	solution := model.SolutionState{
+		ObjectMeta: model.ObjectMeta{Name: "solution1"},
+		Spec: &model.SolutionSpec{
+			Components: []model.ComponentSpec{
+				{
+					Name:       "unchecked",
+					Properties: map[string]interface{}{"replicas": "many"},
+				},
+				{
+					Name:       "web",
+					Metadata:   map[string]string{"schema": "container-schema"},
+					Properties: map[string]interface{}{"replicas": 0},
+				},
+			},
+		},
+	}
+	data, _ := json.Marshal(solution)
+	for _, dryRun := range []string{"true", "false"} {
+		resp := vendor.onSolutions(v1alpha2.COARequest{
+			Method:     fasthttp.MethodPost,
+			Body:       data,
+			Parameters: map[string]string{"__name": "solution1", "dry-run": dryRun},
+			Context:    context.Background(),
+		})
+		assert.Equal(t, v1alpha2.BadRequest, resp.State)
+		assert.Contains(t, string(resp.Body), "/spec/components/1/properties/replicas")
+		assert.Contains(t, string(resp.Body), "image")
+		assert.NotContains(t, string(resp.Body), "/spec/components/0")
+	}
+	_, err = vendor.SolutionsManager.GetState(context.Background(), "solution1", "default")
+	assert.True(t, v1alpha2.IsNotFound(err))
+
+	solution.Spec.Components[1].Properties = map[string]interface{}{"image": "nginx", "replicas": 2}
+	data, _ = json.Marshal(solution)
+	resp := vendor.onSolutions(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Body:       data,
+		Parameters: map[string]string{"__name": "solution1"},
+		Context:    context.Background(),
+	})
+	assert.Equal(t, v1alpha2.OK, resp.State)
+
+	solution.Spec.Components[1].Metadata["schema"] = "missing-schema"
+	data, _ = json.Marshal(solution)
+	resp = vendor.onSolutions(v1alpha2.COARequest{
+		Method:     fasthttp.MethodPost,
+		Body:       data,
+		Parameters: map[string]string{"__name": "solution1", "dry-run": "true"},
+		Context:    context.Background(),
+	})
+	assert.Equal(t, v1alpha2.BadRequest, resp.State)
+	assert.Contains(t, string(resp.Body), "missing-schema")
+}
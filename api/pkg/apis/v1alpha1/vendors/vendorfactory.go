@@ -53,6 +53,8 @@ func (c SymphonyVendorFactory) CreateVendor(config vendors.VendorConfig) (vendor
 		return &SettingsVendor{}, nil
 	case "vendors.trails":
 		return &TrailsVendor{}, nil
+	case "vendors.backup":
+		return &BackupVendor{}, nil
 	case "vendors.backgroundjob":
 		return &BackgroundJobVendor{}, nil
 	case "vendors.visualization.client":
@@ -0,0 +1,249 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+// injectedKeys are the inputs and outputs the stage manager adds to every stage
+var injectedKeys = map[string]bool{
+	"__campaign":             true,
+	"__namespace":            true,
+	"__activation":           true,
+	"__activationGeneration": true,
+	"__stage":                true,
+	"__previousStage":        true,
+	"__site":                 true,
+	"__schedule":             true,
+}
+
+var ActivationCmd = &cobra.Command{
+	Use:   "activation",
+	Short: "Manage campaign activations",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var ActivationListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List activations",
+	Run: func(cmd *cobra.Command, args []string) {
+		url, token, ok := loginToContext()
+		if !ok {
+			os.Exit(2)
+		}
+		list, err := utils.ListObjects(url, token, "Activation", statusNamespace)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		activations := make([]model.ActivationState, 0, len(list))
+		for _, item := range list {
+			var activation model.ActivationState
+			if err = utils.ConvertObject(item, &activation); err != nil {
+				fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+				os.Exit(2)
+			}
+			activations = append(activations, activation)
+		}
+		sort.Slice(activations, func(i, j int) bool {
+			return activations[i].ObjectMeta.Name < activations[j].ObjectMeta.Name
+		})
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Name", "Campaign", "Stage", "Status", "Updated"})
+		for _, activation := range activations {
+			status := objectStatus{Kind: "Activation", Activation: &activation}
+			campaign, stage, updated := "", "", ""
+			if activation.Spec != nil {
+				campaign = activation.Spec.Campaign
+			}
+			if activation.Status != nil {
+				stage = activation.Status.Stage
+				updated = activation.Status.UpdateTime
+			}
+			t.AppendRow(table.Row{activation.ObjectMeta.Name, campaign, stage, status.Result(), updated})
+		}
+		t.SetStyle(table.StyleColoredBright)
+		t.Render()
+	},
+}
+
+var ActivationGetCmd = &cobra.Command{
+	Use:   "get <name>",
+	Short: "Show the status of an activation",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Printf("\n%sPlease specify an activation%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(2)
+		}
+		status, ok := loadStatusFromArgs([]string{"activation", args[0]})
+		if !ok {
+			os.Exit(2)
+		}
+		printStatus(status)
+	},
+}
+
+var ActivationDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete an activation",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Printf("\n%sPlease specify an activation%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(2)
+		}
+		url, token, ok := loginToContext()
+		if !ok {
+			os.Exit(2)
+		}
+		result, err := utils.DeleteObject(url, token, utils.ManifestObject{Kind: "Activation", Name: args[0], Namespace: statusNamespace})
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		fmt.Printf("  activation/%s %s%s%s\n", args[0], utils.ColorCyan(), result, utils.ColorReset())
+	},
+}
+
+var ActivationLogsCmd = &cobra.Command{
+	Use:   "logs <name>",
+	Short: "Follow the stages of an activation until it completes",
+	Long: `Follow the stages of an activation until it completes, printing the inputs, outputs and per-site results
+of each stage. The status is polled, so a stage that completes within the polling interval may not be shown.
+The exit code is 0 when the activation succeeded, 1 when it failed, 2 when it can't be read and 3 when the
+timeout is reached.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Printf("\n%sPlease specify an activation%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(2)
+		}
+		url, token, ok := loginToContext()
+		if !ok {
+			os.Exit(2)
+		}
+		os.Exit(followActivation(url, token, args[0]))
+	},
+}
+
+// followActivation prints each stage of an activation until it completes, and returns the exit code of watch
+func followActivation(url string, token string, name string) int {
+	status, err := getStatus(url, token, "Activation", name, statusNamespace)
+	if err != nil {
+		fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return watchError
+	}
+	last := ""
+	status, code := pollStatus(url, token, status, func(status objectStatus) {
+		s := status.Activation.Status
+		if status.Result() == statusPending {
+			if last == "" {
+				fmt.Printf("%s  waiting for the activation to start\n", time.Now().Format("15:04:05"))
+				last = "waiting"
+			}
+			return
+		}
+		key := fmt.Sprintf("%s|%s|%d|%t|%s", s.Stage, s.NextStage, s.Status, s.IsActive, s.UpdateTime)
+		if key == last {
+			return
+		}
+		last = key
+		printStage(s)
+	})
+	if code == watchSucceeded || code == watchFailed {
+		fmt.Printf("\nactivation/%s %s\n\n", name, colorResult(status.Result()))
+	}
+	return code
+}
+
+func printStage(status *model.ActivationStatus) {
+	line := fmt.Sprintf("stage %s: %s", status.Stage, status.Status.String())
+	if status.NextStage != "" {
+		line += ", next stage " + status.NextStage
+	}
+	fmt.Printf("%s  %s\n", time.Now().Format("15:04:05"), line)
+	if status.ErrorMessage != "" {
+		fmt.Printf("    %serror: %s%s\n", utils.ColorRed(), status.ErrorMessage, utils.ColorReset())
+	}
+	printStageValues("inputs", status.Inputs)
+	sites, outputs := splitSiteOutputs(status.Outputs)
+	printStageValues("outputs", outputs)
+	siteNames := make([]string, 0, len(sites))
+	for site := range sites {
+		siteNames = append(siteNames, site)
+	}
+	sort.Strings(siteNames)
+	for _, site := range siteNames {
+		printStageValues("site "+site, sites[site])
+	}
+}
+
+// splitSiteOutputs separates the outputs of remote sites, which are prefixed with "<site>." and include a
+// "<site>.__status" output, from the outputs of the current site
+func splitSiteOutputs(outputs map[string]interface{}) (map[string]map[string]interface{}, map[string]interface{}) {
+	sites := make(map[string]map[string]interface{})
+	for k := range outputs {
+		if strings.HasSuffix(k, ".__status") {
+			sites[strings.TrimSuffix(k, ".__status")] = make(map[string]interface{})
+		}
+	}
+	local := make(map[string]interface{})
+	for k, v := range outputs {
+		found := false
+		for site := range sites {
+			if strings.HasPrefix(k, site+".") {
+				sites[site][strings.TrimPrefix(k, site+".")] = v
+				found = true
+				break
+			}
+		}
+		if !found {
+			local[k] = v
+		}
+	}
+	return sites, local
+}
+
+func printStageValues(title string, values map[string]interface{}) {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		if !injectedKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) == 0 {
+		return
+	}
+	sort.Strings(keys)
+	fmt.Printf("    %s:\n", title)
+	for _, k := range keys {
+		fmt.Printf("      %s: %s\n", k, formatValue(values[k]))
+	}
+}
+
+func init() {
+	ActivationCmd.PersistentFlags().StringVarP(&statusNamespace, "namespace", "n", "default", "Namespace of the activations")
+	ActivationCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	ActivationCmd.PersistentFlags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	ActivationLogsCmd.Flags().DurationVarP(&watchTimeout, "timeout", "t", 10*time.Minute, "How long to wait, 0 waits forever")
+	ActivationLogsCmd.Flags().DurationVarP(&watchInterval, "interval", "i", time.Second, "How often the status is polled")
+	ActivationCmd.AddCommand(ActivationListCmd)
+	ActivationCmd.AddCommand(ActivationGetCmd)
+	ActivationCmd.AddCommand(ActivationDeleteCmd)
+	ActivationCmd.AddCommand(ActivationLogsCmd)
+	RootCmd.AddCommand(ActivationCmd)
+}
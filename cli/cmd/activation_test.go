@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func activationResponse(status *model.ActivationStatus) fakeResponse {
+	return fakeResponse{body: []model.ActivationState{{
+		ObjectMeta: model.ObjectMeta{Name: "activation1", Namespace: "default"},
+		Spec:       &model.ActivationSpec{Campaign: "campaign1"},
+		Status:     status,
+	}}}
+}
+
+func TestFollowActivationExitCodes(t *testing.T) {
+	testCases := []struct {
+		name        string
+		timeout     time.Duration
+		activations []fakeResponse
+		code        int
+	}{
+		{
+			name: "succeeded",
+			activations: []fakeResponse{
+				activationResponse(nil),
+				activationResponse(&model.ActivationStatus{Stage: "deploy", Status: v1alpha2.Running, IsActive: true}),
+				activationResponse(&model.ActivationStatus{Stage: "deploy", NextStage: "test", Status: v1alpha2.Running, IsActive: true}),
+				activationResponse(&model.ActivationStatus{Stage: "test", Status: v1alpha2.Done}),
+			},
+			code: watchSucceeded,
+		},
+		{
+			name: "failed",
+			activations: []fakeResponse{
+				activationResponse(&model.ActivationStatus{Stage: "deploy", Status: v1alpha2.Running, IsActive: true}),
+				activationResponse(&model.ActivationStatus{Stage: "deploy", Status: v1alpha2.InternalError, ErrorMessage: "failed to deploy"}),
+			},
+			code: watchFailed,
+		},
+		{
+			name:        "not found",
+			activations: []fakeResponse{{body: []model.ActivationState{}}},
+			code:        watchError,
+		},
+		{
+			name:        "API unavailable",
+			activations: []fakeResponse{{status: http.StatusInternalServerError}},
+			code:        watchError,
+		},
+		{
+			name:        "paused",
+			timeout:     20 * time.Millisecond,
+			activations: []fakeResponse{activationResponse(&model.ActivationStatus{Stage: "approve", Status: v1alpha2.Paused})},
+			code:        watchTimedOut,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeout := tc.timeout
+			if timeout == 0 {
+				timeout = 5 * time.Second
+			}
+			setWatchOptions(t, timeout)
+			server := newFakeAPI(t, map[string][]fakeResponse{"/activations/registry": tc.activations})
+			assert.Equal(t, tc.code, followActivation(server.URL, "", "activation1"))
+		})
+	}
+}
+
+func TestActivationStatusResult(t *testing.T) {
+	testCases := []struct {
+		name   string
+		status *model.ActivationStatus
+		result string
+	}{
+		{name: "not started", result: statusPending},
+		{name: "no stage reported", status: &model.ActivationStatus{}, result: statusPending},
+		{name: "running", status: &model.ActivationStatus{Stage: "deploy", Status: v1alpha2.Running, IsActive: true}, result: statusRunning},
+		{name: "between stages", status: &model.ActivationStatus{Stage: "deploy", Status: v1alpha2.OK}, result: statusRunning},
+		{name: "paused", status: &model.ActivationStatus{Stage: "approve", Status: v1alpha2.Paused}, result: statusPaused},
+		{name: "done", status: &model.ActivationStatus{Stage: "test", Status: v1alpha2.Done}, result: statusSucceeded},
+		{name: "failed", status: &model.ActivationStatus{Stage: "test", Status: v1alpha2.InternalError}, result: statusFailed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := objectStatus{Kind: "Activation", Name: "activation1", Activation: &model.ActivationState{Status: tc.status}}
+			assert.Equal(t, tc.result, status.Result())
+		})
+	}
+}
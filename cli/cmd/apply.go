@@ -0,0 +1,108 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestFiles []string
+	dryRun        bool
+)
+
+var ApplyCmd = &cobra.Command{
+	Use:   "apply",
+	Short: "Create or update Symphony objects from manifest files",
+	Run: func(cmd *cobra.Command, args []string) {
+		objects, url, token, ok := loadManifests()
+		if !ok {
+			return
+		}
+		utils.SortForApply(objects)
+		suffix := ""
+		if dryRun {
+			suffix = " (dry run)"
+		}
+		failed := false
+		for _, object := range objects {
+			result, err := utils.ApplyObject(url, token, object, dryRun)
+			if err != nil {
+				fmt.Printf("%s  %s failed: %s%s\n", utils.ColorRed(), object.String(), err.Error(), utils.ColorReset())
+				failed = true
+				continue
+			}
+			fmt.Printf("  %s %s%s%s%s\n", object.String(), utils.ColorCyan(), result, suffix, utils.ColorReset())
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+var DeleteCmd = &cobra.Command{
+	Use:   "delete",
+	Short: "Delete Symphony objects defined in manifest files",
+	Run: func(cmd *cobra.Command, args []string) {
+		objects, url, token, ok := loadManifests()
+		if !ok {
+			return
+		}
+		utils.SortForDelete(objects)
+		failed := false
+		for _, object := range objects {
+			result, err := utils.DeleteObject(url, token, object)
+			if err != nil {
+				fmt.Printf("%s  %s failed: %s%s\n", utils.ColorRed(), object.String(), err.Error(), utils.ColorReset())
+				failed = true
+				continue
+			}
+			fmt.Printf("  %s %s%s%s\n", object.String(), utils.ColorCyan(), result, utils.ColorReset())
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// loadManifests reads the objects of the manifest files and logs in to the current context
+func loadManifests() ([]utils.ManifestObject, string, string, bool) {
+	if len(manifestFiles) == 0 {
+		fmt.Printf("\n%sPlease specify manifest files or directories with -f%s\n\n", utils.ColorRed(), utils.ColorReset())
+		return nil, "", "", false
+	}
+	objects, err := utils.ReadManifests(manifestFiles)
+	if err != nil {
+		fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return nil, "", "", false
+	}
+	if len(objects) == 0 {
+		fmt.Printf("\n%s  No objects found%s\n\n", utils.ColorYellow(), utils.ColorReset())
+		return nil, "", "", false
+	}
+	url, token, ok := loginToContext()
+	if !ok {
+		return nil, "", "", false
+	}
+	return objects, url, token, true
+}
+
+func init() {
+	ApplyCmd.Flags().StringArrayVarP(&manifestFiles, "file", "f", nil, "Manifest file or directory, - for stdin. Can be repeated.")
+	ApplyCmd.Flags().BoolVarP(&dryRun, "dry-run", "", false, "Validate the objects on the server without storing them")
+	ApplyCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	ApplyCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	DeleteCmd.Flags().StringArrayVarP(&manifestFiles, "file", "f", nil, "Manifest file or directory, - for stdin. Can be repeated.")
+	DeleteCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	DeleteCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	RootCmd.AddCommand(ApplyCmd)
+	RootCmd.AddCommand(DeleteCmd)
+}
@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupFile        string
+	restorePolicy     string
+	noDeploymentState bool
+	restoreRedeploy   bool
+	passwordHashes    bool
+)
+
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Export all objects of a Symphony control plane to an archive",
+	Long: `Export the sites, users, targets, devices, models, skills, solutions, catalogs, campaigns, instances and
+activations of all namespaces, with the deployment states of the instances and targets, to a versioned archive.
+The archive is gzipped when the file name ends with .gz, and written to the standard output without a file.
+The password hashes of the users are left out unless --password-hashes is set. Only administrators can export.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		url, token, ok := loginToContext()
+		if !ok {
+			os.Exit(2)
+		}
+		archive, err := utils.ExportBackup(url, token, passwordHashes)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		if err = writeArchive(backupFile, archive); err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		if backupFile != "" {
+			fmt.Printf("\n  %sexported %d objects to %s%s\n\n", utils.ColorGreen(), archiveSize(archive), backupFile, utils.ColorReset())
+		}
+	},
+}
+
+var RestoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "Restore the objects of an archive exported by maestro backup",
+	Long: `Restore the objects of an archive exported by maestro backup, in dependency order. The conflict policy decides
+what happens to objects that already exist: skip keeps them, overwrite replaces them, and fail restores nothing
+when any object exists. Restored instances and targets aren't deployed again unless --redeploy is set.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if backupFile == "" {
+			fmt.Printf("\n%sPlease specify an archive with -f%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(2)
+		}
+		archive, err := readArchive(backupFile)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		url, token, ok := loginToContext()
+		if !ok {
+			os.Exit(2)
+		}
+		result, err := utils.ImportBackup(url, token, archive, restorePolicy, !noDeploymentState, restoreRedeploy)
+		if len(result.Objects) > 0 {
+			printRestoreResult(result)
+		}
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(1)
+		}
+	},
+}
+
+func writeArchive(file string, archive model.BackupArchive) error {
+	data, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	// the archive holds password hashes, so it's only readable by its owner
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var w io.Writer = f
+	if strings.HasSuffix(file, ".gz") {
+		gw := gzip.NewWriter(f)
+		defer gw.Close()
+		w = gw
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func readArchive(file string) (model.BackupArchive, error) {
+	var archive model.BackupArchive
+	f, err := os.Open(file)
+	if err != nil {
+		return archive, err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return archive, fmt.Errorf("failed to read archive %s: %s", file, err.Error())
+		}
+		defer gr.Close()
+		r = gr
+	}
+	if err = json.NewDecoder(r).Decode(&archive); err != nil {
+		return archive, fmt.Errorf("failed to read archive %s: %s", file, err.Error())
+	}
+	if archive.Version != model.BackupArchiveVersion {
+		return archive, fmt.Errorf("archive version %d is not supported, expected version %d", archive.Version, model.BackupArchiveVersion)
+	}
+	return archive, nil
+}
+
+func archiveSize(archive model.BackupArchive) int {
+	return len(archive.Sites) + len(archive.Users) + len(archive.Targets) + len(archive.Devices) + len(archive.Models) +
+		len(archive.Skills) + len(archive.Solutions) + len(archive.Catalogs) + len(archive.Campaigns) +
+		len(archive.Instances) + len(archive.Activations)
+}
+
+func printRestoreResult(result model.RestoreResult) {
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Kind", "Namespace", "Name", "Result", "Error"})
+	for _, object := range result.Objects {
+		t.AppendRow(table.Row{object.Kind, object.Namespace, object.Name, object.Result, object.Error})
+	}
+	t.SetStyle(table.StyleColoredBright)
+	t.Render()
+}
+
+func init() {
+	BackupCmd.Flags().StringVarP(&backupFile, "output", "o", "", "Archive file, gzipped if it ends with .gz")
+	BackupCmd.Flags().BoolVar(&passwordHashes, "password-hashes", false, "Export the password hashes of the users")
+	BackupCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	BackupCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	RestoreCmd.Flags().StringVarP(&backupFile, "file", "f", "", "Archive file, gzipped if it ends with .gz")
+	RestoreCmd.Flags().StringVarP(&restorePolicy, "policy", "p", "skip", "What to do with objects that exist: skip, overwrite or fail")
+	RestoreCmd.Flags().BoolVar(&noDeploymentState, "no-deployment-state", false, "Don't restore the deployment states of instances and targets")
+	RestoreCmd.Flags().BoolVar(&restoreRedeploy, "redeploy", false, "Deploy the restored instances and targets again")
+	RestoreCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	RestoreCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	RootCmd.AddCommand(BackupCmd)
+	RootCmd.AddCommand(RestoreCmd)
+}
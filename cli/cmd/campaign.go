@@ -0,0 +1,148 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	campaignStage      string
+	campaignInputs     []string
+	campaignActivation string
+	campaignFollow     bool
+)
+
+var CampaignCmd = &cobra.Command{
+	Use:   "campaign",
+	Short: "Run Symphony campaigns",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var CampaignRunCmd = &cobra.Command{
+	Use:   "run <campaign>",
+	Short: "Start a campaign by creating an activation",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Printf("\n%sPlease specify a campaign%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(2)
+		}
+		inputs, err := parseInputs(campaignInputs)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		url, token, ok := loginToContext()
+		if !ok {
+			os.Exit(2)
+		}
+		name, err := runCampaign(url, token, args[0], inputs)
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		fmt.Printf("  activation/%s %screated%s\n", name, utils.ColorCyan(), utils.ColorReset())
+		if campaignFollow {
+			os.Exit(followActivation(url, token, name))
+		}
+	},
+}
+
+// runCampaign creates an activation of a campaign and returns the activation name
+func runCampaign(url string, token string, campaign string, inputs map[string]interface{}) (string, error) {
+	campaignObject, err := utils.GetObject(url, token, "Campaign", campaign, statusNamespace)
+	if err != nil {
+		return "", err
+	}
+	if campaignObject == nil {
+		return "", fmt.Errorf("campaign %s is not found in namespace %s", campaign, statusNamespace)
+	}
+	if campaignStage != "" {
+		var state model.CampaignState
+		if err = utils.ConvertObject(campaignObject, &state); err != nil {
+			return "", err
+		}
+		if state.Spec == nil {
+			return "", fmt.Errorf("campaign %s has no spec", campaign)
+		}
+		if _, ok := state.Spec.Stages[campaignStage]; !ok {
+			return "", fmt.Errorf("campaign %s has no stage %s", campaign, campaignStage)
+		}
+	}
+	name := campaignActivation
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", strings.ReplaceAll(campaign, ":", "-"), time.Now().UTC().Format("20060102-150405"))
+	}
+	existing, err := utils.GetObject(url, token, "Activation", name, statusNamespace)
+	if err != nil {
+		return "", err
+	}
+	if existing != nil {
+		return "", fmt.Errorf("activation %s already exists in namespace %s", name, statusNamespace)
+	}
+	activation := utils.ManifestObject{
+		Kind:      "Activation",
+		Name:      name,
+		Namespace: statusNamespace,
+		Body: utils.YamlArtifact{
+			APIVersion: "workflow.symphony/v1",
+			Kind:       "Activation",
+			Metadata: map[string]interface{}{
+				"name":      name,
+				"namespace": statusNamespace,
+			},
+			Spec: model.ActivationSpec{
+				Campaign: campaign,
+				Stage:    campaignStage,
+				Inputs:   inputs,
+			},
+		},
+	}
+	if _, err = utils.ApplyObject(url, token, activation, false); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// parseInputs parses key=value inputs. Values that are valid JSON, like numbers and booleans, are passed as
+// such, other values are passed as strings.
+func parseInputs(pairs []string) (map[string]interface{}, error) {
+	inputs := make(map[string]interface{})
+	for _, pair := range pairs {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("input %s is not in the key=value format", pair)
+		}
+		var value interface{}
+		if err := json.Unmarshal([]byte(parts[1]), &value); err != nil {
+			value = parts[1]
+		}
+		inputs[parts[0]] = value
+	}
+	return inputs, nil
+}
+
+func init() {
+	CampaignCmd.PersistentFlags().StringVarP(&statusNamespace, "namespace", "n", "default", "Namespace of the campaign")
+	CampaignCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	CampaignCmd.PersistentFlags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	CampaignRunCmd.Flags().StringVarP(&campaignStage, "stage", "s", "", "Stage to start from (default is the campaign's first stage)")
+	CampaignRunCmd.Flags().StringArrayVarP(&campaignInputs, "input", "i", nil, "Activation input as key=value. Can be repeated.")
+	CampaignRunCmd.Flags().StringVarP(&campaignActivation, "name", "", "", "Name of the activation (default <campaign>-<UTC time>)")
+	CampaignRunCmd.Flags().BoolVarP(&campaignFollow, "follow", "f", false, "Follow the activation until it completes, like maestro activation logs")
+	CampaignCmd.AddCommand(CampaignRunCmd)
+	RootCmd.AddCommand(CampaignCmd)
+}
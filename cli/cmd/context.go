@@ -0,0 +1,391 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+var (
+	contextUrl        string
+	contextUser       string
+	contextPassword   string
+	contextCACert     string
+	contextClientCert string
+	contextClientKey  string
+	contextInsecure   bool
+	contextUse        bool
+)
+
+var LoginCmd = &cobra.Command{
+	Use:   "login",
+	Short: "Log in to a Symphony API and store the credentials in a context",
+	Long: `Log in to a Symphony API and store the credentials in a context. The context is created when it doesn't
+exist, and updated with the given options otherwise. The password is read from stdin when --password isn't given,
+and is stored encrypted. The token is cached until it expires.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := login(cmd); err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(1)
+		}
+	},
+}
+
+var ContextCmd = &cobra.Command{
+	Use:   "context",
+	Short: "Manage maestro contexts",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var ContextAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a context",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Printf("\n%sPlease specify a context name%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(1)
+		}
+		if err := addContext(cmd, args[0]); err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(1)
+		}
+		fmt.Printf("  context/%s %sadded%s\n", args[0], utils.ColorCyan(), utils.ColorReset())
+	},
+}
+
+var ContextUseCmd = &cobra.Command{
+	Use:   "use <name>",
+	Short: "Make a context the default one",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Printf("\n%sPlease specify a context name%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(1)
+		}
+		err := updateConfigFile(func(c *config.MaestroConfig) error {
+			if _, ok := c.Contexts[args[0]]; !ok {
+				return fmt.Errorf("context %s is not found", args[0])
+			}
+			c.DefaultContext = args[0]
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(1)
+		}
+		fmt.Printf("  context/%s %sis the default context%s\n", args[0], utils.ColorCyan(), utils.ColorReset())
+	},
+}
+
+var ContextListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List contexts",
+	Run: func(cmd *cobra.Command, args []string) {
+		c := config.GetMaestroConfig(configFile)
+		names := make([]string, 0, len(c.Contexts))
+		for name := range c.Contexts {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Current", "Name", "URL", "User", "TLS"})
+		for _, name := range names {
+			current := ""
+			if name == c.DefaultContext {
+				current = "*"
+			}
+			ctx := c.Contexts[name]
+			t.AppendRow(table.Row{current, name, ctx.Url, ctx.User, tlsOptions(ctx)})
+		}
+		t.SetStyle(table.StyleColoredBright)
+		t.Render()
+	},
+}
+
+var ContextDeleteCmd = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a context and its stored credentials",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			fmt.Printf("\n%sPlease specify a context name%s\n\n", utils.ColorRed(), utils.ColorReset())
+			os.Exit(1)
+		}
+		err := updateConfigFile(func(c *config.MaestroConfig) error {
+			ctx, ok := c.Contexts[args[0]]
+			if !ok {
+				return fmt.Errorf("context %s is not found", args[0])
+			}
+			delete(c.Contexts, args[0])
+			if c.DefaultContext == args[0] {
+				c.DefaultContext = ""
+			}
+			// credentials are shared by the contexts with the same user and URL
+			for _, other := range c.Contexts {
+				if other.User == ctx.User && other.Url == ctx.Url {
+					return nil
+				}
+			}
+			return ctx.ClearCredentials()
+		})
+		if err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(1)
+		}
+		fmt.Printf("  context/%s %sdeleted%s\n", args[0], utils.ColorCyan(), utils.ColorReset())
+	},
+}
+
+func login(cmd *cobra.Command) error {
+	return updateConfigFile(func(c *config.MaestroConfig) error {
+		name := configContext
+		if name == "" {
+			name = c.DefaultContext
+		}
+		if name == "" {
+			name = "default"
+		}
+		ctx, ok := c.Contexts[name]
+		if !ok && contextUrl == "" {
+			return fmt.Errorf("context %s is not found, use --url to create it", name)
+		}
+		if !ok {
+			ctx.User = "admin"
+		}
+		applyContextFlags(cmd, &ctx)
+		password := contextPassword
+		if !cmd.Flags().Changed("password") {
+			var err error
+			if password, err = readPassword(); err != nil {
+				return err
+			}
+		}
+		if err := utils.ConfigureClient(clientOptions(ctx)); err != nil {
+			return err
+		}
+		token, err := utils.Login(ctx.Url, ctx.User, password)
+		if err != nil {
+			return err
+		}
+		if err = ctx.SetPassword(password); err != nil {
+			return err
+		}
+		if err = ctx.CacheToken(token); err != nil {
+			fmt.Printf("%s  Failed to cache the token: %s%s\n", utils.ColorYellow(), err.Error(), utils.ColorReset())
+		}
+		c.Contexts[name] = ctx
+		if c.DefaultContext == "" {
+			c.DefaultContext = name
+		}
+		fmt.Printf("  Logged in to %s as %s, context/%s %supdated%s\n", ctx.Url, ctx.User, name, utils.ColorCyan(), utils.ColorReset())
+		return nil
+	})
+}
+
+func addContext(cmd *cobra.Command, name string) error {
+	return updateConfigFile(func(c *config.MaestroConfig) error {
+		if _, ok := c.Contexts[name]; ok {
+			return fmt.Errorf("context %s already exists, use maestro login to update it", name)
+		}
+		if contextUrl == "" {
+			return fmt.Errorf("--url is required")
+		}
+		ctx := config.MaestroContext{User: "admin"}
+		applyContextFlags(cmd, &ctx)
+		if cmd.Flags().Changed("password") {
+			if err := ctx.SetPassword(contextPassword); err != nil {
+				return err
+			}
+		}
+		c.Contexts[name] = ctx
+		if contextUse || c.DefaultContext == "" {
+			c.DefaultContext = name
+		}
+		return nil
+	})
+}
+
+// applyContextFlags updates a context with the options given on the command line
+func applyContextFlags(cmd *cobra.Command, ctx *config.MaestroContext) {
+	if contextUrl != "" {
+		ctx.Url = strings.TrimSuffix(contextUrl, "/")
+	}
+	if contextUser != "" {
+		ctx.User = contextUser
+	}
+	if cmd.Flags().Changed("ca-cert") {
+		ctx.CACert = contextCACert
+	}
+	if cmd.Flags().Changed("client-cert") {
+		ctx.ClientCert = contextClientCert
+	}
+	if cmd.Flags().Changed("client-key") {
+		ctx.ClientKey = contextClientKey
+	}
+	if cmd.Flags().Changed("insecure-skip-tls-verify") {
+		ctx.Insecure = contextInsecure
+	}
+}
+
+// updateConfigFile applies an update to the config file given with -c, or the default config file
+func updateConfigFile(update func(c *config.MaestroConfig) error) error {
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = config.DefaultConfigPath(); err != nil {
+			return err
+		}
+	} else if strings.Contains(path, ":") {
+		return fmt.Errorf("contexts can only be updated in a single config file")
+	}
+	c, err := config.LoadMaestroConfigFile(path)
+	if err != nil {
+		return err
+	}
+	if err = update(&c); err != nil {
+		return err
+	}
+	return config.SaveMaestroConfigFile(path, c)
+}
+
+func readPassword() (string, error) {
+	return readSecret("Password: ")
+}
+
+// stdinReader is shared by the prompts, so that input buffered by one prompt isn't lost for the next one
+var stdinReader = bufio.NewReader(os.Stdin)
+
+// readSecret asks for a secret, without echoing it when the input is a terminal
+func readSecret(prompt string) (string, error) {
+	fmt.Print(prompt)
+	defer fmt.Println()
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		secret, err := term.ReadPassword(int(os.Stdin.Fd()))
+		if err != nil {
+			return "", fmt.Errorf("failed to read the secret: %s", err.Error())
+		}
+		return string(secret), nil
+	}
+	line, err := stdinReader.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read the secret: %s", err.Error())
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func tlsOptions(ctx config.MaestroContext) string {
+	options := make([]string, 0)
+	if ctx.CACert != "" {
+		options = append(options, "ca-cert")
+	}
+	if ctx.ClientCert != "" {
+		options = append(options, "client-cert")
+	}
+	if ctx.Insecure {
+		options = append(options, "insecure")
+	}
+	return strings.Join(options, ",")
+}
+
+func clientOptions(ctx config.MaestroContext) utils.ClientOptions {
+	return utils.ClientOptions{
+		CACert:     ctx.CACert,
+		ClientCert: ctx.ClientCert,
+		ClientKey:  ctx.ClientKey,
+		Insecure:   ctx.Insecure,
+	}
+}
+
+// loginToContext logs in to the context selected with -c and --context, and returns its URL and a token
+func loginToContext() (string, string, bool) {
+	url, token, err := connect(configFile, configContext)
+	if err != nil {
+		fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return "", "", false
+	}
+	return url, token, true
+}
+
+// connect configures the connection to the API of a context, and returns its URL and a token. The cached token
+// is used until it expires, and a new one is requested when the API rejects it.
+func connect(file string, name string) (string, string, error) {
+	c := config.GetMaestroConfig(file)
+	if name == "" {
+		name = c.DefaultContext
+	}
+	if name == "" {
+		name = "default"
+	}
+	ctx, ok := c.Contexts[name]
+	if !ok {
+		return "", "", fmt.Errorf("context %s is not found, use maestro context add or maestro login to create it", name)
+	}
+	if err := utils.ConfigureClient(clientOptions(ctx)); err != nil {
+		return "", "", err
+	}
+	refresh := func() (string, error) {
+		password, err := ctx.Password()
+		if err != nil {
+			return "", err
+		}
+		token, err := utils.Login(ctx.Url, ctx.User, password)
+		if err != nil {
+			return "", err
+		}
+		if err = ctx.CacheToken(token); err != nil {
+			fmt.Printf("%s  Failed to cache the token: %s%s\n", utils.ColorYellow(), err.Error(), utils.ColorReset())
+		}
+		return token, nil
+	}
+	utils.SetTokenRefresher(refresh)
+	token := ctx.CachedToken()
+	if token == "" {
+		var err error
+		if token, err = refresh(); err != nil {
+			return "", "", err
+		}
+	}
+	return ctx.Url, token, nil
+}
+
+func addContextFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&contextUrl, "url", "", "", "URL of the Symphony API, like https://symphony:8081/v1alpha2")
+	cmd.Flags().StringVarP(&contextUser, "user", "u", "", "User name (default admin)")
+	cmd.Flags().StringVarP(&contextPassword, "password", "p", "", "Password")
+	cmd.Flags().StringVarP(&contextCACert, "ca-cert", "", "", "PEM bundle of the certificate authorities trusted for the API server")
+	cmd.Flags().StringVarP(&contextClientCert, "client-cert", "", "", "PEM client certificate presented to the API server")
+	cmd.Flags().StringVarP(&contextClientKey, "client-key", "", "", "PEM key of the client certificate")
+	cmd.Flags().BoolVarP(&contextInsecure, "insecure-skip-tls-verify", "", false, "Don't verify the API server certificate")
+}
+
+func init() {
+	config.ReadPassphrase = readSecret
+	addContextFlags(LoginCmd)
+	LoginCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	LoginCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context (default is the default context)")
+	addContextFlags(ContextAddCmd)
+	ContextAddCmd.Flags().BoolVarP(&contextUse, "use", "", false, "Make the context the default one")
+	ContextCmd.PersistentFlags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	ContextCmd.AddCommand(ContextAddCmd)
+	ContextCmd.AddCommand(ContextUseCmd)
+	ContextCmd.AddCommand(ContextListCmd)
+	ContextCmd.AddCommand(ContextDeleteCmd)
+	RootCmd.AddCommand(LoginCmd)
+	RootCmd.AddCommand(ContextCmd)
+}
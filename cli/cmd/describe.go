@@ -0,0 +1,338 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+const (
+	statusPending   = "pending"
+	statusRunning   = "running"
+	statusPaused    = "paused"
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+)
+
+var (
+	statusNamespace   string
+	errObjectNotFound = errors.New("not found")
+)
+
+var DescribeObjectCmd = &cobra.Command{
+	Use:   "describe <type> <name>",
+	Short: "Show the deployment status of an instance or a target, or the status of an activation",
+	Run: func(cmd *cobra.Command, args []string) {
+		status, ok := loadStatusFromArgs(args)
+		if !ok {
+			os.Exit(2)
+		}
+		printStatus(status)
+	},
+}
+
+// objectStatus is the status of an instance, target or activation
+type objectStatus struct {
+	Kind       string
+	Name       string
+	Namespace  string
+	Generation string
+	Instance   *model.InstanceState
+	Target     *model.TargetState
+	Activation *model.ActivationState
+	// Summary is the summary of the last deployment of an instance or target
+	Summary *model.SummaryResult
+}
+
+// Result returns pending, running, paused, succeeded or failed. The deployment of an instance or target is
+// pending until there's a summary for the generation of the object. The generation of an activation isn't
+// compared, as it's updated with the activation status. An activation is pending until its first stage is
+// reported.
+func (s objectStatus) Result() string {
+	if s.Kind == "Activation" {
+		status := s.Activation.Status
+		if status == nil || (status.Stage == "" && status.Status == 0) {
+			return statusPending
+		}
+		switch {
+		case status.Status == v1alpha2.Done:
+			return statusSucceeded
+		case status.Status == v1alpha2.Paused:
+			return statusPaused
+		case status.IsActive:
+			return statusRunning
+		case isErrorState(status.Status):
+			return statusFailed
+		}
+		return statusRunning
+	}
+	if s.Summary == nil || s.Summary.Generation != s.Generation {
+		return statusPending
+	}
+	if !s.Summary.IsDeploymentFinished() {
+		return statusRunning
+	}
+	if s.Summary.Summary.SuccessCount < s.Summary.Summary.TargetCount || s.Summary.Summary.SummaryMessage != "" {
+		return statusFailed
+	}
+	return statusSucceeded
+}
+
+// IsTerminal returns true when the status won't change until the object is updated
+func (s objectStatus) IsTerminal() bool {
+	result := s.Result()
+	return result == statusSucceeded || result == statusFailed
+}
+
+func isErrorState(state v1alpha2.State) bool {
+	switch state {
+	case v1alpha2.OK, v1alpha2.Accepted, v1alpha2.Updated, v1alpha2.Deleted, v1alpha2.Running, v1alpha2.Paused, v1alpha2.Done, v1alpha2.Delayed, v1alpha2.Untouched:
+		return false
+	}
+	return true
+}
+
+// loadStatusFromArgs logs in to the current context and reads the status of the object named by the arguments
+func loadStatusFromArgs(args []string) (objectStatus, bool) {
+	if len(args) != 2 {
+		fmt.Printf("\n%sPlease specify an object type (instance, target or activation) and a name%s\n\n", utils.ColorRed(), utils.ColorReset())
+		return objectStatus{}, false
+	}
+	kind := ""
+	switch strings.ToLower(args[0]) {
+	case "instance", "instances":
+		kind = "Instance"
+	case "target", "targets":
+		kind = "Target"
+	case "activation", "activations":
+		kind = "Activation"
+	default:
+		fmt.Printf("\n%sUnsupported object type: %s. Supported types are instance, target and activation.%s\n\n", utils.ColorRed(), args[0], utils.ColorReset())
+		return objectStatus{}, false
+	}
+	url, token, ok := loginToContext()
+	if !ok {
+		return objectStatus{}, false
+	}
+	status, err := getStatus(url, token, kind, args[1], statusNamespace)
+	if err == nil {
+		return status, true
+	}
+	fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+	return objectStatus{}, false
+}
+
+func getStatus(url string, token string, kind string, name string, namespace string) (objectStatus, error) {
+	if namespace == "" {
+		namespace = "default"
+	}
+	status := objectStatus{
+		Kind:      kind,
+		Name:      name,
+		Namespace: namespace,
+	}
+	object, err := utils.GetObject(url, token, kind, name, namespace)
+	if err != nil {
+		return status, err
+	}
+	if object == nil {
+		return status, fmt.Errorf("%s %s is %w in namespace %s", strings.ToLower(kind), name, errObjectNotFound, namespace)
+	}
+	summaryName := name
+	switch kind {
+	case "Instance":
+		status.Instance = &model.InstanceState{}
+		err = utils.ConvertObject(object, status.Instance)
+		if err == nil && status.Instance.Spec != nil {
+			status.Generation = status.Instance.Spec.Generation
+		}
+	case "Target":
+		status.Target = &model.TargetState{}
+		err = utils.ConvertObject(object, status.Target)
+		if err == nil && status.Target.Spec != nil {
+			status.Generation = status.Target.Spec.Generation
+		}
+		summaryName = "target-runtime-" + name
+	case "Activation":
+		status.Activation = &model.ActivationState{}
+		err = utils.ConvertObject(object, status.Activation)
+		if err == nil && status.Activation.Spec != nil {
+			status.Generation = status.Activation.Spec.Generation
+		}
+		return status, err
+	}
+	if err != nil {
+		return status, err
+	}
+	status.Summary, err = utils.GetSummary(url, token, summaryName, namespace)
+	return status, err
+}
+
+func printStatus(status objectStatus) {
+	fmt.Println()
+	printField("Name", status.Name)
+	printField("Namespace", status.Namespace)
+	switch status.Kind {
+	case "Instance":
+		if status.Instance.Spec != nil {
+			printField("Solution", status.Instance.Spec.Solution)
+			target := status.Instance.Spec.Target.Name
+			if len(status.Instance.Spec.Target.Selector) > 0 {
+				target = "selector " + formatMap(status.Instance.Spec.Target.Selector)
+			}
+			printField("Target", target)
+		}
+	case "Activation":
+		if status.Activation.Spec != nil {
+			printField("Campaign", status.Activation.Spec.Campaign)
+		}
+	}
+	printField("Generation", status.Generation)
+	printField("Status", colorResult(status.Result()))
+	if status.Kind == "Activation" {
+		printActivationStatus(status.Activation.Status)
+	} else {
+		printSummary(status.Summary)
+	}
+	fmt.Println()
+}
+
+func printSummary(summary *model.SummaryResult) {
+	if summary == nil {
+		fmt.Printf("\n  The deployment hasn't been reported yet\n")
+		return
+	}
+	state := "Running"
+	if summary.IsDeploymentFinished() {
+		state = "Done"
+	}
+	fmt.Printf("\nLast deployment:\n")
+	printField("  State", state)
+	if summary.Summary.IsRemoval {
+		printField("  Removal", "true")
+	}
+	printField("  Generation", summary.Generation)
+	printField("  Hash", summary.DeploymentHash)
+	printField("  Time", summary.Time.Local().Format("2006-01-02 15:04:05 MST"))
+	printField("  Targets", fmt.Sprintf("%d/%d succeeded", summary.Summary.SuccessCount, summary.Summary.TargetCount))
+	if summary.Summary.Skipped {
+		printField("  Skipped", "true, nothing has changed")
+	}
+	if summary.Summary.SummaryMessage != "" {
+		printField("  Message", summary.Summary.SummaryMessage)
+	}
+	if len(summary.Summary.TargetResults) == 0 {
+		return
+	}
+	t := table.NewWriter()
+	t.SetOutputMirror(os.Stdout)
+	t.AppendHeader(table.Row{"Target", "Component", "Status", "Message"})
+	targets := make([]string, 0, len(summary.Summary.TargetResults))
+	for k := range summary.Summary.TargetResults {
+		targets = append(targets, k)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		result := summary.Summary.TargetResults[target]
+		t.AppendRow(table.Row{target, "", result.Status, result.Message})
+		components := make([]string, 0, len(result.ComponentResults))
+		for k := range result.ComponentResults {
+			components = append(components, k)
+		}
+		sort.Strings(components)
+		for _, component := range components {
+			c := result.ComponentResults[component]
+			t.AppendRow(table.Row{"", component, c.Status.String(), c.Message})
+		}
+	}
+	fmt.Println()
+	t.SetStyle(table.StyleColoredBright)
+	t.Render()
+}
+
+func printActivationStatus(status *model.ActivationStatus) {
+	if status == nil || (status.Stage == "" && status.Status == 0) {
+		fmt.Printf("\n  The activation hasn't started yet\n")
+		return
+	}
+	fmt.Printf("\nStage status:\n")
+	printField("  Stage", status.Stage)
+	if status.NextStage != "" {
+		printField("  Next stage", status.NextStage)
+	}
+	printField("  Status", status.Status.String())
+	printField("  Active", fmt.Sprintf("%t", status.IsActive))
+	printField("  Generation", status.ActivationGeneration)
+	printField("  Updated", status.UpdateTime)
+	if status.ErrorMessage != "" {
+		printField("  Error", status.ErrorMessage)
+	}
+	printValues("Inputs", status.Inputs)
+	printValues("Outputs", status.Outputs)
+}
+
+func printValues(title string, values map[string]interface{}) {
+	if len(values) == 0 {
+		return
+	}
+	fmt.Printf("\n%s:\n", title)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Printf("  %s: %s\n", k, formatValue(values[k]))
+	}
+}
+
+func printField(name string, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Printf("%-14s %s\n", name+":", value)
+}
+
+func formatMap(m map[string]string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func colorResult(result string) string {
+	switch result {
+	case statusSucceeded:
+		return utils.ColorGreen() + result + utils.ColorReset()
+	case statusFailed:
+		return utils.ColorRed() + result + utils.ColorReset()
+	}
+	return utils.ColorYellow() + result + utils.ColorReset()
+}
+
+func init() {
+	DescribeObjectCmd.Flags().StringVarP(&statusNamespace, "namespace", "n", "default", "Namespace of the object")
+	DescribeObjectCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	DescribeObjectCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	RootCmd.AddCommand(DescribeObjectCmd)
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+var diffDelete bool
+
+var DiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Preview what deploying the instances in manifest files would change",
+	Run: func(cmd *cobra.Command, args []string) {
+		objects, url, token, ok := loadManifests()
+		if !ok {
+			return
+		}
+		instances := 0
+		failed := false
+		for _, object := range objects {
+			if object.Kind != "Instance" {
+				continue
+			}
+			instances++
+			preview, err := utils.PlanInstance(url, token, object, objects, diffDelete)
+			if err != nil {
+				fmt.Printf("%s  %s failed: %s%s\n", utils.ColorRed(), object.String(), err.Error(), utils.ColorReset())
+				failed = true
+				continue
+			}
+			if !printPlanPreview(object, preview) {
+				failed = true
+			}
+		}
+		if instances == 0 {
+			fmt.Printf("\n%s  No instances found. Solutions and targets are previewed through the instances using them.%s\n\n", utils.ColorYellow(), utils.ColorReset())
+		}
+		if failed {
+			os.Exit(1)
+		}
+	},
+}
+
+// printPlanPreview prints the steps of a plan, and returns false if a target provider rejected a step
+func printPlanPreview(object utils.ManifestObject, preview model.PlanPreview) bool {
+	ok := true
+	fmt.Printf("%s (namespace %s)\n", object.String(), object.Namespace)
+	if len(preview.Steps) == 0 {
+		fmt.Printf("  no components are deployed or removed\n")
+	}
+	for _, step := range preview.Steps {
+		if step.Skipped {
+			fmt.Printf("  target %s (%s): %sno changes%s\n", step.Target, step.Role, utils.ColorCyan(), utils.ColorReset())
+			continue
+		}
+		fmt.Printf("  target %s (%s)\n", step.Target, step.Role)
+		for _, component := range step.Components {
+			printPreviewComponent(component)
+		}
+		if step.Error != "" {
+			fmt.Printf("    %serror: %s%s\n", utils.ColorRed(), step.Error, utils.ColorReset())
+			ok = false
+		}
+	}
+	fmt.Println()
+	return ok
+}
+
+func printPreviewComponent(component model.PreviewComponent) {
+	name := component.Component.Name
+	switch {
+	case component.Action == model.ComponentDelete && component.Changed:
+		fmt.Printf("    %s- %s%s\n", utils.ColorRed(), name, utils.ColorReset())
+	case component.Action == model.ComponentDelete:
+		fmt.Printf("    = %s (already removed)\n", name)
+	case component.Current == nil && component.Changed:
+		fmt.Printf("    %s+ %s%s\n", utils.ColorGreen(), name, utils.ColorReset())
+	case component.Changed:
+		fmt.Printf("    %s~ %s%s\n", utils.ColorYellow(), name, utils.ColorReset())
+	default:
+		fmt.Printf("    = %s (unchanged)\n", name)
+	}
+	for _, reason := range component.Reasons {
+		fmt.Printf("        %s\n", reason)
+	}
+	if component.Action == model.ComponentUpdate && component.Current != nil && component.Changed {
+		printValueChanges("", component.Current.Properties, component.Component.Properties)
+		printValueChanges("metadata.", toInterfaceMap(component.Current.Metadata), toInterfaceMap(component.Component.Metadata))
+	}
+}
+
+// printValueChanges prints the keys of two maps whose values differ, as removed and added lines
+func printValueChanges(prefix string, current map[string]interface{}, desired map[string]interface{}) {
+	keys := make([]string, 0)
+	for k := range current {
+		keys = append(keys, k)
+	}
+	for k := range desired {
+		if _, ok := current[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		cv, cok := current[k]
+		dv, dok := desired[k]
+		if cok && dok && reflect.DeepEqual(cv, dv) {
+			continue
+		}
+		if cok {
+			fmt.Printf("        %s- %s%s: %s%s\n", utils.ColorRed(), prefix, k, formatValue(cv), utils.ColorReset())
+		}
+		if dok {
+			fmt.Printf("        %s+ %s%s: %s%s\n", utils.ColorGreen(), prefix, k, formatValue(dv), utils.ColorReset())
+		}
+	}
+}
+
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+func toInterfaceMap(m map[string]string) map[string]interface{} {
+	ret := make(map[string]interface{})
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
+
+func init() {
+	DiffCmd.Flags().StringArrayVarP(&manifestFiles, "file", "f", nil, "Manifest file or directory, - for stdin. Can be repeated.")
+	DiffCmd.Flags().BoolVarP(&diffDelete, "delete", "", false, "Preview removing the instances instead of deploying them")
+	DiffCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	DiffCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	RootCmd.AddCommand(DiffCmd)
+}
@@ -15,7 +15,6 @@ import (
 	"sort"
 
 	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
-	"github.com/eclipse-symphony/symphony/cli/config"
 	"github.com/eclipse-symphony/symphony/cli/utils"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
@@ -33,21 +32,15 @@ var GetCmd = &cobra.Command{
 	Use:   "get",
 	Short: "Query Symphony objects",
 	Run: func(cmd *cobra.Command, args []string) {
-		c := config.GetMaestroConfig(configFile)
-		ctx := c.DefaultContext
-		if configContext != "" {
-			ctx = configContext
-		}
-
-		if ctx == "" {
-			ctx = "default"
+		url, token, ok := loginToContext()
+		if !ok {
+			return
 		}
 
 		for _, a := range args {
 			list, err := utils.Get(
-				c.Contexts[ctx].Url,
-				c.Contexts[ctx].User,
-				c.Contexts[ctx].Secret,
+				url,
+				token,
 				a,
 				jsonPath,
 				docType,
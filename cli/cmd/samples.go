@@ -17,7 +17,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/eclipse-symphony/symphony/cli/config"
 	"github.com/eclipse-symphony/symphony/cli/utils"
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/spf13/cobra"
@@ -222,21 +221,15 @@ func setSwitchToMap() (map[string]string, error) {
 	return ret, nil
 }
 func removeArtifact(artifact ArtifactSpec) error {
-	c := config.GetMaestroConfig(sampleConfigFile)
-	ctx := c.DefaultContext
-	if sampleConfigContext != "" {
-		ctx = sampleConfigContext
-	}
-
-	if ctx == "" {
-		ctx = "default"
+	url, token, err := connect(sampleConfigFile, sampleConfigContext)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("%sRemoving %s %s%s ...", utils.ColorCyan(), artifact.Type, utils.ColorReset(), artifact.Name)
-	err := utils.Remove(
-		c.Contexts[ctx].Url,
-		c.Contexts[ctx].User,
-		c.Contexts[ctx].Secret,
+	err = utils.Remove(
+		url,
+		token,
 		artifact.Type,
 		artifact.Name)
 	if err != nil {
@@ -272,22 +265,16 @@ func runArtifact(samplePath string, artifact ArtifactSpec, paramMap map[string]s
 			strStr = strings.ReplaceAll(strStr, p.Replace, p.Value)
 		}
 	}
-	c := config.GetMaestroConfig(sampleConfigFile)
-	ctx := c.DefaultContext
-	if sampleConfigContext != "" {
-		ctx = sampleConfigContext
-	}
-
-	if ctx == "" {
-		ctx = "default"
+	url, token, err := connect(sampleConfigFile, sampleConfigContext)
+	if err != nil {
+		return err
 	}
 
 	fmt.Printf("%sCreating %s %s%s ... ", utils.ColorCyan(), artifact.Type, utils.ColorReset(), artifact.Name)
 
 	err = utils.Upsert(
-		c.Contexts[ctx].Url,
-		c.Contexts[ctx].User,
-		c.Contexts[ctx].Secret,
+		url,
+		token,
 		artifact.Type,
 		artifact.Name,
 		[]byte(strStr))
@@ -0,0 +1,231 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
+	"github.com/spf13/cobra"
+)
+
+const secretAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+var (
+	secretFile    string
+	secretKeyFile string
+	secretKeyEnv  string
+	secretLength  int
+	secretShow    bool
+	secretForce   bool
+)
+
+var SecretCmd = &cobra.Command{
+	Use:   "secret",
+	Short: "Manage secrets of the file secret provider",
+	Run: func(cmd *cobra.Command, args []string) {
+	},
+}
+
+var SecretNewKeyCmd = &cobra.Command{
+	Use:   "new-key",
+	Short: "Generate an encryption key into the key file",
+	Run: func(cmd *cobra.Command, args []string) {
+		keyFile := defaultSecretPath(secretKeyFile, "secret.key")
+		if _, err := os.Stat(keyFile); err == nil && !secretForce {
+			fmt.Printf("\n%s  Key file %s already exists, use --force to replace it%s\n\n", utils.ColorRed(), keyFile, utils.ColorReset())
+			return
+		}
+		key, err := filesecret.GenerateKey()
+		if err == nil {
+			err = writeKeyFile(keyFile, key)
+		}
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		fmt.Printf("\n%s  Encryption key is written to %s%s\n\n", utils.ColorCyan(), keyFile, utils.ColorReset())
+	},
+}
+
+var SecretSetCmd = &cobra.Command{
+	Use:   "set <object> <field> [value]",
+	Short: "Set a secret. The value is read from stdin when it's not given.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 && len(args) != 3 {
+			fmt.Printf("\n%sPlease specify an object, a field and optionally a value%s\n\n", utils.ColorRed(), utils.ColorReset())
+			return
+		}
+		var value string
+		if len(args) == 3 {
+			value = args[2]
+		} else {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && line == "" {
+				fmt.Printf("\n%s  Failed to read the value: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+				return
+			}
+			value = strings.TrimRight(line, "\r\n")
+		}
+		provider, err := openSecretProvider()
+		if err == nil {
+			err = provider.Set(args[0], args[1], value)
+		}
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		fmt.Printf("\n%s  Secret %s of %s is set%s\n\n", utils.ColorCyan(), args[1], args[0], utils.ColorReset())
+	},
+}
+
+var SecretRotateCmd = &cobra.Command{
+	Use:   "rotate <object> <field>",
+	Short: "Replace a secret with a new random value",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 2 {
+			fmt.Printf("\n%sPlease specify an object and a field%s\n\n", utils.ColorRed(), utils.ColorReset())
+			return
+		}
+		if secretLength <= 0 {
+			fmt.Printf("\n%s--length must be a positive integer%s\n\n", utils.ColorRed(), utils.ColorReset())
+			return
+		}
+		value, err := randomSecret(secretLength)
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		provider, err := openSecretProvider()
+		if err == nil {
+			err = provider.Set(args[0], args[1], value)
+		}
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		fmt.Printf("\n%s  Secret %s of %s is rotated%s\n", utils.ColorCyan(), args[1], args[0], utils.ColorReset())
+		if secretShow {
+			fmt.Printf("  %s\n", value)
+		}
+		fmt.Println()
+	},
+}
+
+var SecretRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Re-encrypt the secret file with a new encryption key",
+	Run: func(cmd *cobra.Command, args []string) {
+		provider, err := openSecretProvider()
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		key, err := filesecret.GenerateKey()
+		if err == nil {
+			err = provider.RotateKey(key)
+		}
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return
+		}
+		if provider.Config.KeyFile == "" {
+			// the key comes from an environment variable, which can't be updated for the running Symphony
+			fmt.Printf("\n%s  Secret file is re-encrypted, set %s to the new key before Symphony restarts:%s\n  %s\n\n", utils.ColorYellow(), secretKeyEnvName(), utils.ColorReset(), key)
+			return
+		}
+		// the secret file is rewritten before the key file, a running Symphony keeps its secrets until both are updated
+		if err = writeKeyFile(provider.Config.KeyFile, key); err != nil {
+			fmt.Printf("\n%s  Failed to write the new key: %s. The secret file is encrypted with:%s\n  %s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset(), key)
+			return
+		}
+		fmt.Printf("\n%s  Secret file is re-encrypted with a new key in %s%s\n\n", utils.ColorCyan(), provider.Config.KeyFile, utils.ColorReset())
+	},
+}
+
+// openSecretProvider opens the secret file with the key file, or the key environment variable when there's no key file
+func openSecretProvider() (*filesecret.FileSecretProvider, error) {
+	config := filesecret.FileSecretProviderConfig{
+		FilePath:           defaultSecretPath(secretFile, "secrets.json"),
+		KeyEnv:             secretKeyEnv,
+		ReloadIntervalInMs: -1,
+	}
+	keyFile := defaultSecretPath(secretKeyFile, "secret.key")
+	if _, err := os.Stat(keyFile); err == nil || secretKeyFile != "" {
+		config.KeyFile = keyFile
+	} else if os.Getenv(secretKeyEnvName()) == "" {
+		return nil, fmt.Errorf("no encryption key is found, use maestro secret new-key to generate one")
+	}
+	provider := &filesecret.FileSecretProvider{}
+	if err := provider.Init(config); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func secretKeyEnvName() string {
+	if secretKeyEnv != "" {
+		return secretKeyEnv
+	}
+	return filesecret.DefaultKeyEnv
+}
+
+func defaultSecretPath(path string, name string) string {
+	if path != "" {
+		return path
+	}
+	dirname, err := os.UserHomeDir()
+	if err != nil {
+		return name
+	}
+	return filepath.Join(dirname, ".symphony", name)
+}
+
+func writeKeyFile(path string, key string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(key+"\n"), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func randomSecret(length int) (string, error) {
+	var sb strings.Builder
+	max := big.NewInt(int64(len(secretAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		sb.WriteByte(secretAlphabet[n.Int64()])
+	}
+	return sb.String(), nil
+}
+
+func init() {
+	SecretCmd.PersistentFlags().StringVarP(&secretFile, "file", "f", "", "Secret file (default ~/.symphony/secrets.json)")
+	SecretCmd.PersistentFlags().StringVarP(&secretKeyFile, "key-file", "k", "", "Encryption key file (default ~/.symphony/secret.key)")
+	SecretCmd.PersistentFlags().StringVarP(&secretKeyEnv, "key-env", "", "", "Environment variable holding the encryption key when there's no key file (default "+filesecret.DefaultKeyEnv+")")
+	SecretNewKeyCmd.Flags().BoolVarP(&secretForce, "force", "", false, "Replace an existing key file. Secrets encrypted with the old key can't be read anymore.")
+	SecretRotateCmd.Flags().IntVarP(&secretLength, "length", "l", 32, "Length of the new value")
+	SecretRotateCmd.Flags().BoolVarP(&secretShow, "show", "", false, "Print the new value")
+	SecretCmd.AddCommand(SecretNewKeyCmd)
+	SecretCmd.AddCommand(SecretSetCmd)
+	SecretCmd.AddCommand(SecretRotateCmd)
+	SecretCmd.AddCommand(SecretRotateKeyCmd)
+	RootCmd.AddCommand(SecretCmd)
+}
@@ -0,0 +1,278 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/cli/config"
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/spf13/cobra"
+)
+
+const standaloneContext = "standalone"
+
+var (
+	apiBinary       string
+	standalone      utils.StandaloneOptions
+	withAgent       bool
+	agent           utils.AgentOptions
+	standaloneLog   string
+	readyTimeout    time.Duration
+	standalonePurge bool
+)
+
+var DownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Stop the Symphony API and agent started by maestro up --no-k8s",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := standaloneDir()
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		// the agent is stopped first, as it polls the API
+		for _, process := range []utils.ManagedProcess{{Name: "symphony-agent", Dir: dir}, {Name: "symphony-api", Dir: dir}} {
+			stopped, err := process.Stop(10 * time.Second)
+			if err != nil {
+				fmt.Printf("\n%s  Failed to stop %s: %s%s\n\n", utils.ColorRed(), process.Name, err.Error(), utils.ColorReset())
+				os.Exit(2)
+			}
+			if stopped {
+				fmt.Printf("  %s %sstopped%s\n", process.Name, utils.ColorCyan(), utils.ColorReset())
+			}
+		}
+		if standalonePurge {
+			if err = os.RemoveAll(dir); err != nil {
+				fmt.Printf("\n%s  Failed to remove %s: %s%s\n\n", utils.ColorRed(), dir, err.Error(), utils.ColorReset())
+				os.Exit(2)
+			}
+			fmt.Printf("  %s %sremoved%s\n", dir, utils.ColorCyan(), utils.ColorReset())
+		}
+	},
+}
+
+var StatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the Symphony API and agent started by maestro up --no-k8s",
+	Run: func(cmd *cobra.Command, args []string) {
+		dir, err := standaloneDir()
+		if err != nil {
+			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			os.Exit(2)
+		}
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"Process", "PID", "Status", "Url", "Log"})
+		running := 0
+		for _, process := range []utils.ManagedProcess{{Name: "symphony-api", Dir: dir}, {Name: "symphony-agent", Dir: dir}} {
+			pid, ok := process.Pid()
+			if !ok {
+				t.AppendRow(table.Row{process.Name, "", "stopped", "", ""})
+				continue
+			}
+			running++
+			url := readyUrl(process)
+			status := "running"
+			if url != "" && !utils.IsReady(url) {
+				status = "not ready"
+			}
+			t.AppendRow(table.Row{process.Name, pid, status, url, process.LogFile()})
+		}
+		t.SetStyle(table.StyleColoredBright)
+		t.Render()
+		if running == 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// upStandalone generates the config of the Symphony API, and of the agent with --with-agent, and runs them as
+// background processes. Nothing is downloaded, the symphony-api binary must be present.
+func upStandalone() bool {
+	dir, err := standaloneDir()
+	if err != nil {
+		fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return false
+	}
+	binary, err := utils.FindBinary(apiBinary, "symphony-api", filepath.Dir(dir))
+	if err != nil {
+		fmt.Printf("\n%s  %s, use --api-binary to set its path%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return false
+	}
+	if standalone.Queue == "disk" && standalone.QueueDir == "" {
+		standalone.QueueDir = filepath.Join(dir, "queue")
+	}
+	if standalone.Secret == "file" {
+		standalone.SecretFile = defaultSecretPath(standalone.SecretFile, "secrets.json")
+		standalone.SecretKeyFile = defaultSecretPath(standalone.SecretKeyFile, "secret.key")
+		if !ensureSecretKey(standalone.SecretKeyFile) {
+			return false
+		}
+	}
+	apiUrl := fmt.Sprintf("http://localhost:%d/v1alpha2/", standalone.Port)
+	apiConfig, err := utils.StandaloneApiConfig(standalone)
+	if err != nil {
+		fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return false
+	}
+	var agentConfig []byte
+	if withAgent {
+		agent.ApiUrl = apiUrl
+		if agentConfig, err = utils.StandaloneAgentConfig(agent); err != nil {
+			fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+			return false
+		}
+	}
+
+	api := utils.ManagedProcess{Name: "symphony-api", Dir: dir}
+	if !startProcess(api, binary, apiConfig, apiUrl+"greetings") {
+		return false
+	}
+	if withAgent {
+		agentProcess := utils.ManagedProcess{Name: "symphony-agent", Dir: dir}
+		if !startProcess(agentProcess, binary, agentConfig, fmt.Sprintf("http://localhost:%d/v1alpha2/greetings", agent.Port)) {
+			return false
+		}
+	}
+	err = updateConfigFile(func(c *config.MaestroConfig) error {
+		if c.Contexts == nil {
+			c.Contexts = make(map[string]config.MaestroContext)
+		}
+		c.Contexts[standaloneContext] = config.MaestroContext{
+			Url:  fmt.Sprintf("http://localhost:%d/v1alpha2", standalone.Port),
+			User: "admin",
+		}
+		c.DefaultContext = standaloneContext
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("\n%s  Failed to update maestro config file: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return false
+	}
+
+	fmt.Printf("\n%s  Done!%s\n\n", utils.ColorCyan(), utils.ColorReset())
+	fmt.Printf("  %sSymphony API:%s %s%s\n", utils.ColorGreen(), utils.ColorWhite(), apiUrl+"greetings", utils.ColorReset())
+	if withAgent {
+		fmt.Printf("  %sSymphony agent:%s target %s, %s provider%s\n", utils.ColorGreen(), utils.ColorWhite(), agent.Target, agent.Provider, utils.ColorReset())
+	}
+	fmt.Printf("  %sLogs:%s %s%s\n", utils.ColorGreen(), utils.ColorWhite(), dir, utils.ColorReset())
+	fmt.Printf("\n  Use maestro status to check the processes and maestro down to stop them.\n\n")
+	return true
+}
+
+// startProcess writes the config of a process, starts it and waits until it's ready. A process that isn't
+// ready is stopped.
+func startProcess(process utils.ManagedProcess, binary string, processConfig []byte, url string) bool {
+	if pid, ok := process.Pid(); ok {
+		fmt.Printf("\n%s  %s is already running with PID %d, use maestro down to stop it%s\n\n", utils.ColorRed(), process.Name, pid, utils.ColorReset())
+		return false
+	}
+	if err := os.MkdirAll(process.Dir, 0755); err != nil {
+		fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return false
+	}
+	if err := os.WriteFile(process.ConfigFile(), processConfig, 0644); err != nil {
+		fmt.Printf("\n%s  Failed to write %s: %s%s\n\n", utils.ColorRed(), process.ConfigFile(), err.Error(), utils.ColorReset())
+		return false
+	}
+	fmt.Printf("  Launching %s ... ", process.Name)
+	pid, err := process.Start(binary, "-c", process.ConfigFile(), "-l", standaloneLog)
+	if err == nil {
+		err = process.WaitForReady(url, readyTimeout)
+	}
+	if err != nil {
+		fmt.Printf("%sfailed%s\n", utils.ColorRed(), utils.ColorReset())
+		fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		process.Stop(5 * time.Second)
+		return false
+	}
+	fmt.Printf("%sdone%s (PID %d)\n", utils.ColorGreen(), utils.ColorReset(), pid)
+	return true
+}
+
+// readyUrl returns the greetings url of a process from the port of its first binding
+func readyUrl(process utils.ManagedProcess) string {
+	data, err := os.ReadFile(process.ConfigFile())
+	if err != nil {
+		return ""
+	}
+	var processConfig struct {
+		Bindings []struct {
+			Config struct {
+				Port int  `json:"port"`
+				TLS  bool `json:"tls"`
+			} `json:"config"`
+		} `json:"bindings"`
+	}
+	if err = json.Unmarshal(data, &processConfig); err != nil {
+		return ""
+	}
+	for _, binding := range processConfig.Bindings {
+		if binding.Config.Port != 0 && !binding.Config.TLS {
+			return fmt.Sprintf("http://localhost:%d/v1alpha2/greetings", binding.Config.Port)
+		}
+	}
+	return ""
+}
+
+// ensureSecretKey generates the key of the file secret provider when there isn't one
+func ensureSecretKey(keyFile string) bool {
+	if _, err := os.Stat(keyFile); err == nil {
+		return true
+	}
+	key, err := filesecret.GenerateKey()
+	if err == nil {
+		err = writeKeyFile(keyFile, key)
+	}
+	if err != nil {
+		fmt.Printf("\n%s  Failed to generate the secret key: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+		return false
+	}
+	fmt.Printf("  Encryption key is written to %s\n", keyFile)
+	return true
+}
+
+func standaloneDir() (string, error) {
+	dirname, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirname, ".symphony", "standalone"), nil
+}
+
+func init() {
+	UpCmd.Flags().StringVar(&apiBinary, "api-binary", "", "Path of the symphony-api binary (default ~/.symphony/symphony-api, or symphony-api in the PATH)")
+	UpCmd.Flags().StringVar(&standalone.SiteId, "site-id", "standalone", "Site id of the standalone Symphony API")
+	UpCmd.Flags().IntVar(&standalone.Port, "port", 8082, "Port of the standalone Symphony API")
+	UpCmd.Flags().StringVar(&standalone.State, "state", "memory", "State provider of the standalone Symphony API, only memory is supported")
+	UpCmd.Flags().StringVar(&standalone.PubSub, "pubsub", "memory", "Pub-sub provider: memory, redis or mqtt")
+	UpCmd.Flags().StringVar(&standalone.PubSubUrl, "pubsub-url", "", "Redis host or MQTT broker address of the pub-sub provider")
+	UpCmd.Flags().StringVar(&standalone.Queue, "queue", "disk", "Queue provider: disk or memory")
+	UpCmd.Flags().StringVar(&standalone.QueueDir, "queue-dir", "", "Directory of the disk queue (default ~/.symphony/standalone/queue)")
+	UpCmd.Flags().StringVar(&standalone.Secret, "secret", "mock", "Secret provider: mock or file")
+	UpCmd.Flags().StringVar(&standalone.SecretFile, "secret-file", "", "Secret file of the file secret provider (default ~/.symphony/secrets.json)")
+	UpCmd.Flags().StringVar(&standalone.SecretKeyFile, "secret-key-file", "", "Encryption key file of the file secret provider, generated if it doesn't exist (default ~/.symphony/secret.key)")
+	UpCmd.Flags().IntVar(&standalone.TLSPort, "tls-port", 0, "Port of an https binding, 0 turns it off")
+	UpCmd.Flags().StringVar(&standalone.TLSCert, "tls-cert", "", "Certificate of the https binding, generated when it's not set")
+	UpCmd.Flags().StringVar(&standalone.TLSKey, "tls-key", "", "Private key of the https binding")
+	UpCmd.Flags().BoolVar(&withAgent, "with-agent", false, "Run a poll agent alongside the standalone Symphony API")
+	UpCmd.Flags().StringVar(&agent.Target, "agent-target", "local-agent", "Target the agent deploys to")
+	UpCmd.Flags().StringVar(&agent.Provider, "agent-provider", "mock", "Provider the agent applies components with: mock or docker")
+	UpCmd.Flags().IntVar(&agent.Port, "agent-port", 8088, "Port of the agent")
+	UpCmd.Flags().StringVar(&standaloneLog, "log-level", "Info", "Log level of the standalone processes")
+	UpCmd.Flags().DurationVar(&readyTimeout, "ready-timeout", time.Minute, "How long to wait for the standalone processes to be ready")
+	DownCmd.Flags().BoolVar(&standalonePurge, "purge", false, "Remove the generated configs, logs and disk queues")
+	RootCmd.AddCommand(DownCmd)
+	RootCmd.AddCommand(StatusCmd)
+}
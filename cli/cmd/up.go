@@ -10,7 +10,6 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"os/user"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -55,19 +54,9 @@ var UpCmd = &cobra.Command{
 		// 	fmt.Println("Playground\tSymphony Playground")
 		// 	return
 		// }
-		u, err := user.Current()
-		if err != nil {
-			fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
-			return
-		}
 		if noK8s {
-			if !updateSymphonyContext("no-k8s", "localhost") {
-				return
-			}
-			_, err := utils.RunCommandNoCapture("Launching Symphony in standalone mode", "done", filepath.Join(u.HomeDir, ".symphony/symphony-api"), "-c", filepath.Join(u.HomeDir, ".symphony/symphony-api-no-k8s.json"), "-l", "Debug")
-			if err != nil {
-				fmt.Printf("\n%s  Failed: %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
-				return
+			if !upStandalone() {
+				os.Exit(1)
 			}
 		} else {
 			// we don't need to check for Docker, as we are not using it
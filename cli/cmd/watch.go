@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/cli/utils"
+	"github.com/spf13/cobra"
+)
+
+// exit codes of maestro watch
+const (
+	watchSucceeded = 0
+	watchFailed    = 1
+	watchError     = 2
+	watchTimedOut  = 3
+)
+
+var (
+	watchTimeout  time.Duration
+	watchInterval time.Duration
+	watchQuiet    bool
+)
+
+var WatchCmd = &cobra.Command{
+	Use:   "watch <type> <name>",
+	Short: "Wait until the deployment of an instance or a target, or an activation, succeeds or fails",
+	Long: `Wait until the deployment of an instance or a target, or an activation, succeeds or fails.
+The status is polled until it's terminal. The exit code is 0 when the object succeeded, 1 when it failed,
+2 when the object can't be read and 3 when the timeout is reached.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		os.Exit(watch(args))
+	},
+}
+
+func watch(args []string) int {
+	status, ok := loadStatusFromArgs(args)
+	if !ok {
+		return watchError
+	}
+	url, token, ok := loginToContext()
+	if !ok {
+		return watchError
+	}
+	last := ""
+	status, code := pollStatus(url, token, status, func(status objectStatus) {
+		line := statusLine(status)
+		if line != last {
+			fmt.Printf("%s  %s\n", time.Now().Format("15:04:05"), line)
+			last = line
+		}
+	})
+	if code != watchSucceeded && code != watchFailed {
+		return code
+	}
+	if !watchQuiet {
+		printStatus(status)
+	}
+	return code
+}
+
+// pollStatus reports the status of an object until it's terminal or the timeout is reached. It returns the
+// last status, with the exit code of watch.
+func pollStatus(url string, token string, status objectStatus, report func(objectStatus)) (objectStatus, int) {
+	deadline := time.Now().Add(watchTimeout)
+	for {
+		report(status)
+		if status.IsTerminal() {
+			if status.Result() == statusFailed {
+				return status, watchFailed
+			}
+			return status, watchSucceeded
+		}
+		if watchTimeout > 0 && time.Now().After(deadline) {
+			fmt.Printf("\n%s  Timed out after %s, %s/%s is %s%s\n\n", utils.ColorRed(), watchTimeout, status.Kind, status.Name, status.Result(), utils.ColorReset())
+			return status, watchTimedOut
+		}
+		time.Sleep(watchInterval)
+		next, err := getStatus(url, token, status.Kind, status.Name, status.Namespace)
+		if err != nil {
+			if errors.Is(err, errObjectNotFound) {
+				fmt.Printf("\n%s  %s%s\n\n", utils.ColorRed(), err.Error(), utils.ColorReset())
+				return status, watchError
+			}
+			// the API may be restarting, keep polling until the timeout
+			fmt.Printf("%s  %s%s%s\n", time.Now().Format("15:04:05"), utils.ColorYellow(), err.Error(), utils.ColorReset())
+			continue
+		}
+		status = next
+	}
+}
+
+// statusLine is a one line description of a status, printed by watch each time it changes
+func statusLine(status objectStatus) string {
+	line := fmt.Sprintf("%s/%s %s", status.Kind, status.Name, colorResult(status.Result()))
+	if status.Activation != nil && status.Activation.Status != nil {
+		s := status.Activation.Status
+		line += fmt.Sprintf(", stage %s: %s", s.Stage, s.Status.String())
+		if s.NextStage != "" {
+			line += ", next stage " + s.NextStage
+		}
+	} else if status.Summary != nil && status.Summary.Generation == status.Generation {
+		line += fmt.Sprintf(", %d/%d targets succeeded", status.Summary.Summary.SuccessCount, status.Summary.Summary.TargetCount)
+	}
+	return line
+}
+
+func init() {
+	WatchCmd.Flags().StringVarP(&statusNamespace, "namespace", "n", "default", "Namespace of the object")
+	WatchCmd.Flags().DurationVarP(&watchTimeout, "timeout", "t", 10*time.Minute, "How long to wait, 0 waits forever")
+	WatchCmd.Flags().DurationVarP(&watchInterval, "interval", "i", 2*time.Second, "How often the status is polled")
+	WatchCmd.Flags().BoolVarP(&watchQuiet, "quiet", "q", false, "Don't print the status when it's terminal")
+	WatchCmd.Flags().StringVarP(&configFile, "config", "c", "", "Maestro CLI config file")
+	WatchCmd.Flags().StringVarP(&configContext, "context", "", "", "Maestro CLI configuration context")
+	RootCmd.AddCommand(WatchCmd)
+}
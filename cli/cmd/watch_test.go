@@ -0,0 +1,183 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeResponse is a response of the fake API, a status code without a body is returned as is
+type fakeResponse struct {
+	status int
+	body   interface{}
+}
+
+// newFakeAPI serves the responses of each route in turn, and then the last one again
+func newFakeAPI(t *testing.T, responses map[string][]fakeResponse) *httptest.Server {
+	lock := sync.Mutex{}
+	calls := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		sequence, ok := responses[r.URL.Path]
+		if !ok || len(sequence) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		i := calls[r.URL.Path]
+		if i >= len(sequence) {
+			i = len(sequence) - 1
+		}
+		calls[r.URL.Path]++
+		response := sequence[i]
+		if response.body == nil {
+			w.WriteHeader(response.status)
+			return
+		}
+		data, _ := json.Marshal(response.body)
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setWatchOptions polls every millisecond until the timeout for the duration of a test
+func setWatchOptions(t *testing.T, timeout time.Duration) {
+	watchTimeout, watchInterval, statusNamespace = timeout, time.Millisecond, "default"
+	t.Cleanup(func() {
+		watchTimeout, watchInterval, statusNamespace = 10*time.Minute, 2*time.Second, "default"
+	})
+}
+
+func deploymentSummary(state model.SummaryState, targets int, succeeded int) fakeResponse {
+	return fakeResponse{body: model.SummaryResult{
+		Generation: "1",
+		State:      state,
+		Summary:    model.SummarySpec{TargetCount: targets, SuccessCount: succeeded},
+	}}
+}
+
+func TestPollStatusExitCodes(t *testing.T) {
+	instances := []fakeResponse{{body: []model.InstanceState{{
+		ObjectMeta: model.ObjectMeta{Name: "instance1", Namespace: "default"},
+		Spec:       &model.InstanceSpec{Solution: "solution1", Generation: "1"},
+	}}}}
+	testCases := []struct {
+		name      string
+		timeout   time.Duration
+		responses map[string][]fakeResponse
+		code      int
+		result    string
+	}{
+		{
+			name: "succeeded",
+			responses: map[string][]fakeResponse{
+				"/instances": instances,
+				"/solution/queue": {
+					{status: http.StatusNotFound},
+					deploymentSummary(model.SummaryStateRunning, 1, 0),
+					deploymentSummary(model.SummaryStateDone, 1, 1),
+				},
+			},
+			code:   watchSucceeded,
+			result: statusSucceeded,
+		},
+		{
+			name: "failed",
+			responses: map[string][]fakeResponse{
+				"/instances":      instances,
+				"/solution/queue": {deploymentSummary(model.SummaryStateDone, 2, 1)},
+			},
+			code:   watchFailed,
+			result: statusFailed,
+		},
+		{
+			name: "API errors are retried",
+			responses: map[string][]fakeResponse{
+				"/instances": append([]fakeResponse{{status: http.StatusInternalServerError}}, instances...),
+				"/solution/queue": {
+					{status: http.StatusInternalServerError},
+					deploymentSummary(model.SummaryStateDone, 1, 1),
+				},
+			},
+			code:   watchSucceeded,
+			result: statusSucceeded,
+		},
+		{
+			name:      "deleted",
+			responses: map[string][]fakeResponse{"/instances": {{body: []model.InstanceState{}}}},
+			code:      watchError,
+			result:    statusPending,
+		},
+		{
+			name:    "timed out",
+			timeout: 20 * time.Millisecond,
+			responses: map[string][]fakeResponse{
+				"/instances":      instances,
+				"/solution/queue": {deploymentSummary(model.SummaryStateRunning, 1, 0)},
+			},
+			code:   watchTimedOut,
+			result: statusRunning,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeout := tc.timeout
+			if timeout == 0 {
+				// the test fails rather than hangs when the status doesn't become terminal
+				timeout = 5 * time.Second
+			}
+			setWatchOptions(t, timeout)
+			server := newFakeAPI(t, tc.responses)
+			reported := 0
+			status, code := pollStatus(server.URL, "", objectStatus{Kind: "Instance", Name: "instance1", Namespace: "default"}, func(objectStatus) {
+				reported++
+			})
+			assert.Equal(t, tc.code, code)
+			assert.Equal(t, tc.result, status.Result())
+			assert.Greater(t, reported, 0)
+		})
+	}
+}
+
+func TestObjectStatusResult(t *testing.T) {
+	summary := func(generation string, state model.SummaryState, succeeded int, message string) *model.SummaryResult {
+		return &model.SummaryResult{
+			Generation: generation,
+			State:      state,
+			Summary:    model.SummarySpec{TargetCount: 2, SuccessCount: succeeded, SummaryMessage: message},
+		}
+	}
+	testCases := []struct {
+		name     string
+		summary  *model.SummaryResult
+		result   string
+		terminal bool
+	}{
+		{name: "not deployed", result: statusPending},
+		{name: "previous generation", summary: summary("0", model.SummaryStateDone, 2, ""), result: statusPending},
+		{name: "deploying", summary: summary("1", model.SummaryStateRunning, 1, ""), result: statusRunning},
+		{name: "deployed", summary: summary("1", model.SummaryStateDone, 2, ""), result: statusSucceeded, terminal: true},
+		{name: "target failed", summary: summary("1", model.SummaryStateDone, 1, ""), result: statusFailed, terminal: true},
+		{name: "deployment failed", summary: summary("1", model.SummaryStateDone, 2, "failed to plan"), result: statusFailed, terminal: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			status := objectStatus{Kind: "Target", Name: "target1", Generation: "1", Summary: tc.summary}
+			assert.Equal(t, tc.result, status.Result())
+			assert.Equal(t, tc.terminal, status.IsTerminal())
+		})
+	}
+}
@@ -8,6 +8,7 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
@@ -16,9 +17,21 @@ import (
 )
 
 type MaestroContext struct {
-	Url    string `json:"url"`
-	User   string `json:"user"`
+	Url  string `json:"url"`
+	User string `json:"user"`
+	// Secret is a plaintext password of a context created by an earlier version. It's moved to the credential
+	// store when the config is loaded, see SetPassword.
 	Secret string `json:"secret,omitempty"`
+	// StoredPassword tells that the password of the context is in the credential store. Contexts without a
+	// password never open the store.
+	StoredPassword bool `json:"storedPassword,omitempty"`
+	// CACert is a PEM bundle of the certificate authorities trusted for the API server
+	CACert string `json:"caCert,omitempty"`
+	// ClientCert and ClientKey are PEM files of a client certificate presented to the API server
+	ClientCert string `json:"clientCert,omitempty"`
+	ClientKey  string `json:"clientKey,omitempty"`
+	// Insecure skips the verification of the API server certificate
+	Insecure bool `json:"insecureSkipTLSVerify,omitempty"`
 }
 type MaestroConfig struct {
 	DefaultContext string                    `json:"default,omitempty"`
@@ -39,16 +52,27 @@ func UpdateMaestroConfig(context string, address string) error {
 	return SaveMaestroConfig(config)
 }
 func SaveMaestroConfig(config MaestroConfig) error {
-	dirname, err := os.UserHomeDir()
+	configFile, err := DefaultConfigPath()
 	if err != nil {
 		return err
 	}
-	configFile := filepath.Join(dirname, ".symphony", ".config.json")
-	file, err := os.OpenFile(configFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 064)
+	return SaveMaestroConfigFile(configFile, config)
+}
+
+// SaveMaestroConfigFile writes a config to a file, which is only readable by the current user
+func SaveMaestroConfigFile(configFile string, config MaestroConfig) error {
+	if err := os.MkdirAll(filepath.Dir(configFile), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(configFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
+	// the file may have been created by GetMaestroConfig with the default permissions
+	if err = file.Chmod(0600); err != nil {
+		return err
+	}
 
 	b, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
@@ -59,6 +83,36 @@ func SaveMaestroConfig(config MaestroConfig) error {
 	}
 	return nil
 }
+
+// DefaultConfigPath returns the path of the default config file, ~/.symphony/.config.json
+func DefaultConfigPath() (string, error) {
+	dirname, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dirname, ".symphony", ".config.json"), nil
+}
+
+// LoadMaestroConfigFile reads a single config file, without the default context GetMaestroConfig adds. A missing
+// or empty file is an empty config.
+func LoadMaestroConfigFile(configFile string) (MaestroConfig, error) {
+	config := MaestroConfig{}
+	content, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return config, err
+	}
+	if len(content) > 0 {
+		if err = json.Unmarshal(content, &config); err != nil {
+			return config, fmt.Errorf("config file %s is invalid: %s", configFile, err.Error())
+		}
+	}
+	if config.Contexts == nil {
+		config.Contexts = make(map[string]MaestroContext)
+	}
+	migrateLegacySecrets(configFile, &config)
+	return config, nil
+}
+
 func GetMaestroConfig(path string) MaestroConfig {
 	var files []string
 	dirname, err := os.UserHomeDir()
@@ -95,6 +149,7 @@ func GetMaestroConfig(path string) MaestroConfig {
 				var config MaestroConfig
 				err = json.Unmarshal(content, &config)
 				if err == nil {
+					migrateLegacySecrets(f, &config)
 					for k, v := range config.Contexts {
 						ret.Contexts[k] = v
 					}
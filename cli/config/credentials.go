@@ -0,0 +1,390 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package config
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// PassphraseEnv is the environment variable holding the passphrase of the credential store
+	PassphraseEnv = "MAESTRO_CREDENTIALS_PASSPHRASE"
+
+	passwordField = "password"
+	tokenField    = "token"
+	// tokenExpiryMargin is how long before it expires a cached token is refreshed
+	tokenExpiryMargin = time.Minute
+
+	saltSize = 16
+	keySize  = 32
+	// scrypt parameters recommended for interactive logins
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+var (
+	// ReadPassphrase asks for the passphrase of the credential store when PassphraseEnv isn't set. When it's nil,
+	// the credential store can only be opened with PassphraseEnv.
+	ReadPassphrase func(prompt string) (string, error)
+
+	credentialStore *filesecret.FileSecretProvider
+	storeLock       sync.Mutex
+
+	// errNoPassphrase is returned when the passphrase of the credential store isn't set and can't be asked for
+	errNoPassphrase = fmt.Errorf("the credential store is encrypted with a passphrase, set %s", PassphraseEnv)
+)
+
+// credentialObject is the object credentials are stored under. Credentials are shared by the contexts with the
+// same user and URL, wherever they're defined.
+func (c MaestroContext) credentialObject() string {
+	return c.User + "@" + c.Url
+}
+
+// Password returns the password of a context, from the credential store or, for contexts created by earlier
+// versions that couldn't be migrated yet, from the config file. Contexts without a stored password don't open the
+// store.
+func (c MaestroContext) Password() (string, error) {
+	if c.Secret != "" {
+		return c.Secret, nil
+	}
+	if !c.StoredPassword {
+		return "", nil
+	}
+	store, err := openCredentialStore(false, true)
+	if err != nil || store == nil {
+		return "", err
+	}
+	return store.Get(c.credentialObject(), passwordField)
+}
+
+// SetPassword stores the password of a context encrypted in the credential store, and removes the plaintext
+// password from the context. An empty password is removed from the store instead, so that the context doesn't
+// need the store anymore.
+func (c *MaestroContext) SetPassword(password string) error {
+	return c.setPassword(password, true)
+}
+
+// setPassword stores the password of a context. When prompt is false, the passphrase of the store is only read
+// from PassphraseEnv, and errNoPassphrase is returned if it isn't set.
+func (c *MaestroContext) setPassword(password string, prompt bool) error {
+	if password == "" {
+		if c.StoredPassword {
+			store, err := openCredentialStore(false, prompt)
+			if err != nil {
+				return err
+			}
+			if store != nil {
+				if err = store.Delete(c.credentialObject(), passwordField); err != nil {
+					return err
+				}
+			}
+		}
+		c.Secret = ""
+		c.StoredPassword = false
+		return nil
+	}
+	store, err := openCredentialStore(true, prompt)
+	if err != nil {
+		return err
+	}
+	if err = store.Set(c.credentialObject(), passwordField, password); err != nil {
+		return err
+	}
+	c.Secret = ""
+	c.StoredPassword = true
+	return nil
+}
+
+// CachedToken returns the cached token of a context, or an empty string if there's none or it's about to expire.
+// The credential store isn't created and no passphrase is asked for.
+func (c MaestroContext) CachedToken() string {
+	store, err := openCredentialStore(false, false)
+	if err != nil || store == nil {
+		return ""
+	}
+	token, err := store.Get(c.credentialObject(), tokenField)
+	if err != nil || token == "" {
+		return ""
+	}
+	expiry, ok := tokenExpiry(token)
+	if !ok || time.Now().Add(tokenExpiryMargin).After(expiry) {
+		return ""
+	}
+	return token
+}
+
+// CacheToken stores a token of a context until it expires. Tokens without an expiry aren't cached, and neither
+// are tokens of a process that can't open the credential store without asking for its passphrase, or that has no
+// store yet.
+func (c MaestroContext) CacheToken(token string) error {
+	if _, ok := tokenExpiry(token); !ok {
+		return nil
+	}
+	store, err := openCredentialStore(false, false)
+	if errors.Is(err, errNoPassphrase) {
+		return nil
+	}
+	if err != nil || store == nil {
+		return err
+	}
+	return store.Set(c.credentialObject(), tokenField, token)
+}
+
+// ClearCredentials removes the password and the cached token of a context from the credential store. The
+// passphrase is only asked for when the context has a stored password.
+func (c MaestroContext) ClearCredentials() error {
+	store, err := openCredentialStore(false, c.StoredPassword)
+	if errors.Is(err, errNoPassphrase) && !c.StoredPassword {
+		return nil
+	}
+	if err != nil || store == nil {
+		return err
+	}
+	for _, field := range []string{passwordField, tokenField} {
+		if v, _ := store.Get(c.credentialObject(), field); v != "" {
+			if err = store.Delete(c.credentialObject(), field); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tokenExpiry reads the expiry of a JWT bearer token. The token isn't verified, as it's only used to decide
+// when to log in again.
+func tokenExpiry(token string) (time.Time, bool) {
+	parts := strings.Split(strings.TrimPrefix(token, "Bearer "), ".")
+	if len(parts) != 3 {
+		return time.Time{}, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, false
+	}
+	var claims struct {
+		ExpiresAt int64 `json:"exp"`
+	}
+	if err = json.Unmarshal(payload, &claims); err != nil || claims.ExpiresAt == 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(claims.ExpiresAt, 0), true
+}
+
+// openCredentialStore opens the encrypted credential file ~/.symphony/credentials.json. Its key is derived from a
+// passphrase, read from PassphraseEnv or asked with ReadPassphrase, and the salt in ~/.symphony/credentials.salt.
+// The store is opened once per process. When create is false and there's no store yet, it returns nil. When prompt
+// is false, the passphrase isn't asked for.
+func openCredentialStore(create bool, prompt bool) (*filesecret.FileSecretProvider, error) {
+	storeLock.Lock()
+	defer storeLock.Unlock()
+	if credentialStore != nil {
+		return credentialStore, nil
+	}
+	dirname, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	folderName := filepath.Join(dirname, ".symphony")
+	saltFile := filepath.Join(folderName, "credentials.salt")
+	legacyKeyFile := filepath.Join(folderName, "credentials.key")
+	exists, err := fileExists(saltFile)
+	if err != nil {
+		return nil, err
+	}
+	legacy, err := fileExists(legacyKeyFile)
+	if err != nil {
+		return nil, err
+	}
+	if !exists && !legacy && !create {
+		return nil, nil
+	}
+
+	passphrase, err := readPassphrase(!exists, prompt)
+	if err != nil {
+		return nil, err
+	}
+	var salt []byte
+	if exists {
+		data, err := os.ReadFile(saltFile)
+		if err != nil {
+			return nil, err
+		}
+		if salt, err = base64.StdEncoding.DecodeString(strings.TrimSpace(string(data))); err != nil || len(salt) == 0 {
+			return nil, fmt.Errorf("credential store salt %s is invalid", saltFile)
+		}
+	} else {
+		salt = make([]byte, saltSize)
+		if _, err = rand.Read(salt); err != nil {
+			return nil, err
+		}
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		if err = os.MkdirAll(folderName, 0700); err != nil {
+			return nil, err
+		}
+		if err = os.WriteFile(saltFile, []byte(base64.StdEncoding.EncodeToString(salt)+"\n"), 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	store := &filesecret.FileSecretProvider{}
+	config := filesecret.FileSecretProviderConfig{
+		FilePath:           filepath.Join(folderName, "credentials.json"),
+		Key:                key,
+		DisableEnvFallback: true,
+		ReloadIntervalInMs: -1,
+	}
+	if legacy {
+		// earlier versions kept the key next to the credentials, the credentials are encrypted again with the
+		// passphrase and the key is removed
+		config.Key = ""
+		config.KeyFile = legacyKeyFile
+		if err = store.Init(config); err != nil {
+			return nil, err
+		}
+		if err = store.RotateKey(key); err != nil {
+			return nil, err
+		}
+		if err = os.Remove(legacyKeyFile); err != nil {
+			return nil, err
+		}
+	} else if err = store.Init(config); err != nil {
+		return nil, fmt.Errorf("failed to open the credential store, the passphrase may be wrong: %s", err.Error())
+	}
+	credentialStore = store
+	return store, nil
+}
+
+// readPassphrase returns the passphrase of the credential store. A new passphrase is confirmed when it's asked for.
+// When prompt is false, it's only read from PassphraseEnv.
+func readPassphrase(create bool, prompt bool) (string, error) {
+	if v, ok := os.LookupEnv(PassphraseEnv); ok && v != "" {
+		return v, nil
+	}
+	if !prompt || ReadPassphrase == nil {
+		return "", errNoPassphrase
+	}
+	passphrase, err := ReadPassphrase("Credential store passphrase: ")
+	if err != nil {
+		return "", err
+	}
+	if passphrase == "" {
+		return "", errors.New("the credential store passphrase can't be empty")
+	}
+	if create {
+		confirmation, err := ReadPassphrase("Confirm the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if confirmation != passphrase {
+			return "", errors.New("the passphrases don't match")
+		}
+	}
+	return passphrase, nil
+}
+
+// deriveKey derives the base64-encoded key of the credential store from its passphrase
+func deriveKey(passphrase string, salt []byte) (string, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+func fileExists(path string) (bool, error) {
+	if _, err := os.Stat(path); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// migrateLegacySecrets moves the plaintext passwords of contexts created by earlier versions from a config file to
+// the credential store, and saves the file without them. The passphrase isn't asked for, so the passwords are only
+// moved when it's set in PassphraseEnv. Contexts that can't be migrated keep their plaintext password, so that they
+// can still be used.
+func migrateLegacySecrets(file string, config *MaestroConfig) {
+	migrated := markLegacyStoredPasswords(config)
+	for name, ctx := range config.Contexts {
+		if ctx.Secret == "" {
+			continue
+		}
+		err := ctx.setPassword(ctx.Secret, false)
+		if errors.Is(err, errNoPassphrase) {
+			break
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  Failed to move the password of context %s to the credential store: %s\n", name, err.Error())
+			break
+		}
+		config.Contexts[name] = ctx
+		migrated = true
+	}
+	if !migrated {
+		return
+	}
+	if err := SaveMaestroConfigFile(file, *config); err != nil {
+		fmt.Fprintf(os.Stderr, "  Failed to remove the plaintext passwords from %s: %s\n", file, err.Error())
+	}
+}
+
+// markLegacyStoredPasswords flags the contexts whose password is in a credential store of an earlier version, which
+// is encrypted with a key file instead of a passphrase. It returns whether a context was flagged.
+func markLegacyStoredPasswords(config *MaestroConfig) bool {
+	dirname, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	folderName := filepath.Join(dirname, ".symphony")
+	legacyKeyFile := filepath.Join(folderName, "credentials.key")
+	if legacy, err := fileExists(legacyKeyFile); err != nil || !legacy {
+		return false
+	}
+	store := &filesecret.FileSecretProvider{}
+	err = store.Init(filesecret.FileSecretProviderConfig{
+		FilePath:           filepath.Join(folderName, "credentials.json"),
+		KeyFile:            legacyKeyFile,
+		DisableEnvFallback: true,
+		ReloadIntervalInMs: -1,
+	})
+	if err != nil {
+		return false
+	}
+	marked := false
+	for name, ctx := range config.Contexts {
+		if ctx.Secret != "" || ctx.StoredPassword {
+			continue
+		}
+		if v, _ := store.Get(ctx.credentialObject(), passwordField); v != "" {
+			ctx.StoredPassword = true
+			config.Contexts[name] = ctx
+			marked = true
+		}
+	}
+	return marked
+}
@@ -0,0 +1,318 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package config
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupHome points the home directory at a temporary directory and sets the passphrase of the credential store
+func setupHome(t *testing.T, passphrase string) string {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	t.Setenv(PassphraseEnv, passphrase)
+	resetCredentialStore(t)
+	return home
+}
+
+// resetCredentialStore forgets the store opened by the process, like a new maestro process would
+func resetCredentialStore(t *testing.T) {
+	credentialStore = nil
+	t.Cleanup(func() {
+		credentialStore = nil
+	})
+}
+
+func newToken(t *testing.T, expiresAt time.Time) string {
+	payload, err := json.Marshal(map[string]interface{}{"exp": expiresAt.Unix()})
+	assert.Nil(t, err)
+	return "Bearer header." + base64.RawURLEncoding.EncodeToString(payload) + ".signature"
+}
+
+func TestSetPassword(t *testing.T) {
+	testCases := []struct {
+		name       string
+		passphrase string
+		err        string
+	}{
+		{name: "same passphrase", passphrase: "correct horse"},
+		{name: "wrong passphrase", passphrase: "battery staple", err: "the passphrase may be wrong"},
+		{name: "no passphrase", passphrase: "", err: PassphraseEnv},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			home := setupHome(t, "correct horse")
+			ctx := MaestroContext{Url: "http://localhost:8082/v1alpha2", User: "admin", Secret: "plaintext"}
+			assert.Nil(t, ctx.SetPassword("s3cr3t"))
+			assert.Equal(t, "", ctx.Secret)
+
+			// the password is encrypted with a key that isn't stored
+			data, err := os.ReadFile(filepath.Join(home, ".symphony", "credentials.json"))
+			assert.Nil(t, err)
+			assert.False(t, strings.Contains(string(data), "s3cr3t"))
+			assert.NoFileExists(t, filepath.Join(home, ".symphony", "credentials.key"))
+			assert.FileExists(t, filepath.Join(home, ".symphony", "credentials.salt"))
+
+			resetCredentialStore(t)
+			t.Setenv(PassphraseEnv, tc.passphrase)
+			password, err := ctx.Password()
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "s3cr3t", password)
+		})
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	testCases := []struct {
+		name    string
+		answers []string
+		create  bool
+		prompt  bool
+		err     string
+	}{
+		{name: "existing store", answers: []string{"passphrase"}, prompt: true},
+		{name: "new store", answers: []string{"passphrase", "passphrase"}, create: true, prompt: true},
+		{name: "mismatch", answers: []string{"passphrase", "other"}, create: true, prompt: true, err: "don't match"},
+		{name: "empty", answers: []string{""}, prompt: true, err: "can't be empty"},
+		{name: "without prompt", err: PassphraseEnv},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(PassphraseEnv, "")
+			answers := tc.answers
+			ReadPassphrase = func(prompt string) (string, error) {
+				if len(answers) == 0 {
+					return "", fmt.Errorf("unexpected prompt %s", prompt)
+				}
+				answer := answers[0]
+				answers = answers[1:]
+				return answer, nil
+			}
+			defer func() {
+				ReadPassphrase = nil
+			}()
+			passphrase, err := readPassphrase(tc.create, tc.prompt)
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "passphrase", passphrase)
+			assert.Empty(t, answers)
+		})
+	}
+}
+
+func TestMigrateLegacyKeyFile(t *testing.T) {
+	home := setupHome(t, "correct horse")
+	folder := filepath.Join(home, ".symphony")
+	assert.Nil(t, os.MkdirAll(folder, 0700))
+	key, err := filesecret.GenerateKey()
+	assert.Nil(t, err)
+	keyFile := filepath.Join(folder, "credentials.key")
+	assert.Nil(t, os.WriteFile(keyFile, []byte(key+"\n"), 0600))
+	legacy := filesecret.FileSecretProvider{}
+	err = legacy.Init(filesecret.FileSecretProviderConfig{
+		FilePath:           filepath.Join(folder, "credentials.json"),
+		KeyFile:            keyFile,
+		DisableEnvFallback: true,
+		ReloadIntervalInMs: -1,
+	})
+	assert.Nil(t, err)
+	ctx := MaestroContext{Url: "http://localhost:8082/v1alpha2", User: "admin"}
+	assert.Nil(t, legacy.Set(ctx.credentialObject(), passwordField, "s3cr3t"))
+
+	// the contexts with a password in the legacy store are flagged when the config is loaded
+	file := filepath.Join(home, "config.json")
+	assert.Nil(t, SaveMaestroConfigFile(file, MaestroConfig{
+		Contexts: map[string]MaestroContext{
+			"legacy":       ctx,
+			"passwordless": {Url: "http://localhost:8080/v1alpha2", User: "admin"},
+		},
+	}))
+	config, err := LoadMaestroConfigFile(file)
+	assert.Nil(t, err)
+	assert.False(t, config.Contexts["passwordless"].StoredPassword)
+	ctx = config.Contexts["legacy"]
+	assert.True(t, ctx.StoredPassword)
+
+	password, err := ctx.Password()
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", password)
+	assert.NoFileExists(t, keyFile)
+
+	// the credentials are encrypted with the passphrase
+	resetCredentialStore(t)
+	password, err = ctx.Password()
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", password)
+}
+
+func TestMigrateLegacySecrets(t *testing.T) {
+	home := setupHome(t, "correct horse")
+	file := filepath.Join(home, "config.json")
+	err := SaveMaestroConfigFile(file, MaestroConfig{
+		DefaultContext: "legacy",
+		Contexts: map[string]MaestroContext{
+			"legacy": {Url: "http://localhost:8082/v1alpha2", User: "admin", Secret: "s3cr3t"},
+			"new":    {Url: "http://localhost:8080/v1alpha2", User: "admin"},
+		},
+	})
+	assert.Nil(t, err)
+
+	config, err := LoadMaestroConfigFile(file)
+	assert.Nil(t, err)
+	assert.Equal(t, "", config.Contexts["legacy"].Secret)
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), "s3cr3t"))
+
+	resetCredentialStore(t)
+	password, err := config.Contexts["legacy"].Password()
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", password)
+}
+
+func TestMigrateLegacySecretsWithoutPassphrase(t *testing.T) {
+	home := setupHome(t, "")
+	failOnPrompt(t)
+	file := filepath.Join(home, "config.json")
+	err := SaveMaestroConfigFile(file, MaestroConfig{
+		Contexts: map[string]MaestroContext{
+			"legacy": {Url: "http://localhost:8082/v1alpha2", User: "admin", Secret: "s3cr3t"},
+		},
+	})
+	assert.Nil(t, err)
+
+	// the context keeps working with its plaintext password
+	config, err := LoadMaestroConfigFile(file)
+	assert.Nil(t, err)
+	password, err := config.Contexts["legacy"].Password()
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", password)
+	assert.NoFileExists(t, filepath.Join(home, ".symphony", "credentials.salt"))
+}
+
+func TestCachedToken(t *testing.T) {
+	testCases := []struct {
+		name   string
+		token  string
+		cached bool
+	}{
+		{name: "valid", token: newToken(t, time.Now().Add(time.Hour)), cached: true},
+		{name: "about to expire", token: newToken(t, time.Now().Add(tokenExpiryMargin/2))},
+		{name: "expired", token: newToken(t, time.Now().Add(-time.Hour))},
+		{name: "without expiry", token: "Bearer opaque"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			setupHome(t, "correct horse")
+			ctx := MaestroContext{Url: "http://localhost:8082/v1alpha2", User: "admin"}
+			assert.Nil(t, ctx.SetPassword("s3cr3t"))
+			assert.Nil(t, ctx.CacheToken(tc.token))
+			if tc.cached {
+				assert.Equal(t, tc.token, ctx.CachedToken())
+			} else {
+				assert.Equal(t, "", ctx.CachedToken())
+			}
+
+			assert.Nil(t, ctx.ClearCredentials())
+			assert.Equal(t, "", ctx.CachedToken())
+			password, err := ctx.Password()
+			assert.Nil(t, err)
+			assert.Equal(t, "", password)
+		})
+	}
+}
+
+// failOnPrompt makes the test fail if the passphrase of the credential store is asked for
+func failOnPrompt(t *testing.T) {
+	ReadPassphrase = func(prompt string) (string, error) {
+		t.Errorf("unexpected prompt %s", prompt)
+		return "", fmt.Errorf("unexpected prompt %s", prompt)
+	}
+	t.Cleanup(func() {
+		ReadPassphrase = nil
+	})
+}
+
+func TestPasswordlessContextWithoutStore(t *testing.T) {
+	home := setupHome(t, "")
+	failOnPrompt(t)
+	ctx := MaestroContext{Url: "http://localhost:8082/v1alpha2", User: "admin"}
+	assert.Nil(t, ctx.SetPassword(""))
+	assert.False(t, ctx.StoredPassword)
+	password, err := ctx.Password()
+	assert.Nil(t, err)
+	assert.Equal(t, "", password)
+
+	// the token isn't cached, as there's no store to cache it in
+	assert.Nil(t, ctx.CacheToken(newToken(t, time.Now().Add(time.Hour))))
+	assert.Equal(t, "", ctx.CachedToken())
+	assert.Nil(t, ctx.ClearCredentials())
+	assert.NoFileExists(t, filepath.Join(home, ".symphony", "credentials.salt"))
+	assert.NoFileExists(t, filepath.Join(home, ".symphony", "credentials.json"))
+}
+
+func TestCacheTokenWithoutPassphrase(t *testing.T) {
+	setupHome(t, "correct horse")
+	other := MaestroContext{Url: "http://localhost:8080/v1alpha2", User: "admin"}
+	assert.Nil(t, other.SetPassword("s3cr3t"))
+
+	// a new process without the passphrase, like a CI job, keeps working with a passwordless context
+	resetCredentialStore(t)
+	t.Setenv(PassphraseEnv, "")
+	failOnPrompt(t)
+	ctx := MaestroContext{Url: "http://localhost:8082/v1alpha2", User: "admin"}
+	password, err := ctx.Password()
+	assert.Nil(t, err)
+	assert.Equal(t, "", password)
+	assert.Nil(t, ctx.CacheToken(newToken(t, time.Now().Add(time.Hour))))
+	assert.Equal(t, "", ctx.CachedToken())
+	assert.Nil(t, ctx.ClearCredentials())
+}
+
+func TestTokenExpiry(t *testing.T) {
+	expiresAt := time.Unix(time.Now().Add(time.Hour).Unix(), 0)
+	testCases := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{name: "bearer token", token: newToken(t, expiresAt), ok: true},
+		{name: "raw token", token: strings.TrimPrefix(newToken(t, expiresAt), "Bearer "), ok: true},
+		{name: "not a JWT", token: "Bearer opaque"},
+		{name: "bad payload", token: "Bearer header.!!!.signature"},
+		{name: "no expiry", token: "Bearer header." + base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"admin"}`)) + ".signature"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expiry, ok := tokenExpiry(tc.token)
+			assert.Equal(t, tc.ok, ok)
+			if tc.ok {
+				assert.Equal(t, expiresAt, expiry)
+			}
+		})
+	}
+}
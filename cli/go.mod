@@ -9,7 +9,7 @@ replace github.com/eclipse-symphony/symphony/coa => ../coa
 require github.com/spf13/cobra v1.7.0
 
 require (
-	github.com/eclipse-symphony/symphony/coa v0.0.0 // indirect
+	github.com/eclipse-symphony/symphony/coa v0.0.0
 	github.com/fatih/color v1.13.0 // indirect
 	github.com/mattn/go-colorable v0.1.12 // indirect
 	github.com/mattn/go-isatty v0.0.14 // indirect
@@ -22,6 +22,22 @@ require (
 require (
 	github.com/cenkalti/backoff/v4 v4.2.1
 	github.com/princjef/mageutil v1.0.0
+	github.com/stretchr/testify v1.8.4
+	golang.org/x/crypto v0.16.0
+	golang.org/x/term v0.15.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/sirupsen/logrus v1.9.3 // indirect
+	github.com/yalp/jsonpath v0.0.0-20180802001716-5cc68e5049a0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20240102182953-50ed04b92917 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240102182953-50ed04b92917 // indirect
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	k8s.io/client-go v0.25.0 // indirect
 )
 
 require (
@@ -31,6 +47,6 @@ require (
 	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/rivo/uniseg v0.2.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.17.0 // indirect
+	golang.org/x/sys v0.17.0
 	sigs.k8s.io/yaml v1.3.0
 )
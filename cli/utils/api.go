@@ -13,7 +13,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"reflect"
+	"strconv"
 
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
 	"sigs.k8s.io/yaml"
 )
 
@@ -27,11 +30,7 @@ type authResponse struct {
 	TokenType   string `json:"tokenType"`
 }
 
-func Remove(url string, username string, password string, objType string, objName string) error {
-	token, err := Login(url, username, password)
-	if err != nil {
-		return err
-	}
+func Remove(url string, token string, objType string, objName string) error {
 	route := ""
 	switch objType {
 	case "target", "targets":
@@ -45,17 +44,13 @@ func Remove(url string, username string, password string, objType string, objNam
 		return errors.New("object name is missing")
 	}
 	route += "/" + objName
-	_, err = callRestAPI(url, route, "DELETE", nil, token, nil)
-	if err != nil {
+	_, err := callRestAPI(url, route, "DELETE", nil, token, nil)
+	if err != nil && !IsNotFound(err) { // the object is already gone
 		return err
 	}
 	return nil
 }
-func Upsert(url string, username string, password string, objType string, objName string, payload []byte) error {
-	token, err := Login(url, username, password)
-	if err != nil {
-		return err
-	}
+func Upsert(url string, token string, objType string, objName string, payload []byte) error {
 	route := ""
 	switch objType {
 	case "target", "targets":
@@ -69,7 +64,7 @@ func Upsert(url string, username string, password string, objType string, objNam
 		return errors.New("object name is missing")
 	}
 	route += "/" + objName
-	payload, err = yamlToJson(payload)
+	payload, err := yamlToJson(payload)
 	if err != nil {
 		return err
 	}
@@ -96,11 +91,7 @@ func yamlToJson(payload []byte) ([]byte, error) {
 	return json.Marshal(o)
 }
 
-func Get(url string, username string, password string, objType string, path string, docType string, objName string) ([]interface{}, error) {
-	token, err := Login(url, username, password)
-	if err != nil {
-		return nil, err
-	}
+func Get(url string, token string, objType string, path string, docType string, objName string) ([]interface{}, error) {
 	route := ""
 	switch objType {
 	case "target", "targets":
@@ -153,6 +144,10 @@ func Login(url string, username string, password string) (string, error) {
 		Password: password,
 	})
 	resp, err := callRestAPI(url, "/users/auth", "POST", data, "", nil)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return "", fmt.Errorf("failed to log in to %s as %s, the user name or password is wrong", url, username)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -165,12 +160,35 @@ func Login(url string, username string, password string) (string, error) {
 }
 
 func callRestAPI(url string, route string, method string, payload []byte, token string, parameters map[string]string) ([]byte, error) {
-	client := &http.Client{}
-	rUrl := url + route
-	req, err := http.NewRequest(method, rUrl, bytes.NewBuffer(payload))
+	if token != "" && token == staleToken {
+		token = refreshedToken
+	}
+	resp, bodyBytes, err := sendRequest(url+route, method, payload, token, parameters)
 	if err != nil {
 		return nil, err
 	}
+	if resp.StatusCode == http.StatusUnauthorized && token != "" && tokenRefresher != nil {
+		newToken, err := tokenRefresher()
+		if err != nil {
+			return nil, err
+		}
+		staleToken, refreshedToken = token, newToken
+		resp, bodyBytes, err = sendRequest(url+route, method, payload, newToken, parameters)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode >= 300 {
+		return nil, newAPIError(resp.StatusCode, bodyBytes)
+	}
+	return bodyBytes, nil
+}
+
+func sendRequest(rUrl string, method string, payload []byte, token string, parameters map[string]string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(method, rUrl, bytes.NewBuffer(payload))
+	if err != nil {
+		return nil, nil, err
+	}
 	req.Header.Set("Content-Type", "application/json")
 
 	if token != "" {
@@ -185,20 +203,338 @@ func callRestAPI(url string, route string, method string, payload []byte, token
 		req.URL.RawQuery = query.Encode()
 	}
 
-	resp, err := client.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, bodyBytes, nil
+}
+
+// ApplyObject creates or updates an object, and returns "created", "updated" or "unchanged". With dryRun, the
+// object is validated by the API without being stored.
+func ApplyObject(url string, token string, object ManifestObject, dryRun bool) (string, error) {
+	kind := objectKinds[object.Kind]
+	existing, err := findObject(url, token, object)
+	if err != nil {
+		return "", err
+	}
+	result := "created"
+	if existing != nil {
+		changed, err := isObjectChanged(object, existing)
+		if err != nil {
+			return "", err
+		}
+		if !changed {
+			return "unchanged", nil
+		}
+		result = "updated"
+	}
+	payload, err := json.Marshal(object.Body)
+	if err != nil {
+		return "", err
+	}
+	params := map[string]string{
+		"namespace": object.Namespace,
+	}
+	if dryRun {
+		params["dry-run"] = "true"
+	}
+	_, err = callRestAPI(url, kind.route+"/"+object.Name, "POST", payload, token, params)
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// DeleteObject deletes an object, and returns "deleted" or "not found"
+func DeleteObject(url string, token string, object ManifestObject) (string, error) {
+	existing, err := findObject(url, token, object)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil {
+		return "not found", nil
+	}
+	_, err = callRestAPI(url, objectKinds[object.Kind].route+"/"+object.Name, "DELETE", nil, token, map[string]string{
+		"namespace": object.Namespace,
+	})
+	if IsNotFound(err) {
+		return "not found", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "deleted", nil
+}
+
+// findObject returns the stored object with the name and namespace of an object, or nil if there isn't one.
+// Objects are looked up in the list of their namespace, as reading a missing object isn't reported as Not Found
+// by all routes.
+func findObject(url string, token string, object ManifestObject) (map[string]interface{}, error) {
+	list, err := listObjects(url, token, object.Kind, object.Namespace)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode >= 300 {
-		if resp.StatusCode == 404 { // API service is already gone
-			return nil, nil
+	for _, item := range list {
+		if metadata, ok := item["metadata"].(map[string]interface{}); ok && metadata["name"] == object.Name {
+			return item, nil
 		}
-		return nil, fmt.Errorf("failed to invoke Symphony API: [%d] - %v", resp.StatusCode, string(bodyBytes))
 	}
-	return bodyBytes, nil
+	return nil, nil
+}
+
+// ListObjects returns the stored objects of a kind in a namespace
+func ListObjects(url string, token string, kind string, namespace string) ([]map[string]interface{}, error) {
+	if _, ok := objectKinds[kind]; !ok {
+		return nil, fmt.Errorf("kind %s is not supported", kind)
+	}
+	return listObjects(url, token, kind, namespace)
+}
+
+func listObjects(url string, token string, kind string, namespace string) ([]map[string]interface{}, error) {
+	resp, err := callRestAPI(url, objectKinds[kind].route, "GET", nil, token, map[string]string{
+		"namespace": namespace,
+	})
+	list := make([]map[string]interface{}, 0)
+	if IsNotFound(err) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return list, nil
+	}
+	if err = json.Unmarshal(resp, &list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// isObjectChanged compares the spec, labels and annotations of an object with the stored object. Both are
+// normalized through the object's model type, so that defaults and empty fields don't count as changes.
+func isObjectChanged(object ManifestObject, existing map[string]interface{}) (bool, error) {
+	desired, err := normalizeObject(object.Kind, object.Body)
+	if err != nil {
+		return false, err
+	}
+	current, err := normalizeObject(object.Kind, existing)
+	if err != nil {
+		return false, err
+	}
+	return !reflect.DeepEqual(desired, current), nil
+}
+
+func normalizeObject(kind string, obj interface{}) (map[string]interface{}, error) {
+	state := objectKinds[kind].newState()
+	jData, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(jData, state); err != nil {
+		return nil, err
+	}
+	jData, err = json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	var normalized map[string]interface{}
+	if err = json.Unmarshal(jData, &normalized); err != nil {
+		return nil, err
+	}
+	// the generation is maintained by the API
+	if spec, ok := normalized["spec"].(map[string]interface{}); ok {
+		delete(spec, "generation")
+	}
+	ret := map[string]interface{}{
+		"spec": normalized["spec"],
+	}
+	if metadata, ok := normalized["metadata"].(map[string]interface{}); ok {
+		ret["labels"] = metadata["labels"]
+		ret["annotations"] = metadata["annotations"]
+	}
+	return ret, nil
+}
+
+// PlanInstance returns what deploying an instance would do, or removing it when remove is set. The solution and
+// targets of the instance are taken from objects when they're defined there, and read from the API otherwise,
+// so that changes to them can be previewed before they're applied.
+func PlanInstance(url string, token string, instance ManifestObject, objects []ManifestObject, remove bool) (model.PlanPreview, error) {
+	var preview model.PlanPreview
+	var instanceState model.InstanceState
+	if err := ConvertObject(instance.Body, &instanceState); err != nil {
+		return preview, err
+	}
+	if instanceState.Spec == nil || instanceState.Spec.Solution == "" {
+		return preview, errors.New("instance doesn't refer to a solution")
+	}
+	instanceState.ObjectMeta.Name = instance.Name
+	instanceState.ObjectMeta.Namespace = instance.Namespace
+
+	solutionObject := ManifestObject{Kind: "Solution", Name: instanceState.Spec.Solution, Namespace: instance.Namespace}
+	var solution interface{}
+	for _, object := range objects {
+		if object.Kind == "Solution" && object.Name == solutionObject.Name && object.Namespace == solutionObject.Namespace {
+			solution = object.Body
+		}
+	}
+	if solution == nil {
+		existing, err := findObject(url, token, solutionObject)
+		if err != nil {
+			return preview, err
+		}
+		if existing == nil {
+			return preview, fmt.Errorf("solution %s is not found", solutionObject.Name)
+		}
+		solution = existing
+	}
+	var solutionState model.SolutionState
+	if err := ConvertObject(solution, &solutionState); err != nil {
+		return preview, err
+	}
+	if solutionState.Spec == nil {
+		solutionState.Spec = &model.SolutionSpec{}
+	}
+
+	list, err := listObjects(url, token, "Target", instance.Namespace)
+	if err != nil {
+		return preview, err
+	}
+	stored := make([]model.TargetState, 0, len(list))
+	if err = ConvertObject(list, &stored); err != nil {
+		return preview, err
+	}
+	targets := make(map[string]model.TargetState, len(stored))
+	for _, target := range stored {
+		targets[target.ObjectMeta.Name] = target
+	}
+	for _, object := range objects {
+		if object.Kind != "Target" || object.Namespace != instance.Namespace {
+			continue
+		}
+		var target model.TargetState
+		if err = ConvertObject(object.Body, &target); err != nil {
+			return preview, err
+		}
+		target.ObjectMeta.Name = object.Name
+		target.ObjectMeta.Namespace = object.Namespace
+		targets[object.Name] = target
+	}
+
+	// the deployment is sent without assignments, so that the API matches the targets of the instance and assigns
+	// the components to them the way it does when the instance is deployed
+	deployment := model.DeploymentSpec{
+		Instance: instanceState,
+		Solution: solutionState,
+		Targets:  targets,
+	}
+	payload, err := json.Marshal(deployment)
+	if err != nil {
+		return preview, err
+	}
+	params := map[string]string{
+		"namespace": instance.Namespace,
+	}
+	if remove {
+		params["delete"] = "true"
+	}
+	resp, err := callRestAPI(url, "/solution/plan", "POST", payload, token, params)
+	if err != nil {
+		return preview, err
+	}
+	if err = json.Unmarshal(resp, &preview); err != nil {
+		return preview, err
+	}
+	return preview, nil
+}
+
+// ConvertObject converts an object read from a manifest or the API to its model type
+func ConvertObject(obj interface{}, target interface{}) error {
+	jData, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(jData, target)
+}
+
+// GetObject returns a stored object of a kind, or nil if there isn't one
+func GetObject(url string, token string, kind string, name string, namespace string) (map[string]interface{}, error) {
+	if _, ok := objectKinds[kind]; !ok {
+		return nil, fmt.Errorf("kind %s is not supported", kind)
+	}
+	return findObject(url, token, ManifestObject{Kind: kind, Name: name, Namespace: namespace})
+}
+
+// GetSummary returns the summary of the last deployment of an instance, or nil if it hasn't been deployed yet.
+// Targets are deployed as instances named "target-runtime-<target name>".
+func GetSummary(url string, token string, instance string, namespace string) (*model.SummaryResult, error) {
+	resp, err := callRestAPI(url, "/solution/queue", "GET", nil, token, map[string]string{
+		"instance":  instance,
+		"namespace": namespace,
+	})
+	if IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) == 0 {
+		return nil, nil
+	}
+	var summary model.SummaryResult
+	if err = json.Unmarshal(resp, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// ExportBackup returns the archive of all objects of the control plane
+func ExportBackup(url string, token string, passwordHashes bool) (model.BackupArchive, error) {
+	var archive model.BackupArchive
+	resp, err := callRestAPI(url, "/backup/export", "GET", nil, token, map[string]string{
+		"include-password-hashes": strconv.FormatBool(passwordHashes),
+	})
+	if err != nil {
+		return archive, err
+	}
+	err = json.Unmarshal(resp, &archive)
+	return archive, err
+}
+
+// ImportBackup restores an archive, and returns the result of each object. The result is returned with the error
+// when some objects can't be restored, or when the policy is fail and some objects already exist.
+func ImportBackup(url string, token string, archive model.BackupArchive, policy string, deploymentState bool, redeploy bool) (model.RestoreResult, error) {
+	var result model.RestoreResult
+	payload, err := json.Marshal(archive)
+	if err != nil {
+		return result, err
+	}
+	resp, err := callRestAPI(url, "/backup/import", "POST", payload, token, map[string]string{
+		"conflict":         policy,
+		"deployment-state": strconv.FormatBool(deploymentState),
+		"redeploy":         strconv.FormatBool(redeploy),
+	})
+	if err != nil {
+		// the response of a partial restore is the result, which replaces the message of the error
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && json.Unmarshal([]byte(apiErr.Message), &result) == nil {
+			counts := make(map[string]int)
+			for _, object := range result.Objects {
+				counts[object.Result]++
+			}
+			if counts["conflict"] > 0 {
+				apiErr.Message = fmt.Sprintf("%d objects already exist, nothing is restored", counts["conflict"])
+			} else {
+				apiErr.Message = fmt.Sprintf("%d objects failed to be restored", counts["failed"])
+			}
+		}
+		return result, err
+	}
+	err = json.Unmarshal(resp, &result)
+	return result, err
 }
@@ -0,0 +1,154 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallRestAPIRefreshesToken(t *testing.T) {
+	testCases := []struct {
+		name       string
+		validToken string
+		refresher  func() (string, error)
+		refreshes  int
+		status     int
+		err        string
+	}{
+		{name: "valid token", validToken: "Bearer old", status: http.StatusOK},
+		{
+			name:       "refreshed token",
+			validToken: "Bearer new",
+			refresher:  func() (string, error) { return "Bearer new", nil },
+			refreshes:  1,
+			status:     http.StatusOK,
+		},
+		{
+			name:       "refresh failure",
+			validToken: "Bearer new",
+			refresher:  func() (string, error) { return "", errors.New("login failed") },
+			refreshes:  1,
+			err:        "login failed",
+		},
+		{
+			name:       "refreshed token rejected",
+			validToken: "Bearer other",
+			refresher:  func() (string, error) { return "Bearer new", nil },
+			refreshes:  1,
+			status:     http.StatusUnauthorized,
+		},
+		{name: "no refresher", validToken: "Bearer new", status: http.StatusUnauthorized},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") != tc.validToken {
+					w.WriteHeader(http.StatusUnauthorized)
+					w.Write([]byte("token is rejected"))
+					return
+				}
+				w.Write([]byte("{}"))
+			}))
+			defer server.Close()
+			refreshes := 0
+			if tc.refresher != nil {
+				SetTokenRefresher(func() (string, error) {
+					refreshes++
+					return tc.refresher()
+				})
+			}
+			defer func() {
+				SetTokenRefresher(nil)
+				staleToken, refreshedToken = "", ""
+			}()
+
+			body, err := callRestAPI(server.URL, "/solutions", "GET", nil, "Bearer old", nil)
+			assert.Equal(t, tc.refreshes, refreshes)
+			switch {
+			case tc.err != "":
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+			case tc.status == http.StatusOK:
+				assert.Nil(t, err)
+				assert.Equal(t, "{}", string(body))
+			default:
+				assert.True(t, IsUnauthorized(err))
+			}
+
+			// later calls with the rejected token use the refreshed one without logging in again
+			if tc.status == http.StatusOK && tc.refreshes > 0 {
+				_, err = callRestAPI(server.URL, "/solutions", "GET", nil, "Bearer old", nil)
+				assert.Nil(t, err)
+				assert.Equal(t, tc.refreshes, refreshes)
+			}
+		})
+	}
+}
+
+func TestIsObjectChanged(t *testing.T) {
+	object := ManifestObject{
+		Kind: "Solution",
+		Name: "redis",
+		Body: YamlArtifact{
+			Kind:     "Solution",
+			Metadata: map[string]interface{}{"name": "redis", "labels": map[string]interface{}{"app": "redis"}},
+			Spec: map[string]interface{}{
+				"components": []interface{}{
+					map[string]interface{}{"name": "redis", "type": "container"},
+				},
+			},
+		},
+	}
+	existing := func(labels map[string]interface{}, spec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "redis", "namespace": "default", "labels": labels},
+			"spec":     spec,
+			"status":   map[string]interface{}{"deployed": 1},
+		}
+	}
+	component := map[string]interface{}{"name": "redis", "type": "container"}
+	testCases := []struct {
+		name     string
+		existing map[string]interface{}
+		changed  bool
+	}{
+		{
+			name:     "same object",
+			existing: existing(map[string]interface{}{"app": "redis"}, map[string]interface{}{"components": []interface{}{component}}),
+		},
+		{
+			name: "generation and empty fields",
+			existing: existing(map[string]interface{}{"app": "redis"}, map[string]interface{}{
+				"generation":  "3",
+				"displayName": "",
+				"components":  []interface{}{component},
+			}),
+		},
+		{
+			name:     "changed spec",
+			existing: existing(map[string]interface{}{"app": "redis"}, map[string]interface{}{"components": []interface{}{}}),
+			changed:  true,
+		},
+		{
+			name:     "changed labels",
+			existing: existing(map[string]interface{}{"app": "cache"}, map[string]interface{}{"components": []interface{}{component}}),
+			changed:  true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			changed, err := isObjectChanged(object, tc.existing)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.changed, changed)
+		})
+	}
+}
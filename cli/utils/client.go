@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ClientOptions are the TLS options of the connection to the Symphony API
+type ClientOptions struct {
+	// CACert is a PEM bundle of the certificate authorities trusted for the API server, in addition to the
+	// system ones
+	CACert string
+	// ClientCert and ClientKey are PEM files of a client certificate presented to the API server
+	ClientCert string
+	ClientKey  string
+	// Insecure skips the verification of the API server certificate
+	Insecure bool
+}
+
+// APIError is an error response of the Symphony API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Sprintf("failed to invoke Symphony API: [%d] - %s, use maestro login to log in again", e.StatusCode, e.Message)
+	case http.StatusForbidden:
+		return fmt.Sprintf("failed to invoke Symphony API: [%d] - %s, the user isn't allowed to do this", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("failed to invoke Symphony API: [%d] - %s", e.StatusCode, e.Message)
+}
+
+// IsNotFound returns true when err is a Not Found response of the API
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized returns true when err is an Unauthorized response of the API
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+var (
+	httpClient = &http.Client{}
+	// tokenRefresher logs in again when the API rejects a token, which may be revoked before it expires
+	tokenRefresher func() (string, error)
+	// staleToken is the token replaced by refreshedToken, so that the callers holding it don't log in again
+	staleToken     string
+	refreshedToken string
+)
+
+// ConfigureClient sets the TLS options of the connection to the Symphony API
+func ConfigureClient(options ClientOptions) error {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: options.Insecure,
+	}
+	if options.CACert != "" {
+		pem, err := os.ReadFile(options.CACert)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle %s: %s", options.CACert, err.Error())
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("CA bundle %s doesn't contain any PEM certificate", options.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if options.ClientCert != "" || options.ClientKey != "" {
+		if options.ClientCert == "" || options.ClientKey == "" {
+			return errors.New("both a client certificate and a client key are required")
+		}
+		cert, err := tls.LoadX509KeyPair(options.ClientCert, options.ClientKey)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate %s: %s", options.ClientCert, err.Error())
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	httpClient = &http.Client{Transport: transport}
+	return nil
+}
+
+// SetTokenRefresher sets the function called to log in again when the API rejects a token
+func SetTokenRefresher(refresh func() (string, error)) {
+	tokenRefresher = refresh
+}
+
+// newAPIError reads the message of an error response. COA errors are returned as plain text, while some routes
+// return a JSON object with a message.
+func newAPIError(statusCode int, body []byte) *APIError {
+	message := strings.TrimSpace(string(body))
+	var obj map[string]interface{}
+	if json.Unmarshal(body, &obj) == nil {
+		for _, key := range []string{"message", "error", "Message"} {
+			if v, ok := obj[key].(string); ok && v != "" {
+				message = v
+				break
+			}
+		}
+	}
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return &APIError{StatusCode: statusCode, Message: message}
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/api/pkg/apis/v1alpha1/model"
+	"sigs.k8s.io/yaml"
+)
+
+// ManifestObject is a Symphony object read from a manifest
+type ManifestObject struct {
+	Kind      string
+	Name      string
+	Namespace string
+	// Source is the file the object is read from, "-" for stdin
+	Source string
+	Body   YamlArtifact
+}
+
+func (o ManifestObject) String() string {
+	return strings.ToLower(o.Kind) + "/" + o.Name
+}
+
+// objectKinds lists the kinds maestro can apply. Objects of a lower rank are applied before objects of a higher
+// rank, and deleted after them.
+var objectKinds = map[string]struct {
+	rank     int
+	route    string
+	newState func() interface{}
+}{
+	"Target":     {0, "/targets/registry", func() interface{} { return &model.TargetState{} }},
+	"Solution":   {0, "/solutions", func() interface{} { return &model.SolutionState{} }},
+	"Catalog":    {0, "/catalogs/registry", func() interface{} { return &model.CatalogState{} }},
+	"Campaign":   {0, "/campaigns", func() interface{} { return &model.CampaignState{} }},
+	"Instance":   {1, "/instances", func() interface{} { return &model.InstanceState{} }},
+	"Activation": {1, "/activations/registry", func() interface{} { return &model.ActivationState{} }},
+}
+
+// ReadManifests reads the objects in files and directories. A directory is read recursively for .yaml, .yml and
+// .json files, and "-" reads stdin. YAML files can hold multiple documents, and JSON files can hold an array.
+func ReadManifests(paths []string) ([]ManifestObject, error) {
+	ret := make([]ManifestObject, 0)
+	for _, path := range paths {
+		if path == "-" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return nil, err
+			}
+			objects, err := parseManifest("-", data)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, objects...)
+			continue
+		}
+		err := filepath.WalkDir(path, func(file string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			ext := strings.ToLower(filepath.Ext(file))
+			if file != path && ext != ".yaml" && ext != ".yml" && ext != ".json" {
+				return nil
+			}
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			objects, err := parseManifest(file, data)
+			if err != nil {
+				return err
+			}
+			ret = append(ret, objects...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// SortForApply orders objects so that the objects they depend on come first. Objects of the same rank keep
+// the order of the manifests.
+func SortForApply(objects []ManifestObject) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objectKinds[objects[i].Kind].rank < objectKinds[objects[j].Kind].rank
+	})
+}
+
+// SortForDelete orders objects so that the objects depending on others come first
+func SortForDelete(objects []ManifestObject) {
+	sort.SliceStable(objects, func(i, j int) bool {
+		return objectKinds[objects[i].Kind].rank > objectKinds[objects[j].Kind].rank
+	})
+}
+
+func parseManifest(source string, data []byte) ([]ManifestObject, error) {
+	ret := make([]ManifestObject, 0)
+	for i, doc := range splitDocuments(data) {
+		jData, err := yaml.YAMLToJSON(doc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: document %d is invalid: %s", source, i+1, err.Error())
+		}
+		jData = bytes.TrimSpace(jData)
+		if len(jData) == 0 || string(jData) == "null" {
+			continue
+		}
+		var artifacts []YamlArtifact
+		if jData[0] == '[' {
+			err = json.Unmarshal(jData, &artifacts)
+		} else {
+			var artifact YamlArtifact
+			err = json.Unmarshal(jData, &artifact)
+			artifacts = append(artifacts, artifact)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: document %d is invalid: %s", source, i+1, err.Error())
+		}
+		for _, artifact := range artifacts {
+			object, err := toManifestObject(source, artifact)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, object)
+		}
+	}
+	return ret, nil
+}
+
+// splitDocuments splits a multi-document YAML file on "---" lines
+func splitDocuments(data []byte) [][]byte {
+	docs := make([][]byte, 0)
+	var current bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "---") && strings.TrimSpace(strings.TrimPrefix(line, "---")) == "" {
+			docs = append(docs, append([]byte(nil), current.Bytes()...))
+			current.Reset()
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	return append(docs, current.Bytes())
+}
+
+func toManifestObject(source string, artifact YamlArtifact) (ManifestObject, error) {
+	name, _ := artifact.Metadata["name"].(string)
+	if name == "" {
+		return ManifestObject{}, fmt.Errorf("%s: an object has no metadata.name", source)
+	}
+	kind, err := inferKind(artifact)
+	if err != nil {
+		return ManifestObject{}, fmt.Errorf("%s: %s: %s", source, name, err.Error())
+	}
+	namespace, _ := artifact.Metadata["namespace"].(string)
+	if namespace == "" {
+		namespace = "default"
+	}
+	artifact.Kind = kind
+	return ManifestObject{
+		Kind:      kind,
+		Name:      name,
+		Namespace: namespace,
+		Source:    source,
+		Body:      artifact,
+	}, nil
+}
+
+// inferKind returns the kind of an object from its kind field, or from the shape of its spec when it has no kind
+func inferKind(artifact YamlArtifact) (string, error) {
+	if artifact.Kind != "" {
+		for kind := range objectKinds {
+			if strings.EqualFold(kind, artifact.Kind) {
+				return kind, nil
+			}
+		}
+		return "", fmt.Errorf("kind %s is not supported", artifact.Kind)
+	}
+	spec, ok := artifact.Spec.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("object has no kind and no spec")
+	}
+	has := func(keys ...string) bool {
+		for _, key := range keys {
+			if _, ok := spec[key]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("solution"):
+		return "Instance", nil
+	case has("campaign"):
+		return "Activation", nil
+	case has("stages", "firstStage", "selfDriving"):
+		return "Campaign", nil
+	case has("type") && has("properties"):
+		return "Catalog", nil
+	case has("topologies", "forceRedeploy", "constraints", "properties", "scope"):
+		return "Target", nil
+	case has("components"):
+		// solutions have no fields of their own, so that a spec with components only may be a target too
+		return "", fmt.Errorf("object has no kind, and a spec with components can be a Solution or a Target, set its kind")
+	}
+	return "", fmt.Errorf("object has no kind, and its kind can't be inferred from its spec")
+}
@@ -0,0 +1,197 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeManifest(t *testing.T, dir string, name string, content string) string {
+	file := filepath.Join(dir, name)
+	assert.Nil(t, os.MkdirAll(filepath.Dir(file), 0755))
+	assert.Nil(t, os.WriteFile(file, []byte(content), 0644))
+	return file
+}
+
+func objectNames(objects []ManifestObject) []string {
+	ret := make([]string, 0, len(objects))
+	for _, object := range objects {
+		ret = append(ret, object.String())
+	}
+	return ret
+}
+
+func TestReadManifests(t *testing.T) {
+	testCases := []struct {
+		name    string
+		files   map[string]string
+		path    string
+		objects []string
+		err     string
+	}{
+		{
+			name: "multiple documents",
+			files: map[string]string{"app.yaml": `kind: Solution
+metadata:
+  name: redis
+spec:
+  components: []
+---
+---
+kind: Instance
+metadata:
+  name: redis-instance
+  namespace: ns1
+spec:
+  solution: redis
+`},
+			path:    "app.yaml",
+			objects: []string{"solution/redis", "instance/redis-instance"},
+		},
+		{
+			name:    "json array",
+			files:   map[string]string{"app.json": `[{"kind": "Target", "metadata": {"name": "t1"}, "spec": {}}, {"metadata": {"name": "c1"}, "spec": {"stages": {}}}]`},
+			path:    "app.json",
+			objects: []string{"target/t1", "campaign/c1"},
+		},
+		{
+			name: "directory",
+			files: map[string]string{
+				"a/solution.yml":  "kind: solution\nmetadata:\n  name: s1\nspec: {}\n",
+				"a/b/target.yaml": "kind: Target\nmetadata:\n  name: t1\nspec: {}\n",
+				"a/readme.md":     "not a manifest",
+			},
+			path:    "a",
+			objects: []string{"target/t1", "solution/s1"},
+		},
+		{
+			name:  "missing name",
+			files: map[string]string{"app.yaml": "kind: Solution\nmetadata: {}\nspec: {}\n"},
+			path:  "app.yaml",
+			err:   "has no metadata.name",
+		},
+		{
+			name:  "unsupported kind",
+			files: map[string]string{"app.yaml": "kind: Device\nmetadata:\n  name: d1\nspec: {}\n"},
+			path:  "app.yaml",
+			err:   "kind Device is not supported",
+		},
+		{
+			name:  "invalid document",
+			files: map[string]string{"app.yaml": "kind: Solution\nmetadata:\n  name: s1\nspec: {}\n---\nkind: [\n"},
+			path:  "app.yaml",
+			err:   "document 2 is invalid",
+		},
+		{
+			name: "missing file",
+			path: "missing.yaml",
+			err:  "no such file",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tc.files {
+				writeManifest(t, dir, name, content)
+			}
+			objects, err := ReadManifests([]string{filepath.Join(dir, tc.path)})
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.objects, objectNames(objects))
+		})
+	}
+}
+
+func TestReadManifestsDefaults(t *testing.T) {
+	dir := t.TempDir()
+	file := writeManifest(t, dir, "app.yaml", "kind: solution\nmetadata:\n  name: s1\nspec: {}\n")
+	objects, err := ReadManifests([]string{file})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(objects))
+	assert.Equal(t, "default", objects[0].Namespace)
+	assert.Equal(t, file, objects[0].Source)
+	// the kind is normalized in the body sent to the API
+	assert.Equal(t, "Solution", objects[0].Body.Kind)
+}
+
+func TestInferKind(t *testing.T) {
+	testCases := []struct {
+		name     string
+		artifact YamlArtifact
+		kind     string
+		err      string
+	}{
+		{name: "explicit kind", artifact: YamlArtifact{Kind: "catalog"}, kind: "Catalog"},
+		{name: "instance", artifact: YamlArtifact{Spec: map[string]interface{}{"solution": "s1"}}, kind: "Instance"},
+		{name: "activation", artifact: YamlArtifact{Spec: map[string]interface{}{"campaign": "c1"}}, kind: "Activation"},
+		{name: "campaign", artifact: YamlArtifact{Spec: map[string]interface{}{"firstStage": "s1"}}, kind: "Campaign"},
+		{name: "catalog", artifact: YamlArtifact{Spec: map[string]interface{}{"type": "config", "properties": map[string]interface{}{}}}, kind: "Catalog"},
+		{name: "target", artifact: YamlArtifact{Spec: map[string]interface{}{"topologies": []interface{}{}}}, kind: "Target"},
+		{name: "target with properties", artifact: YamlArtifact{Spec: map[string]interface{}{"properties": map[string]interface{}{}}}, kind: "Target"},
+		{name: "target with scope and components", artifact: YamlArtifact{Spec: map[string]interface{}{"scope": "default", "components": []interface{}{}}}, kind: "Target"},
+		{name: "components only", artifact: YamlArtifact{Spec: map[string]interface{}{"components": []interface{}{}}}, err: "set its kind"},
+		{name: "solution with explicit kind", artifact: YamlArtifact{Kind: "Solution", Spec: map[string]interface{}{"components": []interface{}{}}}, kind: "Solution"},
+		{name: "unsupported kind", artifact: YamlArtifact{Kind: "Device"}, err: "not supported"},
+		{name: "no spec", artifact: YamlArtifact{}, err: "no kind and no spec"},
+		{name: "unknown spec", artifact: YamlArtifact{Spec: map[string]interface{}{"foo": "bar"}}, err: "can't be inferred"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			kind, err := inferKind(tc.artifact)
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.kind, kind)
+		})
+	}
+}
+
+func TestSortManifestObjects(t *testing.T) {
+	objects := func() []ManifestObject {
+		return []ManifestObject{
+			{Kind: "Instance", Name: "i1"},
+			{Kind: "Solution", Name: "s1"},
+			{Kind: "Activation", Name: "a1"},
+			{Kind: "Target", Name: "t1"},
+			{Kind: "Campaign", Name: "c1"},
+		}
+	}
+	testCases := []struct {
+		name    string
+		sort    func([]ManifestObject)
+		objects []string
+	}{
+		{
+			name:    "apply",
+			sort:    SortForApply,
+			objects: []string{"solution/s1", "target/t1", "campaign/c1", "instance/i1", "activation/a1"},
+		},
+		{
+			name:    "delete",
+			sort:    SortForDelete,
+			objects: []string{"instance/i1", "activation/a1", "solution/s1", "target/t1", "campaign/c1"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sorted := objects()
+			tc.sort(sorted)
+			assert.Equal(t, tc.objects, objectNames(sorted))
+		})
+	}
+}
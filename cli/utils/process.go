@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ManagedProcess is a background process started by maestro, with its config, PID and log files in a directory
+type ManagedProcess struct {
+	Name string
+	Dir  string
+}
+
+func (p ManagedProcess) ConfigFile() string {
+	return filepath.Join(p.Dir, p.Name+".json")
+}
+
+func (p ManagedProcess) PidFile() string {
+	return filepath.Join(p.Dir, p.Name+".pid")
+}
+
+func (p ManagedProcess) LogFile() string {
+	return filepath.Join(p.Dir, p.Name+".log")
+}
+
+// Pid returns the PID of the process, and whether it's running. A PID file left by a process that exited, or
+// whose PID is now used by another process, is removed.
+func (p ManagedProcess) Pid() (int, bool) {
+	pid, identity, err := p.readPidFile()
+	if err != nil || !isProcess(pid, identity) {
+		os.Remove(p.PidFile())
+		return 0, false
+	}
+	return pid, true
+}
+
+// readPidFile returns the PID and the identity of the process, which are written on two lines
+func (p ManagedProcess) readPidFile() (int, string, error) {
+	data, err := os.ReadFile(p.PidFile())
+	if err != nil {
+		return 0, "", err
+	}
+	lines := strings.SplitN(strings.TrimSpace(string(data)), "\n", 2)
+	if len(lines) != 2 {
+		return 0, "", fmt.Errorf("%s has no process identity", p.PidFile())
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(lines[0]))
+	if err != nil {
+		return 0, "", err
+	}
+	return pid, strings.TrimSpace(lines[1]), nil
+}
+
+// isProcess returns true when the process with the PID is running and is still the process with the identity,
+// rather than a later process the PID has been reused for
+func isProcess(pid int, identity string) bool {
+	if !processRunning(pid) {
+		return false
+	}
+	current, err := processIdentity(pid)
+	return err == nil && current == identity
+}
+
+// Start runs a binary in the background, detached from the terminal, with its output appended to the log file
+func (p ManagedProcess) Start(binary string, args ...string) (int, error) {
+	if pid, ok := p.Pid(); ok {
+		return 0, fmt.Errorf("%s is already running with PID %d", p.Name, pid)
+	}
+	if err := os.MkdirAll(p.Dir, 0755); err != nil {
+		return 0, err
+	}
+	logFile, err := os.OpenFile(p.LogFile(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer logFile.Close()
+	cmd := exec.Command(binary, args...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.Dir = p.Dir
+	detach(cmd)
+	if err = cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	// Start returns once the binary is executed, so the identity is the one of the binary rather than maestro
+	identity, err := processIdentity(pid)
+	if err == nil {
+		err = os.WriteFile(p.PidFile(), []byte(strconv.Itoa(pid)+"\n"+identity+"\n"), 0644)
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		return 0, err
+	}
+	// the process isn't waited for, release it so that it keeps running after maestro exits
+	cmd.Process.Release()
+	return pid, nil
+}
+
+// Stop terminates the process, and kills it if it hasn't exited within the timeout. It returns false when the
+// process wasn't running. The process is only signaled while it's the process maestro started.
+func (p ManagedProcess) Stop(timeout time.Duration) (bool, error) {
+	pid, identity, err := p.readPidFile()
+	if err != nil || !isProcess(pid, identity) {
+		os.Remove(p.PidFile())
+		return false, nil
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false, err
+	}
+	defer process.Release()
+	if err = terminate(process); err != nil {
+		return true, err
+	}
+	deadline := time.Now().Add(timeout)
+	for isProcess(pid, identity) && time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+	}
+	if isProcess(pid, identity) {
+		if err = process.Kill(); err != nil {
+			return true, err
+		}
+	}
+	os.Remove(p.PidFile())
+	return true, nil
+}
+
+// WaitForReady polls the url until it responds with 200, the process exits or the timeout is reached
+func (p ManagedProcess) WaitForReady(url string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	client := &http.Client{Timeout: 2 * time.Second}
+	for {
+		resp, err := client.Get(url)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+		if _, ok := p.Pid(); !ok {
+			return fmt.Errorf("%s exited, see %s", p.Name, p.LogFile())
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s isn't ready after %s, see %s", p.Name, timeout, p.LogFile())
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// IsReady returns true when the url responds with 200
+func IsReady(url string) bool {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// FindBinary returns the path of a binary, from the given path, the maestro directory or the PATH
+func FindBinary(path string, name string, dir string) (string, error) {
+	if path != "" {
+		if _, err := os.Stat(path); err != nil {
+			return "", fmt.Errorf("%s is not found at %s", name, path)
+		}
+		return path, nil
+	}
+	candidate := filepath.Join(dir, name)
+	if _, err := os.Stat(candidate); err == nil {
+		return candidate, nil
+	}
+	if found, err := exec.LookPath(name); err == nil {
+		return found, nil
+	}
+	return "", errors.New(name + " is not found in " + dir + " or the PATH")
+}
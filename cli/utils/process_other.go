@@ -0,0 +1,70 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// detach starts the process in its own session, so that it isn't stopped with the terminal
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+func processRunning(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+func terminate(process *os.Process) error {
+	return process.Signal(syscall.SIGTERM)
+}
+
+// processIdentity returns the start time and the executable of a process. They're read from /proc on Linux, and
+// from ps on other systems. A zombie process, which has exited but isn't waited for yet, has no identity.
+func processIdentity(pid int) (string, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if os.IsNotExist(err) {
+		if _, statErr := os.Stat("/proc/self/stat"); statErr == nil {
+			return "", fmt.Errorf("process %d is not running", pid)
+		}
+		out, err := exec.Command("ps", "-o", "lstart=", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	// the fields after the command name, which may have spaces, start with the state and end with the start time
+	// as the 20th of them
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndex(stat, ")")+1:])
+	if len(fields) < 20 {
+		return "", fmt.Errorf("/proc/%d/stat can't be parsed", pid)
+	}
+	if fields[0] == "Z" {
+		return "", fmt.Errorf("process %d has exited", pid)
+	}
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", err
+	}
+	return fields[19] + " " + strings.TrimSuffix(exe, " (deleted)"), nil
+}
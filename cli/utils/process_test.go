@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	helperProcessEnv = "MAESTRO_TEST_PROCESS"
+	helperReadyEnv   = "MAESTRO_TEST_PROCESS_READY"
+)
+
+// TestHelperProcess is the process started by the tests, it runs until it's stopped. With ignore-term, it keeps
+// running when it's asked to terminate.
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv(helperProcessEnv)
+	if mode == "" {
+		return
+	}
+	if mode == "ignore-term" {
+		signal.Ignore(syscall.SIGTERM)
+	}
+	if ready := os.Getenv(helperReadyEnv); ready != "" {
+		os.WriteFile(ready, []byte("ready"), 0644)
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+// startHelperProcess starts the test binary as a managed process, and waits until it's ready
+func startHelperProcess(t *testing.T, process ManagedProcess, mode string) int {
+	ready := filepath.Join(t.TempDir(), "ready")
+	t.Setenv(helperProcessEnv, mode)
+	t.Setenv(helperReadyEnv, ready)
+	binary, err := os.Executable()
+	assert.Nil(t, err)
+	pid, err := process.Start(binary, "-test.run=^TestHelperProcess$")
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		if p, err := os.FindProcess(pid); err == nil {
+			p.Kill()
+		}
+	})
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if _, err := os.Stat(ready); err == nil {
+			return pid
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("the helper process isn't ready, see %s", process.LogFile())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManagedProcessStartStop(t *testing.T) {
+	testCases := []struct {
+		name string
+		mode string
+	}{
+		{name: "terminated", mode: "run"},
+		{name: "killed", mode: "ignore-term"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			process := ManagedProcess{Name: "helper", Dir: t.TempDir()}
+			pid := startHelperProcess(t, process, tc.mode)
+			running, ok := process.Pid()
+			assert.True(t, ok)
+			assert.Equal(t, pid, running)
+
+			_, err := process.Start("unused")
+			assert.NotNil(t, err)
+			assert.Contains(t, err.Error(), "already running")
+
+			stopped, err := process.Stop(500 * time.Millisecond)
+			assert.Nil(t, err)
+			assert.True(t, stopped)
+			_, ok = process.Pid()
+			assert.False(t, ok)
+			assert.NoFileExists(t, process.PidFile())
+
+			stopped, err = process.Stop(500 * time.Millisecond)
+			assert.Nil(t, err)
+			assert.False(t, stopped)
+		})
+	}
+}
+
+func TestManagedProcessStalePidFile(t *testing.T) {
+	identity, err := processIdentity(os.Getpid())
+	assert.Nil(t, err)
+	testCases := []struct {
+		name    string
+		content string
+		running bool
+	}{
+		{name: "current process", content: strconv.Itoa(os.Getpid()) + "\n" + identity + "\n", running: true},
+		{name: "reused PID", content: strconv.Itoa(os.Getpid()) + "\n" + identity + "0\n"},
+		{name: "no identity", content: strconv.Itoa(os.Getpid())},
+		{name: "invalid PID", content: "pid\n" + identity},
+		{name: "empty", content: ""},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			process := ManagedProcess{Name: "stale", Dir: t.TempDir()}
+			assert.Nil(t, os.WriteFile(process.PidFile(), []byte(tc.content), 0644))
+			pid, ok := process.Pid()
+			assert.Equal(t, tc.running, ok)
+			if tc.running {
+				assert.Equal(t, os.Getpid(), pid)
+				return
+			}
+			assert.NoFileExists(t, process.PidFile())
+
+			// the process the PID now belongs to isn't signaled
+			assert.Nil(t, os.WriteFile(process.PidFile(), []byte(tc.content), 0644))
+			stopped, err := process.Stop(time.Second)
+			assert.Nil(t, err)
+			assert.False(t, stopped)
+			assert.NoFileExists(t, process.PidFile())
+		})
+	}
+}
+
+func TestFindBinary(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "symphony-api")
+	assert.Nil(t, os.WriteFile(binary, []byte{}, 0755))
+	testCases := []struct {
+		name  string
+		path  string
+		dir   string
+		found string
+		err   string
+	}{
+		{name: "path", path: binary, found: binary},
+		{name: "missing path", path: filepath.Join(dir, "missing"), err: "is not found at"},
+		{name: "directory", dir: dir, found: binary},
+		{name: "missing", dir: t.TempDir(), err: "or the PATH"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("PATH", "")
+			found, err := FindBinary(tc.path, "symphony-api", tc.dir)
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// stillActive is the exit code of a process that hasn't exited
+const stillActive = 259
+
+// detach starts the process in its own process group, so that it isn't stopped with the console
+func detach(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
+
+// processRunning opens the process and checks it hasn't exited. Opening the process of a PID isn't enough, as it
+// succeeds for exited processes other processes still have a handle to.
+func processRunning(pid int) bool {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer windows.CloseHandle(handle)
+	var exitCode uint32
+	if err = windows.GetExitCodeProcess(handle, &exitCode); err != nil {
+		return false
+	}
+	return exitCode == stillActive
+}
+
+// processIdentity returns the creation time and the executable of a process
+func processIdentity(pid int) (string, error) {
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return "", err
+	}
+	defer windows.CloseHandle(handle)
+	var creation, exit, kernel, user windows.Filetime
+	if err = windows.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return "", err
+	}
+	buffer := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buffer))
+	if err = windows.QueryFullProcessImageName(handle, 0, &buffer[0], &size); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s", creation.Nanoseconds(), windows.UTF16ToString(buffer[:size])), nil
+}
+
+// terminate kills the process, as Windows processes can't be sent a termination signal
+func terminate(process *os.Process) error {
+	return process.Kill()
+}
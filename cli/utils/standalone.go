@@ -0,0 +1,560 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/managers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/vendors"
+)
+
+// StandaloneOptions are the providers of a Symphony API run by maestro up without Kubernetes
+type StandaloneOptions struct {
+	SiteId string
+	Port   int
+	// State is the state provider. Only memory is supported, as the other state providers either require
+	// Kubernetes or can't list objects.
+	State string
+	// PubSub is memory, redis or mqtt, PubSubUrl is the redis host or the mqtt broker address
+	PubSub    string
+	PubSubUrl string
+	// Queue is memory or disk, QueueDir is the directory of the disk queue
+	Queue    string
+	QueueDir string
+	// Secret is mock or file, SecretFile and SecretKeyFile are the files of the file secret provider
+	Secret        string
+	SecretFile    string
+	SecretKeyFile string
+	// TLSPort adds an https binding when it isn't 0. Its certificate is generated unless TLSCert and TLSKey are set.
+	TLSPort int
+	TLSCert string
+	TLSKey  string
+}
+
+// AgentOptions are the providers of a poll agent run by maestro up alongside a standalone Symphony API
+type AgentOptions struct {
+	// ApiUrl is the url of the Symphony API the agent polls
+	ApiUrl string
+	// Target is the target the agent deploys to, which uses the staging provider on the Symphony API
+	Target string
+	// Provider is mock or docker, the provider the agent applies the staged components with
+	Provider string
+	Port     int
+}
+
+// standaloneConfig is the host config of the Symphony API. It's the subset of the COA host config maestro
+// generates, which is declared here to keep the bindings and providers out of the CLI.
+type standaloneConfig struct {
+	SiteInfo v1alpha2.SiteInfo `json:"siteInfo"`
+	API      standaloneAPI     `json:"api"`
+	Bindings []bindingConfig   `json:"bindings,omitempty"`
+}
+
+type standaloneAPI struct {
+	PubSub  pubSubConfig           `json:"pubsub"`
+	Vendors []vendors.VendorConfig `json:"vendors"`
+}
+
+type pubSubConfig struct {
+	Shared   bool                    `json:"shared"`
+	Provider managers.ProviderConfig `json:"provider"`
+}
+
+type bindingConfig struct {
+	Type   string                 `json:"type"`
+	Config map[string]interface{} `json:"config"`
+}
+
+// Validate checks the provider types and their settings
+func (o StandaloneOptions) Validate() error {
+	if o.State != "memory" {
+		return fmt.Errorf("state provider '%s' is not supported without Kubernetes, use memory", o.State)
+	}
+	switch o.PubSub {
+	case "memory":
+	case "redis", "mqtt":
+		if o.PubSubUrl == "" {
+			return fmt.Errorf("the %s pub-sub provider requires a pub-sub url", o.PubSub)
+		}
+	default:
+		return fmt.Errorf("pub-sub provider '%s' is not supported, use memory, redis or mqtt", o.PubSub)
+	}
+	switch o.Queue {
+	case "memory":
+	case "disk":
+		if o.QueueDir == "" {
+			return fmt.Errorf("the disk queue provider requires a queue directory")
+		}
+	default:
+		return fmt.Errorf("queue provider '%s' is not supported, use memory or disk", o.Queue)
+	}
+	switch o.Secret {
+	case "mock":
+	case "file":
+		if o.SecretFile == "" || o.SecretKeyFile == "" {
+			return fmt.Errorf("the file secret provider requires a secret file and a key file")
+		}
+	default:
+		return fmt.Errorf("secret provider '%s' is not supported, use mock or file", o.Secret)
+	}
+	if (o.TLSCert == "") != (o.TLSKey == "") {
+		return fmt.Errorf("both a TLS certificate and a TLS key are required")
+	}
+	if o.TLSCert != "" && o.TLSPort == 0 {
+		return fmt.Errorf("a TLS certificate requires a TLS port")
+	}
+	if o.Port == o.TLSPort {
+		return fmt.Errorf("the http and https ports must be different")
+	}
+	return nil
+}
+
+// Validate checks the agent provider
+func (o AgentOptions) Validate() error {
+	switch o.Provider {
+	case "mock", "docker":
+	default:
+		return fmt.Errorf("agent provider '%s' is not supported, use mock or docker", o.Provider)
+	}
+	if o.Target == "" {
+		return fmt.Errorf("the agent requires a target name")
+	}
+	return nil
+}
+
+// StandaloneApiConfig generates the config of a Symphony API that runs without Kubernetes. The managers of the
+// objects are singletons, so that the vendors sharing them see the same objects with the memory state provider.
+func StandaloneApiConfig(o StandaloneOptions) ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	baseUrl := fmt.Sprintf("http://localhost:%d/v1alpha2/", o.Port)
+	objectManager := func(name string, managerType string) managers.ManagerConfig {
+		return o.manager(name, managerType, map[string]string{"singleton": "true"})
+	}
+	solutionManager := o.manager("solution-manager", "managers.symphony.solution", map[string]string{
+		"singleton":        "true",
+		"providers.config": "mock-config",
+		"providers.secret": "secret",
+	})
+	solutionManager.Providers["mock-config"] = managers.ProviderConfig{Type: "providers.config.mock", Config: map[string]interface{}{}}
+	solutionManager.Providers["secret"] = o.secretProvider()
+	catalogsManager := o.manager("catalogs-manager", "managers.symphony.catalogs", map[string]string{
+		"singleton":           "true",
+		"providers.revisions": "revisions",
+	})
+	catalogsManager.Providers["graph"] = managers.ProviderConfig{Type: "providers.graph.memory", Config: map[string]interface{}{}}
+	catalogsManager.Providers["revisions"] = o.stateProvider()
+	stagingManager := o.manager("staging-manager", "managers.symphony.staging", map[string]string{
+		"poll.enabled":    "true",
+		"interval":        "#15",
+		"providers.queue": "queue",
+	})
+	stagingManager.Providers["queue"] = o.queueProvider()
+	credentials := map[string]string{
+		"baseUrl":  baseUrl,
+		"user":     "admin",
+		"password": "",
+	}
+
+	config := standaloneConfig{
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: o.SiteId,
+			CurrentSite: v1alpha2.SiteConnection{
+				BaseUrl:  baseUrl,
+				Username: "admin",
+			},
+		},
+		API: standaloneAPI{
+			PubSub: pubSubConfig{
+				Shared:   true,
+				Provider: o.pubSubProvider(),
+			},
+			Vendors: []vendors.VendorConfig{
+				{
+					Type: "vendors.settings",
+					Managers: []managers.ManagerConfig{
+						{
+							Name:       "config-manager",
+							Type:       "managers.symphony.configs",
+							Properties: map[string]string{"singleton": "true"},
+							Providers: map[string]managers.ProviderConfig{
+								"catalog": {Type: "providers.config.catalog", Config: credentials},
+							},
+						},
+					},
+				},
+				{
+					Type:  "vendors.stage",
+					Route: "stage",
+					Properties: map[string]string{
+						"wait.baseUrl":       baseUrl,
+						"wait.user":          "admin",
+						"wait.password":      "",
+						"wait.wait.interval": "15",
+						"wait.wait.count":    "10",
+					},
+					Managers: []managers.ManagerConfig{
+						o.manager("stage-manager", "managers.symphony.stage", credentials),
+						objectManager("campaigns-manager", "managers.symphony.campaigns"),
+						objectManager("activations-manager", "managers.symphony.activations"),
+					},
+				},
+				{
+					Type:     "vendors.activations",
+					Route:    "activations",
+					Managers: []managers.ManagerConfig{objectManager("activations-manager", "managers.symphony.activations")},
+				},
+				{
+					Type:         "vendors.backgroundjob",
+					Route:        "backgroundjob",
+					LoopInterval: 3600,
+					Managers: []managers.ManagerConfig{
+						o.manager("activations-cleanup-manager", "managers.symphony.activationscleanup", map[string]string{
+							"singleton":          "true",
+							"RetentionInMinutes": "1440",
+						}),
+					},
+				},
+				{
+					Type:     "vendors.campaigns",
+					Route:    "campaigns",
+					Managers: []managers.ManagerConfig{objectManager("campaigns-manager", "managers.symphony.campaigns")},
+				},
+				{
+					Type:     "vendors.echo",
+					Route:    "greetings",
+					Managers: []managers.ManagerConfig{},
+				},
+				{
+					Type:         "vendors.jobs",
+					Route:        "jobs",
+					LoopInterval: 15,
+					Managers: []managers.ManagerConfig{
+						o.manager("jobs-manager", "managers.symphony.jobs", map[string]string{
+							"baseUrl":          baseUrl,
+							"user":             "admin",
+							"password":         "",
+							"interval":         "#15",
+							"poll.enabled":     "true",
+							"schedule.enabled": "true",
+						}),
+					},
+				},
+				{
+					Type:         "vendors.targets",
+					Route:        "targets",
+					LoopInterval: 15,
+					Properties:   map[string]string{"useJobManager": "true"},
+					Managers:     []managers.ManagerConfig{objectManager("targets-manager", "managers.symphony.targets")},
+				},
+				{
+					Type:         "vendors.solutions",
+					Route:        "solutions",
+					LoopInterval: 15,
+					Managers:     []managers.ManagerConfig{objectManager("solutions-manager", "managers.symphony.solutions"), catalogsManager},
+				},
+				{
+					Type:         "vendors.instances",
+					Route:        "instances",
+					LoopInterval: 15,
+					Properties:   map[string]string{"useJobManager": "true"},
+					Managers:     []managers.ManagerConfig{objectManager("instances-manager", "managers.symphony.instances")},
+				},
+				{
+					Type:         "vendors.devices",
+					Route:        "devices",
+					LoopInterval: 15,
+					Managers:     []managers.ManagerConfig{objectManager("devices-manager", "managers.symphony.devices")},
+				},
+				{
+					Type:         "vendors.models",
+					Route:        "models",
+					LoopInterval: 15,
+					Managers:     []managers.ManagerConfig{objectManager("models-manager", "managers.symphony.models")},
+				},
+				{
+					Type:         "vendors.skills",
+					Route:        "skills",
+					LoopInterval: 15,
+					Managers:     []managers.ManagerConfig{objectManager("skills-manager", "managers.symphony.skills")},
+				},
+				{
+					Type:         "vendors.users",
+					Route:        "users",
+					LoopInterval: 15,
+					Properties:   map[string]string{"test-users": "true"},
+					Managers:     []managers.ManagerConfig{objectManager("users-manager", "managers.symphony.users")},
+				},
+				{
+					Type:         "vendors.solution",
+					Route:        "solution",
+					LoopInterval: 15,
+					Managers:     []managers.ManagerConfig{solutionManager},
+				},
+				{
+					Type:         "vendors.federation",
+					Route:        "federation",
+					LoopInterval: 15,
+					Managers: []managers.ManagerConfig{
+						{
+							Name:       "trails-manager",
+							Type:       "managers.symphony.trails",
+							Properties: map[string]string{},
+							Providers: map[string]managers.ProviderConfig{
+								"mock": {Type: "providers.ledger.mock", Config: map[string]interface{}{}},
+							},
+						},
+						objectManager("sites-manager", "managers.symphony.sites"),
+						catalogsManager,
+						stagingManager,
+					},
+				},
+				{
+					Type:     "vendors.catalogs",
+					Route:    "catalogs",
+					Managers: []managers.ManagerConfig{catalogsManager},
+				},
+				// the backup vendor shares the singleton managers of the vendors before it, which only requires their types
+				{
+					Type:  "vendors.backup",
+					Route: "backup",
+					Managers: []managers.ManagerConfig{
+						{Name: "sites-manager", Type: "managers.symphony.sites"},
+						{Name: "users-manager", Type: "managers.symphony.users"},
+						{Name: "targets-manager", Type: "managers.symphony.targets"},
+						{Name: "devices-manager", Type: "managers.symphony.devices"},
+						{Name: "models-manager", Type: "managers.symphony.models"},
+						{Name: "skills-manager", Type: "managers.symphony.skills"},
+						{Name: "solutions-manager", Type: "managers.symphony.solutions"},
+						{Name: "catalogs-manager", Type: "managers.symphony.catalogs"},
+						{Name: "campaigns-manager", Type: "managers.symphony.campaigns"},
+						{Name: "instances-manager", Type: "managers.symphony.instances"},
+						{Name: "activations-manager", Type: "managers.symphony.activations"},
+						{Name: "solution-manager", Type: "managers.symphony.solution"},
+					},
+				},
+			},
+		},
+		Bindings: []bindingConfig{
+			{
+				Type: "bindings.http",
+				Config: map[string]interface{}{
+					"port":     o.Port,
+					"pipeline": standalonePipeline(),
+				},
+			},
+		},
+	}
+	if o.TLSPort != 0 {
+		certProvider := map[string]interface{}{
+			"type":   "certs.autogen",
+			"config": map[string]interface{}{},
+		}
+		if o.TLSCert != "" {
+			certProvider = map[string]interface{}{
+				"type": "certs.localfile",
+				"config": map[string]interface{}{
+					"cert": o.TLSCert,
+					"key":  o.TLSKey,
+				},
+			}
+		}
+		config.Bindings = append(config.Bindings, bindingConfig{
+			Type: "bindings.http",
+			Config: map[string]interface{}{
+				"port":         o.TLSPort,
+				"tls":          true,
+				"certProvider": certProvider,
+				"pipeline":     standalonePipeline(),
+			},
+		})
+	}
+	return json.MarshalIndent(config, "", "  ")
+}
+
+// StandaloneAgentConfig generates the config of a poll agent. The agent polls the Symphony API for the deployments
+// staged for its target, applies them with its provider, and reports the components back.
+func StandaloneAgentConfig(o AgentOptions) ([]byte, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	provider := managers.ProviderConfig{Type: "providers.target.mock", Config: map[string]interface{}{"id": o.Target}}
+	if o.Provider == "docker" {
+		provider = managers.ProviderConfig{Type: "providers.target.docker", Config: map[string]interface{}{"name": "docker"}}
+	}
+	config := standaloneConfig{
+		SiteInfo: v1alpha2.SiteInfo{
+			SiteId: o.Target,
+			ParentSite: v1alpha2.SiteConnection{
+				BaseUrl:  o.ApiUrl,
+				Username: "admin",
+			},
+		},
+		API: standaloneAPI{
+			PubSub: pubSubConfig{
+				Shared:   true,
+				Provider: managers.ProviderConfig{Type: "providers.pubsub.memory", Config: map[string]interface{}{}},
+			},
+			Vendors: []vendors.VendorConfig{
+				{
+					Type:     "vendors.echo",
+					Route:    "greetings",
+					Managers: []managers.ManagerConfig{},
+				},
+				{
+					Type:         "vendors.solution",
+					Route:        "solution",
+					LoopInterval: 15,
+					Managers: []managers.ManagerConfig{
+						{
+							Name: "solution-manager",
+							Type: "managers.symphony.solution",
+							Properties: map[string]string{
+								"providers.state":  "mem-state",
+								"providers.config": "mock-config",
+								"providers.secret": "mock-secret",
+								"isTarget":         "true",
+								"targetNames":      o.Target,
+								"poll.enabled":     "true",
+							},
+							Providers: map[string]managers.ProviderConfig{
+								"mem-state":   {Type: "providers.state.memory", Config: map[string]interface{}{}},
+								"mock-config": {Type: "providers.config.mock", Config: map[string]interface{}{}},
+								"mock-secret": {Type: "providers.secret.mock", Config: map[string]interface{}{}},
+								// the staged components of the instance role are applied by the agent provider
+								"instance": provider,
+							},
+						},
+					},
+				},
+			},
+		},
+		Bindings: []bindingConfig{
+			{
+				Type:   "bindings.http",
+				Config: map[string]interface{}{"port": o.Port},
+			},
+		},
+	}
+	return json.MarshalIndent(config, "", "  ")
+}
+
+// manager returns the config of a manager with a state provider and the given properties
+func (o StandaloneOptions) manager(name string, managerType string, properties map[string]string) managers.ManagerConfig {
+	props := map[string]string{
+		"providers.state": "state",
+	}
+	for k, v := range properties {
+		props[k] = v
+	}
+	return managers.ManagerConfig{
+		Name:       name,
+		Type:       managerType,
+		Properties: props,
+		Providers: map[string]managers.ProviderConfig{
+			"state": o.stateProvider(),
+		},
+	}
+}
+
+func (o StandaloneOptions) stateProvider() managers.ProviderConfig {
+	return managers.ProviderConfig{Type: "providers.state.memory", Config: map[string]interface{}{}}
+}
+
+func (o StandaloneOptions) pubSubProvider() managers.ProviderConfig {
+	switch o.PubSub {
+	case "redis":
+		return managers.ProviderConfig{
+			Type: "providers.pubsub.redis",
+			Config: map[string]interface{}{
+				"name":            "redis",
+				"host":            o.PubSubUrl,
+				"requireTLS":      false,
+				"password":        "",
+				"numberOfWorkers": 1,
+			},
+		}
+	case "mqtt":
+		return managers.ProviderConfig{
+			Type: "providers.pubsub.mqtt",
+			Config: map[string]interface{}{
+				"name":          "mqtt",
+				"brokerAddress": o.PubSubUrl,
+				"clientID":      "symphony-" + o.SiteId,
+			},
+		}
+	}
+	return managers.ProviderConfig{Type: "providers.pubsub.memory", Config: map[string]interface{}{}}
+}
+
+func (o StandaloneOptions) queueProvider() managers.ProviderConfig {
+	if o.Queue == "disk" {
+		return managers.ProviderConfig{
+			Type: "providers.queue.disk",
+			Config: map[string]interface{}{
+				"name": "disk-queue",
+				"dir":  o.QueueDir,
+			},
+		}
+	}
+	return managers.ProviderConfig{Type: "providers.queue.memory", Config: map[string]interface{}{}}
+}
+
+func (o StandaloneOptions) secretProvider() managers.ProviderConfig {
+	if o.Secret == "file" {
+		return managers.ProviderConfig{
+			Type: "providers.secret.file",
+			Config: map[string]interface{}{
+				"name":     "file-secret",
+				"filePath": o.SecretFile,
+				"keyFile":  o.SecretKeyFile,
+			},
+		}
+	}
+	return managers.ProviderConfig{Type: "providers.secret.mock", Config: map[string]interface{}{}}
+}
+
+// standalonePipeline is the middleware of the Symphony API bindings, with the roles of the test users
+func standalonePipeline() []interface{} {
+	return []interface{}{
+		map[string]interface{}{
+			"type": "middleware.http.cors",
+			"properties": map[string]interface{}{
+				"Access-Control-Allow-Headers":     "authorization,Content-Type",
+				"Access-Control-Allow-Credentials": "true",
+				"Access-Control-Allow-Methods":     "HEAD,GET,POST,PUT,DELETE,OPTIONS",
+				"Access-Control-Allow-Origin":      "*",
+			},
+		},
+		map[string]interface{}{
+			"type": "middleware.http.jwt",
+			"properties": map[string]interface{}{
+				"ignorePaths": []string{"/v1alpha2/users/auth", "/v1alpha2/solution/instances", "/v1alpha2/agent/references", "/v1alpha2/greetings"},
+				"verifyKey":   "SymphonyKey",
+				"enableRBAC":  true,
+				"roles": []map[string]string{
+					{"role": "administrator", "claim": "user", "value": "admin"},
+					{"role": "reader", "claim": "user", "value": "*"},
+					{"role": "solution-creator", "claim": "user", "value": "developer"},
+					{"role": "target-manager", "claim": "user", "value": "device-manager"},
+					{"role": "operator", "claim": "user", "value": "solution-operator"},
+				},
+				"policy": map[string]interface{}{
+					"administrator":     map[string]interface{}{"items": map[string]string{"*": "*"}},
+					"reader":            map[string]interface{}{"items": map[string]string{"*": "GET"}},
+					"solution-creator":  map[string]interface{}{"items": map[string]string{"/v1alpha2/solutions": "*"}},
+					"target-manager":    map[string]interface{}{"items": map[string]string{"/v1alpha2/targets": "*"}},
+					"solution-operator": map[string]interface{}{"items": map[string]string{"/v1alpha2/instances": "*"}},
+				},
+			},
+		},
+	}
+}
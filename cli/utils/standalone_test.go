@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package utils
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func standaloneOptions() StandaloneOptions {
+	return StandaloneOptions{SiteId: "hq", Port: 8082, State: "memory", PubSub: "memory", Queue: "memory", Secret: "mock"}
+}
+
+func TestStandaloneOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		change func(o *StandaloneOptions)
+		err    string
+	}{
+		{name: "defaults", change: func(o *StandaloneOptions) {}},
+		{name: "redis", change: func(o *StandaloneOptions) { o.PubSub, o.PubSubUrl = "redis", "localhost:6379" }},
+		{name: "disk queue", change: func(o *StandaloneOptions) { o.Queue, o.QueueDir = "disk", "/tmp/queue" }},
+		{name: "file secrets", change: func(o *StandaloneOptions) {
+			o.Secret, o.SecretFile, o.SecretKeyFile = "file", "secrets.json", "secrets.key"
+		}},
+		{name: "generated certificate", change: func(o *StandaloneOptions) { o.TLSPort = 8443 }},
+		{name: "certificate", change: func(o *StandaloneOptions) { o.TLSPort, o.TLSCert, o.TLSKey = 8443, "tls.crt", "tls.key" }},
+		{name: "k8s state", change: func(o *StandaloneOptions) { o.State = "k8s" }, err: "is not supported without Kubernetes"},
+		{name: "mqtt without url", change: func(o *StandaloneOptions) { o.PubSub = "mqtt" }, err: "requires a pub-sub url"},
+		{name: "unknown pub-sub", change: func(o *StandaloneOptions) { o.PubSub = "kafka" }, err: "pub-sub provider 'kafka'"},
+		{name: "disk queue without directory", change: func(o *StandaloneOptions) { o.Queue = "disk" }, err: "requires a queue directory"},
+		{name: "unknown queue", change: func(o *StandaloneOptions) { o.Queue = "redis" }, err: "queue provider 'redis'"},
+		{name: "file secrets without key", change: func(o *StandaloneOptions) { o.Secret, o.SecretFile = "file", "secrets.json" }, err: "a secret file and a key file"},
+		{name: "unknown secret", change: func(o *StandaloneOptions) { o.Secret = "vault" }, err: "secret provider 'vault'"},
+		{name: "certificate without key", change: func(o *StandaloneOptions) { o.TLSPort, o.TLSCert = 8443, "tls.crt" }, err: "both a TLS certificate and a TLS key"},
+		{name: "certificate without port", change: func(o *StandaloneOptions) { o.TLSCert, o.TLSKey = "tls.crt", "tls.key" }, err: "requires a TLS port"},
+		{name: "same ports", change: func(o *StandaloneOptions) { o.TLSPort = o.Port }, err: "must be different"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := standaloneOptions()
+			tc.change(&o)
+			err := o.Validate()
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestStandaloneApiConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		change   func(o *StandaloneOptions)
+		bindings int
+		pubSub   string
+	}{
+		{name: "defaults", change: func(o *StandaloneOptions) {}, bindings: 1, pubSub: "providers.pubsub.memory"},
+		{name: "mqtt and tls", change: func(o *StandaloneOptions) { o.PubSub, o.PubSubUrl, o.TLSPort = "mqtt", "tcp://localhost:1883", 8443 }, bindings: 2, pubSub: "providers.pubsub.mqtt"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := standaloneOptions()
+			tc.change(&o)
+			data, err := StandaloneApiConfig(o)
+			assert.Nil(t, err)
+			var config standaloneConfig
+			assert.Nil(t, json.Unmarshal(data, &config))
+			assert.Equal(t, "hq", config.SiteInfo.SiteId)
+			assert.Equal(t, tc.bindings, len(config.Bindings))
+			assert.Equal(t, tc.pubSub, config.API.PubSub.Provider.Type)
+
+			// the backup vendor comes last, and its managers are singletons of the vendors before it
+			vendors := config.API.Vendors
+			backup := vendors[len(vendors)-1]
+			assert.Equal(t, "vendors.backup", backup.Type)
+			singletons := map[string]bool{}
+			for _, vendor := range vendors[:len(vendors)-1] {
+				assert.NotEqual(t, "vendors.backup", vendor.Type)
+				for _, manager := range vendor.Managers {
+					if manager.Properties["singleton"] == "true" {
+						singletons[manager.Type] = true
+					}
+				}
+			}
+			for _, manager := range backup.Managers {
+				assert.True(t, singletons[manager.Type], manager.Type)
+				assert.Empty(t, manager.Providers)
+			}
+		})
+	}
+}
+
+func TestStandaloneApiConfigInvalid(t *testing.T) {
+	o := standaloneOptions()
+	o.State = "redis"
+	_, err := StandaloneApiConfig(o)
+	assert.NotNil(t, err)
+}
+
+func TestStandaloneAgentConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		options  AgentOptions
+		provider string
+		err      string
+	}{
+		{name: "mock", options: AgentOptions{ApiUrl: "http://localhost:8082/v1alpha2/", Target: "edge", Provider: "mock", Port: 8088}, provider: "providers.target.mock"},
+		{name: "docker", options: AgentOptions{ApiUrl: "http://localhost:8082/v1alpha2/", Target: "edge", Provider: "docker", Port: 8088}, provider: "providers.target.docker"},
+		{name: "unknown provider", options: AgentOptions{Target: "edge", Provider: "helm"}, err: "agent provider 'helm'"},
+		{name: "no target", options: AgentOptions{Provider: "mock"}, err: "requires a target name"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := StandaloneAgentConfig(tc.options)
+			if tc.err != "" {
+				assert.NotNil(t, err)
+				assert.Contains(t, err.Error(), tc.err)
+				return
+			}
+			assert.Nil(t, err)
+			var config standaloneConfig
+			assert.Nil(t, json.Unmarshal(data, &config))
+			assert.Equal(t, "edge", config.SiteInfo.SiteId)
+			assert.Equal(t, tc.options.ApiUrl, config.SiteInfo.ParentSite.BaseUrl)
+			assert.Contains(t, string(data), tc.provider)
+		})
+	}
+}
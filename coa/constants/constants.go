@@ -13,4 +13,13 @@ const (
 	APIOperationErrors             = "symphony_api_operation_errors"
 	APIOperationLatencyDescription = "measure of overall latency for API operation side"
 	APIOperationErrorsDescription  = "count of errors in API operation side"
+
+	PubSubPublished                 = "symphony_pubsub_published"
+	PubSubConsumeLatency            = "symphony_pubsub_consume_latency"
+	PubSubConsumeErrors             = "symphony_pubsub_consume_errors"
+	PubSubDeadLetters               = "symphony_pubsub_dead_letters"
+	PubSubPublishedDescription      = "count of events published to pub-sub topics"
+	PubSubConsumeLatencyDescription = "measure of latency for pub-sub event handlers"
+	PubSubConsumeErrorsDescription  = "count of errors returned by pub-sub event handlers"
+	PubSubDeadLettersDescription    = "count of pub-sub events moved to the dead-letter topic"
 )
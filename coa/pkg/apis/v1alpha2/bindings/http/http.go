@@ -131,7 +131,7 @@ func wrapAsHTTPHandler(endpoint v1alpha2.Endpoint, handler v1alpha2.COAHandler)
 		}
 		req.Parameters = make(map[string]string)
 
-		for _, p := range endpoint.Parameters {
+		for _, p := range append(routeParameters(endpoint.Route), endpoint.Parameters...) {
 			k := p
 			if strings.HasSuffix(p, "?") {
 				k = k[:len(p)-1]
@@ -159,8 +159,24 @@ func wrapAsHTTPHandler(endpoint v1alpha2.Endpoint, handler v1alpha2.COAHandler)
 				reqCtx.Response.Header.Set(v1alpha2.COAMetaHeader, string(data))
 			}
 			reqCtx.SetContentType(resp.ContentType)
-			reqCtx.SetBody(resp.Body)
+			if resp.BodyStream != nil {
+				reqCtx.SetBodyStreamWriter(resp.BodyStream)
+			} else {
+				reqCtx.SetBody(resp.Body)
+			}
 			reqCtx.SetStatusCode(int(resp.State))
 		}
 	}
 }
+
+// routeParameters returns the names of the parameters in the route of an endpoint, such as name in
+// catalogs/registry/{name}/revisions
+func routeParameters(route string) []string {
+	var ret []string
+	for _, segment := range strings.Split(route, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			ret = append(ret, segment[1:len(segment)-1])
+		}
+	}
+	return ret
+}
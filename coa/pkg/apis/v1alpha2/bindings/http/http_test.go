@@ -7,6 +7,7 @@
 package http
 
 import (
+	"bufio"
 	"bytes"
 	"context"
 	"crypto/tls"
@@ -118,6 +119,17 @@ func TestHTTPEcho(t *testing.T) {
 				}
 			},
 		},
+		{
+			Methods:    []string{"GET"},
+			Route:      "greetings4/{name}/titles",
+			Version:    "v1",
+			Parameters: []string{"title?"},
+			Handler: func(c v1alpha2.COARequest) v1alpha2.COAResponse {
+				return v1alpha2.COAResponse{
+					Body: []byte("Hi " + c.Parameters["__title"] + " " + c.Parameters["__name"] + "!!!"),
+				}
+			},
+		},
 		{
 			Methods: []string{"POST"},
 			Route:   "greetingsWithMetadata",
@@ -148,6 +160,10 @@ func TestHTTPEcho(t *testing.T) {
 	// path parameters
 	testHttpRequestHelper(context.Background(), t, fasthttp.MethodGet, "http://localhost:8080/v1/greetings3/John", nil, 200, "Hi John!!!")
 
+	// parameters in the route
+	testHttpRequestHelper(context.Background(), t, fasthttp.MethodGet, "http://localhost:8080/v1/greetings4/John/titles/Dr.", nil, 200, "Hi Dr. John!!!")
+	testHttpRequestHelper(context.Background(), t, fasthttp.MethodGet, "http://localhost:8080/v1/greetings4/John/titles", nil, 200, "Hi  John!!!")
+
 	// req metadata and resp metadata
 	req4Metadata := map[string]string{
 		"key": "Alice",
@@ -169,6 +185,52 @@ func TestHTTPEcho(t *testing.T) {
 		})
 }
 
+func TestHTTPStream(t *testing.T) {
+	config := HttpBindingConfig{
+		Port: 8082,
+		TLS:  false,
+	}
+	binding := HttpBinding{}
+	endpoints := []v1alpha2.Endpoint{
+		{
+			Methods: []string{"GET"},
+			Route:   "stream",
+			Version: "v1",
+			Handler: func(c v1alpha2.COARequest) v1alpha2.COAResponse {
+				return v1alpha2.COAResponse{
+					State:       v1alpha2.OK,
+					ContentType: "application/x-ndjson",
+					BodyStream: func(w *bufio.Writer) {
+						for i := 0; i < 3; i++ {
+							fmt.Fprintf(w, "line %d\n", i)
+							if err := w.Flush(); err != nil {
+								return
+							}
+						}
+					},
+				}
+			},
+		},
+	}
+	err := binding.Launch(config, endpoints, nil)
+	assert.Nil(t, err)
+
+	// wait for http server startup
+	time.Sleep(5 * time.Second)
+
+	resp, err := http.Get("http://localhost:8082/v1/stream")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, 200, resp.StatusCode)
+	assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+	scanner := bufio.NewScanner(resp.Body)
+	lines := make([]string, 0)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	assert.Equal(t, []string{"line 0", "line 1", "line 2"}, lines)
+}
+
 func TestHTTPEchoWithTLS(t *testing.T) {
 	config := HttpBindingConfig{
 		Port: 8888,
@@ -94,22 +94,28 @@ func (j JWT) JWT(next fasthttp.RequestHandler) fasthttp.RequestHandler {
 		} else {
 			if j.AuthServer == AuthServerKuberenetes {
 				log.Debugf("JWT: Validating token with k8s.\n")
-				err := j.validateServiceAccountToken(ctx, tokenStr)
+				username, err := j.validateServiceAccountToken(ctx, tokenStr)
 				if err != nil {
 					log.Errorf("JWT: Validate token with k8s failed. %s\n", err.Error())
 					ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
 					return
 				}
+				ctx.SetUserValue(v1alpha2.AuthenticatedUser, username)
+				ctx.SetUserValue(v1alpha2.AuthenticatedServiceAccount, true)
 				next(ctx)
 			} else {
 				log.Debugf("JWT: Validating token with username plus pwd.\n")
-				_, roles, err := j.validateToken(tokenStr)
+				claims, roles, err := j.validateToken(tokenStr)
 				if err != nil {
 					log.Error("JWT: Validate token with user creds failed. %s\n", err.Error())
 					ctx.Response.SetStatusCode(fasthttp.StatusForbidden)
 					return
 				} else {
+					if user, ok := claims["user"].(string); ok {
+						ctx.SetUserValue(v1alpha2.AuthenticatedUser, user)
+					}
 					if j.EnableRBAC {
+						ctx.SetUserValue(v1alpha2.AuthenticatedRoles, roles)
 						path := string(ctx.Path())
 						method := string(ctx.Method())
 						for _, role := range roles {
@@ -210,11 +216,11 @@ func (j *JWT) validateToken(tokenStr string) (map[string]interface{}, []string,
 	}
 	return ret, roles, nil
 }
-func (j *JWT) validateServiceAccountToken(ctx *fasthttp.RequestCtx, tokenStr string) error {
+func (j *JWT) validateServiceAccountToken(ctx *fasthttp.RequestCtx, tokenStr string) (string, error) {
 	clientset, err := getKubernetesClient()
 	if err != nil {
 		log.Errorf("JWT: Could not initialize Kubernetes client.\n")
-		return v1alpha2.NewCOAError(err, "Could not initialize Kubernetes client", v1alpha2.InternalError)
+		return "", v1alpha2.NewCOAError(err, "Could not initialize Kubernetes client", v1alpha2.InternalError)
 	}
 	tokenReview := &v1.TokenReview{
 		Spec: v1.TokenReviewSpec{
@@ -228,26 +234,26 @@ func (j *JWT) validateServiceAccountToken(ctx *fasthttp.RequestCtx, tokenStr str
 	result, err := clientset.AuthenticationV1().TokenReviews().Create(ctx, tokenReview, metav1.CreateOptions{})
 	if err != nil {
 		log.Errorf("JWT: Token review using kubernetes api server failed. %s\n", err.Error())
-		return v1alpha2.NewCOAError(err, "Token review using kubernetes api server failed.", v1alpha2.InternalError)
+		return "", v1alpha2.NewCOAError(err, "Token review using kubernetes api server failed.", v1alpha2.InternalError)
 	}
 	if !result.Status.Authenticated {
 		log.Errorf("JWT: Validate token with k8s failed. K8s returned not authenticated.\n")
-		return v1alpha2.NewCOAError(nil, "Authentication failed.", v1alpha2.Unauthorized)
+		return "", v1alpha2.NewCOAError(nil, "Authentication failed.", v1alpha2.Unauthorized)
 	} else {
 		apiUsername, err := getApiServiceAccountUsername()
 		if err != nil {
-			return err
+			return "", err
 		}
 		controllerUsername, err := getControllerServiceAccountUsername()
 		if err != nil {
-			return err
+			return "", err
 		}
 		if result.Status.User.Username != apiUsername && result.Status.User.Username != controllerUsername {
 			log.Errorf("JWT: Validate token with k8s failed. K8s returned invalid username, %s\n", result.Status.User.Username)
-			return v1alpha2.NewCOAError(nil, "Authentication failed.", v1alpha2.Unauthorized)
+			return "", v1alpha2.NewCOAError(nil, "Authentication failed.", v1alpha2.Unauthorized)
 		}
 	}
-	return nil
+	return result.Status.User.Username, nil
 
 }
 func getKubernetesClient() (*kubernetes.Clientset, error) {
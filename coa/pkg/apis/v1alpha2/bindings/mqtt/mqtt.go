@@ -9,10 +9,12 @@ package mqtt
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"strings"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	mqttutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
 	gmqtt "github.com/eclipse/paho.mqtt.golang"
 )
@@ -24,35 +26,33 @@ type MQTTBindingConfig struct {
 	ClientID      string `json:"clientID"`
 	RequestTopic  string `json:"requestTopic"`
 	ResponseTopic string `json:"responseTopic"`
+	mqttutils.ConnectionConfig
 }
 
 type MQTTBinding struct {
 	MQTTClient gmqtt.Client
+	routeTable map[string]v1alpha2.Endpoint
 }
 
-var routeTable map[string]v1alpha2.Endpoint
-
 func (m *MQTTBinding) Launch(config MQTTBindingConfig, endpoints []v1alpha2.Endpoint) error {
-	routeTable = make(map[string]v1alpha2.Endpoint)
+	m.routeTable = make(map[string]v1alpha2.Endpoint)
 	for _, endpoint := range endpoints {
-		route := endpoint.Route
-		lastSlash := strings.LastIndex(endpoint.Route, "/")
-		if lastSlash > 0 {
-			route = strings.TrimPrefix(route, route[:lastSlash+1])
-		}
-		routeTable[route] = endpoint
+		m.routeTable[strings.Trim(endpoint.Route, "/")] = endpoint
 	}
 
-	opts := gmqtt.NewClientOptions().AddBroker(config.BrokerAddress).SetClientID(config.ClientID)
+	opts, err := mqttutils.NewClientOptions(config.BrokerAddress, config.ClientID, config.ConnectionConfig, false)
+	if err != nil {
+		return err
+	}
 	opts.SetKeepAlive(2 * time.Second)
 	opts.SetPingTimeout(1 * time.Second)
-	opts.CleanSession = false
 	m.MQTTClient = gmqtt.NewClient(opts)
 	if token := m.MQTTClient.Connect(); token.Wait() && token.Error() != nil {
 		return v1alpha2.NewCOAError(token.Error(), "failed to connect to MQTT broker", v1alpha2.InternalError)
 	}
 
-	if token := m.MQTTClient.Subscribe(config.RequestTopic, 0, func(client gmqtt.Client, msg gmqtt.Message) {
+	qos := config.GetQoS()
+	if token := m.MQTTClient.Subscribe(config.RequestTopic, qos, func(client gmqtt.Client, msg gmqtt.Message) {
 		var request v1alpha2.COARequest
 		var response v1alpha2.COAResponse
 		request.Context = context.TODO()
@@ -63,8 +63,14 @@ func (m *MQTTBinding) Launch(config MQTTBindingConfig, endpoints []v1alpha2.Endp
 				ContentType: "application/text",
 				Body:        []byte(err.Error()),
 			}
+		} else if endpoint, ok := m.lookupRoute(request.Route); ok {
+			response = endpoint.Handler(request)
 		} else {
-			response = routeTable[request.Route].Handler(request)
+			response = v1alpha2.COAResponse{
+				State:       v1alpha2.NotFound,
+				ContentType: "application/text",
+				Body:        []byte(fmt.Sprintf("route '%s' is not found", request.Route)),
+			}
 		}
 
 		// needs to carry call-context from request into response
@@ -79,7 +85,7 @@ func (m *MQTTBinding) Launch(config MQTTBindingConfig, endpoints []v1alpha2.Endp
 
 		data, _ := json.Marshal(response)
 
-		if token := client.Publish(config.ResponseTopic, 0, false, data); token.Wait() && token.Error() != nil {
+		if token := client.Publish(config.ResponseTopic, qos, false, data); token.Wait() && token.Error() != nil {
 			log.Errorf("failed to handle request from MOTT: %s", token.Error())
 		}
 	}); token.Wait() && token.Error() != nil {
@@ -92,6 +98,31 @@ func (m *MQTTBinding) Launch(config MQTTBindingConfig, endpoints []v1alpha2.Endp
 	return nil
 }
 
+// lookupRoute finds the endpoint serving a route. Routes are matched in full first. For compatibility
+// with clients that only send the last route segment (like "instances"), a route without a full match
+// falls back to the endpoint whose last segment matches, as long as exactly one endpoint does.
+func (m *MQTTBinding) lookupRoute(route string) (v1alpha2.Endpoint, bool) {
+	route = strings.Trim(route, "/")
+	if endpoint, ok := m.routeTable[route]; ok {
+		return endpoint, true
+	}
+	if strings.Contains(route, "/") {
+		return v1alpha2.Endpoint{}, false
+	}
+	var ret v1alpha2.Endpoint
+	matches := 0
+	for key, endpoint := range m.routeTable {
+		if key == route || strings.HasSuffix(key, "/"+route) {
+			ret = endpoint
+			matches++
+		}
+	}
+	if matches > 1 {
+		log.Errorf("MQTT: route '%s' matches %d endpoints, use the full route instead", route, matches)
+	}
+	return ret, matches == 1
+}
+
 // Shutdown stops the MQTT binding
 func (m *MQTTBinding) Shutdown(ctx context.Context) error {
 	m.MQTTClient.Disconnect(1000)
@@ -7,12 +7,15 @@
 package mqtt
 
 import (
+	"context"
 	"encoding/json"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	mqttutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt/mqtttest"
 	gmqtt "github.com/eclipse/paho.mqtt.golang"
 	"github.com/stretchr/testify/assert"
 )
@@ -210,3 +213,116 @@ func TestMQTTEchoWithCallContext(t *testing.T) {
 	token.Wait()
 	<-sig
 }
+
+func routeHandler(name string) v1alpha2.COAHandler {
+	return func(c v1alpha2.COARequest) v1alpha2.COAResponse {
+		return v1alpha2.COAResponse{
+			State: v1alpha2.OK,
+			Body:  []byte(name),
+		}
+	}
+}
+
+// sendRequests publishes requests to a binding and returns the responses in order
+func sendRequests(t *testing.T, config MQTTBindingConfig, requests []v1alpha2.COARequest) []v1alpha2.COAResponse {
+	opts, err := mqttutils.NewClientOptions(config.BrokerAddress, "test-sender-routes", config.ConnectionConfig, true)
+	assert.Nil(t, err)
+	c := gmqtt.NewClient(opts)
+	token := c.Connect()
+	token.Wait()
+	assert.Nil(t, token.Error())
+	defer c.Disconnect(100)
+
+	responses := make(chan v1alpha2.COAResponse)
+	token = c.Subscribe(config.ResponseTopic, config.GetQoS(), func(client gmqtt.Client, msg gmqtt.Message) {
+		var response v1alpha2.COAResponse
+		json.Unmarshal(msg.Payload(), &response)
+		responses <- response
+	})
+	token.Wait()
+	assert.Nil(t, token.Error())
+
+	ret := make([]v1alpha2.COAResponse, 0, len(requests))
+	for _, request := range requests {
+		data, _ := json.Marshal(request)
+		token := c.Publish(config.RequestTopic, config.GetQoS(), false, data)
+		token.Wait()
+		assert.Nil(t, token.Error())
+		select {
+		case response := <-responses:
+			ret = append(ret, response)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("didn't get response for route %s", request.Route)
+		}
+	}
+	return ret
+}
+
+func TestMQTTFullRouteDispatch(t *testing.T) {
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{})
+	assert.Nil(t, err)
+	defer broker.Close()
+
+	config := MQTTBindingConfig{
+		BrokerAddress: broker.Address,
+		ClientID:      "coabinding-routes",
+		RequestTopic:  "coabinding-request-routes",
+		ResponseTopic: "coabinding-response-routes",
+	}
+	binding := MQTTBinding{}
+	err = binding.Launch(config, []v1alpha2.Endpoint{
+		{Methods: []string{"GET"}, Route: "solution/instances", Handler: routeHandler("solution")},
+		{Methods: []string{"GET"}, Route: "targets/instances", Handler: routeHandler("targets")},
+		{Methods: []string{"GET"}, Route: "solution/queue", Handler: routeHandler("queue")},
+	})
+	assert.Nil(t, err)
+	defer binding.Shutdown(context.Background())
+
+	responses := sendRequests(t, config, []v1alpha2.COARequest{
+		{Route: "solution/instances", Method: "GET"},
+		{Route: "targets/instances", Method: "GET"},
+		{Route: "queue", Method: "GET"},
+		{Route: "instances", Method: "GET"},
+		{Route: "solution/unknown", Method: "GET"},
+	})
+	assert.Equal(t, "solution", string(responses[0].Body))
+	assert.Equal(t, "targets", string(responses[1].Body))
+	// the last segment still works when it identifies a single endpoint
+	assert.Equal(t, "queue", string(responses[2].Body))
+	// but not when it's ambiguous
+	assert.Equal(t, v1alpha2.NotFound, responses[3].State)
+	assert.Equal(t, v1alpha2.NotFound, responses[4].State)
+}
+
+func TestMQTTSecureTransport(t *testing.T) {
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{
+		Users: map[string]string{"symphony": "secret"},
+	})
+	assert.Nil(t, err)
+	defer broker.Close()
+
+	config := MQTTBindingConfig{
+		BrokerAddress: broker.Address,
+		ClientID:      "coabinding-secure",
+		RequestTopic:  "coabinding-request-secure",
+		ResponseTopic: "coabinding-response-secure",
+	}
+	binding := MQTTBinding{}
+	err = binding.Launch(config, nil)
+	assert.NotNil(t, err)
+
+	config.Username = "symphony"
+	config.Password = "secret"
+	config.QoS = 1
+	err = binding.Launch(config, []v1alpha2.Endpoint{
+		{Methods: []string{"GET"}, Route: "greetings", Handler: routeHandler("Hi there!!")},
+	})
+	assert.Nil(t, err)
+	defer binding.Shutdown(context.Background())
+
+	responses := sendRequests(t, config, []v1alpha2.COARequest{
+		{Route: "greetings", Method: "GET", Metadata: map[string]string{"call-context": "test-context"}},
+	})
+	assert.Equal(t, "Hi there!!", string(responses[0].Body))
+	assert.Equal(t, "test-context", responses[0].Metadata["call-context"])
+}
@@ -8,23 +8,64 @@ package memory
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	contexts "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	providers "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/metrics"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/google/uuid"
 )
 
 var log = logger.NewLogger("coa.runtime")
 
+var pubsubMetrics *metrics.Metrics
+
+const (
+	providerType = "memory"
+
+	defaultMaxRetries           = 3
+	defaultRetryIntervalInMs    = 100
+	defaultMaxRetryIntervalInMs = 5000
+	defaultDeadLetterTopic      = "deadletter"
+)
+
+// InMemoryPubSubProvider delivers the events of each topic to each subscriber in publishing order. A handler
+// that returns an error gets the event again with an exponential backoff, and events that still fail after the
+// maximum number of retries are published to the dead-letter topic.
 type InMemoryPubSubProvider struct {
-	Config      InMemoryPubSubConfig               `json:"config"`
-	Subscribers map[string][]v1alpha2.EventHandler `json:"subscribers"`
+	Config      InMemoryPubSubConfig `json:"config"`
 	Context     *contexts.ManagerContext
+	lock        sync.RWMutex
+	subscribers map[string][]*subscription
 }
 
 type InMemoryPubSubConfig struct {
 	Name string `json:"name"`
+	// MaxRetries is the number of times a failed event is delivered again to a handler, 3 if not set. A negative
+	// value disables the retries.
+	MaxRetries int `json:"maxRetries,omitempty"`
+	// RetryIntervalInMs is the delay before the first retry, doubled after each retry
+	RetryIntervalInMs int `json:"retryIntervalInMs,omitempty"`
+	// MaxRetryIntervalInMs caps the delay between retries
+	MaxRetryIntervalInMs int `json:"maxRetryIntervalInMs,omitempty"`
+	// DeadLetterTopic receives the events that failed all retries
+	DeadLetterTopic string `json:"deadLetterTopic,omitempty"`
+}
+
+// subscription queues the events of a topic for one handler and delivers them one at a time
+type subscription struct {
+	id      string
+	topic   string
+	handler v1alpha2.EventHandler
+	lock    sync.Mutex
+	events  []v1alpha2.Event
+	signal  chan struct{}
+	done    chan struct{}
 }
 
 func InMemoryPubSubConfigFromMap(properties map[string]string) (InMemoryPubSubConfig, error) {
@@ -32,6 +73,30 @@ func InMemoryPubSubConfigFromMap(properties map[string]string) (InMemoryPubSubCo
 	if v, ok := properties["name"]; ok {
 		ret.Name = v
 	}
+	if v, ok := properties["maxRetries"]; ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "invalid int value in the 'maxRetries' setting of in-memory pub-sub provider", v1alpha2.BadConfig)
+		}
+		ret.MaxRetries = n
+	}
+	if v, ok := properties["retryIntervalInMs"]; ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return ret, v1alpha2.NewCOAError(err, "invalid int value in the 'retryIntervalInMs' setting of in-memory pub-sub provider", v1alpha2.BadConfig)
+		}
+		ret.RetryIntervalInMs = n
+	}
+	if v, ok := properties["maxRetryIntervalInMs"]; ok && v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return ret, v1alpha2.NewCOAError(err, "invalid int value in the 'maxRetryIntervalInMs' setting of in-memory pub-sub provider", v1alpha2.BadConfig)
+		}
+		ret.MaxRetryIntervalInMs = n
+	}
+	if v, ok := properties["deadLetterTopic"]; ok {
+		ret.DeadLetterTopic = v
+	}
 	return ret, nil
 }
 
@@ -58,29 +123,206 @@ func (i *InMemoryPubSubProvider) Init(config providers.IProviderConfig) error {
 		log.Errorf("  P (Memory PubSub): failed to parse provider config %+v", err)
 		return v1alpha2.NewCOAError(nil, "provided config is not a valid in-memory pub-sub provider config", v1alpha2.BadConfig)
 	}
+	if vConfig.MaxRetries == 0 {
+		vConfig.MaxRetries = defaultMaxRetries
+	}
+	if vConfig.RetryIntervalInMs == 0 {
+		vConfig.RetryIntervalInMs = defaultRetryIntervalInMs
+	}
+	if vConfig.MaxRetryIntervalInMs == 0 {
+		vConfig.MaxRetryIntervalInMs = defaultMaxRetryIntervalInMs
+	}
+	if vConfig.DeadLetterTopic == "" {
+		vConfig.DeadLetterTopic = defaultDeadLetterTopic
+	}
+	if pubsubMetrics == nil {
+		pubsubMetrics, err = metrics.New()
+		if err != nil {
+			return err
+		}
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
 	i.Config = vConfig
-	i.Subscribers = make(map[string][]v1alpha2.EventHandler)
+	for _, subs := range i.subscribers {
+		for _, sub := range subs {
+			sub.stop()
+		}
+	}
+	i.subscribers = make(map[string][]*subscription)
 	return nil
 }
+
+// Publish queues an event for each subscriber of the topic. It doesn't wait for the handlers.
 func (i *InMemoryPubSubProvider) Publish(topic string, event v1alpha2.Event) error {
-	arr, ok := i.Subscribers[topic]
-	if ok && arr != nil {
-		for _, s := range arr {
-			go func(handler v1alpha2.EventHandler, topic string, event v1alpha2.Event) {
-				handler(topic, event)
-			}(s, topic, event)
-		}
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	for _, sub := range i.subscribers[topic] {
+		sub.push(event)
 	}
+	pubsubMetrics.Published(providerType, topic)
 	return nil
 }
+
 func (i *InMemoryPubSubProvider) Subscribe(topic string, handler v1alpha2.EventHandler) error {
-	arr, ok := i.Subscribers[topic]
-	if !ok || arr == nil {
-		i.Subscribers[topic] = make([]v1alpha2.EventHandler, 0)
+	_, err := i.SubscribeWithId(topic, handler)
+	return err
+}
+
+// SubscribeWithId subscribes a handler to a topic and returns the id of the subscription, which can be
+// used to unsubscribe the handler
+func (i *InMemoryPubSubProvider) SubscribeWithId(topic string, handler v1alpha2.EventHandler) (string, error) {
+	sub := &subscription{
+		id:      uuid.New().String(),
+		topic:   topic,
+		handler: handler,
+		events:  make([]v1alpha2.Event, 0),
+		signal:  make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.subscribers == nil {
+		i.subscribers = make(map[string][]*subscription)
 	}
-	i.Subscribers[topic] = append(i.Subscribers[topic], handler)
+	i.subscribers[topic] = append(i.subscribers[topic], sub)
+	go i.deliver(sub)
+	return sub.id, nil
+}
 
-	return nil
+// Unsubscribe removes a subscription. The events that are queued for it and not delivered yet are dropped.
+func (i *InMemoryPubSubProvider) Unsubscribe(topic string, id string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	subs := i.subscribers[topic]
+	for k, sub := range subs {
+		if sub.id == id {
+			sub.stop()
+			remaining := make([]*subscription, 0, len(subs)-1)
+			remaining = append(remaining, subs[:k]...)
+			i.subscribers[topic] = append(remaining, subs[k+1:]...)
+			if len(i.subscribers[topic]) == 0 {
+				delete(i.subscribers, topic)
+			}
+			return nil
+		}
+	}
+	return v1alpha2.NewCOAError(nil, fmt.Sprintf("subscription %s to topic %s is not found", id, topic), v1alpha2.NotFound)
+}
+
+func (s *subscription) push(event v1alpha2.Event) {
+	s.lock.Lock()
+	s.events = append(s.events, event)
+	s.lock.Unlock()
+	select {
+	case s.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (s *subscription) pop() (v1alpha2.Event, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.events) == 0 {
+		return v1alpha2.Event{}, false
+	}
+	event := s.events[0]
+	s.events = s.events[1:]
+	return event, true
+}
+
+func (s *subscription) stop() {
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+}
+
+// deliver hands the queued events of a subscription to its handler in order until the subscription is removed
+func (i *InMemoryPubSubProvider) deliver(sub *subscription) {
+	for {
+		event, ok := sub.pop()
+		if !ok {
+			select {
+			case <-sub.signal:
+				continue
+			case <-sub.done:
+				return
+			}
+		}
+		if !i.handle(sub, event) {
+			return
+		}
+	}
+}
+
+// handle delivers an event to a handler, retrying with a backoff while the handler fails. It returns false if
+// the subscription is removed while waiting for a retry.
+func (i *InMemoryPubSubProvider) handle(sub *subscription, event v1alpha2.Event) bool {
+	i.lock.RLock()
+	config := i.Config
+	i.lock.RUnlock()
+
+	interval := time.Duration(config.RetryIntervalInMs) * time.Millisecond
+	maxInterval := time.Duration(config.MaxRetryIntervalInMs) * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		startTime := time.Now()
+		err := sub.handler(sub.topic, copyEvent(event))
+		pubsubMetrics.ConsumeLatency(startTime, providerType, sub.topic)
+		if err == nil {
+			return true
+		}
+		pubsubMetrics.ConsumeErrors(providerType, sub.topic, errorCode(err))
+		if attempt >= config.MaxRetries {
+			i.deadLetter(config, sub.topic, event, err)
+			return true
+		}
+		log.Debugf("  P (Memory PubSub): handler of topic %s failed, retrying in %v: %+v", sub.topic, interval, err)
+		select {
+		case <-time.After(interval):
+		case <-sub.done:
+			return false
+		}
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
+
+// deadLetter publishes an event that failed all retries to the dead-letter topic. Events of the dead-letter
+// topic itself are dropped, so that they don't loop.
+func (i *InMemoryPubSubProvider) deadLetter(config InMemoryPubSubConfig, topic string, event v1alpha2.Event, err error) {
+	pubsubMetrics.DeadLettered(providerType, topic)
+	if topic == config.DeadLetterTopic {
+		log.Errorf("  P (Memory PubSub): dropping an event of the dead-letter topic: %+v", err)
+		return
+	}
+	log.Errorf("  P (Memory PubSub): handler of topic %s failed after %d retries, moving the event to topic %s: %+v", topic, config.MaxRetries, config.DeadLetterTopic, err)
+	letter := copyEvent(event)
+	letter.Metadata["originalTopic"] = topic
+	letter.Metadata["error"] = err.Error()
+	i.Publish(config.DeadLetterTopic, letter)
+}
+
+// copyEvent gives each delivery its own metadata, as handlers may modify it
+func copyEvent(event v1alpha2.Event) v1alpha2.Event {
+	metadata := make(map[string]string, len(event.Metadata))
+	for k, v := range event.Metadata {
+		metadata[k] = v
+	}
+	return v1alpha2.Event{
+		Metadata: metadata,
+		Body:     event.Body,
+	}
+}
+
+func errorCode(err error) string {
+	if coaErr, ok := err.(v1alpha2.COAError); ok {
+		return coaErr.State.String()
+	}
+	return v1alpha2.InternalError.String()
 }
 
 func toInMemoryPubSubConfig(config providers.IProviderConfig) (InMemoryPubSubConfig, error) {
@@ -7,7 +7,11 @@
 package memory
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/stretchr/testify/assert"
@@ -134,3 +138,216 @@ func TestCloneWithConfig(t *testing.T) {
 	})
 	assert.Nil(t, err)
 }
+
+func TestMemoryPubsubProviderConfigFromMapBadRetries(t *testing.T) {
+	_, err := InMemoryPubSubConfigFromMap(map[string]string{
+		"maxRetries": "many",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestInitDefaults(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	err := provider.Init(InMemoryPubSubConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, defaultMaxRetries, provider.Config.MaxRetries)
+	assert.Equal(t, defaultRetryIntervalInMs, provider.Config.RetryIntervalInMs)
+	assert.Equal(t, defaultDeadLetterTopic, provider.Config.DeadLetterTopic)
+}
+
+func TestOrderedDelivery(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test"})
+	received := make(chan int, 100)
+	provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+		// a slow first event would be overtaken if handlers ran concurrently
+		if event.Body.(int) == 0 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		received <- event.Body.(int)
+		return nil
+	})
+	for i := 0; i < 100; i++ {
+		provider.Publish("test", v1alpha2.Event{Body: i})
+	}
+	for i := 0; i < 100; i++ {
+		select {
+		case n := <-received:
+			assert.Equal(t, i, n)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "event is not delivered")
+			return
+		}
+	}
+}
+
+func TestRetryOnHandlerError(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test", RetryIntervalInMs: 1})
+	attempts := make(chan int, 10)
+	count := 0
+	provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+		count++
+		attempts <- count
+		if count < 3 {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	provider.Publish("test", v1alpha2.Event{Body: "TEST"})
+	for i := 1; i <= 3; i++ {
+		select {
+		case n := <-attempts:
+			assert.Equal(t, i, n)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "event is not retried")
+			return
+		}
+	}
+	select {
+	case <-attempts:
+		assert.Fail(t, "event is delivered after it succeeded")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test", MaxRetries: 2, RetryIntervalInMs: 1})
+	attempts := 0
+	provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+		attempts++
+		return v1alpha2.NewCOAError(nil, "bad event", v1alpha2.BadRequest)
+	})
+	letters := make(chan v1alpha2.Event, 1)
+	provider.Subscribe(defaultDeadLetterTopic, func(topic string, event v1alpha2.Event) error {
+		letters <- event
+		return nil
+	})
+	provider.Publish("test", v1alpha2.Event{Metadata: map[string]string{"id": "1"}, Body: "TEST"})
+	select {
+	case letter := <-letters:
+		assert.Equal(t, 3, attempts)
+		assert.Equal(t, "test", letter.Metadata["originalTopic"])
+		assert.Equal(t, "1", letter.Metadata["id"])
+		assert.Contains(t, letter.Metadata["error"], "bad event")
+		assert.Equal(t, "TEST", letter.Body)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "event is not dead-lettered")
+	}
+}
+
+func TestNoRetries(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test", MaxRetries: -1, DeadLetterTopic: "failed"})
+	attempts := 0
+	provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+		attempts++
+		return errors.New("failed")
+	})
+	letters := make(chan v1alpha2.Event, 1)
+	provider.Subscribe("failed", func(topic string, event v1alpha2.Event) error {
+		letters <- event
+		return nil
+	})
+	provider.Publish("test", v1alpha2.Event{Body: "TEST"})
+	select {
+	case <-letters:
+		assert.Equal(t, 1, attempts)
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "event is not dead-lettered")
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test"})
+	received1 := make(chan string, 10)
+	received2 := make(chan string, 10)
+	id, err := provider.SubscribeWithId("test", func(topic string, event v1alpha2.Event) error {
+		received1 <- event.Body.(string)
+		return nil
+	})
+	assert.Nil(t, err)
+	provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+		received2 <- event.Body.(string)
+		return nil
+	})
+	provider.Publish("test", v1alpha2.Event{Body: "first"})
+	assert.Equal(t, "first", <-received1)
+	assert.Equal(t, "first", <-received2)
+
+	err = provider.Unsubscribe("test", id)
+	assert.Nil(t, err)
+	provider.Publish("test", v1alpha2.Event{Body: "second"})
+	assert.Equal(t, "second", <-received2)
+	select {
+	case <-received1:
+		assert.Fail(t, "event is delivered after unsubscribe")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	err = provider.Unsubscribe("test", id)
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.NotFound, err.(v1alpha2.COAError).State)
+}
+
+func TestUnsubscribeStopsRetries(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test", RetryIntervalInMs: 60000})
+	called := make(chan struct{}, 10)
+	id, _ := provider.SubscribeWithId("test", func(topic string, event v1alpha2.Event) error {
+		called <- struct{}{}
+		return errors.New("failed")
+	})
+	provider.Publish("test", v1alpha2.Event{Body: "TEST"})
+	<-called
+	err := provider.Unsubscribe("test", id)
+	assert.Nil(t, err)
+}
+
+// TestHandlersGetOwnMetadata reproduces the race between handlers that modify the metadata of the same event
+func TestHandlersGetOwnMetadata(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test"})
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		n := i
+		provider.Subscribe("test", func(topic string, event v1alpha2.Event) error {
+			defer wg.Done()
+			event.Metadata["handler"] = fmt.Sprintf("%d", n)
+			return nil
+		})
+	}
+	event := v1alpha2.Event{Metadata: map[string]string{"id": "1"}, Body: "TEST"}
+	provider.Publish("test", event)
+	wg.Wait()
+	assert.Equal(t, map[string]string{"id": "1"}, event.Metadata)
+}
+
+// TestConcurrentSubscribeAndPublish reproduces the race on the subscribers when topics are subscribed,
+// unsubscribed and published concurrently, run it with -race
+func TestConcurrentSubscribeAndPublish(t *testing.T) {
+	provider := InMemoryPubSubProvider{}
+	provider.Init(InMemoryPubSubConfig{Name: "test"})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		topic := fmt.Sprintf("topic%d", i%3)
+		go func() {
+			defer wg.Done()
+			id, err := provider.SubscribeWithId(topic, func(topic string, event v1alpha2.Event) error {
+				return nil
+			})
+			assert.Nil(t, err)
+			assert.Nil(t, provider.Unsubscribe(topic, id))
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, provider.Publish(topic, v1alpha2.Event{Body: "TEST"}))
+		}()
+	}
+	wg.Wait()
+}
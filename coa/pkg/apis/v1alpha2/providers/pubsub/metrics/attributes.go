@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package metrics
+
+// Topic gets common attributes for a pub-sub topic.
+func Topic(
+	providerType string,
+	topic string,
+) map[string]any {
+	return map[string]any{
+		"providerType": providerType,
+		"topic":        topic,
+	}
+}
+
+// Error gets common attributes for an error.
+func Error(
+	errorCode string,
+) map[string]any {
+	return map[string]any{
+		"errorCode": errorCode,
+	}
+}
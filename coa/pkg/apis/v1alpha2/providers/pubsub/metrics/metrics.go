@@ -0,0 +1,158 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package metrics
+
+import (
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/constants"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/observability"
+)
+
+// Metrics is a metrics tracker for pub-sub providers.
+type Metrics struct {
+	published      observability.Counter
+	consumeLatency observability.Histogram
+	consumeErrors  observability.Counter
+	deadLetters    observability.Counter
+}
+
+func New() (*Metrics, error) {
+	observable := observability.New(constants.API)
+
+	published, err := observable.Metrics.Counter(
+		constants.PubSubPublished,
+		constants.PubSubPublishedDescription,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	consumeLatency, err := observable.Metrics.Histogram(
+		constants.PubSubConsumeLatency,
+		constants.PubSubConsumeLatencyDescription,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	consumeErrors, err := observable.Metrics.Counter(
+		constants.PubSubConsumeErrors,
+		constants.PubSubConsumeErrorsDescription,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	deadLetters, err := observable.Metrics.Counter(
+		constants.PubSubDeadLetters,
+		constants.PubSubDeadLettersDescription,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{
+		published:      published,
+		consumeLatency: consumeLatency,
+		consumeErrors:  consumeErrors,
+		deadLetters:    deadLetters,
+	}, nil
+}
+
+// Close closes all metrics.
+func (m *Metrics) Close() {
+	if m == nil {
+		return
+	}
+
+	m.published.Close()
+	m.consumeErrors.Close()
+	m.deadLetters.Close()
+}
+
+// Published increments the count of events published to a topic.
+func (m *Metrics) Published(
+	providerType string,
+	topic string,
+) {
+	if m == nil {
+		return
+	}
+
+	m.published.Add(
+		1,
+		Topic(
+			providerType,
+			topic,
+		),
+	)
+}
+
+// ConsumeLatency tracks the latency of a handler that consumed an event.
+func (m *Metrics) ConsumeLatency(
+	startTime time.Time,
+	providerType string,
+	topic string,
+) {
+	if m == nil {
+		return
+	}
+
+	m.consumeLatency.Add(
+		latency(startTime),
+		Topic(
+			providerType,
+			topic,
+		),
+	)
+}
+
+// ConsumeErrors increments the count of errors returned by handlers.
+func (m *Metrics) ConsumeErrors(
+	providerType string,
+	topic string,
+	errorCode string,
+) {
+	if m == nil {
+		return
+	}
+
+	m.consumeErrors.Add(
+		1,
+		Topic(
+			providerType,
+			topic,
+		),
+		Error(
+			errorCode,
+		),
+	)
+}
+
+// DeadLettered increments the count of events moved to the dead-letter topic.
+func (m *Metrics) DeadLettered(
+	providerType string,
+	topic string,
+) {
+	if m == nil {
+		return
+	}
+
+	m.deadLetters.Add(
+		1,
+		Topic(
+			providerType,
+			topic,
+		),
+	)
+}
+
+// Latency gets the time since the given start in milliseconds.
+func latency(start time.Time) float64 {
+	return float64(time.Since(start)) / float64(time.Millisecond)
+}
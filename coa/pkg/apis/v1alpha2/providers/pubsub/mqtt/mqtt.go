@@ -0,0 +1,292 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package mqtt
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/pubsub/metrics"
+	mqttutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	gmqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+var mLog = logger.NewLogger("coa.runtime")
+
+var pubsubMetrics *metrics.Metrics
+
+const providerType = "mqtt"
+
+// MQTTPubSubProvider carries events over an MQTT broker, so that processes sharing the broker, like the
+// Symphony API and a co-located agent, can exchange events. Events are published with QoS 1 (at least once)
+// and the client keeps a persistent session, so events published while it's disconnected are delivered when it
+// reconnects. An event is acknowledged after all handlers of its topic succeed.
+type MQTTPubSubProvider struct {
+	Config      MQTTPubSubProviderConfig `json:"config"`
+	Context     *contexts.ManagerContext
+	Client      gmqtt.Client
+	lock        sync.RWMutex
+	subscribers map[string][]v1alpha2.EventHandler
+}
+
+type MQTTPubSubProviderConfig struct {
+	Name          string `json:"name"`
+	BrokerAddress string `json:"brokerAddress"`
+	// ClientID identifies the persistent session of the client on the broker
+	ClientID string `json:"clientID"`
+	// TopicPrefix is prepended to the topics, to share a broker with other applications
+	TopicPrefix string `json:"topicPrefix,omitempty"`
+	// ConsumerGroup makes the providers with the same group compete for the events of a topic through MQTT
+	// shared subscriptions, instead of each of them receiving all events
+	ConsumerGroup         string `json:"consumerGroup,omitempty"`
+	ConnectTimeoutSeconds int    `json:"connectTimeoutSeconds,omitempty"`
+	mqttutils.ConnectionConfig
+}
+
+func MQTTPubSubProviderConfigFromMap(properties map[string]string) (MQTTPubSubProviderConfig, error) {
+	ret := MQTTPubSubProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = v
+	}
+	if v, ok := properties["brokerAddress"]; ok {
+		ret.BrokerAddress = v
+	} else {
+		return ret, v1alpha2.NewCOAError(nil, "'brokerAddress' is missing in MQTT pub-sub provider config", v1alpha2.BadConfig)
+	}
+	if v, ok := properties["clientID"]; ok {
+		ret.ClientID = v
+	} else {
+		return ret, v1alpha2.NewCOAError(nil, "'clientID' is missing in MQTT pub-sub provider config", v1alpha2.BadConfig)
+	}
+	if v, ok := properties["topicPrefix"]; ok {
+		ret.TopicPrefix = v
+	}
+	if v, ok := properties["consumerGroup"]; ok {
+		ret.ConsumerGroup = v
+	}
+	if v, ok := properties["connectTimeoutSeconds"]; ok {
+		num, err := strconv.Atoi(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(nil, "'connectTimeoutSeconds' is not an integer in MQTT pub-sub provider config", v1alpha2.BadConfig)
+		}
+		ret.ConnectTimeoutSeconds = num
+	}
+	connectionConfig, err := mqttutils.ConnectionConfigFromMap(properties)
+	if err != nil {
+		return ret, err
+	}
+	if _, ok := properties["qos"]; !ok {
+		connectionConfig.QoS = 1
+	}
+	ret.ConnectionConfig = connectionConfig
+	return ret, nil
+}
+
+func (v *MQTTPubSubProvider) ID() string {
+	return v.Config.Name
+}
+
+func (s *MQTTPubSubProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *MQTTPubSubProvider) InitWithMap(properties map[string]string) error {
+	config, err := MQTTPubSubProviderConfigFromMap(properties)
+	if err != nil {
+		mLog.Errorf("  P (MQTT PubSub): failed to parse provider config from map %+v", err)
+		return err
+	}
+	return i.Init(config)
+}
+
+func (i *MQTTPubSubProvider) Init(config providers.IProviderConfig) error {
+	vConfig, err := toMQTTPubSubProviderConfig(config)
+	if err != nil {
+		mLog.Errorf("  P (MQTT PubSub): failed to parse provider config %+v", err)
+		return v1alpha2.NewCOAError(nil, "provided config is not a valid MQTT pub-sub provider config", v1alpha2.BadConfig)
+	}
+	if vConfig.BrokerAddress == "" {
+		return v1alpha2.NewCOAError(nil, "MQTT broker address is not supplied", v1alpha2.MissingConfig)
+	}
+	if vConfig.ClientID == "" {
+		return v1alpha2.NewCOAError(nil, "MQTT client ID is not supplied", v1alpha2.MissingConfig)
+	}
+	if vConfig.QoS == 0 {
+		vConfig.QoS = 1
+	}
+	if vConfig.ConnectTimeoutSeconds <= 0 {
+		vConfig.ConnectTimeoutSeconds = 30
+	}
+	if pubsubMetrics == nil {
+		pubsubMetrics, err = metrics.New()
+		if err != nil {
+			return err
+		}
+	}
+	i.Config = vConfig
+	i.subscribers = make(map[string][]v1alpha2.EventHandler)
+
+	opts, err := mqttutils.NewClientOptions(i.Config.BrokerAddress, i.Config.ClientID, i.Config.ConnectionConfig, false)
+	if err != nil {
+		mLog.Errorf("  P (MQTT PubSub): invalid MQTT connection config - %+v", err)
+		return err
+	}
+	opts.SetConnectTimeout(time.Duration(i.Config.ConnectTimeoutSeconds) * time.Second)
+	opts.SetAutoReconnect(true)
+	// handlers may publish events, which can't complete while the client waits for a handler in order
+	opts.SetOrderMatters(false)
+	// events are acknowledged after they are handled, so that a failed event is delivered again
+	opts.SetAutoAckDisabled(true)
+	opts.SetOnConnectHandler(i.onConnect)
+	opts.SetConnectionLostHandler(func(client gmqtt.Client, err error) {
+		mLog.Errorf("  P (MQTT PubSub): lost connection to MQTT broker - %+v", err)
+	})
+	i.Client = gmqtt.NewClient(opts)
+	if token := i.Client.Connect(); token.Wait() && token.Error() != nil {
+		mLog.Errorf("  P (MQTT PubSub): failed to connect to MQTT broker - %+v", token.Error())
+		return v1alpha2.NewCOAError(token.Error(), "failed to connect to MQTT broker", v1alpha2.InternalError)
+	}
+	return nil
+}
+
+// Publish sends an event to the broker and waits for the broker to acknowledge it
+func (i *MQTTPubSubProvider) Publish(topic string, event v1alpha2.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return v1alpha2.NewCOAError(err, "failed to serialize event", v1alpha2.BadRequest)
+	}
+	token := i.Client.Publish(i.Config.TopicPrefix+topic, i.Config.GetQoS(), false, data)
+	if token.Wait() && token.Error() != nil {
+		mLog.Errorf("  P (MQTT PubSub): failed to publish to topic %s - %+v", topic, token.Error())
+		return v1alpha2.NewCOAError(token.Error(), "failed to publish message", v1alpha2.InternalError)
+	}
+	pubsubMetrics.Published(providerType, topic)
+	return nil
+}
+
+// Subscribe adds a handler to a topic. The provider subscribes to each topic once and hands the events to all
+// handlers of the topic.
+func (i *MQTTPubSubProvider) Subscribe(topic string, handler v1alpha2.EventHandler) error {
+	i.lock.Lock()
+	_, subscribed := i.subscribers[topic]
+	i.subscribers[topic] = append(i.subscribers[topic], handler)
+	i.lock.Unlock()
+	if subscribed {
+		return nil
+	}
+	err := i.subscribe(topic)
+	if err != nil {
+		i.lock.Lock()
+		delete(i.subscribers, topic)
+		i.lock.Unlock()
+	}
+	return err
+}
+
+// Close disconnects from the broker. The persistent session keeps the subscriptions and the events published
+// until the client connects again with the same client ID.
+func (i *MQTTPubSubProvider) Close() error {
+	if i.Client != nil {
+		i.Client.Disconnect(250)
+	}
+	return nil
+}
+
+func (i *MQTTPubSubProvider) subscribe(topic string) error {
+	token := i.Client.Subscribe(i.filter(topic), i.Config.GetQoS(), func(client gmqtt.Client, msg gmqtt.Message) {
+		i.handleMessage(topic, msg)
+	})
+	if token.Wait() && token.Error() != nil {
+		mLog.Errorf("  P (MQTT PubSub): failed to subscribe to topic %s - %+v", topic, token.Error())
+		return v1alpha2.NewCOAError(token.Error(), "failed to subscribe to topic "+topic, v1alpha2.InternalError)
+	}
+	return nil
+}
+
+// filter returns the topic filter of a topic, which is a shared subscription when a consumer group is set
+func (i *MQTTPubSubProvider) filter(topic string) string {
+	if i.Config.ConsumerGroup != "" {
+		return "$share/" + i.Config.ConsumerGroup + "/" + i.Config.TopicPrefix + topic
+	}
+	return i.Config.TopicPrefix + topic
+}
+
+// onConnect restores the subscriptions, as the broker may have lost the session
+func (i *MQTTPubSubProvider) onConnect(client gmqtt.Client) {
+	i.lock.RLock()
+	topics := make([]string, 0, len(i.subscribers))
+	for topic := range i.subscribers {
+		topics = append(topics, topic)
+	}
+	i.lock.RUnlock()
+	for _, topic := range topics {
+		i.subscribe(topic)
+	}
+}
+
+// handleMessage hands an event to the handlers of its topic. The event is acknowledged only if all handlers
+// succeed, otherwise the broker delivers it again when the client reconnects.
+func (i *MQTTPubSubProvider) handleMessage(topic string, msg gmqtt.Message) {
+	var event v1alpha2.Event
+	if err := json.Unmarshal(msg.Payload(), &event); err != nil {
+		mLog.Errorf("  P (MQTT PubSub): dropping a message of topic %s that is not an event - %+v", topic, err)
+		msg.Ack()
+		return
+	}
+	i.lock.RLock()
+	handlers := append([]v1alpha2.EventHandler{}, i.subscribers[topic]...)
+	i.lock.RUnlock()
+	failed := false
+	for _, handler := range handlers {
+		startTime := time.Now()
+		err := handler(topic, copyEvent(event))
+		pubsubMetrics.ConsumeLatency(startTime, providerType, topic)
+		if err != nil {
+			mLog.Errorf("  P (MQTT PubSub): handler of topic %s failed - %+v", topic, err)
+			pubsubMetrics.ConsumeErrors(providerType, topic, errorCode(err))
+			failed = true
+		}
+	}
+	if !failed {
+		msg.Ack()
+	}
+}
+
+// copyEvent gives each handler its own metadata, as handlers may modify it
+func copyEvent(event v1alpha2.Event) v1alpha2.Event {
+	metadata := make(map[string]string, len(event.Metadata))
+	for k, v := range event.Metadata {
+		metadata[k] = v
+	}
+	return v1alpha2.Event{
+		Metadata: metadata,
+		Body:     event.Body,
+	}
+}
+
+func errorCode(err error) string {
+	if coaErr, ok := err.(v1alpha2.COAError); ok {
+		return coaErr.State.String()
+	}
+	return v1alpha2.InternalError.String()
+}
+
+func toMQTTPubSubProviderConfig(config providers.IProviderConfig) (MQTTPubSubProviderConfig, error) {
+	ret := MQTTPubSubProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
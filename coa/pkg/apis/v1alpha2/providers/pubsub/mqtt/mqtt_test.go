@@ -0,0 +1,212 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package mqtt
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	mqttutils "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt/mqtttest"
+	"github.com/stretchr/testify/assert"
+)
+
+func startBroker(t *testing.T, users map[string]string) *mqtttest.Broker {
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{Users: users})
+	assert.Nil(t, err)
+	t.Cleanup(func() { broker.Close() })
+	return broker
+}
+
+func newProvider(t *testing.T, config MQTTPubSubProviderConfig) *MQTTPubSubProvider {
+	provider := &MQTTPubSubProvider{}
+	err := provider.Init(config)
+	assert.Nil(t, err)
+	t.Cleanup(func() { provider.Close() })
+	return provider
+}
+
+func waitForEvent(t *testing.T, events chan v1alpha2.Event) v1alpha2.Event {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "event is not delivered")
+		return v1alpha2.Event{}
+	}
+}
+
+func TestMQTTPubSubProviderConfigFromMap(t *testing.T) {
+	config, err := MQTTPubSubProviderConfigFromMap(map[string]string{
+		"name":          "mqtt",
+		"brokerAddress": "tcp://localhost:1883",
+		"clientID":      "symphony-api",
+		"consumerGroup": "symphony",
+		"topicPrefix":   "symphony/",
+		"useTLS":        "true",
+		"username":      "admin",
+		"password":      "secret",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "mqtt", config.Name)
+	assert.Equal(t, "symphony", config.ConsumerGroup)
+	assert.Equal(t, "symphony/", config.TopicPrefix)
+	assert.Equal(t, byte(1), config.GetQoS())
+	assert.True(t, config.UseTLS)
+	assert.Equal(t, "admin", config.Username)
+}
+
+func TestMQTTPubSubProviderConfigFromMapMissingBroker(t *testing.T) {
+	_, err := MQTTPubSubProviderConfigFromMap(map[string]string{
+		"clientID": "symphony-api",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+
+	_, err = MQTTPubSubProviderConfigFromMap(map[string]string{
+		"brokerAddress": "tcp://localhost:1883",
+		"clientID":      "symphony-api",
+		"qos":           "3",
+	})
+	assert.NotNil(t, err)
+}
+
+func TestInitWithoutClientID(t *testing.T) {
+	provider := MQTTPubSubProvider{}
+	err := provider.Init(MQTTPubSubProviderConfig{BrokerAddress: "tcp://localhost:1883"})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.MissingConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	broker := startBroker(t, nil)
+	provider := newProvider(t, MQTTPubSubProviderConfig{
+		BrokerAddress: broker.Address,
+		ClientID:      "test-client",
+		TopicPrefix:   "symphony/",
+	})
+	events1 := make(chan v1alpha2.Event, 1)
+	events2 := make(chan v1alpha2.Event, 1)
+	err := provider.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		event.Metadata["handler"] = "1"
+		events1 <- event
+		return nil
+	})
+	assert.Nil(t, err)
+	err = provider.Subscribe("job", func(topic string, event v1alpha2.Event) error {
+		events2 <- event
+		return nil
+	})
+	assert.Nil(t, err)
+
+	err = provider.Publish("job", v1alpha2.Event{
+		Metadata: map[string]string{"objectType": "instance"},
+		Body:     map[string]interface{}{"id": "instance1"},
+	})
+	assert.Nil(t, err)
+	event := waitForEvent(t, events1)
+	assert.Equal(t, "instance", event.Metadata["objectType"])
+	assert.Equal(t, map[string]interface{}{"id": "instance1"}, event.Body)
+	event = waitForEvent(t, events2)
+	assert.Equal(t, map[string]string{"objectType": "instance"}, event.Metadata)
+}
+
+func TestSharedSubscription(t *testing.T) {
+	broker := startBroker(t, nil)
+	var received int32
+	events := make(chan v1alpha2.Event, 20)
+	handler := func(topic string, event v1alpha2.Event) error {
+		atomic.AddInt32(&received, 1)
+		events <- event
+		return nil
+	}
+	consumer1 := newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "consumer1", ConsumerGroup: "workers"})
+	consumer2 := newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "consumer2", ConsumerGroup: "workers"})
+	assert.Nil(t, consumer1.Subscribe("job", handler))
+	assert.Nil(t, consumer2.Subscribe("job", handler))
+	publisher := newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "publisher"})
+
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, publisher.Publish("job", v1alpha2.Event{Body: i}))
+	}
+	for i := 0; i < 10; i++ {
+		waitForEvent(t, events)
+	}
+	// each event goes to one member of the group only
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, int32(10), atomic.LoadInt32(&received))
+}
+
+func TestPersistentSession(t *testing.T) {
+	broker := startBroker(t, nil)
+	events := make(chan v1alpha2.Event, 1)
+	handler := func(topic string, event v1alpha2.Event) error {
+		events <- event
+		return nil
+	}
+	consumer := newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "consumer"})
+	assert.Nil(t, consumer.Subscribe("job", handler))
+	consumer.Close()
+
+	publisher := newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "publisher"})
+	assert.Nil(t, publisher.Publish("job", v1alpha2.Event{Body: "while offline"}))
+
+	// the consumer receives the event published while it was offline when it reconnects
+	consumer = newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "consumer"})
+	assert.Nil(t, consumer.Subscribe("job", handler))
+	event := waitForEvent(t, events)
+	assert.Equal(t, "while offline", event.Body)
+}
+
+func TestRedeliveryOnHandlerError(t *testing.T) {
+	broker := startBroker(t, nil)
+	var fail atomic.Bool
+	fail.Store(true)
+	events := make(chan v1alpha2.Event, 2)
+	handler := func(topic string, event v1alpha2.Event) error {
+		events <- event
+		if fail.Load() {
+			return errors.New("failed")
+		}
+		return nil
+	}
+	consumer := newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "consumer"})
+	assert.Nil(t, consumer.Subscribe("job", handler))
+	publisher := newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "publisher"})
+	assert.Nil(t, publisher.Publish("job", v1alpha2.Event{Body: "TEST"}))
+	waitForEvent(t, events)
+	consumer.Close()
+
+	// the failed event wasn't acknowledged, so it's delivered again to the resumed session
+	fail.Store(false)
+	consumer = newProvider(t, MQTTPubSubProviderConfig{BrokerAddress: broker.Address, ClientID: "consumer"})
+	assert.Nil(t, consumer.Subscribe("job", handler))
+	event := waitForEvent(t, events)
+	assert.Equal(t, "TEST", event.Body)
+}
+
+func TestCredentials(t *testing.T) {
+	broker := startBroker(t, map[string]string{"admin": "secret"})
+	provider := MQTTPubSubProvider{}
+	err := provider.Init(MQTTPubSubProviderConfig{
+		BrokerAddress:         broker.Address,
+		ClientID:              "intruder",
+		ConnectTimeoutSeconds: 2,
+		ConnectionConfig:      mqttutils.ConnectionConfig{Username: "admin", Password: "wrong"},
+	})
+	assert.NotNil(t, err)
+
+	authorized := newProvider(t, MQTTPubSubProviderConfig{
+		BrokerAddress:    broker.Address,
+		ClientID:         "admin",
+		ConnectionConfig: mqttutils.ConnectionConfig{Username: "admin", Password: "secret"},
+	})
+	assert.Nil(t, authorized.Publish("job", v1alpha2.Event{Body: "TEST"}))
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package conformance
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+func FirstInFirstOut[P queue.IQueueProvider](t *testing.T, p P) {
+	assert.Nil(t, p.Enqueue("fifo", "a"))
+	assert.Nil(t, p.Enqueue("fifo", "b"))
+	assert.Equal(t, 2, p.Size("fifo"))
+	element, err := p.Peek("fifo")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+	element, err = p.Dequeue("fifo")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", element)
+	element, err = p.Dequeue("fifo")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", element)
+	_, err = p.Dequeue("fifo")
+	assert.NotNil(t, err)
+	_, err = p.Dequeue("unknown")
+	assert.NotNil(t, err)
+}
+
+func LeaseAndAck[P queue.IQueueProvider](t *testing.T, p P) {
+	assert.Nil(t, p.Enqueue("ack", "a"))
+	assert.Nil(t, p.Enqueue("ack", "b"))
+	item, err := p.DequeueWithLease("ack", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", item.Element)
+	assert.NotEqual(t, "", item.LeaseId)
+	assert.Equal(t, 1, item.Deliveries)
+	assert.Equal(t, 1, p.Length("ack", queue.Ready))
+	assert.Equal(t, 1, p.Length("ack", queue.Leased))
+	assert.Equal(t, 2, p.Length("ack", queue.All))
+
+	// a leased element is invisible
+	element, err := p.Peek("ack")
+	assert.Nil(t, err)
+	assert.Equal(t, "b", element)
+
+	assert.Nil(t, p.Ack("ack", item.LeaseId))
+	assert.Equal(t, 1, p.Length("ack", queue.All))
+	err = p.Ack("ack", item.LeaseId)
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func Nack[P queue.IQueueProvider](t *testing.T, p P) {
+	assert.Nil(t, p.Enqueue("nack", "a"))
+	assert.Nil(t, p.Enqueue("nack", "b"))
+	item, err := p.DequeueWithLease("nack", time.Minute)
+	assert.Nil(t, err)
+	assert.Nil(t, p.Nack("nack", item.LeaseId))
+	assert.Equal(t, 0, p.Length("nack", queue.Leased))
+
+	// a nacked element is delivered again first
+	item, err = p.DequeueWithLease("nack", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", item.Element)
+	assert.Equal(t, 2, item.Deliveries)
+	err = p.Nack("nack", "unknown")
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func VisibilityTimeout[P queue.IQueueProvider](t *testing.T, p P) {
+	assert.Nil(t, p.Enqueue("timeout", "a"))
+	item, err := p.DequeueWithLease("timeout", 50*time.Millisecond)
+	assert.Nil(t, err)
+	_, err = p.DequeueWithLease("timeout", 50*time.Millisecond)
+	assert.NotNil(t, err)
+
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, p.Length("timeout", queue.Ready))
+	err = p.Ack("timeout", item.LeaseId)
+	assert.True(t, v1alpha2.IsNotFound(err))
+	redelivered, err := p.DequeueWithLease("timeout", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, "a", redelivered.Element)
+	assert.NotEqual(t, item.LeaseId, redelivered.LeaseId)
+	assert.Equal(t, 2, redelivered.Deliveries)
+}
+
+func Extend[P queue.IQueueProvider](t *testing.T, p P) {
+	assert.Nil(t, p.Enqueue("extend", "a"))
+	item, err := p.DequeueWithLease("extend", 50*time.Millisecond)
+	assert.Nil(t, err)
+	assert.Nil(t, p.Extend("extend", item.LeaseId, time.Minute))
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, p.Length("extend", queue.Leased))
+	assert.Nil(t, p.Ack("extend", item.LeaseId))
+	err = p.Extend("extend", item.LeaseId, time.Minute)
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func ConformanceSuite[P queue.IQueueProvider](t *testing.T, p P) {
+	t.Run("Level=Basic", func(t *testing.T) {
+		FirstInFirstOut(t, p)
+	})
+	t.Run("Level=Lease", func(t *testing.T) {
+		LeaseAndAck(t, p)
+		Nack(t, p)
+		VisibilityTimeout(t, p)
+		Extend(t, p)
+	})
+}
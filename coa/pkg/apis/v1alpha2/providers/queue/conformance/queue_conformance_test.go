@@ -0,0 +1,30 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package conformance
+
+import (
+	"testing"
+
+	diskqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/disk"
+	memoryqueue "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue/memory"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryQueueConformance(t *testing.T) {
+	provider := &memoryqueue.MemoryQueueProvider{}
+	err := provider.Init(memoryqueue.MemoryQueueProviderConfig{})
+	assert.Nil(t, err)
+	ConformanceSuite(t, provider)
+}
+
+func TestDiskQueueConformance(t *testing.T) {
+	provider := &diskqueue.DiskQueueProvider{}
+	err := provider.Init(diskqueue.DiskQueueProviderConfig{Dir: t.TempDir()})
+	assert.Nil(t, err)
+	defer provider.Close()
+	ConformanceSuite(t, provider)
+}
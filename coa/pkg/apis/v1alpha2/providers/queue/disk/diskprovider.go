@@ -0,0 +1,515 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package diskqueue
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	queue_pkg "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/google/uuid"
+)
+
+var dLog = logger.NewLogger("coa.runtime")
+
+const (
+	queueFileExtension = ".queue"
+	// a queue file is compacted when it has more than compactThreshold records and four times as many records as elements
+	compactThreshold = 1000
+)
+
+const (
+	opEnqueue = "enqueue"
+	opRemove  = "remove"
+	opLease   = "lease"
+	opRelease = "release"
+	opExtend  = "extend"
+)
+
+type DiskQueueProviderConfig struct {
+	Name string `json:"name"`
+	// Dir is the directory holding one file per queue
+	Dir string `json:"dir"`
+	// SyncIntervalInMs batches fsyncs of queue files. With 0, every change is synced before it returns.
+	SyncIntervalInMs int `json:"syncIntervalInMs,omitempty"`
+}
+
+func DiskQueueProviderConfigFromMap(properties map[string]string) (DiskQueueProviderConfig, error) {
+	ret := DiskQueueProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = utils.ParseProperty(v)
+	}
+	if v, ok := properties["dir"]; ok {
+		ret.Dir = utils.ParseProperty(v)
+	}
+	if v, ok := properties["syncIntervalInMs"]; ok {
+		interval, err := strconv.Atoi(utils.ParseProperty(v))
+		if err != nil || interval < 0 {
+			return ret, v1alpha2.NewCOAError(err, "'syncIntervalInMs' must be a non-negative integer", v1alpha2.BadConfig)
+		}
+		ret.SyncIntervalInMs = interval
+	}
+	return ret, nil
+}
+
+// journalRecord is a change to a queue. A queue file is the list of changes since the queue was last compacted.
+type journalRecord struct {
+	Op         string      `json:"op"`
+	Time       time.Time   `json:"time"`
+	Id         string      `json:"id,omitempty"`
+	Element    interface{} `json:"element,omitempty"`
+	Deliveries int         `json:"deliveries,omitempty"`
+	LeaseId    string      `json:"leaseId,omitempty"`
+	Expires    time.Time   `json:"expires,omitempty"`
+}
+
+type diskQueue struct {
+	queue   *queue_pkg.Queue
+	file    *os.File
+	records int
+	dirty   bool
+	// reclaimed is when expired leases were last reclaimed, changes are journaled with this time
+	reclaimed time.Time
+}
+
+type DiskQueueProvider struct {
+	Config  DiskQueueProviderConfig
+	Context *contexts.ManagerContext
+	queues  map[string]*diskQueue
+	lock    sync.Mutex
+	stop    chan struct{}
+}
+
+func (s *DiskQueueProvider) ID() string {
+	return s.Config.Name
+}
+
+func (s *DiskQueueProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *DiskQueueProvider) InitWithMap(properties map[string]string) error {
+	config, err := DiskQueueProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func toDiskQueueProviderConfig(config providers.IProviderConfig) (DiskQueueProviderConfig, error) {
+	ret := DiskQueueProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (s *DiskQueueProvider) Init(config providers.IProviderConfig) error {
+	queueConfig, err := toDiskQueueProviderConfig(config)
+	if err != nil {
+		return errors.New("expected DiskQueueProviderConfig")
+	}
+	if queueConfig.Dir == "" {
+		return v1alpha2.NewCOAError(nil, "'dir' is required", v1alpha2.MissingConfig)
+	}
+	s.Config = queueConfig
+	if err = os.MkdirAll(s.Config.Dir, 0700); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to create queue directory %s", s.Config.Dir), v1alpha2.InternalError)
+	}
+	s.queues = make(map[string]*diskQueue)
+	files, err := filepath.Glob(filepath.Join(s.Config.Dir, "*"+queueFileExtension))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name, err := url.PathUnescape(strings.TrimSuffix(filepath.Base(file), queueFileExtension))
+		if err != nil {
+			dLog.Errorf("  P (Disk Queue): skipping queue file %s: %+v", file, err)
+			continue
+		}
+		if err = s.load(name, file); err != nil {
+			return err
+		}
+	}
+	if s.Config.SyncIntervalInMs > 0 {
+		s.stop = make(chan struct{})
+		go s.syncLoop(time.Duration(s.Config.SyncIntervalInMs)*time.Millisecond, s.stop)
+	}
+	return nil
+}
+
+// Close syncs and closes all queue files
+func (s *DiskQueueProvider) Close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	var ret error
+	for _, q := range s.queues {
+		if err := q.file.Sync(); err != nil {
+			ret = err
+		}
+		if err := q.file.Close(); err != nil {
+			ret = err
+		}
+	}
+	s.queues = make(map[string]*diskQueue)
+	return ret
+}
+
+func (s *DiskQueueProvider) syncLoop(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.lock.Lock()
+			for name, q := range s.queues {
+				if q.dirty {
+					if err := q.file.Sync(); err != nil {
+						dLog.Errorf("  P (Disk Queue): failed to sync queue %s: %+v", name, err)
+						continue
+					}
+					q.dirty = false
+				}
+			}
+			s.lock.Unlock()
+		}
+	}
+}
+
+func (s *DiskQueueProvider) queueFile(queue string) string {
+	return filepath.Join(s.Config.Dir, url.PathEscape(queue)+queueFileExtension)
+}
+
+// load replays a queue file and rewrites it with the current elements only
+func (s *DiskQueueProvider) load(queue string, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	q := queue_pkg.NewQueue()
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var record journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// a record that was partially written when the process stopped
+			dLog.Errorf("  P (Disk Queue): skipping malformed record in queue %s: %+v", queue, err)
+			continue
+		}
+		apply(q, record)
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	s.queues[queue] = &diskQueue{queue: q}
+	return s.compact(queue)
+}
+
+func apply(q *queue_pkg.Queue, record journalRecord) {
+	// every change reclaims expired leases before it's applied, so does the replay
+	q.Reclaim(record.Time)
+	switch record.Op {
+	case opEnqueue:
+		q.Push(queue_pkg.QueueItem{
+			Id:         record.Id,
+			Element:    record.Element,
+			Deliveries: record.Deliveries,
+		})
+	case opRemove:
+		q.Remove(record.Id)
+	case opLease:
+		q.LeaseItem(record.Id, record.LeaseId, record.Expires)
+	case opRelease:
+		q.Nack(record.LeaseId)
+	case opExtend:
+		q.Extend(record.LeaseId, record.Expires)
+	}
+}
+
+// compact rewrites a queue file with the records of the current elements
+func (s *DiskQueueProvider) compact(queue string) error {
+	q := s.queues[queue]
+	path := s.queueFile(queue)
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(file)
+	records := 0
+	now := time.Now().UTC()
+	for _, item := range q.queue.Items() {
+		enqueue := journalRecord{Op: opEnqueue, Time: now, Id: item.Id, Element: item.Element, Deliveries: item.Deliveries}
+		if item.LeaseId != "" {
+			enqueue.Deliveries--
+		}
+		if err = writeRecord(writer, enqueue); err != nil {
+			file.Close()
+			return err
+		}
+		records++
+		if item.LeaseId != "" {
+			if err = writeRecord(writer, journalRecord{Op: opLease, Time: now, Id: item.Id, LeaseId: item.LeaseId, Expires: item.LeaseExpires}); err != nil {
+				file.Close()
+				return err
+			}
+			records++
+		}
+	}
+	if err = writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if q.file != nil {
+		q.file.Close()
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	q.file, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	q.records = records
+	q.dirty = false
+	return nil
+}
+
+func writeRecord(writer *bufio.Writer, record journalRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = writer.Write(data); err != nil {
+		return err
+	}
+	return writer.WriteByte('\n')
+}
+
+// getQueue returns a queue, creating its file if needed, after reclaiming its expired leases
+func (s *DiskQueueProvider) getQueue(queue string, create bool) (*diskQueue, error) {
+	q, ok := s.queues[queue]
+	if !ok {
+		if !create {
+			return nil, errors.New("queue not found")
+		}
+		file, err := os.OpenFile(s.queueFile(queue), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, err
+		}
+		q = &diskQueue{queue: queue_pkg.NewQueue(), file: file}
+		s.queues[queue] = q
+	}
+	q.reclaimed = time.Now().UTC()
+	q.queue.Reclaim(q.reclaimed)
+	return q, nil
+}
+
+// journal appends a change to a queue file before the change is applied in memory
+func (s *DiskQueueProvider) journal(queue string, q *diskQueue, record journalRecord) error {
+	record.Time = q.reclaimed
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err = q.file.Write(append(data, '\n')); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to write to queue %s", queue), v1alpha2.InternalError)
+	}
+	q.records++
+	if s.Config.SyncIntervalInMs == 0 {
+		if err = q.file.Sync(); err != nil {
+			return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to sync queue %s", queue), v1alpha2.InternalError)
+		}
+	} else {
+		q.dirty = true
+	}
+	return nil
+}
+
+// afterChange compacts a queue file that has grown too large
+func (s *DiskQueueProvider) afterChange(queue string, q *diskQueue) {
+	if q.records > compactThreshold && q.records > 4*q.queue.Length(queue_pkg.All) {
+		if err := s.compact(queue); err != nil {
+			dLog.Errorf("  P (Disk Queue): failed to compact queue %s: %+v", queue, err)
+		}
+	}
+}
+
+func (s *DiskQueueProvider) Enqueue(queue string, element interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, true)
+	if err != nil {
+		return err
+	}
+	item := queue_pkg.QueueItem{
+		Id:      uuid.New().String(),
+		Element: element,
+	}
+	if err = s.journal(queue, q, journalRecord{Op: opEnqueue, Id: item.Id, Element: element}); err != nil {
+		return err
+	}
+	q.queue.Push(item)
+	return nil
+}
+
+func (s *DiskQueueProvider) Dequeue(queue string) (interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, false)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := q.queue.Front()
+	if !ok {
+		return nil, errors.New("queue is empty")
+	}
+	if err = s.journal(queue, q, journalRecord{Op: opRemove, Id: item.Id}); err != nil {
+		return nil, err
+	}
+	q.queue.Pop()
+	s.afterChange(queue, q)
+	return item.Element, nil
+}
+
+func (s *DiskQueueProvider) Peek(queue string) (interface{}, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, false)
+	if err != nil {
+		return nil, err
+	}
+	item, ok := q.queue.Front()
+	if !ok {
+		return nil, errors.New("queue is empty")
+	}
+	return item.Element, nil
+}
+
+func (s *DiskQueueProvider) Size(queue string) int {
+	return s.Length(queue, queue_pkg.Ready)
+}
+
+func (s *DiskQueueProvider) DequeueWithLease(queue string, visibilityTimeout time.Duration) (queue_pkg.QueueItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, false)
+	if err != nil {
+		return queue_pkg.QueueItem{}, err
+	}
+	front, ok := q.queue.Front()
+	if !ok {
+		return queue_pkg.QueueItem{}, errors.New("queue is empty")
+	}
+	leaseId := uuid.New().String()
+	expires := time.Now().UTC().Add(visibilityTimeout)
+	if err = s.journal(queue, q, journalRecord{Op: opLease, Id: front.Id, LeaseId: leaseId, Expires: expires}); err != nil {
+		return queue_pkg.QueueItem{}, err
+	}
+	item, _ := q.queue.LeaseItem(front.Id, leaseId, expires)
+	return item, nil
+}
+
+func (s *DiskQueueProvider) Ack(queue string, leaseId string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, false)
+	if err != nil {
+		return leaseNotFound(queue, leaseId)
+	}
+	item, ok := q.queue.Leased(leaseId)
+	if !ok {
+		return leaseNotFound(queue, leaseId)
+	}
+	if err = s.journal(queue, q, journalRecord{Op: opRemove, Id: item.Id}); err != nil {
+		return err
+	}
+	q.queue.Ack(leaseId)
+	s.afterChange(queue, q)
+	return nil
+}
+
+func (s *DiskQueueProvider) Nack(queue string, leaseId string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, false)
+	if err != nil {
+		return leaseNotFound(queue, leaseId)
+	}
+	if _, ok := q.queue.Leased(leaseId); !ok {
+		return leaseNotFound(queue, leaseId)
+	}
+	if err = s.journal(queue, q, journalRecord{Op: opRelease, LeaseId: leaseId}); err != nil {
+		return err
+	}
+	q.queue.Nack(leaseId)
+	s.afterChange(queue, q)
+	return nil
+}
+
+func (s *DiskQueueProvider) Extend(queue string, leaseId string, visibilityTimeout time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, false)
+	if err != nil {
+		return leaseNotFound(queue, leaseId)
+	}
+	if _, ok := q.queue.Leased(leaseId); !ok {
+		return leaseNotFound(queue, leaseId)
+	}
+	expires := time.Now().UTC().Add(visibilityTimeout)
+	if err = s.journal(queue, q, journalRecord{Op: opExtend, LeaseId: leaseId, Expires: expires}); err != nil {
+		return err
+	}
+	q.queue.Extend(leaseId, expires)
+	s.afterChange(queue, q)
+	return nil
+}
+
+func (s *DiskQueueProvider) Length(queue string, state queue_pkg.QueueItemState) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	q, err := s.getQueue(queue, false)
+	if err != nil {
+		return 0
+	}
+	return q.queue.Length(state)
+}
+
+func leaseNotFound(queue string, leaseId string) error {
+	return v1alpha2.NewCOAError(nil, fmt.Sprintf("lease '%s' is not found in queue %s, it may have expired", leaseId, queue), v1alpha2.NotFound)
+}
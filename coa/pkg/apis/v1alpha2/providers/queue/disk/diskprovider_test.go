@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package diskqueue
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	queue_pkg "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitWithMap(t *testing.T) {
+	queue := DiskQueueProvider{}
+	err := queue.InitWithMap(map[string]string{
+		"name":             "test",
+		"dir":              t.TempDir(),
+		"syncIntervalInMs": "100",
+	})
+	assert.Nil(t, err)
+	defer queue.Close()
+	assert.Equal(t, "test", queue.ID())
+	assert.Equal(t, 100, queue.Config.SyncIntervalInMs)
+}
+
+func TestInitWithMapBadSyncInterval(t *testing.T) {
+	queue := DiskQueueProvider{}
+	err := queue.InitWithMap(map[string]string{
+		"dir":              t.TempDir(),
+		"syncIntervalInMs": "soon",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestInitWithoutDir(t *testing.T) {
+	queue := DiskQueueProvider{}
+	err := queue.Init(DiskQueueProviderConfig{})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.MissingConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestRestart(t *testing.T) {
+	dir := t.TempDir()
+	queue := &DiskQueueProvider{}
+	err := queue.Init(DiskQueueProviderConfig{Dir: dir})
+	assert.Nil(t, err)
+	queue.Enqueue("site/1", "a")
+	queue.Enqueue("site/1", map[string]interface{}{"id": "b"})
+	queue.Enqueue("site/1", "c")
+	_, err = queue.Dequeue("site/1")
+	assert.Nil(t, err)
+	item, err := queue.DequeueWithLease("site/1", time.Minute)
+	assert.Nil(t, err)
+	assert.Nil(t, queue.Close())
+
+	queue = &DiskQueueProvider{}
+	err = queue.Init(DiskQueueProviderConfig{Dir: dir})
+	assert.Nil(t, err)
+	defer queue.Close()
+	assert.Equal(t, 1, queue.Length("site/1", queue_pkg.Ready))
+	assert.Equal(t, 1, queue.Length("site/1", queue_pkg.Leased))
+	element, err := queue.Peek("site/1")
+	assert.Nil(t, err)
+	assert.Equal(t, "c", element)
+
+	// the lease survives the restart
+	assert.Nil(t, queue.Nack("site/1", item.LeaseId))
+	item, err = queue.DequeueWithLease("site/1", time.Minute)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": "b"}, item.Element)
+	assert.Equal(t, 2, item.Deliveries)
+}
+
+func TestRestartWithBatchedSync(t *testing.T) {
+	dir := t.TempDir()
+	queue := &DiskQueueProvider{}
+	err := queue.Init(DiskQueueProviderConfig{Dir: dir, SyncIntervalInMs: 10})
+	assert.Nil(t, err)
+	queue.Enqueue("queue1", "a")
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, queue.Close())
+
+	queue = &DiskQueueProvider{}
+	err = queue.Init(DiskQueueProviderConfig{Dir: dir})
+	assert.Nil(t, err)
+	defer queue.Close()
+	assert.Equal(t, 1, queue.Size("queue1"))
+}
+
+func TestRestartWithPartialRecord(t *testing.T) {
+	dir := t.TempDir()
+	queue := &DiskQueueProvider{}
+	err := queue.Init(DiskQueueProviderConfig{Dir: dir})
+	assert.Nil(t, err)
+	queue.Enqueue("queue1", "a")
+	file := queue.queueFile("queue1")
+	assert.Nil(t, queue.Close())
+
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_WRONLY, 0600)
+	assert.Nil(t, err)
+	f.WriteString("{\"op\":\"enq")
+	f.Close()
+
+	queue = &DiskQueueProvider{}
+	err = queue.Init(DiskQueueProviderConfig{Dir: dir})
+	assert.Nil(t, err)
+	defer queue.Close()
+	assert.Equal(t, 1, queue.Size("queue1"))
+	queue.Enqueue("queue1", "b")
+	assert.Equal(t, 2, queue.Size("queue1"))
+}
+
+func TestCompact(t *testing.T) {
+	queue := &DiskQueueProvider{}
+	err := queue.Init(DiskQueueProviderConfig{Dir: t.TempDir()})
+	assert.Nil(t, err)
+	defer queue.Close()
+	for i := 0; i < compactThreshold; i++ {
+		queue.Enqueue("queue1", i)
+		queue.Dequeue("queue1")
+	}
+	queue.Enqueue("queue1", "last")
+	assert.True(t, queue.queues["queue1"].records < compactThreshold)
+	element, err := queue.Peek("queue1")
+	assert.Nil(t, err)
+	assert.Equal(t, "last", element)
+}
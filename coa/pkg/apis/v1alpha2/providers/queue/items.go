@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package queue
+
+import (
+	"sort"
+	"time"
+)
+
+// Queue holds the ready and leased elements of a single queue. It implements the lease bookkeeping shared by
+// the queue providers, and isn't safe for concurrent use: providers guard it with their own lock.
+type Queue struct {
+	ready  []QueueItem
+	leased map[string]QueueItem
+}
+
+func NewQueue() *Queue {
+	return &Queue{
+		ready:  make([]QueueItem, 0),
+		leased: make(map[string]QueueItem),
+	}
+}
+
+// Push appends a ready element to the queue
+func (q *Queue) Push(item QueueItem) {
+	q.ready = append(q.ready, item)
+}
+
+// Pop removes the first ready element
+func (q *Queue) Pop() (QueueItem, bool) {
+	if len(q.ready) == 0 {
+		return QueueItem{}, false
+	}
+	item := q.ready[0]
+	q.ready = q.ready[1:]
+	return item, true
+}
+
+// Front returns the first ready element without removing it
+func (q *Queue) Front() (QueueItem, bool) {
+	if len(q.ready) == 0 {
+		return QueueItem{}, false
+	}
+	return q.ready[0], true
+}
+
+// Lease leases the first ready element
+func (q *Queue) Lease(leaseId string, expires time.Time) (QueueItem, bool) {
+	if len(q.ready) == 0 {
+		return QueueItem{}, false
+	}
+	return q.LeaseItem(q.ready[0].Id, leaseId, expires)
+}
+
+// LeaseItem leases a ready element by its id
+func (q *Queue) LeaseItem(id string, leaseId string, expires time.Time) (QueueItem, bool) {
+	for i, item := range q.ready {
+		if item.Id == id {
+			q.ready = append(q.ready[:i], q.ready[i+1:]...)
+			item.LeaseId = leaseId
+			item.LeaseExpires = expires
+			item.Deliveries++
+			q.leased[leaseId] = item
+			return item, true
+		}
+	}
+	return QueueItem{}, false
+}
+
+// Leased returns a leased element by its lease id
+func (q *Queue) Leased(leaseId string) (QueueItem, bool) {
+	item, ok := q.leased[leaseId]
+	return item, ok
+}
+
+// Ack removes a leased element
+func (q *Queue) Ack(leaseId string) (QueueItem, bool) {
+	item, ok := q.leased[leaseId]
+	if ok {
+		delete(q.leased, leaseId)
+	}
+	return item, ok
+}
+
+// Nack returns a leased element to the front of the queue
+func (q *Queue) Nack(leaseId string) (QueueItem, bool) {
+	item, ok := q.leased[leaseId]
+	if !ok {
+		return item, false
+	}
+	delete(q.leased, leaseId)
+	item.LeaseId = ""
+	item.LeaseExpires = time.Time{}
+	q.ready = append([]QueueItem{item}, q.ready...)
+	return item, true
+}
+
+// Extend moves the expiry of a lease
+func (q *Queue) Extend(leaseId string, expires time.Time) (QueueItem, bool) {
+	item, ok := q.leased[leaseId]
+	if !ok {
+		return item, false
+	}
+	item.LeaseExpires = expires
+	q.leased[leaseId] = item
+	return item, true
+}
+
+// Remove removes an element in any state by its id
+func (q *Queue) Remove(id string) bool {
+	for i, item := range q.ready {
+		if item.Id == id {
+			q.ready = append(q.ready[:i], q.ready[i+1:]...)
+			return true
+		}
+	}
+	for leaseId, item := range q.leased {
+		if item.Id == id {
+			delete(q.leased, leaseId)
+			return true
+		}
+	}
+	return false
+}
+
+// Reclaim returns the elements whose leases have expired to the front of the queue, oldest lease first
+func (q *Queue) Reclaim(now time.Time) {
+	expired := make([]QueueItem, 0)
+	for leaseId, item := range q.leased {
+		if !item.LeaseExpires.After(now) {
+			delete(q.leased, leaseId)
+			expired = append(expired, item)
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		if expired[i].LeaseExpires.Equal(expired[j].LeaseExpires) {
+			return expired[i].Id < expired[j].Id
+		}
+		return expired[i].LeaseExpires.Before(expired[j].LeaseExpires)
+	})
+	for i := range expired {
+		expired[i].LeaseId = ""
+		expired[i].LeaseExpires = time.Time{}
+	}
+	q.ready = append(expired, q.ready...)
+}
+
+// Length returns the number of elements in the given state
+func (q *Queue) Length(state QueueItemState) int {
+	switch state {
+	case Ready:
+		return len(q.ready)
+	case Leased:
+		return len(q.leased)
+	}
+	return len(q.ready) + len(q.leased)
+}
+
+// Items returns all elements, ready elements first in queue order followed by leased elements
+func (q *Queue) Items() []QueueItem {
+	ret := make([]QueueItem, 0, q.Length(All))
+	ret = append(ret, q.ready...)
+	leased := make([]QueueItem, 0, len(q.leased))
+	for _, item := range q.leased {
+		leased = append(leased, item)
+	}
+	sort.Slice(leased, func(i, j int) bool {
+		return leased[i].LeaseExpires.Before(leased[j].LeaseExpires)
+	})
+	return append(ret, leased...)
+}
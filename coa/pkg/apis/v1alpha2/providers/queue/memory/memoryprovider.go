@@ -9,16 +9,20 @@ package memoryqueue
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"sync"
+	"time"
 
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	queue_pkg "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/queue"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
 	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	"github.com/google/uuid"
 )
 
 var mLog = logger.NewLogger("coa.runtime")
-var mLock sync.Mutex
 
 type MemoryQueueProviderConfig struct {
 	Name string `json:"name"`
@@ -34,8 +38,9 @@ func MemoryQueueProviderConfigFromMap(properties map[string]string) (MemoryQueue
 
 type MemoryQueueProvider struct {
 	Config  MemoryQueueProviderConfig
-	Data    map[string][]interface{}
+	Data    map[string]*queue_pkg.Queue
 	Context *contexts.ManagerContext
+	lock    sync.Mutex
 }
 
 func (s *MemoryQueueProvider) ID() string {
@@ -72,53 +77,109 @@ func (s *MemoryQueueProvider) Init(config providers.IProviderConfig) error {
 		return errors.New("expected MemoryQueueProviderConfig")
 	}
 	s.Config = stateConfig
-	s.Data = make(map[string][]interface{})
+	s.Data = make(map[string]*queue_pkg.Queue)
 	return nil
 }
 
-func (s *MemoryQueueProvider) Enqueue(queue string, data interface{}) error {
-	mLock.Lock()
-	defer mLock.Unlock()
-	if _, ok := s.Data[queue]; !ok {
-		s.Data[queue] = make([]interface{}, 0)
+func (s *MemoryQueueProvider) getQueue(queue string) *queue_pkg.Queue {
+	q, ok := s.Data[queue]
+	if !ok {
+		q = queue_pkg.NewQueue()
+		s.Data[queue] = q
 	}
-	s.Data[queue] = append(s.Data[queue], data)
+	q.Reclaim(time.Now().UTC())
+	return q
+}
+
+func (s *MemoryQueueProvider) Enqueue(queue string, data interface{}) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.getQueue(queue).Push(queue_pkg.QueueItem{
+		Id:      uuid.New().String(),
+		Element: data,
+	})
 	return nil
 }
+
 func (s *MemoryQueueProvider) Dequeue(queue string) (interface{}, error) {
-	mLock.Lock()
-	defer mLock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	if _, ok := s.Data[queue]; !ok {
 		return nil, errors.New("queue not found")
 	}
-	if len(s.Data[queue]) == 0 {
+	item, ok := s.getQueue(queue).Pop()
+	if !ok {
 		return nil, errors.New("queue is empty")
 	}
-	// ret := s.Data[queue][len(s.Data[queue])-1]
-	// s.Data[queue] = s.Data[queue][:len(s.Data[queue])-1]
-	ret := s.Data[queue][0]
-	s.Data[queue] = s.Data[queue][1:]
-	return ret, nil
+	return item.Element, nil
 }
 
 func (s *MemoryQueueProvider) Peek(queue string) (interface{}, error) {
-	mLock.Lock()
-	defer mLock.Unlock()
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	if _, ok := s.Data[queue]; !ok {
 		return nil, errors.New("queue not found")
 	}
-	if len(s.Data[queue]) == 0 {
+	item, ok := s.getQueue(queue).Front()
+	if !ok {
 		return nil, errors.New("queue is empty")
 	}
-	//return s.Data[queue][len(s.Data[queue])-1], nil
-	return s.Data[queue][0], nil
+	return item.Element, nil
 }
 
 func (s *MemoryQueueProvider) Size(queue string) int {
-	mLock.Lock()
-	defer mLock.Unlock()
+	return s.Length(queue, queue_pkg.Ready)
+}
+
+func (s *MemoryQueueProvider) DequeueWithLease(queue string, visibilityTimeout time.Duration) (queue_pkg.QueueItem, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.Data[queue]; !ok {
+		return queue_pkg.QueueItem{}, errors.New("queue not found")
+	}
+	item, ok := s.getQueue(queue).Lease(uuid.New().String(), time.Now().UTC().Add(visibilityTimeout))
+	if !ok {
+		return queue_pkg.QueueItem{}, errors.New("queue is empty")
+	}
+	return item, nil
+}
+
+func (s *MemoryQueueProvider) Ack(queue string, leaseId string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.getQueue(queue).Ack(leaseId); !ok {
+		return leaseNotFound(queue, leaseId)
+	}
+	return nil
+}
+
+func (s *MemoryQueueProvider) Nack(queue string, leaseId string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.getQueue(queue).Nack(leaseId); !ok {
+		return leaseNotFound(queue, leaseId)
+	}
+	return nil
+}
+
+func (s *MemoryQueueProvider) Extend(queue string, leaseId string, visibilityTimeout time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.getQueue(queue).Extend(leaseId, time.Now().UTC().Add(visibilityTimeout)); !ok {
+		return leaseNotFound(queue, leaseId)
+	}
+	return nil
+}
+
+func (s *MemoryQueueProvider) Length(queue string, state queue_pkg.QueueItemState) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	if _, ok := s.Data[queue]; !ok {
 		return 0
 	}
-	return len(s.Data[queue])
+	return s.getQueue(queue).Length(state)
+}
+
+func leaseNotFound(queue string, leaseId string) error {
+	return v1alpha2.NewCOAError(nil, fmt.Sprintf("lease '%s' is not found in queue %s, it may have expired", leaseId, queue), v1alpha2.NotFound)
 }
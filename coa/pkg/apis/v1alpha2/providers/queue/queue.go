@@ -6,9 +6,47 @@
 
 package queue
 
+import (
+	"time"
+)
+
+// QueueItemState is the state of an element in a queue
+type QueueItemState string
+
+const (
+	// Ready elements can be dequeued
+	Ready QueueItemState = "ready"
+	// Leased elements have been dequeued with a lease and are invisible until the lease is acked, nacked or expires
+	Leased QueueItemState = "leased"
+	// All counts elements in any state
+	All QueueItemState = ""
+)
+
+// QueueItem is an element delivered under a lease
+type QueueItem struct {
+	Id           string      `json:"id"`
+	Element      interface{} `json:"element"`
+	LeaseId      string      `json:"leaseId,omitempty"`
+	LeaseExpires time.Time   `json:"leaseExpires,omitempty"`
+	// Deliveries is the number of times the element has been leased, including the current lease
+	Deliveries int `json:"deliveries"`
+}
+
 type IQueueProvider interface {
 	Enqueue(queue string, element interface{}) error
 	Dequeue(queue string) (interface{}, error)
 	Peek(queue string) (interface{}, error)
+	// Size returns the number of ready elements in a queue
 	Size(queue string) int
+	// DequeueWithLease leases the first ready element of a queue. The element becomes ready again if the lease
+	// isn't acked before the visibility timeout.
+	DequeueWithLease(queue string, visibilityTimeout time.Duration) (QueueItem, error)
+	// Ack removes a leased element from a queue
+	Ack(queue string, leaseId string) error
+	// Nack returns a leased element to the front of a queue
+	Nack(queue string, leaseId string) error
+	// Extend resets the visibility timeout of a lease
+	Extend(queue string, leaseId string, visibilityTimeout time.Duration) error
+	// Length returns the number of elements in a queue in the given state
+	Length(queue string, state QueueItemState) int
 }
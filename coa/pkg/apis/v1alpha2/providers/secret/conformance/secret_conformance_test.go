@@ -7,8 +7,10 @@
 package conformance
 
 import (
+	"path/filepath"
 	"testing"
 
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/mock"
 	"github.com/stretchr/testify/assert"
 )
@@ -26,3 +28,16 @@ func TestConformanceSuite(t *testing.T) {
 	assert.Nil(t, err)
 	ConformanceSuite(t, provider)
 }
+
+func TestFileSecretConformanceSuite(t *testing.T) {
+	key, err := filesecret.GenerateKey()
+	assert.Nil(t, err)
+	t.Setenv(filesecret.DefaultKeyEnv, key)
+	provider := &filesecret.FileSecretProvider{}
+	err = provider.Init(filesecret.FileSecretProviderConfig{
+		FilePath: filepath.Join(t.TempDir(), "secrets.json"),
+	})
+	assert.Nil(t, err)
+	defer provider.Close()
+	ConformanceSuite(t, provider)
+}
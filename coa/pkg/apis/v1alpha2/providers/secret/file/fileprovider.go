@@ -0,0 +1,489 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package filesecret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/contexts"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+)
+
+var sLog = logger.NewLogger("coa.runtime")
+
+const (
+	// DefaultKeyEnv is the environment variable holding the encryption key when neither keyFile nor keyEnv is set
+	DefaultKeyEnv = "SYMPHONY_SECRET_KEY"
+
+	defaultReloadIntervalInMs = 1000
+	keySize                   = 32
+	fileVersion               = 1
+)
+
+type FileSecretProviderConfig struct {
+	Name string `json:"name"`
+	// FilePath is the encrypted secret file. It's created by the first write.
+	FilePath string `json:"filePath"`
+	// Key is the base64-encoded 256-bit encryption key, for callers that derive the key themselves. It isn't read
+	// from configuration, and takes precedence over KeyFile and KeyEnv.
+	Key string `json:"-"`
+	// KeyFile holds the base64-encoded 256-bit encryption key. It takes precedence over KeyEnv.
+	KeyFile string `json:"keyFile,omitempty"`
+	// KeyEnv is the environment variable holding the base64-encoded encryption key
+	KeyEnv string `json:"keyEnv,omitempty"`
+	// EnvPrefix is prepended to the names of the fallback environment variables
+	EnvPrefix          string `json:"envPrefix,omitempty"`
+	DisableEnvFallback bool   `json:"disableEnvFallback,omitempty"`
+	// Strict makes Get fail with Not Found for a missing secret instead of returning an empty value
+	Strict bool `json:"strict,omitempty"`
+	// ReloadIntervalInMs is how often the file is checked for changes. A negative value disables hot reload.
+	ReloadIntervalInMs int `json:"reloadIntervalInMs,omitempty"`
+}
+
+func FileSecretProviderConfigFromMap(properties map[string]string) (FileSecretProviderConfig, error) {
+	ret := FileSecretProviderConfig{}
+	if v, ok := properties["name"]; ok {
+		ret.Name = utils.ParseProperty(v)
+	}
+	if v, ok := properties["filePath"]; ok {
+		ret.FilePath = utils.ParseProperty(v)
+	}
+	if v, ok := properties["keyFile"]; ok {
+		ret.KeyFile = utils.ParseProperty(v)
+	}
+	if v, ok := properties["keyEnv"]; ok {
+		ret.KeyEnv = utils.ParseProperty(v)
+	}
+	if v, ok := properties["envPrefix"]; ok {
+		ret.EnvPrefix = utils.ParseProperty(v)
+	}
+	if v, ok := properties["disableEnvFallback"]; ok {
+		bVal, err := strconv.ParseBool(utils.ParseProperty(v))
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "'disableEnvFallback' must be a boolean", v1alpha2.BadConfig)
+		}
+		ret.DisableEnvFallback = bVal
+	}
+	if v, ok := properties["strict"]; ok {
+		bVal, err := strconv.ParseBool(utils.ParseProperty(v))
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "'strict' must be a boolean", v1alpha2.BadConfig)
+		}
+		ret.Strict = bVal
+	}
+	if v, ok := properties["reloadIntervalInMs"]; ok {
+		interval, err := strconv.Atoi(utils.ParseProperty(v))
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(err, "'reloadIntervalInMs' must be an integer", v1alpha2.BadConfig)
+		}
+		ret.ReloadIntervalInMs = interval
+	}
+	return ret, nil
+}
+
+// secretFile is the on-disk format. Data is the AES-GCM encrypted JSON of the secrets, keyed by object and field.
+type secretFile struct {
+	Version int    `json:"version"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+type FileSecretProvider struct {
+	Config  FileSecretProviderConfig
+	Context *contexts.ManagerContext
+	key     []byte
+	secrets map[string]map[string]string
+	// modTime and size identify the version of the file that was loaded last
+	modTime time.Time
+	size    int64
+	lock    sync.RWMutex
+	stop    chan struct{}
+}
+
+func (s *FileSecretProvider) ID() string {
+	return s.Config.Name
+}
+
+func (s *FileSecretProvider) SetContext(ctx *contexts.ManagerContext) {
+	s.Context = ctx
+}
+
+func (i *FileSecretProvider) InitWithMap(properties map[string]string) error {
+	config, err := FileSecretProviderConfigFromMap(properties)
+	if err != nil {
+		return err
+	}
+	return i.Init(config)
+}
+
+func toFileSecretProviderConfig(config providers.IProviderConfig) (FileSecretProviderConfig, error) {
+	// the key isn't serialized, so configs passed as structs are used as they are
+	switch c := config.(type) {
+	case FileSecretProviderConfig:
+		return c, nil
+	case *FileSecretProviderConfig:
+		return *c, nil
+	}
+	ret := FileSecretProviderConfig{}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return ret, err
+	}
+	err = json.Unmarshal(data, &ret)
+	return ret, err
+}
+
+func (s *FileSecretProvider) Init(config providers.IProviderConfig) error {
+	secretConfig, err := toFileSecretProviderConfig(config)
+	if err != nil {
+		return v1alpha2.NewCOAError(err, "provided config is not a valid file secret provider config", v1alpha2.BadConfig)
+	}
+	if secretConfig.FilePath == "" {
+		return v1alpha2.NewCOAError(nil, "'filePath' is required", v1alpha2.MissingConfig)
+	}
+	if secretConfig.ReloadIntervalInMs == 0 {
+		secretConfig.ReloadIntervalInMs = defaultReloadIntervalInMs
+	}
+	s.Close()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.Config = secretConfig
+	s.key, err = loadKey(secretConfig)
+	if err != nil {
+		return err
+	}
+	s.secrets = make(map[string]map[string]string)
+	s.modTime = time.Time{}
+	s.size = 0
+	if err = s.refresh(); err != nil {
+		return err
+	}
+	if s.Config.ReloadIntervalInMs > 0 {
+		s.stop = make(chan struct{})
+		go s.watch(time.Duration(s.Config.ReloadIntervalInMs)*time.Millisecond, s.stop)
+	}
+	return nil
+}
+
+// Close stops watching the secret file for changes
+func (s *FileSecretProvider) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+}
+
+// Get returns a secret from the file. A secret that isn't in the file is read from the environment variable
+// named by EnvVariableName, unless the fallback is disabled. A secret that isn't found anywhere is returned as
+// an empty value, or as a Not Found error in strict mode.
+func (s *FileSecretProvider) Get(object string, field string) (string, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if fields, ok := s.secrets[object]; ok {
+		if v, ok := fields[field]; ok {
+			return v, nil
+		}
+	}
+	if !s.Config.DisableEnvFallback {
+		if v, ok := os.LookupEnv(EnvVariableName(s.Config.EnvPrefix, object, field)); ok {
+			return v, nil
+		}
+	}
+	if s.Config.Strict {
+		return "", v1alpha2.NewCOAError(nil, fmt.Sprintf("secret %s of object %s is not found", field, object), v1alpha2.NotFound)
+	}
+	return "", nil
+}
+
+// Set stores a secret in the file
+func (s *FileSecretProvider) Set(object string, field string, value string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// pick up changes made by other writers before the file is rewritten
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	secrets := copySecrets(s.secrets)
+	if _, ok := secrets[object]; !ok {
+		secrets[object] = make(map[string]string)
+	}
+	secrets[object][field] = value
+	return s.save(s.key, secrets)
+}
+
+// Delete removes a secret from the file
+func (s *FileSecretProvider) Delete(object string, field string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.refresh(); err != nil {
+		return err
+	}
+	if _, ok := s.secrets[object][field]; !ok {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("secret %s of object %s is not found", field, object), v1alpha2.NotFound)
+	}
+	secrets := copySecrets(s.secrets)
+	delete(secrets[object], field)
+	if len(secrets[object]) == 0 {
+		delete(secrets, object)
+	}
+	return s.save(s.key, secrets)
+}
+
+// RotateKey re-encrypts the file with a new base64-encoded key. The caller is responsible for storing the new key
+// where the key is read from, such as the key file.
+func (s *FileSecretProvider) RotateKey(newKey string) error {
+	key, err := decodeKey(newKey)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err = s.refresh(); err != nil {
+		return err
+	}
+	return s.save(key, s.secrets)
+}
+
+// GenerateKey returns a new random base64-encoded 256-bit key
+func GenerateKey() (string, error) {
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// EnvVariableName returns the name of the environment variable a secret falls back to. The object and field are
+// joined with an underscore, upper-cased, and characters other than letters and digits are replaced with
+// underscores. For example, field password of object mqtt-broker falls back to MQTT_BROKER_PASSWORD.
+func EnvVariableName(prefix string, object string, field string) string {
+	name := strings.ToUpper(object + "_" + field)
+	return prefix + strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func (s *FileSecretProvider) watch(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastErr := ""
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.lock.Lock()
+			err := s.refresh()
+			s.lock.Unlock()
+			if err != nil {
+				// a failed reload is retried on the next tick, the error is only logged once
+				if err.Error() != lastErr {
+					sLog.Errorf("  P (File Secret): failed to reload secrets from %s, keeping the previous secrets: %+v", s.Config.FilePath, err)
+				}
+				lastErr = err.Error()
+			} else {
+				lastErr = ""
+			}
+		}
+	}
+}
+
+// refresh reloads the file if it has changed since it was loaded last. The key is read again as well, so that a
+// key rotated in the key file is picked up. The caller holds the lock.
+func (s *FileSecretProvider) refresh() error {
+	info, err := os.Stat(s.Config.FilePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			if !s.modTime.IsZero() {
+				sLog.Infof("  P (File Secret): secret file %s has been removed", s.Config.FilePath)
+			}
+			s.secrets = make(map[string]map[string]string)
+			s.modTime = time.Time{}
+			s.size = 0
+			return nil
+		}
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read secret file %s", s.Config.FilePath), v1alpha2.InternalError)
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+	key, err := loadKey(s.Config)
+	if err != nil {
+		return err
+	}
+	secrets, err := readSecretFile(s.Config.FilePath, key)
+	if err != nil {
+		return err
+	}
+	if !s.modTime.IsZero() {
+		sLog.Infof("  P (File Secret): reloaded secrets from %s", s.Config.FilePath)
+	}
+	s.key = key
+	s.secrets = secrets
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return nil
+}
+
+// save atomically replaces the file with the secrets encrypted by the key. The caller holds the lock.
+func (s *FileSecretProvider) save(key []byte, secrets map[string]map[string]string) error {
+	data, err := encrypt(key, secrets)
+	if err != nil {
+		return err
+	}
+	dir := filepath.Dir(s.Config.FilePath)
+	if err = os.MkdirAll(dir, 0700); err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to create directory %s", dir), v1alpha2.InternalError)
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(s.Config.FilePath)+"-*")
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to write secret file %s", s.Config.FilePath), v1alpha2.InternalError)
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cErr := tmp.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.Config.FilePath)
+	}
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to write secret file %s", s.Config.FilePath), v1alpha2.InternalError)
+	}
+	info, err := os.Stat(s.Config.FilePath)
+	if err != nil {
+		return v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read secret file %s", s.Config.FilePath), v1alpha2.InternalError)
+	}
+	s.key = key
+	s.secrets = secrets
+	s.modTime = info.ModTime()
+	s.size = info.Size()
+	return nil
+}
+
+func loadKey(config FileSecretProviderConfig) ([]byte, error) {
+	if config.Key != "" {
+		return decodeKey(config.Key)
+	}
+	if config.KeyFile != "" {
+		data, err := os.ReadFile(config.KeyFile)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read key file %s", config.KeyFile), v1alpha2.BadConfig)
+		}
+		return decodeKey(string(data))
+	}
+	keyEnv := config.KeyEnv
+	if keyEnv == "" {
+		keyEnv = DefaultKeyEnv
+	}
+	value := os.Getenv(keyEnv)
+	if value == "" {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("encryption key is not set, set 'keyFile' or environment variable %s", keyEnv), v1alpha2.MissingConfig)
+	}
+	return decodeKey(value)
+}
+
+func decodeKey(value string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value))
+	if err != nil || len(key) != keySize {
+		return nil, v1alpha2.NewCOAError(err, "encryption key must be a base64-encoded 256-bit key", v1alpha2.BadConfig)
+	}
+	return key, nil
+}
+
+func encrypt(key []byte, secrets map[string]map[string]string) ([]byte, error) {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return json.Marshal(secretFile{
+		Version: fileVersion,
+		Nonce:   nonce,
+		Data:    gcm.Seal(nil, nonce, plain, nil),
+	})
+}
+
+func readSecretFile(path string, key []byte) (map[string]map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to read secret file %s", path), v1alpha2.InternalError)
+	}
+	var file secretFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("secret file %s is corrupted", path), v1alpha2.InternalError)
+	}
+	if file.Version != fileVersion {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("secret file %s has unsupported version %d", path, file.Version), v1alpha2.InternalError)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("secret file %s is corrupted", path), v1alpha2.InternalError)
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("failed to decrypt secret file %s, the encryption key may be wrong", path), v1alpha2.InternalError)
+	}
+	secrets := make(map[string]map[string]string)
+	if err = json.Unmarshal(plain, &secrets); err != nil {
+		return nil, v1alpha2.NewCOAError(err, fmt.Sprintf("secret file %s is corrupted", path), v1alpha2.InternalError)
+	}
+	return secrets, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, "invalid encryption key", v1alpha2.BadConfig)
+	}
+	return cipher.NewGCM(block)
+}
+
+func copySecrets(secrets map[string]map[string]string) map[string]map[string]string {
+	ret := make(map[string]map[string]string, len(secrets))
+	for object, fields := range secrets {
+		ret[object] = make(map[string]string, len(fields))
+		for k, v := range fields {
+			ret[object][k] = v
+		}
+	}
+	return ret
+}
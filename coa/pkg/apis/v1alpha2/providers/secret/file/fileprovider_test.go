@@ -0,0 +1,305 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package filesecret
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeKeyFile(t *testing.T, dir string) string {
+	key, err := GenerateKey()
+	assert.Nil(t, err)
+	keyFile := filepath.Join(dir, "secret.key")
+	assert.Nil(t, os.WriteFile(keyFile, []byte(key+"\n"), 0600))
+	return keyFile
+}
+
+func TestInitWithMap(t *testing.T) {
+	dir := t.TempDir()
+	provider := FileSecretProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"name":               "test",
+		"filePath":           filepath.Join(dir, "secrets.json"),
+		"keyFile":            writeKeyFile(t, dir),
+		"envPrefix":          "SYMPHONY_",
+		"strict":             "true",
+		"reloadIntervalInMs": "-1",
+	})
+	assert.Nil(t, err)
+	defer provider.Close()
+	assert.Equal(t, "test", provider.ID())
+	assert.Equal(t, "SYMPHONY_", provider.Config.EnvPrefix)
+	assert.True(t, provider.Config.Strict)
+	assert.Nil(t, provider.stop)
+}
+
+func TestInitWithMapBadStrict(t *testing.T) {
+	provider := FileSecretProvider{}
+	err := provider.InitWithMap(map[string]string{
+		"filePath": filepath.Join(t.TempDir(), "secrets.json"),
+		"strict":   "maybe",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestInitWithoutFilePath(t *testing.T) {
+	provider := FileSecretProvider{}
+	err := provider.Init(FileSecretProviderConfig{})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.MissingConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestInitWithoutKey(t *testing.T) {
+	provider := FileSecretProvider{}
+	err := provider.Init(FileSecretProviderConfig{
+		FilePath: filepath.Join(t.TempDir(), "secrets.json"),
+		KeyEnv:   "FILE_SECRET_TEST_UNSET_KEY",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.MissingConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestInitWithBadKey(t *testing.T) {
+	t.Setenv("FILE_SECRET_TEST_KEY", "dG9vIHNob3J0")
+	provider := FileSecretProvider{}
+	err := provider.Init(FileSecretProviderConfig{
+		FilePath: filepath.Join(t.TempDir(), "secrets.json"),
+		KeyEnv:   "FILE_SECRET_TEST_KEY",
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
+
+func TestSetAndGet(t *testing.T) {
+	key, err := GenerateKey()
+	assert.Nil(t, err)
+	t.Setenv(DefaultKeyEnv, key)
+	file := filepath.Join(t.TempDir(), "secrets", "secrets.json")
+	provider := FileSecretProvider{}
+	err = provider.Init(FileSecretProviderConfig{FilePath: file})
+	assert.Nil(t, err)
+	defer provider.Close()
+	assert.Nil(t, provider.Set("mqtt-broker", "password", "s3cr3t"))
+	value, err := provider.Get("mqtt-broker", "password")
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", value)
+
+	// secrets are encrypted at rest
+	data, err := os.ReadFile(file)
+	assert.Nil(t, err)
+	assert.False(t, strings.Contains(string(data), "s3cr3t"))
+	assert.False(t, strings.Contains(string(data), "mqtt-broker"))
+	info, err := os.Stat(file)
+	assert.Nil(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// secrets survive a restart
+	other := FileSecretProvider{}
+	err = other.Init(FileSecretProviderConfig{FilePath: file})
+	assert.Nil(t, err)
+	defer other.Close()
+	value, err = other.Get("mqtt-broker", "password")
+	assert.Nil(t, err)
+	assert.Equal(t, "s3cr3t", value)
+}
+
+func TestGetWithWrongKey(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secrets.json")
+	provider := FileSecretProvider{}
+	err := provider.Init(FileSecretProviderConfig{FilePath: file, KeyFile: writeKeyFile(t, dir)})
+	assert.Nil(t, err)
+	defer provider.Close()
+	assert.Nil(t, provider.Set("object", "field", "value"))
+
+	other := FileSecretProvider{}
+	err = other.Init(FileSecretProviderConfig{FilePath: file, KeyFile: writeKeyFile(t, t.TempDir())})
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.InternalError, err.(v1alpha2.COAError).State)
+}
+
+func TestInitWithKey(t *testing.T) {
+	key, err := GenerateKey()
+	assert.Nil(t, err)
+	file := filepath.Join(t.TempDir(), "secrets.json")
+	provider := FileSecretProvider{}
+	err = provider.Init(FileSecretProviderConfig{FilePath: file, Key: key, KeyEnv: "FILE_SECRET_TEST_UNSET_KEY"})
+	assert.Nil(t, err)
+	defer provider.Close()
+	assert.Nil(t, provider.Set("object", "field", "value"))
+
+	other := FileSecretProvider{}
+	err = other.Init(FileSecretProviderConfig{FilePath: file, Key: key})
+	assert.Nil(t, err)
+	defer other.Close()
+	value, err := other.Get("object", "field")
+	assert.Nil(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func TestEnvFallback(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MQTT_BROKER_PASSWORD", "from-env")
+	t.Setenv("SYMPHONY_MQTT_BROKER_USER", "admin")
+	provider := FileSecretProvider{}
+	err := provider.Init(FileSecretProviderConfig{
+		FilePath: filepath.Join(dir, "secrets.json"),
+		KeyFile:  writeKeyFile(t, dir),
+	})
+	assert.Nil(t, err)
+	defer provider.Close()
+	value, err := provider.Get("mqtt-broker", "password")
+	assert.Nil(t, err)
+	assert.Equal(t, "from-env", value)
+
+	// the file takes precedence over the environment
+	assert.Nil(t, provider.Set("mqtt-broker", "password", "from-file"))
+	value, err = provider.Get("mqtt-broker", "password")
+	assert.Nil(t, err)
+	assert.Equal(t, "from-file", value)
+
+	provider.Config.EnvPrefix = "SYMPHONY_"
+	value, err = provider.Get("mqtt-broker", "user")
+	assert.Nil(t, err)
+	assert.Equal(t, "admin", value)
+
+	provider.Config.DisableEnvFallback = true
+	value, err = provider.Get("mqtt-broker", "user")
+	assert.Nil(t, err)
+	assert.Equal(t, "", value)
+}
+
+func TestEnvVariableName(t *testing.T) {
+	assert.Equal(t, "MQTT_BROKER_PASSWORD", EnvVariableName("", "mqtt-broker", "password"))
+	assert.Equal(t, "APP_DB_CONN_STRING", EnvVariableName("APP_", "db", "conn.string"))
+}
+
+func TestStrict(t *testing.T) {
+	dir := t.TempDir()
+	provider := FileSecretProvider{}
+	err := provider.Init(FileSecretProviderConfig{
+		FilePath: filepath.Join(dir, "secrets.json"),
+		KeyFile:  writeKeyFile(t, dir),
+		Strict:   true,
+	})
+	assert.Nil(t, err)
+	defer provider.Close()
+	_, err = provider.Get("fake_object", "fake_key")
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestDelete(t *testing.T) {
+	dir := t.TempDir()
+	provider := FileSecretProvider{}
+	err := provider.Init(FileSecretProviderConfig{
+		FilePath: filepath.Join(dir, "secrets.json"),
+		KeyFile:  writeKeyFile(t, dir),
+		Strict:   true,
+	})
+	assert.Nil(t, err)
+	defer provider.Close()
+	assert.Nil(t, provider.Set("object", "a", "1"))
+	assert.Nil(t, provider.Set("object", "b", "2"))
+	assert.Nil(t, provider.Delete("object", "a"))
+	_, err = provider.Get("object", "a")
+	assert.True(t, v1alpha2.IsNotFound(err))
+	value, err := provider.Get("object", "b")
+	assert.Nil(t, err)
+	assert.Equal(t, "2", value)
+	err = provider.Delete("object", "a")
+	assert.True(t, v1alpha2.IsNotFound(err))
+}
+
+func TestHotReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secrets.json")
+	keyFile := writeKeyFile(t, dir)
+	reader := FileSecretProvider{}
+	err := reader.Init(FileSecretProviderConfig{FilePath: file, KeyFile: keyFile, ReloadIntervalInMs: 10})
+	assert.Nil(t, err)
+	defer reader.Close()
+	writer := FileSecretProvider{}
+	err = writer.Init(FileSecretProviderConfig{FilePath: file, KeyFile: keyFile, ReloadIntervalInMs: -1})
+	assert.Nil(t, err)
+
+	assert.Nil(t, writer.Set("object", "field", "v1"))
+	assert.Eventually(t, func() bool {
+		value, _ := reader.Get("object", "field")
+		return value == "v1"
+	}, time.Second, 10*time.Millisecond)
+
+	assert.Nil(t, writer.Set("object", "field", "v2"))
+	assert.Eventually(t, func() bool {
+		value, _ := reader.Get("object", "field")
+		return value == "v2"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSetKeepsChangesOfOtherWriters(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secrets.json")
+	keyFile := writeKeyFile(t, dir)
+	first := FileSecretProvider{}
+	err := first.Init(FileSecretProviderConfig{FilePath: file, KeyFile: keyFile, ReloadIntervalInMs: -1})
+	assert.Nil(t, err)
+	second := FileSecretProvider{}
+	err = second.Init(FileSecretProviderConfig{FilePath: file, KeyFile: keyFile, ReloadIntervalInMs: -1})
+	assert.Nil(t, err)
+
+	assert.Nil(t, first.Set("object", "a", "1"))
+	assert.Nil(t, second.Set("object", "b", "2"))
+	value, err := second.Get("object", "a")
+	assert.Nil(t, err)
+	assert.Equal(t, "1", value)
+}
+
+func TestRotateKey(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "secrets.json")
+	keyFile := writeKeyFile(t, dir)
+	reader := FileSecretProvider{}
+	err := reader.Init(FileSecretProviderConfig{FilePath: file, KeyFile: keyFile, ReloadIntervalInMs: 10})
+	assert.Nil(t, err)
+	defer reader.Close()
+	writer := FileSecretProvider{}
+	err = writer.Init(FileSecretProviderConfig{FilePath: file, KeyFile: keyFile, ReloadIntervalInMs: -1})
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Set("object", "field", "v1"))
+	assert.Eventually(t, func() bool {
+		value, _ := reader.Get("object", "field")
+		return value == "v1"
+	}, time.Second, 10*time.Millisecond)
+
+	newKey, err := GenerateKey()
+	assert.Nil(t, err)
+	assert.Nil(t, writer.RotateKey(newKey))
+	assert.Nil(t, writer.Set("object", "field", "v2"))
+
+	// the reader can't decrypt the file until the key file is updated, and keeps the previous secrets meanwhile
+	time.Sleep(50 * time.Millisecond)
+	value, err := reader.Get("object", "field")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", value)
+
+	assert.Nil(t, os.WriteFile(keyFile, []byte(newKey), 0600))
+	assert.Eventually(t, func() bool {
+		value, _ := reader.Get("object", "field")
+		return value == "v2"
+	}, time.Second, 10*time.Millisecond)
+
+	err = writer.RotateKey("not a key")
+	assert.NotNil(t, err)
+	assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+}
@@ -14,3 +14,9 @@ type ISecretProvider interface {
 	Init(config providers.IProviderConfig) error
 	Get(object string, field string) (string, error)
 }
+
+// ISecretWriter is implemented by secret providers that can store secrets
+type ISecretWriter interface {
+	Set(object string, field string, value string) error
+	Delete(object string, field string) error
+}
@@ -113,6 +113,19 @@ func (s *MemoryStateProvider) Upsert(ctx context.Context, entry states.UpsertReq
 		sLog.Errorf("  P (Memory State): failed to upsert %s states: %+v, traceId: %s", entry.Value.ID, err, span.SpanContext().TraceID().String())
 		return "", err
 	}
+	if entry.ETag != nil {
+		// the entry is only written if it hasn't changed since it was read with the given ETag, an empty ETag
+		// means it didn't exist
+		current := ""
+		if existing, ok := list[entry.Value.ID].(states.StateEntry); ok {
+			current = existing.ETag
+		}
+		if current != *entry.ETag {
+			err = v1alpha2.NewCOAError(nil, fmt.Sprintf("entry '%s' has changed, its ETag is '%s' instead of '%s'", entry.Value.ID, current, *entry.ETag), v1alpha2.Conflict)
+			sLog.Errorf("  P (Memory State): failed to upsert %s state: %+v, traceId: %s", entry.Value.ID, err, span.SpanContext().TraceID().String())
+			return "", err
+		}
+	}
 	if entry.Options.UpdateStateOnly {
 		existing, ok := list[entry.Value.ID]
 		if !ok {
@@ -74,6 +74,41 @@ func TestUpSert(t *testing.T) {
 	assert.Equal(t, "123", id)
 }
 
+func TestUpSertWithETag(t *testing.T) {
+	provider := MemoryStateProvider{}
+	err := provider.Init(MemoryStateProvider{})
+	assert.Nil(t, err)
+	upsert := func(etag string, value int) error {
+		_, err := provider.Upsert(context.Background(), states.UpsertRequest{
+			Value: states.StateEntry{
+				ID:   "123",
+				Body: TestPayload{Name: "Random name", Value: value},
+				ETag: etag,
+			},
+			ETag: &etag,
+		})
+		return err
+	}
+
+	// an empty ETag only creates the entry
+	assert.Nil(t, upsert("", 1))
+	err = upsert("", 2)
+	assert.NotNil(t, err)
+	assert.True(t, v1alpha2.IsConflict(err))
+
+	entry, err := provider.Get(context.Background(), states.GetRequest{ID: "123"})
+	assert.Nil(t, err)
+	assert.Nil(t, upsert(entry.ETag, 2))
+	// the entry has changed since it was read
+	err = upsert(entry.ETag, 3)
+	assert.NotNil(t, err)
+	assert.True(t, v1alpha2.IsConflict(err))
+
+	entry, err = provider.Get(context.Background(), states.GetRequest{ID: "123"})
+	assert.Nil(t, err)
+	assert.Equal(t, "2", entry.ETag)
+}
+
 func TestUpSertWithNamespace(t *testing.T) {
 	provider := MemoryStateProvider{}
 	err := provider.Init(MemoryStateProvider{})
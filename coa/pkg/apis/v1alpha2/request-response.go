@@ -7,6 +7,7 @@
 package v1alpha2
 
 import (
+	"bufio"
 	"context"
 	"fmt"
 )
@@ -62,6 +63,9 @@ type COAResponse struct {
 	State       State             `json:"state"`
 	Metadata    map[string]string `json:"metadata,omitempty"`
 	RedirectUri string            `json:"redirectUri,omitempty"`
+	// BodyStream, when set, is used instead of Body to write a long-lived response. The binding calls it after
+	// the handler returns; it should write and flush until the client goes away.
+	BodyStream func(w *bufio.Writer) `json:"-"`
 }
 
 func (c COAResponse) String() string {
@@ -87,6 +87,7 @@ const (
 	HelmChartLoadFailed          State = 10020
 	HelmChartApplyFailed         State = 10021
 	HelmChartUninstallFailed     State = 10022
+	HealthCheckFailed            State = 10023
 
 	// instance controller errors
 	SolutionGetFailed             State = 11000
@@ -201,6 +202,8 @@ func (s State) String() string {
 		return "Helm Chart Apply Failed"
 	case HelmChartUninstallFailed:
 		return "Helm Chart Uninstall Failed"
+	case HealthCheckFailed:
+		return "Health Check Failed"
 	case TargetCandidatesNotFound:
 		return "Target does not exist"
 	case SolutionGetFailed:
@@ -236,4 +239,11 @@ const (
 	StatusOutput            = "__status"
 	ErrorOutput             = "__error"
 	StateOutput             = "__state"
+	// AuthenticatedUser is the key of the user authenticated by the http binding in request contexts
+	AuthenticatedUser = "__user"
+	// AuthenticatedRoles is the key of the roles of the authenticated user in request contexts, when RBAC is enabled
+	AuthenticatedRoles = "__roles"
+	// AuthenticatedServiceAccount is set in request contexts when the http binding authenticates the user with the
+	// kubernetes API server, which only accepts the service accounts of Symphony
+	AuthenticatedServiceAccount = "__serviceAccount"
 )
@@ -63,6 +63,7 @@ func TestStateString(t *testing.T) {
 		HelmChartLoadFailed:           "Helm Chart Load Failed",
 		HelmChartApplyFailed:          "Helm Chart Apply Failed",
 		HelmChartUninstallFailed:      "Helm Chart Uninstall Failed",
+		HealthCheckFailed:             "Health Check Failed",
 		SolutionGetFailed:             "Solution does not exist",
 		TargetCandidatesNotFound:      "Target does not exist",
 		TargetListGetFailed:           "Target list does not exist",
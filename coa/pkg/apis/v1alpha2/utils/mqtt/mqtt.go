@@ -0,0 +1,308 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package mqtt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs/autogen"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/certs/localfile"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret"
+	filesecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/file"
+	mocksecret "github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/providers/secret/mock"
+	"github.com/eclipse-symphony/symphony/coa/pkg/logger"
+	gmqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+var log = logger.NewLogger("coa.runtime")
+
+// CertProviderConfig selects the cert provider that supplies the client certificate for mutual TLS.
+type CertProviderConfig struct {
+	Type   string                    `json:"type"`
+	Config providers.IProviderConfig `json:"config"`
+}
+
+// SecretProviderConfig selects the secret provider that resolves MQTT credentials.
+type SecretProviderConfig struct {
+	Type   string                    `json:"type"`
+	Config providers.IProviderConfig `json:"config"`
+}
+
+// ConnectionConfig contains the transport settings shared by MQTT clients. It's meant to be embedded
+// in binding and provider configs so that its fields appear at the top level of those configs.
+type ConnectionConfig struct {
+	UseTLS             bool                  `json:"useTLS,omitempty"`
+	CACertPath         string                `json:"caCertPath,omitempty"`
+	ClientCertPath     string                `json:"clientCertPath,omitempty"`
+	ClientKeyPath      string                `json:"clientKeyPath,omitempty"`
+	CertProvider       *CertProviderConfig   `json:"certProvider,omitempty"`
+	ServerName         string                `json:"serverName,omitempty"`
+	InsecureSkipVerify bool                  `json:"insecureSkipVerify,omitempty"`
+	Username           string                `json:"username,omitempty"`
+	Password           string                `json:"password,omitempty"`
+	UsernameSecret     string                `json:"usernameSecret,omitempty"`
+	PasswordSecret     string                `json:"passwordSecret,omitempty"`
+	SecretProvider     *SecretProviderConfig `json:"secretProvider,omitempty"`
+	QoS                int                   `json:"qos,omitempty"`
+	CleanSession       *bool                 `json:"cleanSession,omitempty"`
+}
+
+// ConnectionConfigFromMap reads the transport settings from a provider property map. Cert providers and
+// secret providers can't be expressed as plain properties, so they are only available in typed configs.
+func ConnectionConfigFromMap(properties map[string]string) (ConnectionConfig, error) {
+	ret := ConnectionConfig{}
+	var err error
+	if ret.UseTLS, err = boolProperty(properties, "useTLS"); err != nil {
+		return ret, err
+	}
+	if ret.InsecureSkipVerify, err = boolProperty(properties, "insecureSkipVerify"); err != nil {
+		return ret, err
+	}
+	if v, ok := properties["cleanSession"]; ok {
+		cleanSession, err := strconv.ParseBool(v)
+		if err != nil {
+			return ret, v1alpha2.NewCOAError(nil, "'cleanSession' is not a boolean in MQTT config", v1alpha2.BadConfig)
+		}
+		ret.CleanSession = &cleanSession
+	}
+	if v, ok := properties["qos"]; ok {
+		if ret.QoS, err = strconv.Atoi(v); err != nil {
+			return ret, v1alpha2.NewCOAError(nil, "'qos' is not an integer in MQTT config", v1alpha2.BadConfig)
+		}
+	}
+	ret.CACertPath = properties["caCertPath"]
+	ret.ClientCertPath = properties["clientCertPath"]
+	ret.ClientKeyPath = properties["clientKeyPath"]
+	ret.ServerName = properties["serverName"]
+	ret.Username = properties["username"]
+	ret.Password = properties["password"]
+	ret.UsernameSecret = properties["usernameSecret"]
+	ret.PasswordSecret = properties["passwordSecret"]
+	return ret, ret.Validate()
+}
+
+func boolProperty(properties map[string]string, key string) (bool, error) {
+	v, ok := properties[key]
+	if !ok {
+		return false, nil
+	}
+	ret, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, v1alpha2.NewCOAError(nil, fmt.Sprintf("'%s' is not a boolean in MQTT config", key), v1alpha2.BadConfig)
+	}
+	return ret, nil
+}
+
+// Validate checks the transport settings for consistency.
+func (c ConnectionConfig) Validate() error {
+	if c.QoS < 0 || c.QoS > 2 {
+		return v1alpha2.NewCOAError(nil, fmt.Sprintf("'qos' must be 0, 1 or 2, but is %d", c.QoS), v1alpha2.BadConfig)
+	}
+	if (c.ClientCertPath == "") != (c.ClientKeyPath == "") {
+		return v1alpha2.NewCOAError(nil, "'clientCertPath' and 'clientKeyPath' must be set together", v1alpha2.BadConfig)
+	}
+	if c.ClientCertPath != "" && c.CertProvider != nil {
+		return v1alpha2.NewCOAError(nil, "client certificate files and 'certProvider' can't be used together", v1alpha2.BadConfig)
+	}
+	if (c.UsernameSecret != "" || c.PasswordSecret != "") && c.SecretProvider == nil {
+		return v1alpha2.NewCOAError(nil, "'secretProvider' is required when credentials are read from secrets", v1alpha2.BadConfig)
+	}
+	return nil
+}
+
+// GetQoS returns the QoS level used to publish and subscribe.
+func (c ConnectionConfig) GetQoS() byte {
+	return byte(c.QoS)
+}
+
+// NewClientOptions creates paho client options for the given broker with TLS, credentials and session
+// settings applied. cleanSession is used when the config doesn't set the session behavior explicitly.
+func NewClientOptions(brokerAddress string, clientID string, config ConnectionConfig, cleanSession bool) (*gmqtt.ClientOptions, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	opts := gmqtt.NewClientOptions().SetClientID(clientID)
+	if config.CleanSession != nil {
+		cleanSession = *config.CleanSession
+	}
+	opts.SetCleanSession(cleanSession)
+
+	useTLS := config.UseTLS || isTLSScheme(brokerAddress)
+	if useTLS {
+		tlsConfig, err := config.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+		brokerAddress = toTLSScheme(brokerAddress)
+	}
+	opts.AddBroker(brokerAddress)
+
+	username, password, err := config.credentials()
+	if err != nil {
+		return nil, err
+	}
+	if username != "" {
+		opts.SetUsername(username)
+	}
+	if password != "" {
+		opts.SetPassword(password)
+	}
+	return opts, nil
+}
+
+func (c ConnectionConfig) tlsConfig() (*tls.Config, error) {
+	ret := &tls.Config{
+		ServerName:         c.ServerName,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if c.CACertPath != "" {
+		data, err := os.ReadFile(c.CACertPath)
+		if err != nil {
+			log.Errorf("failed to read MQTT CA certificate: %+v", err)
+			return nil, v1alpha2.NewCOAError(err, "failed to read CA certificate", v1alpha2.BadConfig)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, v1alpha2.NewCOAError(nil, "CA certificate doesn't contain any PEM certificates", v1alpha2.BadConfig)
+		}
+		ret.RootCAs = pool
+	}
+
+	var certData, keyData []byte
+	if c.ClientCertPath != "" {
+		var err error
+		if certData, err = os.ReadFile(c.ClientCertPath); err != nil {
+			log.Errorf("failed to read MQTT client certificate: %+v", err)
+			return nil, v1alpha2.NewCOAError(err, "failed to read client certificate", v1alpha2.BadConfig)
+		}
+		if keyData, err = os.ReadFile(c.ClientKeyPath); err != nil {
+			log.Errorf("failed to read MQTT client key: %+v", err)
+			return nil, v1alpha2.NewCOAError(err, "failed to read client key", v1alpha2.BadConfig)
+		}
+	} else if c.CertProvider != nil {
+		provider, err := newCertProvider(*c.CertProvider)
+		if err != nil {
+			return nil, err
+		}
+		if certData, keyData, err = provider.GetCert(c.ServerName); err != nil {
+			log.Errorf("failed to get MQTT client certificate: %+v", err)
+			return nil, err
+		}
+	}
+	if certData != nil {
+		cert, err := tls.X509KeyPair(certData, keyData)
+		if err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to load client certificate", v1alpha2.BadConfig)
+		}
+		ret.Certificates = []tls.Certificate{cert}
+	}
+	return ret, nil
+}
+
+func newCertProvider(config CertProviderConfig) (certs.ICertProvider, error) {
+	var provider certs.ICertProvider
+	switch config.Type {
+	case "certs.autogen":
+		provider = &autogen.AutoGenCertProvider{}
+	case "certs.localfile":
+		provider = &localfile.LocalCertFileProvider{}
+	default:
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("cert provider type '%s' is not recognized", config.Type), v1alpha2.BadConfig)
+	}
+	if err := provider.Init(config.Config); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// credentials returns the username and password, reading them from the secret provider when secret
+// references in the form of "<object>/<field>" are given.
+func (c ConnectionConfig) credentials() (string, string, error) {
+	username := c.Username
+	password := c.Password
+	if c.UsernameSecret == "" && c.PasswordSecret == "" {
+		return username, password, nil
+	}
+	provider, err := newSecretProvider(*c.SecretProvider)
+	if err != nil {
+		return "", "", err
+	}
+	// credentials are only read once, so providers that watch their secrets (like the file provider) are
+	// closed right away instead of watching for the lifetime of the process
+	if closer, ok := provider.(interface{ Close() }); ok {
+		defer closer.Close()
+	}
+	if c.UsernameSecret != "" {
+		if username, err = readSecret(provider, c.UsernameSecret); err != nil {
+			return "", "", err
+		}
+	}
+	if c.PasswordSecret != "" {
+		if password, err = readSecret(provider, c.PasswordSecret); err != nil {
+			return "", "", err
+		}
+	}
+	return username, password, nil
+}
+
+func newSecretProvider(config SecretProviderConfig) (secret.ISecretProvider, error) {
+	var provider secret.ISecretProvider
+	switch config.Type {
+	case "providers.secret.mock":
+		provider = &mocksecret.MockSecretProvider{}
+	case "providers.secret.file":
+		provider = &filesecret.FileSecretProvider{}
+	default:
+		return nil, v1alpha2.NewCOAError(nil, fmt.Sprintf("secret provider type '%s' is not recognized", config.Type), v1alpha2.BadConfig)
+	}
+	if err := provider.Init(config.Config); err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+func readSecret(provider secret.ISecretProvider, reference string) (string, error) {
+	parts := strings.SplitN(reference, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", v1alpha2.NewCOAError(nil, fmt.Sprintf("secret reference '%s' is not in the form of <object>/<field>", reference), v1alpha2.BadConfig)
+	}
+	ret, err := provider.Get(parts[0], parts[1])
+	if err != nil {
+		log.Errorf("failed to read MQTT credential from secret '%s': %+v", reference, err)
+		return "", err
+	}
+	return ret, nil
+}
+
+func isTLSScheme(address string) bool {
+	for _, scheme := range []string{"ssl://", "tls://", "tcps://", "mqtts://"} {
+		if strings.HasPrefix(address, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// toTLSScheme switches plain tcp:// and mqtt:// addresses to ssl:// so that paho uses the TLS config
+func toTLSScheme(address string) string {
+	for _, scheme := range []string{"tcp://", "mqtt://"} {
+		if strings.HasPrefix(address, scheme) {
+			return "ssl://" + strings.TrimPrefix(address, scheme)
+		}
+	}
+	return address
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package mqtt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2/utils/mqtt/mqtttest"
+	gmqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert     *x509.Certificate
+	key      *ecdsa.PrivateKey
+	certPath string
+	keyPath  string
+}
+
+// newTestCert creates a certificate signed by the given CA, or a self-signed CA if ca is nil.
+func newTestCert(t *testing.T, name string, ca *testCert, usage x509.ExtKeyUsage) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+	parent, signer := template, key
+	if ca == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{usage}
+		parent, signer = ca.cert, ca.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	assert.Nil(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	assert.Nil(t, err)
+
+	folder := t.TempDir()
+	ret := &testCert{
+		cert:     cert,
+		key:      key,
+		certPath: filepath.Join(folder, name+".crt"),
+		keyPath:  filepath.Join(folder, name+".key"),
+	}
+	err = os.WriteFile(ret.certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	assert.Nil(t, err)
+	err = os.WriteFile(ret.keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)
+	assert.Nil(t, err)
+	return ret
+}
+
+func startTLSBroker(t *testing.T, requireClientCert bool) (*mqtttest.Broker, *testCert, *testCert) {
+	ca := newTestCert(t, "ca", nil, 0)
+	server := newTestCert(t, "server", ca, x509.ExtKeyUsageServerAuth)
+	client := newTestCert(t, "client", ca, x509.ExtKeyUsageClientAuth)
+	serverCert, err := tls.LoadX509KeyPair(server.certPath, server.keyPath)
+	assert.Nil(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    pool,
+	}
+	if requireClientCert {
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{TLSConfig: tlsConfig})
+	assert.Nil(t, err)
+	t.Cleanup(func() { broker.Close() })
+	return broker, ca, client
+}
+
+func connect(t *testing.T, address string, config ConnectionConfig) error {
+	opts, err := NewClientOptions(address, "test-client", config, true)
+	if err != nil {
+		return err
+	}
+	opts.SetConnectTimeout(2 * time.Second)
+	client := gmqtt.NewClient(opts)
+	token := client.Connect()
+	token.Wait()
+	if token.Error() == nil {
+		client.Disconnect(100)
+	}
+	return token.Error()
+}
+
+func TestConnectionConfigFromMap(t *testing.T) {
+	config, err := ConnectionConfigFromMap(map[string]string{
+		"useTLS":       "true",
+		"caCertPath":   "ca.crt",
+		"qos":          "1",
+		"cleanSession": "false",
+		"username":     "admin",
+		"password":     "secret",
+	})
+	assert.Nil(t, err)
+	assert.True(t, config.UseTLS)
+	assert.Equal(t, "ca.crt", config.CACertPath)
+	assert.Equal(t, byte(1), config.GetQoS())
+	assert.False(t, *config.CleanSession)
+	assert.Equal(t, "admin", config.Username)
+	assert.Equal(t, "secret", config.Password)
+}
+
+func TestConnectionConfigFromMapInvalid(t *testing.T) {
+	for _, properties := range []map[string]string{
+		{"useTLS": "maybe"},
+		{"cleanSession": "sometimes"},
+		{"qos": "one"},
+		{"qos": "3"},
+		{"clientCertPath": "client.crt"},
+		{"passwordSecret": "mqtt/password"},
+	} {
+		_, err := ConnectionConfigFromMap(properties)
+		assert.NotNil(t, err)
+		assert.Equal(t, v1alpha2.BadConfig, err.(v1alpha2.COAError).State)
+	}
+}
+
+func TestNewClientOptions(t *testing.T) {
+	cleanSession := false
+	opts, err := NewClientOptions("tcp://127.0.0.1:1883", "client", ConnectionConfig{
+		UseTLS:       true,
+		CleanSession: &cleanSession,
+		Username:     "admin",
+		Password:     "secret",
+	}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "ssl", opts.Servers[0].Scheme)
+	assert.NotNil(t, opts.TLSConfig)
+	assert.False(t, opts.CleanSession)
+	assert.Equal(t, "admin", opts.Username)
+	assert.Equal(t, "secret", opts.Password)
+
+	opts, err = NewClientOptions("tcp://127.0.0.1:1883", "client", ConnectionConfig{}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "tcp", opts.Servers[0].Scheme)
+	assert.True(t, opts.CleanSession)
+}
+
+func TestCredentialsFromSecretProvider(t *testing.T) {
+	opts, err := NewClientOptions("tcp://127.0.0.1:1883", "client", ConnectionConfig{
+		UsernameSecret: "mqtt/username",
+		PasswordSecret: "mqtt/password",
+		SecretProvider: &SecretProviderConfig{
+			Type:   "providers.secret.mock",
+			Config: map[string]interface{}{"name": "secrets"},
+		},
+	}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "mqtt>>username", opts.Username)
+	assert.Equal(t, "mqtt>>password", opts.Password)
+
+	_, err = NewClientOptions("tcp://127.0.0.1:1883", "client", ConnectionConfig{
+		PasswordSecret: "password",
+		SecretProvider: &SecretProviderConfig{Type: "providers.secret.mock"},
+	}, true)
+	assert.NotNil(t, err)
+
+	_, err = NewClientOptions("tcp://127.0.0.1:1883", "client", ConnectionConfig{
+		PasswordSecret: "mqtt/password",
+		SecretProvider: &SecretProviderConfig{Type: "providers.secret.unknown"},
+	}, true)
+	assert.NotNil(t, err)
+}
+
+func TestConnectWithCredentials(t *testing.T) {
+	broker, err := mqtttest.StartBroker(mqtttest.BrokerConfig{
+		Users: map[string]string{"admin": "secret"},
+	})
+	assert.Nil(t, err)
+	defer broker.Close()
+
+	err = connect(t, broker.Address, ConnectionConfig{Username: "admin", Password: "secret"})
+	assert.Nil(t, err)
+	err = connect(t, broker.Address, ConnectionConfig{Username: "admin", Password: "wrong"})
+	assert.NotNil(t, err)
+	err = connect(t, broker.Address, ConnectionConfig{})
+	assert.NotNil(t, err)
+}
+
+func TestConnectWithTLS(t *testing.T) {
+	broker, ca, _ := startTLSBroker(t, false)
+
+	err := connect(t, broker.Address, ConnectionConfig{CACertPath: ca.certPath})
+	assert.Nil(t, err)
+	// the broker certificate isn't trusted without the CA
+	err = connect(t, broker.Address, ConnectionConfig{UseTLS: true})
+	assert.NotNil(t, err)
+	err = connect(t, broker.Address, ConnectionConfig{InsecureSkipVerify: true})
+	assert.Nil(t, err)
+}
+
+func TestConnectWithMutualTLS(t *testing.T) {
+	broker, ca, client := startTLSBroker(t, true)
+
+	err := connect(t, broker.Address, ConnectionConfig{CACertPath: ca.certPath})
+	assert.NotNil(t, err)
+	err = connect(t, broker.Address, ConnectionConfig{
+		CACertPath:     ca.certPath,
+		ClientCertPath: client.certPath,
+		ClientKeyPath:  client.keyPath,
+	})
+	assert.Nil(t, err)
+	err = connect(t, broker.Address, ConnectionConfig{
+		CACertPath: ca.certPath,
+		CertProvider: &CertProviderConfig{
+			Type: "certs.localfile",
+			Config: map[string]interface{}{
+				"cert": client.certPath,
+				"key":  client.keyPath,
+			},
+		},
+	})
+	assert.Nil(t, err)
+}
+
+func TestConnectWithBadCertFiles(t *testing.T) {
+	err := connect(t, "ssl://127.0.0.1:1883", ConnectionConfig{CACertPath: "missing.crt"})
+	assert.NotNil(t, err)
+	err = connect(t, "ssl://127.0.0.1:1883", ConnectionConfig{
+		ClientCertPath: "missing.crt",
+		ClientKeyPath:  "missing.key",
+	})
+	assert.NotNil(t, err)
+	err = connect(t, "ssl://127.0.0.1:1883", ConnectionConfig{
+		CertProvider: &CertProviderConfig{Type: "certs.unknown"},
+	})
+	assert.NotNil(t, err)
+}
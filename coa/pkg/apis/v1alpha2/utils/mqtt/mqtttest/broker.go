@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+// Package mqtttest provides an in-process MQTT broker for tests.
+package mqtttest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
+	mqttserver "github.com/mochi-mqtt/server/v2"
+	"github.com/mochi-mqtt/server/v2/hooks/auth"
+	"github.com/mochi-mqtt/server/v2/listeners"
+	"github.com/rs/zerolog"
+)
+
+// BrokerConfig configures an in-process MQTT broker.
+type BrokerConfig struct {
+	// Address to listen on. Defaults to a random port on the loopback interface.
+	Address string `json:"address,omitempty"`
+	// TLSConfig enables TLS on the listener. Set ClientAuth to require client certificates.
+	TLSConfig *tls.Config `json:"-"`
+	// Users restricts access to the given username/password pairs. When empty, all clients are allowed.
+	Users map[string]string `json:"users,omitempty"`
+}
+
+// Broker is an in-process MQTT broker, used to test the MQTT binding and providers without an
+// external broker.
+type Broker struct {
+	Address string
+	server  *mqttserver.Server
+}
+
+// StartBroker starts an in-process MQTT broker with the given config.
+func StartBroker(config BrokerConfig) (*Broker, error) {
+	address := config.Address
+	if address == "" {
+		var err error
+		if address, err = freeAddress(); err != nil {
+			return nil, v1alpha2.NewCOAError(err, "failed to allocate a port for the MQTT broker", v1alpha2.InternalError)
+		}
+	}
+
+	logger := zerolog.New(os.Stderr).Level(zerolog.ErrorLevel)
+	server := mqttserver.New(&mqttserver.Options{Logger: &logger})
+	var err error
+	if len(config.Users) == 0 {
+		err = server.AddHook(new(auth.AllowHook), nil)
+	} else {
+		ledger := &auth.Ledger{
+			ACL: auth.ACLRules{{Filters: auth.Filters{"#": auth.ReadWrite}}},
+		}
+		for username, password := range config.Users {
+			ledger.Auth = append(ledger.Auth, auth.AuthRule{
+				Username: auth.RString(username),
+				Password: auth.RString(password),
+				Allow:    true,
+			})
+		}
+		err = server.AddHook(new(auth.Hook), &auth.Options{Ledger: ledger})
+	}
+	if err != nil {
+		return nil, v1alpha2.NewCOAError(err, "failed to configure MQTT broker authentication", v1alpha2.InternalError)
+	}
+
+	listener := listeners.NewTCP("symphony", address, &listeners.Config{TLSConfig: config.TLSConfig})
+	if err = server.AddListener(listener); err != nil {
+		return nil, v1alpha2.NewCOAError(err, "failed to listen for MQTT connections", v1alpha2.InternalError)
+	}
+	if err = server.Serve(); err != nil {
+		return nil, v1alpha2.NewCOAError(err, "failed to start MQTT broker", v1alpha2.InternalError)
+	}
+
+	scheme := "tcp"
+	if config.TLSConfig != nil {
+		scheme = "ssl"
+	}
+	return &Broker{
+		Address: fmt.Sprintf("%s://%s", scheme, address),
+		server:  server,
+	}, nil
+}
+
+// Close stops the broker and disconnects all clients.
+func (b *Broker) Close() error {
+	return b.server.Close()
+}
+
+func freeAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+	return listener.Addr().String(), nil
+}
@@ -12,7 +12,9 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/eclipse-symphony/symphony/coa/pkg/apis/v1alpha2"
@@ -57,6 +59,49 @@ type EvaluationContext struct {
 	Component      string
 	Value          interface{}
 	Namespace      string
+	// ConfigReads records the config objects read while evaluating, when it's set
+	ConfigReads *ConfigReads
+}
+
+// ConfigReads collects the objects and fields config providers read. An empty field stands for the whole object.
+type ConfigReads struct {
+	lock  sync.Mutex
+	reads map[string]map[string]bool
+}
+
+// Record records a read of a field of an object. Reads aren't recorded on a nil ConfigReads.
+func (r *ConfigReads) Record(object string, field string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.reads == nil {
+		r.reads = make(map[string]map[string]bool)
+	}
+	if _, ok := r.reads[object]; !ok {
+		r.reads[object] = make(map[string]bool)
+	}
+	r.reads[object][field] = true
+}
+
+// Objects returns the sorted fields read from each object
+func (r *ConfigReads) Objects() map[string][]string {
+	ret := make(map[string][]string)
+	if r == nil {
+		return ret
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for object, fields := range r.reads {
+		list := make([]string, 0, len(fields))
+		for field := range fields {
+			list = append(list, field)
+		}
+		sort.Strings(list)
+		ret[object] = list
+	}
+	return ret
 }
 
 func (e *EvaluationContext) Clone() *EvaluationContext {
@@ -102,3 +102,19 @@ func TestEvaluationContextClone(t *testing.T) {
 	var ec2 *EvaluationContext = nil
 	assert.Nil(t, ec2.Clone())
 }
+
+func TestConfigReads(t *testing.T) {
+	reads := &ConfigReads{}
+	reads.Record("config1", "image")
+	reads.Record("config1", "replicas")
+	reads.Record("config1", "image")
+	reads.Record("config2", "")
+	assert.Equal(t, map[string][]string{
+		"config1": {"image", "replicas"},
+		"config2": {""},
+	}, reads.Objects())
+
+	var none *ConfigReads
+	none.Record("config1", "image")
+	assert.Equal(t, 0, len(none.Objects()))
+}
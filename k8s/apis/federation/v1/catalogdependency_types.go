@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CatalogDependencySpec defines the catalog properties an instance read when it was deployed
+type CatalogDependencySpec struct {
+	Instance  string `json:"instance"`
+	Namespace string `json:"namespace,omitempty"`
+	// Catalogs are the read properties keyed by catalog name, an empty property stands for the whole catalog
+	Catalogs map[string][]string `json:"catalogs,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CatalogDependency is the Schema for the catalogdependencies API, it's named after the instance it belongs to
+type CatalogDependency struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CatalogDependencySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CatalogDependencyList contains a list of CatalogDependency
+type CatalogDependencyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CatalogDependency `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CatalogDependency{}, &CatalogDependencyList{})
+}
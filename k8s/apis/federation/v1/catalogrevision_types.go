@@ -0,0 +1,40 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// CatalogRevisionSpec defines the revision history of a catalog
+type CatalogRevisionSpec struct {
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Revisions []runtime.RawExtension `json:"revisions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CatalogRevision is the Schema for the catalogrevisions API, it's named after the catalog it keeps the history of
+type CatalogRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CatalogRevisionSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CatalogRevisionList contains a list of CatalogRevision
+type CatalogRevisionList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CatalogRevision `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CatalogRevision{}, &CatalogRevisionList{})
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CatalogTombstoneSpec defines a catalog deletion that is synced to child sites
+type CatalogTombstoneSpec struct {
+	Name        string      `json:"name"`
+	Namespace   string      `json:"namespace,omitempty"`
+	DeletedTime metav1.Time `json:"deletedTime"`
+	// PendingSites are the sites the deletion has been queued for
+	PendingSites []string `json:"pendingSites,omitempty"`
+	// AckedSites are the sites that have acknowledged the deletion
+	AckedSites []string `json:"ackedSites,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CatalogTombstone is the Schema for the catalogtombstones API
+type CatalogTombstone struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec CatalogTombstoneSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// CatalogTombstoneList contains a list of CatalogTombstone
+type CatalogTombstoneList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []CatalogTombstone `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&CatalogTombstone{}, &CatalogTombstoneList{})
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeadLetterSpec defines a job that couldn't be delivered to a site within the maximum number of attempts
+type DeadLetterSpec struct {
+	Id   string `json:"id"`
+	Site string `json:"site"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Job      runtime.RawExtension `json:"job"`
+	Attempts int                  `json:"attempts"`
+	DeadTime metav1.Time          `json:"deadTime"`
+}
+
+// +kubebuilder:object:root=true
+// DeadLetter is the Schema for the deadletters API
+type DeadLetter struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec DeadLetterSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// DeadLetterList contains a list of DeadLetter
+type DeadLetterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []DeadLetter `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&DeadLetter{}, &DeadLetterList{})
+}
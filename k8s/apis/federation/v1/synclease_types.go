@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) Microsoft Corporation.
+ * Licensed under the MIT license.
+ * SPDX-License-Identifier: MIT
+ */
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SyncLeaseItem is a job of a sync lease
+type SyncLeaseItem struct {
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +kubebuilder:validation:Schemaless
+	Job      runtime.RawExtension `json:"job"`
+	Attempts int                  `json:"attempts"`
+	// LeaseId is the lease of the job in the site's queue
+	LeaseId string `json:"leaseId,omitempty"`
+}
+
+// SyncLeaseSpec defines a batch of jobs that has been delivered to a site but not yet acknowledged
+type SyncLeaseSpec struct {
+	Id      string          `json:"id"`
+	Site    string          `json:"site"`
+	Expires metav1.Time     `json:"expires"`
+	Items   []SyncLeaseItem `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// SyncLease is the Schema for the syncleases API
+type SyncLease struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SyncLeaseSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// SyncLeaseList contains a list of SyncLease
+type SyncLeaseList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SyncLease `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SyncLease{}, &SyncLeaseList{})
+}
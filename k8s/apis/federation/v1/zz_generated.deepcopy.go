@@ -42,6 +42,94 @@ func (in *Catalog) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogDependency) DeepCopyInto(out *CatalogDependency) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogDependency.
+func (in *CatalogDependency) DeepCopy() *CatalogDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogDependency) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogDependencyList) DeepCopyInto(out *CatalogDependencyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CatalogDependency, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogDependencyList.
+func (in *CatalogDependencyList) DeepCopy() *CatalogDependencyList {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogDependencyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogDependencyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogDependencySpec) DeepCopyInto(out *CatalogDependencySpec) {
+	*out = *in
+	if in.Catalogs != nil {
+		in, out := &in.Catalogs, &out.Catalogs
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogDependencySpec.
+func (in *CatalogDependencySpec) DeepCopy() *CatalogDependencySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogDependencySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CatalogList) DeepCopyInto(out *CatalogList) {
 	*out = *in
@@ -74,6 +162,86 @@ func (in *CatalogList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogRevision) DeepCopyInto(out *CatalogRevision) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogRevision.
+func (in *CatalogRevision) DeepCopy() *CatalogRevision {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogRevision)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogRevision) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogRevisionList) DeepCopyInto(out *CatalogRevisionList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CatalogRevision, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogRevisionList.
+func (in *CatalogRevisionList) DeepCopy() *CatalogRevisionList {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogRevisionList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogRevisionList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogRevisionSpec) DeepCopyInto(out *CatalogRevisionSpec) {
+	*out = *in
+	if in.Revisions != nil {
+		in, out := &in.Revisions, &out.Revisions
+		*out = make([]runtime.RawExtension, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogRevisionSpec.
+func (in *CatalogRevisionSpec) DeepCopy() *CatalogRevisionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogRevisionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CatalogStatus) DeepCopyInto(out *CatalogStatus) {
 	*out = *in
@@ -96,6 +264,165 @@ func (in *CatalogStatus) DeepCopy() *CatalogStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogTombstone) DeepCopyInto(out *CatalogTombstone) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogTombstone.
+func (in *CatalogTombstone) DeepCopy() *CatalogTombstone {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogTombstone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogTombstone) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogTombstoneList) DeepCopyInto(out *CatalogTombstoneList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CatalogTombstone, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogTombstoneList.
+func (in *CatalogTombstoneList) DeepCopy() *CatalogTombstoneList {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogTombstoneList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CatalogTombstoneList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CatalogTombstoneSpec) DeepCopyInto(out *CatalogTombstoneSpec) {
+	*out = *in
+	in.DeletedTime.DeepCopyInto(&out.DeletedTime)
+	if in.PendingSites != nil {
+		in, out := &in.PendingSites, &out.PendingSites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AckedSites != nil {
+		in, out := &in.AckedSites, &out.AckedSites
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CatalogTombstoneSpec.
+func (in *CatalogTombstoneSpec) DeepCopy() *CatalogTombstoneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CatalogTombstoneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeadLetter) DeepCopyInto(out *DeadLetter) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadLetter.
+func (in *DeadLetter) DeepCopy() *DeadLetter {
+	if in == nil {
+		return nil
+	}
+	out := new(DeadLetter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeadLetter) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeadLetterList) DeepCopyInto(out *DeadLetterList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]DeadLetter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadLetterList.
+func (in *DeadLetterList) DeepCopy() *DeadLetterList {
+	if in == nil {
+		return nil
+	}
+	out := new(DeadLetterList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *DeadLetterList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DeadLetterSpec) DeepCopyInto(out *DeadLetterSpec) {
+	*out = *in
+	in.Job.DeepCopyInto(&out.Job)
+	in.DeadTime.DeepCopyInto(&out.DeadTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DeadLetterSpec.
+func (in *DeadLetterSpec) DeepCopy() *DeadLetterSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(DeadLetterSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Site) DeepCopyInto(out *Site) {
 	*out = *in
@@ -154,3 +481,100 @@ func (in *SiteList) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncLease) DeepCopyInto(out *SyncLease) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncLease.
+func (in *SyncLease) DeepCopy() *SyncLease {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncLease)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SyncLease) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncLeaseList) DeepCopyInto(out *SyncLeaseList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SyncLease, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncLeaseList.
+func (in *SyncLeaseList) DeepCopy() *SyncLeaseList {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncLeaseList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SyncLeaseList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncLeaseItem) DeepCopyInto(out *SyncLeaseItem) {
+	*out = *in
+	in.Job.DeepCopyInto(&out.Job)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncLeaseItem.
+func (in *SyncLeaseItem) DeepCopy() *SyncLeaseItem {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncLeaseItem)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SyncLeaseSpec) DeepCopyInto(out *SyncLeaseSpec) {
+	*out = *in
+	in.Expires.DeepCopyInto(&out.Expires)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SyncLeaseItem, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SyncLeaseSpec.
+func (in *SyncLeaseSpec) DeepCopy() *SyncLeaseSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SyncLeaseSpec)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -10,6 +10,7 @@ import (
 	"context"
 	"encoding/json"
 
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -44,7 +45,10 @@ func (r *CatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	catalog := &federationv1.Catalog{}
 	if err := r.Client.Get(ctx, req.NamespacedName, catalog); err != nil {
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+		if kerrors.IsNotFound(err) { // deleted
+			err = api_utils.CatalogDeleteHook(ctx, "http://symphony-service:8080/v1alpha2/", "admin", "", req.Name, req.Namespace)
+		}
+		return ctrl.Result{}, err
 	}
 
 	if catalog.ObjectMeta.DeletionTimestamp.IsZero() { // update